@@ -18,6 +18,8 @@ package api
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/blang/semver/v4"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -70,22 +72,113 @@ type AddonSpec struct {
 
 	// NeedsPKI determines if channels should provision a CA and a cert-manager issuer for the addon.
 	NeedsPKI bool `json:"needsPKI,omitempty"`
+
+	// DependsOn lists the names of addons which must be applied and healthy before this addon is applied,
+	// for example because they provide CustomResourceDefinitions or webhooks which it uses.
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// HealthCheck, if set, makes channels wait for the workloads of the addon to become ready after applying it,
+	// and roll back to the previously installed version if they do not.
+	HealthCheck *HealthCheckSpec `json:"healthCheck,omitempty"`
+}
+
+// HealthCheckSpec configures the check that an addon is healthy after it is applied.
+// The addon is healthy when all the Deployments, DaemonSets and StatefulSets matching its Selector are ready.
+type HealthCheckSpec struct {
+	// Timeout is how long to wait for the workloads to become ready; defaults to 5 minutes.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
 }
 
 func (a *Addons) Verify() error {
 	for _, addon := range a.Spec.Addons {
-		if addon != nil && addon.Version != nil && *addon.Version != "" {
-			name := a.ObjectMeta.Name
-			if addon.Name != nil {
-				name = *addon.Name
-			}
+		if addon == nil {
+			continue
+		}
+		name := a.ObjectMeta.Name
+		if addon.Name != nil {
+			name = *addon.Name
+		}
 
+		if addon.Version != nil && *addon.Version != "" {
 			_, err := semver.ParseTolerant(*addon.Version)
 			if err != nil {
 				return fmt.Errorf("addon %q has unparseable version %q: %v", name, *addon.Version, err)
 			}
 		}
+
+		if addon.HealthCheck != nil {
+			if len(addon.Selector) == 0 {
+				return fmt.Errorf("addon %q has a health check but no selector", name)
+			}
+			if addon.HealthCheck.Timeout != nil && addon.HealthCheck.Timeout.Duration <= 0 {
+				return fmt.Errorf("addon %q has non-positive health check timeout %v", name, addon.HealthCheck.Timeout.Duration)
+			}
+		}
+	}
+
+	if cycle := a.findDependencyCycle(); cycle != nil {
+		return fmt.Errorf("addons have a circular dependency: %s", strings.Join(cycle, " -> "))
 	}
 
 	return nil
 }
+
+// findDependencyCycle returns a cycle in the dependencies between addons, or nil if there is none.
+// Dependencies of all versions of an addon are considered, as any of them may be applied.
+func (a *Addons) findDependencyCycle() []string {
+	dependencies := make(map[string][]string)
+	for _, addon := range a.Spec.Addons {
+		if addon == nil {
+			continue
+		}
+		name := a.ObjectMeta.Name
+		if addon.Name != nil {
+			name = *addon.Name
+		}
+		dependencies[name] = append(dependencies[name], addon.DependsOn...)
+	}
+
+	var names []string
+	for name := range dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			for i, n := range path {
+				if n == name {
+					return append(append([]string{}, path[i:]...), name)
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range dependencies[name] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range names {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
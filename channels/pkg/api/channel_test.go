@@ -18,6 +18,7 @@ package api
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -45,6 +46,71 @@ func Test_UnparseableVersion(t *testing.T) {
 	assert.EqualError(t, err, "addon \"testaddon\" has unparseable version \"1.0-kops\": Short version cannot contain PreRelease/Build meta data", "detected invalid version")
 }
 
+func Test_HealthCheckTimeout(t *testing.T) {
+	addons := Addons{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: AddonsSpec{
+			Addons: []*AddonSpec{
+				{
+					Name:     s("testaddon"),
+					Version:  s("1.0.0"),
+					Selector: map[string]string{"k8s-addon": "testaddon"},
+					HealthCheck: &HealthCheckSpec{
+						Timeout: &v1.Duration{Duration: -time.Minute},
+					},
+				},
+			},
+		},
+	}
+
+	err := addons.Verify()
+	assert.EqualError(t, err, "addon \"testaddon\" has non-positive health check timeout -1m0s", "detected invalid timeout")
+
+	addons.Spec.Addons[0].HealthCheck.Timeout.Duration = time.Minute
+	assert.NoError(t, addons.Verify(), "valid timeout")
+
+	addons.Spec.Addons[0].Selector = nil
+	err = addons.Verify()
+	assert.EqualError(t, err, "addon \"testaddon\" has a health check but no selector", "detected missing selector")
+}
+
+func Test_DependencyCycle(t *testing.T) {
+	addons := Addons{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: AddonsSpec{
+			Addons: []*AddonSpec{
+				{
+					Name:    s("cert-manager"),
+					Version: s("1.0.0"),
+				},
+				{
+					Name:      s("aws-load-balancer-controller"),
+					Version:   s("1.0.0"),
+					DependsOn: []string{"cert-manager"},
+				},
+				{
+					Name:      s("external-dns"),
+					Version:   s("1.0.0"),
+					DependsOn: []string{"aws-load-balancer-controller", "coredns"},
+				},
+			},
+		},
+	}
+	assert.NoError(t, addons.Verify(), "acyclic dependencies")
+
+	addons.Spec.Addons = append(addons.Spec.Addons, &AddonSpec{
+		Name:      s("cert-manager"),
+		Version:   s("1.1.0"),
+		DependsOn: []string{"external-dns"},
+	})
+	err := addons.Verify()
+	assert.EqualError(t, err, "addons have a circular dependency: aws-load-balancer-controller -> cert-manager -> external-dns -> aws-load-balancer-controller", "detected cycle")
+}
+
 func s(v string) *string {
 	return &v
 }
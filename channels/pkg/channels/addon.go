@@ -33,6 +33,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"k8s.io/kops/channels/pkg/api"
+	"k8s.io/kops/util/pkg/vfs"
 
 	cmv1 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -152,7 +153,12 @@ func (a *Addon) GetManifestFullUrl() (*url.URL, error) {
 	return manifestURL, nil
 }
 
-func (a *Addon) EnsureUpdated(ctx context.Context, k8sClient kubernetes.Interface, cmClient certmanager.Interface) (*AddonUpdate, error) {
+// FieldManager returns the field manager used to apply the objects of the addon.
+func (a *Addon) FieldManager() string {
+	return FieldManagerPrefix + a.Name
+}
+
+func (a *Addon) EnsureUpdated(ctx context.Context, k8sClient kubernetes.Interface, cmClient certmanager.Interface, applier *Applier) (*AddonUpdate, error) {
 	required, err := a.GetRequiredUpdates(ctx, k8sClient, cmClient)
 	if err != nil {
 		return nil, err
@@ -168,11 +174,48 @@ func (a *Addon) EnsureUpdated(ctx context.Context, k8sClient kubernetes.Interfac
 		}
 		klog.Infof("Applying update from %q", manifestURL)
 
-		err = Apply(manifestURL.String())
+		manifest, err := vfs.Context.ReadFile(manifestURL.String())
+		if err != nil {
+			return nil, fmt.Errorf("error reading manifest %q: %v", manifestURL, err)
+		}
+
+		channel := a.buildChannel()
+		previousObjects, err := channel.GetInstalledObjects(ctx, k8sClient)
+		if err != nil {
+			return nil, err
+		}
+		previousManifest, err := channel.GetInstalledManifest(ctx, k8sClient)
+		if err != nil {
+			return nil, err
+		}
+
+		objects, err := applier.Apply(ctx, a.FieldManager(), manifest)
 		if err != nil {
 			return nil, fmt.Errorf("error applying update from %q: %v", manifestURL, err)
 		}
 
+		if a.Spec.HealthCheck != nil {
+			err = a.WaitForHealthy(ctx, k8sClient)
+			if err != nil {
+				return nil, a.handleUnhealthy(ctx, k8sClient, applier, required.ExistingVersion, previousManifest, previousObjects, objects, err)
+			}
+		}
+
+		err = applier.Prune(ctx, a.FieldManager(), previousObjects, objects)
+		if err != nil {
+			return nil, fmt.Errorf("error pruning objects removed from %q: %v", manifestURL, err)
+		}
+
+		err = channel.SetInstalledObjects(ctx, k8sClient, objects)
+		if err != nil {
+			return nil, fmt.Errorf("error applying annotation to record addon objects: %v", err)
+		}
+
+		err = channel.SetInstalledManifest(ctx, k8sClient, &InstalledManifest{Manifest: manifest, Selector: a.Spec.Selector})
+		if err != nil {
+			return nil, err
+		}
+
 		if required.ExistingVersion != nil {
 			if a.Spec.NeedsRollingUpdate != "" {
 				err = a.AddNeedsUpdateLabel(ctx, k8sClient)
@@ -182,7 +225,6 @@ func (a *Addon) EnsureUpdated(ctx context.Context, k8sClient kubernetes.Interfac
 			}
 		}
 
-		channel := a.buildChannel()
 		err = channel.SetInstalledVersion(ctx, k8sClient, a.ChannelVersion())
 		if err != nil {
 			return nil, fmt.Errorf("error applying annotation to record addon installation: %v", err)
@@ -197,6 +239,61 @@ func (a *Addon) EnsureUpdated(ctx context.Context, k8sClient kubernetes.Interfac
 	return required, nil
 }
 
+// handleUnhealthy rolls back the addon after the new version was not healthy, returning healthErr with the outcome.
+// If there is no previous version to roll back to, or the rollback fails, the objects which may have been applied
+// are recorded, so that the next update of the addon prunes those which are not in its manifest.
+func (a *Addon) handleUnhealthy(ctx context.Context, k8sClient kubernetes.Interface, applier *Applier, existingVersion *ChannelVersion, previousManifest *InstalledManifest, previousObjects []ObjectRef, objects []ObjectRef, healthErr error) error {
+	channel := a.buildChannel()
+	if existingVersion == nil {
+		// The version is not recorded, so the next run installs the addon again
+		if err := channel.SetInstalledObjects(ctx, k8sClient, objects); err != nil {
+			return fmt.Errorf("%v; error applying annotation to record addon objects: %v", healthErr, err)
+		}
+		return fmt.Errorf("%v; not rolled back, as no previous version of the addon was installed", healthErr)
+	}
+
+	rollbackErr := a.rollback(ctx, k8sClient, applier, existingVersion, previousManifest, objects)
+	if rollbackErr != nil {
+		if err := channel.SetInstalledObjects(ctx, k8sClient, unionObjects(previousObjects, objects)); err != nil {
+			klog.Warningf("error applying annotation to record addon objects: %v", err)
+		}
+		return fmt.Errorf("%v; unable to roll back: %v", healthErr, rollbackErr)
+	}
+	return fmt.Errorf("%v; rolled back to %s", healthErr, existingVersion)
+}
+
+// rollback reapplies the previously installed manifest of the addon after a new version was not healthy,
+// removing any objects which were added by the new version.
+func (a *Addon) rollback(ctx context.Context, k8sClient kubernetes.Interface, applier *Applier, existingVersion *ChannelVersion, previousManifest *InstalledManifest, objects []ObjectRef) error {
+	if previousManifest == nil {
+		return fmt.Errorf("the manifest of the previous version of the addon was not recorded")
+	}
+
+	klog.Warningf("rolling back addon %q to %s", a.Name, existingVersion)
+	rolledBack, err := applier.Apply(ctx, a.FieldManager(), previousManifest.Manifest)
+	if err != nil {
+		return fmt.Errorf("error applying previous manifest: %v", err)
+	}
+
+	err = applier.Prune(ctx, a.FieldManager(), objects, rolledBack)
+	if err != nil {
+		return fmt.Errorf("error pruning objects added by the new version: %v", err)
+	}
+
+	channel := a.buildChannel()
+	err = channel.SetInstalledObjects(ctx, k8sClient, rolledBack)
+	if err != nil {
+		return fmt.Errorf("error applying annotation to record addon objects: %v", err)
+	}
+
+	// The version is only recorded once the new version is healthy, but we restore it in case of a concurrent update
+	err = channel.SetInstalledVersion(ctx, k8sClient, existingVersion)
+	if err != nil {
+		return fmt.Errorf("error applying annotation to record addon installation: %v", err)
+	}
+	return nil
+}
+
 func (a *Addon) AddNeedsUpdateLabel(ctx context.Context, k8sClient kubernetes.Interface) error {
 	klog.Infof("addon %v wants to update %v nodes", a.Name, a.Spec.NeedsRollingUpdate)
 	selector := ""
@@ -34,6 +34,27 @@ type Addons struct {
 	APIObject       *api.Addons
 }
 
+// ResolveChannel returns the location of a channel given by name.
+func ResolveChannel(name string) (*url.URL, error) {
+	location, err := url.Parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse argument %q as url", name)
+	}
+	if !location.IsAbs() {
+		// We recognize the following "well-known" format:
+		// <name> with no slashes ->
+		if strings.Contains(name, "/") {
+			return nil, fmt.Errorf("Channel format not recognized (did you mean to use `-f` to specify a local file?): %q", name)
+		}
+		expanded := "https://raw.githubusercontent.com/kubernetes/kops/master/addons/" + name + "/addon.yaml"
+		location, err = url.Parse(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse expanded argument %q as url", expanded)
+		}
+	}
+	return location, nil
+}
+
 func LoadAddons(name string, location *url.URL) (*Addons, error) {
 	klog.V(2).Infof("Loading addons channel from %q", location)
 	data, err := vfs.Context.ReadFile(location.String())
@@ -57,18 +78,8 @@ func ParseAddons(name string, location *url.URL, data []byte) (*Addons, error) {
 		}
 	}
 
-	for _, addon := range apiObject.Spec.Addons {
-		if addon != nil && addon.Version != nil && *addon.Version != "" {
-			name := apiObject.ObjectMeta.Name
-			if addon.Name != nil {
-				name = *addon.Name
-			}
-
-			_, err := semver.ParseTolerant(*addon.Version)
-			if err != nil {
-				return nil, fmt.Errorf("addon %q has unparseable version %q: %v", name, *addon.Version, err)
-			}
-		}
+	if err := apiObject.Verify(); err != nil {
+		return nil, err
 	}
 
 	return &Addons{ChannelName: name, ChannelLocation: *location, APIObject: apiObject}, nil
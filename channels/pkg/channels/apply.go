@@ -17,60 +17,289 @@ limitations under the License.
 package channels
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"os/exec"
-	"path"
-	"strings"
+	"io"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/klog/v2"
-	"k8s.io/kops/util/pkg/vfs"
 )
 
-// Apply calls kubectl apply to apply the manifest.
-// We will likely in future change this to create things directly (or more likely embed this logic into kubectl itself)
-func Apply(manifest string) error {
-	// We copy the manifest to a temp file because it is likely e.g. an s3 URL, which kubectl can't read
-	data, err := vfs.Context.ReadFile(manifest)
-	if err != nil {
-		return fmt.Errorf("error reading manifest: %v", err)
+const (
+	// FieldManagerPrefix is prefixed to the name of an addon to build the field manager used to apply it
+	FieldManagerPrefix = "channels/"
+
+	// discoveryTimeout is how long we wait for the kind of an object to be served,
+	// for example when a manifest defines a CustomResourceDefinition and then uses it.
+	discoveryTimeout = time.Minute
+)
+
+// ObjectRef identifies an object applied from an addon manifest.
+// The version is omitted so that objects remain identified when their preferred version changes.
+type ObjectRef struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func (r ObjectRef) String() string {
+	s := r.Kind
+	if r.Group != "" {
+		s += "." + r.Group
+	}
+	s += " "
+	if r.Namespace != "" {
+		s += r.Namespace + "/"
 	}
+	return s + r.Name
+}
+
+// Applier applies addon manifests to the cluster with server-side apply, and compares them with the cluster.
+type Applier struct {
+	Client    dynamic.Interface
+	Discovery discovery.DiscoveryInterface
 
-	tmpDir, err := ioutil.TempDir("", "channel")
+	restMapper meta.RESTMapper
+}
+
+// NewApplier builds an Applier.
+func NewApplier(client dynamic.Interface, discovery discovery.DiscoveryInterface) *Applier {
+	return &Applier{
+		Client:    client,
+		Discovery: discovery,
+	}
+}
+
+// ParseManifest parses the objects in a multi-document YAML or JSON manifest.
+func ParseManifest(data []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("error parsing manifest: %v", err)
+		}
+		// Skip empty or commented-out documents
+		if len(u.Object) == 0 {
+			continue
+		}
+		if u.GetKind() == "" || u.GetAPIVersion() == "" {
+			return nil, fmt.Errorf("object %q in manifest does not have a kind and apiVersion", u.GetName())
+		}
+		if u.GetName() == "" {
+			return nil, fmt.Errorf("%s object in manifest does not have a name", u.GetKind())
+		}
+		objects = append(objects, u)
+	}
+	return objects, nil
+}
+
+// Apply applies the objects in the manifest with server-side apply, using the field manager,
+// and returns the objects which were applied.
+func (a *Applier) Apply(ctx context.Context, fieldManager string, manifest []byte) ([]ObjectRef, error) {
+	objects, err := ParseManifest(manifest)
 	if err != nil {
-		return fmt.Errorf("error creating temp dir: %v", err)
+		return nil, err
 	}
 
-	defer func() {
-		if err := os.RemoveAll(tmpDir); err != nil {
-			klog.Warningf("error deleting temp dir %q: %v", tmpDir, err)
+	var applied []ObjectRef
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		mapping, err := a.waitForMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		ref := ObjectRef{
+			Group: gvk.Group,
+			Kind:  gvk.Kind,
+			Name:  obj.GetName(),
+		}
+		var resource dynamic.ResourceInterface = a.Client.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if obj.GetNamespace() == "" {
+				obj.SetNamespace(metav1.NamespaceDefault)
+			}
+			ref.Namespace = obj.GetNamespace()
+			resource = a.Client.Resource(mapping.Resource).Namespace(ref.Namespace)
+		} else {
+			obj.SetNamespace("")
+		}
+
+		body, err := obj.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("error serializing %s: %v", ref, err)
 		}
-	}()
 
-	localManifestFile := path.Join(tmpDir, "manifest.yaml")
-	if err := ioutil.WriteFile(localManifestFile, data, 0600); err != nil {
-		return fmt.Errorf("error writing temp file: %v", err)
+		klog.V(2).Infof("applying %s", ref)
+		// Channels is the source of truth for the objects in its addons, so we take ownership of any conflicting fields
+		force := true
+		_, err = resource.Patch(ctx, ref.Name, types.ApplyPatchType, body, metav1.PatchOptions{
+			FieldManager: fieldManager,
+			Force:        &force,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error applying %s: %v", ref, err)
+		}
+		applied = append(applied, ref)
 	}
+	return applied, nil
+}
+
+// Prune deletes the objects which were previously applied, but are not in the current manifest.
+// Objects are only deleted if they are still managed by the field manager. Namespaces and
+// CustomResourceDefinitions are never deleted, as that would also delete the objects they contain.
+func (a *Applier) Prune(ctx context.Context, fieldManager string, previous []ObjectRef, current []ObjectRef) error {
+	for _, ref := range objectsToPrune(previous, current) {
+		if !isPrunable(ref) {
+			klog.Infof("not pruning %s, which is no longer in the manifest", ref)
+			continue
+		}
+
+		mapping, err := a.restMapping(schema.GroupKind{Group: ref.Group, Kind: ref.Kind})
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				klog.V(2).Infof("not pruning %s, as its kind is no longer served", ref)
+				continue
+			}
+			return err
+		}
 
-	_, err = execKubectl("apply", "-f", localManifestFile)
-	return err
+		var resource dynamic.ResourceInterface = a.Client.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			resource = a.Client.Resource(mapping.Resource).Namespace(ref.Namespace)
+		}
+
+		obj, err := resource.Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("error getting %s: %v", ref, err)
+		}
+		if !isManagedBy(obj, fieldManager) {
+			klog.Infof("not pruning %s, which is no longer managed by %q", ref, fieldManager)
+			continue
+		}
+
+		klog.Infof("pruning %s", ref)
+		propagation := metav1.DeletePropagationBackground
+		uid := obj.GetUID()
+		err = resource.Delete(ctx, ref.Name, metav1.DeleteOptions{
+			PropagationPolicy: &propagation,
+			Preconditions:     &metav1.Preconditions{UID: &uid},
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error pruning %s: %v", ref, err)
+		}
+	}
+	return nil
+}
+
+// objectsToPrune returns the objects in previous which are not in current,
+// in the reverse of the order in which they were applied.
+func objectsToPrune(previous []ObjectRef, current []ObjectRef) []ObjectRef {
+	keep := make(map[ObjectRef]bool)
+	for _, ref := range current {
+		keep[ref] = true
+	}
+	var prune []ObjectRef
+	for i := len(previous) - 1; i >= 0; i-- {
+		if !keep[previous[i]] {
+			prune = append(prune, previous[i])
+		}
+	}
+	return prune
+}
+
+// unionObjects returns the objects in a followed by those in b which are not in a.
+func unionObjects(a []ObjectRef, b []ObjectRef) []ObjectRef {
+	seen := make(map[ObjectRef]bool)
+	var union []ObjectRef
+	for _, refs := range [][]ObjectRef{a, b} {
+		for _, ref := range refs {
+			if !seen[ref] {
+				seen[ref] = true
+				union = append(union, ref)
+			}
+		}
+	}
+	return union
+}
+
+func isPrunable(ref ObjectRef) bool {
+	switch (schema.GroupKind{Group: ref.Group, Kind: ref.Kind}) {
+	case schema.GroupKind{Kind: "Namespace"}, schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:
+		return false
+	}
+	return true
+}
+
+// isManagedBy returns true if the field manager has applied fields of the object.
+func isManagedBy(obj metav1.Object, fieldManager string) bool {
+	for _, f := range obj.GetManagedFields() {
+		if f.Manager == fieldManager && f.Operation == metav1.ManagedFieldsOperationApply {
+			return true
+		}
+	}
+	return false
+}
+
+// restMapping maps the kind to a resource, using the preferred version if none are given.
+func (a *Applier) restMapping(gk schema.GroupKind, versions ...string) (*meta.RESTMapping, error) {
+	if a.restMapper == nil {
+		if err := a.refreshRESTMapper(); err != nil {
+			return nil, err
+		}
+	}
+	return a.restMapper.RESTMapping(gk, versions...)
 }
 
-func execKubectl(args ...string) (string, error) {
-	kubectlPath := "kubectl" // Assume in PATH
-	cmd := exec.Command(kubectlPath, args...)
-	env := os.Environ()
-	cmd.Env = env
+// waitForMapping maps the kind to a resource, waiting for it to be served if it is not yet known.
+func (a *Applier) waitForMapping(gk schema.GroupKind, version string) (*meta.RESTMapping, error) {
+	mapping, err := a.restMapping(gk, version)
+	if err == nil || !meta.IsNoMatchError(err) {
+		return mapping, err
+	}
 
-	human := strings.Join(cmd.Args, " ")
-	klog.V(2).Infof("Running command: %s", human)
-	output, err := cmd.CombinedOutput()
+	klog.Infof("waiting for %s to be served", gk)
+	err = wait.PollImmediate(2*time.Second, discoveryTimeout, func() (bool, error) {
+		if err := a.refreshRESTMapper(); err != nil {
+			return false, err
+		}
+		mapping, err = a.restMapper.RESTMapping(gk, version)
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
 	if err != nil {
-		klog.Infof("error running %s", human)
-		klog.Info(string(output))
-		return string(output), fmt.Errorf("error running kubectl: %v", err)
+		return nil, fmt.Errorf("error finding resource for %s/%s: %v", gk, version, err)
 	}
+	return mapping, nil
+}
 
-	return string(output), err
+func (a *Applier) refreshRESTMapper() error {
+	groupResources, err := restmapper.GetAPIGroupResources(a.Discovery)
+	if err != nil {
+		return fmt.Errorf("error discovering API resources: %v", err)
+	}
+	a.restMapper = restmapper.NewDiscoveryRESTMapper(groupResources)
+	return nil
 }
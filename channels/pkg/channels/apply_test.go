@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channels
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_ParseManifest(t *testing.T) {
+	manifest := `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: test
+  namespace: kube-system
+---
+# A commented-out object
+# apiVersion: v1
+# kind: ConfigMap
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  namespace: kube-system
+spec:
+  replicas: 1
+`
+	objects, err := ParseManifest([]byte(manifest))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var kinds []string
+	for _, obj := range objects {
+		kinds = append(kinds, obj.GetAPIVersion()+" "+obj.GetKind()+" "+obj.GetNamespace()+"/"+obj.GetName())
+	}
+	expected := []string{"v1 ServiceAccount kube-system/test", "apps/v1 Deployment kube-system/test"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("unexpected objects %v, expected %v", kinds, expected)
+	}
+
+	if _, err := ParseManifest([]byte("apiVersion: v1\nkind: ConfigMap\n")); err == nil {
+		t.Errorf("expected error parsing object without a name")
+	}
+}
+
+func Test_ObjectsToPrune(t *testing.T) {
+	deployment := ObjectRef{Group: "apps", Kind: "Deployment", Namespace: "kube-system", Name: "test"}
+	oldConfigMap := ObjectRef{Kind: "ConfigMap", Namespace: "kube-system", Name: "test-v1"}
+	newConfigMap := ObjectRef{Kind: "ConfigMap", Namespace: "kube-system", Name: "test-v2"}
+	clusterRole := ObjectRef{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "test"}
+
+	prune := objectsToPrune(
+		[]ObjectRef{deployment, oldConfigMap, clusterRole},
+		[]ObjectRef{deployment, newConfigMap},
+	)
+	expected := []ObjectRef{clusterRole, oldConfigMap}
+	if !reflect.DeepEqual(prune, expected) {
+		t.Errorf("unexpected objects to prune %v, expected %v", prune, expected)
+	}
+
+	if prune := objectsToPrune(nil, []ObjectRef{deployment}); len(prune) != 0 {
+		t.Errorf("expected nothing to prune without previous objects, got %v", prune)
+	}
+}
+
+func Test_UnionObjects(t *testing.T) {
+	deployment := ObjectRef{Group: "apps", Kind: "Deployment", Namespace: "kube-system", Name: "test"}
+	oldConfigMap := ObjectRef{Kind: "ConfigMap", Namespace: "kube-system", Name: "test-v1"}
+	newConfigMap := ObjectRef{Kind: "ConfigMap", Namespace: "kube-system", Name: "test-v2"}
+
+	union := unionObjects(
+		[]ObjectRef{deployment, oldConfigMap},
+		[]ObjectRef{deployment, newConfigMap},
+	)
+	expected := []ObjectRef{deployment, oldConfigMap, newConfigMap}
+	if !reflect.DeepEqual(union, expected) {
+		t.Errorf("unexpected union %v, expected %v", union, expected)
+	}
+}
+
+func Test_IsPrunable(t *testing.T) {
+	grid := []struct {
+		Ref      ObjectRef
+		Expected bool
+	}{
+		{Ref: ObjectRef{Group: "apps", Kind: "DaemonSet", Namespace: "kube-system", Name: "test"}, Expected: true},
+		{Ref: ObjectRef{Kind: "Namespace", Name: "test"}, Expected: false},
+		{Ref: ObjectRef{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition", Name: "tests.example.com"}, Expected: false},
+	}
+	for _, g := range grid {
+		if actual := isPrunable(g.Ref); actual != g.Expected {
+			t.Errorf("unexpected result for %s: %v, expected %v", g.Ref, actual, g.Expected)
+		}
+	}
+}
+
+func Test_IsManagedBy(t *testing.T) {
+	obj := &metav1.ObjectMeta{
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{Manager: "kubectl-edit", Operation: metav1.ManagedFieldsOperationUpdate},
+			{Manager: "channels/test", Operation: metav1.ManagedFieldsOperationApply},
+		},
+	}
+	if !isManagedBy(obj, "channels/test") {
+		t.Errorf("expected object to be managed by channels/test")
+	}
+	if isManagedBy(obj, "channels/other") {
+		t.Errorf("expected object not to be managed by channels/other")
+	}
+	if isManagedBy(obj, "kubectl-edit") {
+		t.Errorf("updates should not count as applied fields")
+	}
+}
@@ -17,9 +17,12 @@ limitations under the License.
 package channels
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"strings"
 
 	"github.com/blang/semver/v4"
@@ -34,6 +37,14 @@ import (
 
 const AnnotationPrefix = "addons.k8s.io/"
 
+// ManifestSecretPrefix is the prefix of the names of the secrets holding the manifest last installed for each addon,
+// so that it can be reapplied if a new version of the addon is not healthy. Secrets are used as manifests may contain credentials.
+const ManifestSecretPrefix = "channels-manifest."
+
+// ObjectsAnnotationPrefix is the prefix of the annotations recording the objects applied from each addon,
+// so that they can be pruned when they are removed from the addon's manifest
+const ObjectsAnnotationPrefix = "objects.addons.k8s.io/"
+
 type Channel struct {
 	Namespace string
 	Name      string
@@ -104,6 +115,14 @@ func (c *Channel) AnnotationName() string {
 	return AnnotationPrefix + c.Name
 }
 
+func (c *Channel) ObjectsAnnotationName() string {
+	return ObjectsAnnotationPrefix + c.Name
+}
+
+func (c *Channel) ManifestSecretName() string {
+	return ManifestSecretPrefix + c.Name
+}
+
 func (c *ChannelVersion) replaces(existing *ChannelVersion) bool {
 	klog.V(4).Infof("Checking existing channel: %v compared to new channel: %v", existing, c)
 	if existing.Version != nil {
@@ -221,3 +240,149 @@ func (c *Channel) SetInstalledVersion(ctx context.Context, k8sClient kubernetes.
 	}
 	return nil
 }
+
+// GetInstalledObjects returns the objects recorded as applied from the addon, or nil if none were recorded.
+func (c *Channel) GetInstalledObjects(ctx context.Context, k8sClient kubernetes.Interface) ([]ObjectRef, error) {
+	ns, err := k8sClient.CoreV1().Namespaces().Get(ctx, c.Namespace, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error querying namespace %q: %v", c.Namespace, err)
+	}
+
+	annotationValue, ok := ns.Annotations[c.ObjectsAnnotationName()]
+	if !ok {
+		return nil, nil
+	}
+
+	var objects []ObjectRef
+	if err := json.Unmarshal([]byte(annotationValue), &objects); err != nil {
+		return nil, fmt.Errorf("error parsing annotation %q: %v", c.ObjectsAnnotationName(), err)
+	}
+	return objects, nil
+}
+
+// SetInstalledObjects records the objects applied from the addon.
+func (c *Channel) SetInstalledObjects(ctx context.Context, k8sClient kubernetes.Interface, objects []ObjectRef) error {
+	if objects == nil {
+		objects = []ObjectRef{}
+	}
+	value, err := json.Marshal(objects)
+	if err != nil {
+		return fmt.Errorf("error encoding objects: %v", err)
+	}
+
+	annotationPatch := &annotationPatch{Metadata: annotationPatchMetadata{Annotations: map[string]string{c.ObjectsAnnotationName(): string(value)}}}
+	annotationPatchJSON, err := json.Marshal(annotationPatch)
+	if err != nil {
+		return fmt.Errorf("error building annotation patch: %v", err)
+	}
+
+	klog.V(2).Infof("sending patch: %q", string(annotationPatchJSON))
+
+	_, err = k8sClient.CoreV1().Namespaces().Patch(ctx, c.Namespace, types.StrategicMergePatchType, annotationPatchJSON, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("error applying annotation to namespace: %v", err)
+	}
+	return nil
+}
+
+// InstalledManifest is the manifest recorded as installed for an addon.
+type InstalledManifest struct {
+	Manifest []byte
+	// Selector is the selector of the addon when it was installed
+	Selector map[string]string
+}
+
+// GetInstalledManifest returns the manifest recorded as installed for the addon, or nil if none was recorded.
+func (c *Channel) GetInstalledManifest(ctx context.Context, k8sClient kubernetes.Interface) (*InstalledManifest, error) {
+	secret, err := k8sClient.CoreV1().Secrets(c.Namespace).Get(ctx, c.ManifestSecretName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying secret %s/%s: %v", c.Namespace, c.ManifestSecretName(), err)
+	}
+
+	installed := &InstalledManifest{
+		Manifest: secret.Data["manifest"],
+	}
+	// Manifests recorded by older versions of channels are not compressed
+	if compressed, found := secret.Data["manifest.gz"]; found {
+		installed.Manifest, err = gunzip(compressed)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing manifest in secret %s/%s: %v", c.Namespace, c.ManifestSecretName(), err)
+		}
+	}
+	if selector := secret.Data["selector"]; len(selector) != 0 {
+		if err := json.Unmarshal(selector, &installed.Selector); err != nil {
+			return nil, fmt.Errorf("error parsing selector in secret %s/%s: %v", c.Namespace, c.ManifestSecretName(), err)
+		}
+	}
+	return installed, nil
+}
+
+// SetInstalledManifest records the manifest installed for the addon, compressed with gzip.
+// If the compressed manifest does not fit in a secret, any previously recorded manifest is removed instead,
+// so that the addon cannot be rolled back to a manifest older than the installed one.
+func (c *Channel) SetInstalledManifest(ctx context.Context, k8sClient kubernetes.Interface, installed *InstalledManifest) error {
+	selector, err := json.Marshal(installed.Selector)
+	if err != nil {
+		return fmt.Errorf("error encoding selector: %v", err)
+	}
+
+	manifest, err := gzipBytes(installed.Manifest)
+	if err != nil {
+		return fmt.Errorf("error compressing manifest: %v", err)
+	}
+
+	secrets := k8sClient.CoreV1().Secrets(c.Namespace)
+
+	if size := len(manifest) + len(selector); size >= v1.MaxSecretSize {
+		klog.Warningf("not recording manifest of addon %q, as its compressed size of %d bytes exceeds the secret size limit of %d bytes; the addon cannot be rolled back to this version", c.Name, size, v1.MaxSecretSize)
+		err = secrets.Delete(ctx, c.ManifestSecretName(), metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error deleting previously recorded manifest in secret %s/%s: %v", c.Namespace, c.ManifestSecretName(), err)
+		}
+		return nil
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.ManifestSecretName(),
+			Namespace: c.Namespace,
+		},
+		Data: map[string][]byte{
+			"manifest.gz": manifest,
+			"selector":    selector,
+		},
+	}
+
+	_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	if errors.IsNotFound(err) {
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error recording manifest in secret %s/%s: %v", c.Namespace, c.ManifestSecretName(), err)
+	}
+	return nil
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
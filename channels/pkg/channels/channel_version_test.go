@@ -18,6 +18,8 @@ package channels
 
 import (
 	"context"
+	"math/rand"
+	"reflect"
 	"testing"
 
 	cmv1 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1"
@@ -78,3 +80,132 @@ func Test_IsPKIInstalled(t *testing.T) {
 	}
 
 }
+
+func Test_InstalledObjects(t *testing.T) {
+	ctx := context.Background()
+	fakek8s := fakekubernetes.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "kube-system",
+			Annotations: map[string]string{
+				"addons.k8s.io/test": `{"version":"1.0.0"}`,
+			},
+		},
+	})
+
+	channel := &Channel{
+		Namespace: "kube-system",
+		Name:      "test",
+	}
+	objects, err := channel.GetInstalledObjects(ctx, fakek8s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if objects != nil {
+		t.Errorf("expected no objects to be recorded, got %v", objects)
+	}
+
+	expected := []ObjectRef{
+		{Group: "apps", Kind: "Deployment", Namespace: "kube-system", Name: "test"},
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "test"},
+	}
+	if err := channel.SetInstalledObjects(ctx, fakek8s, expected); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	objects, err = channel.GetInstalledObjects(ctx, fakek8s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(objects, expected) {
+		t.Errorf("unexpected objects %v, expected %v", objects, expected)
+	}
+
+	ns, err := fakek8s.CoreV1().Namespaces().Get(ctx, "kube-system", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if addons := FindAddons(ns); len(addons) != 1 || addons["test"] == nil {
+		t.Errorf("recording objects should not affect installed addons, got %v", addons)
+	}
+}
+
+func Test_InstalledManifest(t *testing.T) {
+	ctx := context.Background()
+	fakek8s := fakekubernetes.NewSimpleClientset()
+
+	channel := &Channel{
+		Namespace: "kube-system",
+		Name:      "test",
+	}
+	manifest, err := channel.GetInstalledManifest(ctx, fakek8s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if manifest != nil {
+		t.Errorf("expected no manifest to be recorded, got %+v", manifest)
+	}
+
+	for _, expected := range []*InstalledManifest{
+		{Manifest: []byte("kind: ConfigMap\n"), Selector: map[string]string{"k8s-addon": "test"}},
+		{Manifest: []byte("kind: Secret\n")},
+	} {
+		if err := channel.SetInstalledManifest(ctx, fakek8s, expected); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		manifest, err = channel.GetInstalledManifest(ctx, fakek8s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(manifest, expected) {
+			t.Errorf("unexpected manifest %+v, expected %+v", manifest, expected)
+		}
+	}
+
+	secret, err := fakek8s.CoreV1().Secrets("kube-system").Get(ctx, "channels-manifest.test", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found := secret.Data["manifest"]; found {
+		t.Errorf("expected manifest to be recorded compressed, got %q", secret.Data["manifest"])
+	}
+
+	// A manifest which does not fit in a secret even when compressed is not recorded
+	large := make([]byte, 2*corev1.MaxSecretSize)
+	rand.New(rand.NewSource(1)).Read(large)
+	if err := channel.SetInstalledManifest(ctx, fakek8s, &InstalledManifest{Manifest: large}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	manifest, err = channel.GetInstalledManifest(ctx, fakek8s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if manifest != nil {
+		t.Errorf("expected the previously recorded manifest to be removed, got %+v", manifest)
+	}
+}
+
+func Test_InstalledManifestUncompressed(t *testing.T) {
+	ctx := context.Background()
+	fakek8s := fakekubernetes.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "channels-manifest.test",
+			Namespace: "kube-system",
+		},
+		Data: map[string][]byte{
+			"manifest": []byte("kind: ConfigMap\n"),
+			"selector": []byte(`{"k8s-addon":"test"}`),
+		},
+	})
+
+	channel := &Channel{
+		Namespace: "kube-system",
+		Name:      "test",
+	}
+	manifest, err := channel.GetInstalledManifest(ctx, fakek8s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &InstalledManifest{Manifest: []byte("kind: ConfigMap\n"), Selector: map[string]string{"k8s-addon": "test"}}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("unexpected manifest %+v, expected %+v", manifest, expected)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channels
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortedAddons returns the addons of the menu ordered so that each addon comes after the addons it depends on.
+// Addons which do not depend on each other are ordered by name. Dependencies on addons which are not in
+// the menu are ignored, as they are assumed to be installed by other means.
+func (m *AddonMenu) SortedAddons() ([]*Addon, error) {
+	var names []string
+	for name := range m.Addons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Kahn's algorithm, always taking the first ready addon by name so the order is stable
+	dependents := make(map[string][]string)
+	remaining := make(map[string]int)
+	for _, name := range names {
+		remaining[name] = 0
+		for _, dep := range m.Addons[name].Spec.DependsOn {
+			if m.Addons[dep] == nil || dep == name {
+				continue
+			}
+			dependents[dep] = append(dependents[dep], name)
+			remaining[name]++
+		}
+	}
+
+	var sorted []*Addon
+	for len(sorted) < len(names) {
+		next := ""
+		for _, name := range names {
+			if n, ok := remaining[name]; ok && n == 0 {
+				next = name
+				break
+			}
+		}
+		if next == "" {
+			var cycle []string
+			for _, name := range names {
+				if _, ok := remaining[name]; ok {
+					cycle = append(cycle, name)
+				}
+			}
+			return nil, fmt.Errorf("addons have a circular dependency between %s", strings.Join(cycle, ", "))
+		}
+
+		delete(remaining, next)
+		for _, dependent := range dependents[next] {
+			remaining[dependent]--
+		}
+		sorted = append(sorted, m.Addons[next])
+	}
+	return sorted, nil
+}
+
+// Dependencies returns the addons in the menu which the addon depends on.
+func (m *AddonMenu) Dependencies(addon *Addon) []*Addon {
+	var dependencies []*Addon
+	for _, name := range addon.Spec.DependsOn {
+		if dep := m.Addons[name]; dep != nil && name != addon.Name {
+			dependencies = append(dependencies, dep)
+		}
+	}
+	return dependencies
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func addonWithDependencies(t *testing.T, name string, dependsOn ...string) *Addon {
+	a := addon(t, name, "1.0.0", "", "")
+	a.Spec.DependsOn = dependsOn
+	return a
+}
+
+func addonNames(addons []*Addon) []string {
+	var names []string
+	for _, a := range addons {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+func Test_SortedAddons(t *testing.T) {
+	menu := addonMenu(
+		addonWithDependencies(t, "aws-load-balancer-controller", "cert-manager"),
+		addonWithDependencies(t, "cert-manager"),
+		addonWithDependencies(t, "coredns"),
+		addonWithDependencies(t, "external-dns", "aws-load-balancer-controller", "not-in-menu"),
+	)
+
+	sorted, err := menu.SortedAddons()
+	assert.NoError(t, err, "sorting addons")
+	assert.Equal(t, []string{"cert-manager", "aws-load-balancer-controller", "coredns", "external-dns"}, addonNames(sorted))
+
+	assert.Equal(t, []string{"aws-load-balancer-controller"}, addonNames(menu.Dependencies(menu.Addons["external-dns"])))
+}
+
+func Test_SortedAddonsCycle(t *testing.T) {
+	menu := addonMenu(
+		addonWithDependencies(t, "a", "b"),
+		addonWithDependencies(t, "b", "a"),
+		addonWithDependencies(t, "c"),
+	)
+
+	_, err := menu.SortedAddons()
+	assert.EqualError(t, err, "addons have a circular dependency between a, b")
+}
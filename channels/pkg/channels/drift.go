@@ -0,0 +1,427 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channels
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/upup/pkg/fi/utils"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// DriftStatus describes how a live object differs from the manifest of its addon.
+type DriftStatus string
+
+const (
+	// DriftModified means fields set in the manifest have different values in the live object
+	DriftModified DriftStatus = "Modified"
+	// DriftMissing means the object is in the manifest but not in the cluster
+	DriftMissing DriftStatus = "Missing"
+	// DriftExtra means the object is in the cluster and belongs to the addon, but is not in the manifest
+	DriftExtra DriftStatus = "Extra"
+)
+
+// Drift is a difference between the manifest of an installed addon and the cluster.
+type Drift struct {
+	Addon  string      `json:"addon"`
+	Object ObjectRef   `json:"object"`
+	Status DriftStatus `json:"status"`
+	// Fields are the paths of the fields which were modified
+	Fields []string `json:"fields,omitempty"`
+}
+
+// InstalledAddon is an addon recorded as installed in a namespace.
+type InstalledAddon struct {
+	Name      string
+	Namespace string
+	Version   *ChannelVersion
+}
+
+// FindInstalledAddons returns the addons recorded as installed in any namespace, ordered by namespace and name.
+func FindInstalledAddons(ctx context.Context, k8sClient kubernetes.Interface) ([]*InstalledAddon, error) {
+	namespaces, err := k8sClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing namespaces: %v", err)
+	}
+
+	var installed []*InstalledAddon
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		for name, version := range FindAddons(ns) {
+			installed = append(installed, &InstalledAddon{
+				Name:      name,
+				Namespace: ns.Name,
+				Version:   version,
+			})
+		}
+	}
+	sort.Slice(installed, func(i, j int) bool {
+		if installed[i].Namespace != installed[j].Namespace {
+			return installed[i].Namespace < installed[j].Namespace
+		}
+		return installed[i].Name < installed[j].Name
+	})
+	return installed, nil
+}
+
+// FindDrift compares an installed addon with the cluster. The location of the channel the addon was installed
+// from is resolved with resolveChannel, if its manifest was not recorded.
+func (a *Applier) FindDrift(ctx context.Context, k8sClient kubernetes.Interface, addon *InstalledAddon, resolveChannel func(name string) (*url.URL, error)) ([]*Drift, error) {
+	channel := &Channel{
+		Namespace: addon.Namespace,
+		Name:      addon.Name,
+	}
+	installed, err := FindInstalledManifest(ctx, k8sClient, channel, addon.Version, resolveChannel)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := channel.GetInstalledObjects(ctx, k8sClient)
+	if err != nil {
+		return nil, err
+	}
+	return a.Diff(ctx, addon.Name, installed.Selector, installed.Manifest, objects)
+}
+
+// SummarizeDrift counts the objects of an addon by how they differ from its manifest.
+func SummarizeDrift(drifts []*Drift) string {
+	if len(drifts) == 0 {
+		return "-"
+	}
+	counts := make(map[DriftStatus]int)
+	for _, d := range drifts {
+		counts[d.Status]++
+	}
+	var summary []string
+	for _, status := range []DriftStatus{DriftModified, DriftMissing, DriftExtra} {
+		if counts[status] != 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[status], strings.ToLower(string(status))))
+		}
+	}
+	return strings.Join(summary, ", ")
+}
+
+// FindInstalledManifest returns the manifest of the installed version of an addon. The manifest recorded
+// when the addon was applied is used if there is one; otherwise the manifest is fetched from the channel
+// the addon was installed from. The location of the channel is resolved with resolveChannel.
+func FindInstalledManifest(ctx context.Context, k8sClient kubernetes.Interface, channel *Channel, version *ChannelVersion, resolveChannel func(name string) (*url.URL, error)) (*InstalledManifest, error) {
+	installed, err := channel.GetInstalledManifest(ctx, k8sClient)
+	if err != nil || installed != nil {
+		return installed, err
+	}
+
+	if version.Channel == nil {
+		return nil, fmt.Errorf("the channel of addon %q was not recorded", channel.Name)
+	}
+	location, err := resolveChannel(*version.Channel)
+	if err != nil {
+		return nil, err
+	}
+	addons, err := LoadAddons(*version.Channel, location)
+	if err != nil {
+		return nil, err
+	}
+	all, err := addons.wrapInAddons()
+	if err != nil {
+		return nil, err
+	}
+	for _, addon := range all {
+		if addon.Name != channel.Name || stringValue(addon.Spec.Version) != stringValue(version.Version) || addon.Spec.Id != version.Id {
+			continue
+		}
+		manifestURL, err := addon.GetManifestFullUrl()
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := vfs.Context.ReadFile(manifestURL.String())
+		if err != nil {
+			return nil, fmt.Errorf("error reading manifest %q: %v", manifestURL, err)
+		}
+		if version.ManifestHash != "" {
+			hash, err := utils.HashString(string(manifest))
+			if err != nil {
+				return nil, fmt.Errorf("error hashing manifest: %v", err)
+			}
+			if hash != version.ManifestHash {
+				return nil, fmt.Errorf("manifest %q has changed since addon %q was installed", manifestURL, channel.Name)
+			}
+		}
+		return &InstalledManifest{Manifest: manifest, Selector: addon.Spec.Selector}, nil
+	}
+	return nil, fmt.Errorf("installed version %s of addon %q not found in channel %q", version, channel.Name, location)
+}
+
+// Diff compares the manifest of an addon with the objects in the cluster. Objects are extra if they were
+// recorded as applied from the addon, or match the selector of the addon and are of a kind in the manifest.
+func (a *Applier) Diff(ctx context.Context, addon string, selector map[string]string, manifest []byte, installed []ObjectRef) ([]*Drift, error) {
+	objects, err := ParseManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []*Drift
+	inManifest := make(map[ObjectRef]bool)
+	kinds := make(map[schema.GroupKind]bool)
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		ref := ObjectRef{
+			Group:     gvk.Group,
+			Kind:      gvk.Kind,
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+		}
+
+		mapping, err := a.restMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			// The kind is not served, for example because its CustomResourceDefinition was deleted
+			inManifest[ref] = true
+			drifts = append(drifts, &Drift{Addon: addon, Object: ref, Status: DriftMissing})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error finding resource for %s: %v", gvk, err)
+		}
+
+		ref.Namespace = ""
+		var resource dynamic.ResourceInterface = a.Client.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			ref.Namespace = obj.GetNamespace()
+			if ref.Namespace == "" {
+				ref.Namespace = metav1.NamespaceDefault
+			}
+			resource = a.Client.Resource(mapping.Resource).Namespace(ref.Namespace)
+		}
+		inManifest[ref] = true
+		kinds[gvk.GroupKind()] = true
+
+		live, err := resource.Get(ctx, ref.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			drifts = append(drifts, &Drift{Addon: addon, Object: ref, Status: DriftMissing})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error getting %s: %v", ref, err)
+		}
+
+		if fields := diffObject(obj, live); len(fields) != 0 {
+			drifts = append(drifts, &Drift{Addon: addon, Object: ref, Status: DriftModified, Fields: fields})
+		}
+	}
+
+	extra := make(map[ObjectRef]bool)
+	for _, ref := range installed {
+		if inManifest[ref] {
+			continue
+		}
+		exists, err := a.exists(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			extra[ref] = true
+		}
+	}
+	if len(selector) != 0 {
+		for gk := range kinds {
+			mapping, err := a.restMapping(gk)
+			if err != nil {
+				return nil, err
+			}
+			list, err := a.Client.Resource(mapping.Resource).List(ctx, metav1.ListOptions{LabelSelector: labels.SelectorFromSet(selector).String()})
+			if err != nil {
+				return nil, fmt.Errorf("error listing %s: %v", gk, err)
+			}
+			for _, item := range list.Items {
+				ref := ObjectRef{Group: gk.Group, Kind: gk.Kind, Namespace: item.GetNamespace(), Name: item.GetName()}
+				if !inManifest[ref] {
+					extra[ref] = true
+				}
+			}
+		}
+	}
+	for ref := range extra {
+		drifts = append(drifts, &Drift{Addon: addon, Object: ref, Status: DriftExtra})
+	}
+
+	sort.SliceStable(drifts, func(i, j int) bool {
+		return drifts[i].Object.String() < drifts[j].Object.String()
+	})
+	return drifts, nil
+}
+
+// exists returns true if the object is in the cluster.
+func (a *Applier) exists(ctx context.Context, ref ObjectRef) (bool, error) {
+	mapping, err := a.restMapping(schema.GroupKind{Group: ref.Group, Kind: ref.Kind})
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var resource dynamic.ResourceInterface = a.Client.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resource = a.Client.Resource(mapping.Resource).Namespace(ref.Namespace)
+	}
+	_, err = resource.Get(ctx, ref.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error getting %s: %v", ref, err)
+	}
+	return true, nil
+}
+
+// diffObject returns the paths of the fields set in the desired object which have different values in the live object.
+// Fields which are only set in the live object, for example by defaulting, are ignored, except in lists,
+// which must have the same length.
+func diffObject(desired *unstructured.Unstructured, live *unstructured.Unstructured) []string {
+	desired = normalizeWriteOnly(desired)
+
+	var fields []string
+	for k, v := range desired.Object {
+		switch k {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			// The namespace may be defaulted, and the server manages other fields
+			for _, f := range []string{"labels", "annotations"} {
+				d, _, _ := unstructured.NestedFieldNoCopy(desired.Object, "metadata", f)
+				l, _, _ := unstructured.NestedFieldNoCopy(live.Object, "metadata", f)
+				if d != nil {
+					fields = append(fields, diffValue("metadata."+f, d, l)...)
+				}
+			}
+		default:
+			fields = append(fields, diffValue(k, v, live.Object[k])...)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// normalizeWriteOnly returns a copy of obj with fields that the server never returns
+// rewritten to the fields they are stored in.
+func normalizeWriteOnly(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != "" || gvk.Kind != "Secret" {
+		return obj
+	}
+	stringData, found, _ := unstructured.NestedStringMap(obj.Object, "stringData")
+	if !found {
+		return obj
+	}
+
+	obj = obj.DeepCopy()
+	data, ok := obj.Object["data"].(map[string]interface{})
+	if !ok {
+		data = make(map[string]interface{})
+		obj.Object["data"] = data
+	}
+	for k, v := range stringData {
+		// stringData takes precedence over data
+		data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	delete(obj.Object, "stringData")
+	return obj
+}
+
+func diffValue(path string, desired interface{}, live interface{}) []string {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			if len(d) == 0 && live == nil {
+				return nil
+			}
+			return []string{path}
+		}
+		var fields []string
+		for k, v := range d {
+			fields = append(fields, diffValue(path+"."+k, v, l[k])...)
+		}
+		return fields
+
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			if len(d) == 0 && live == nil {
+				return nil
+			}
+			return []string{path}
+		}
+		if len(d) != len(l) {
+			return []string{path}
+		}
+		var fields []string
+		for i := range d {
+			fields = append(fields, diffValue(path+"["+strconv.Itoa(i)+"]", d[i], l[i])...)
+		}
+		return fields
+
+	case nil:
+		return nil
+
+	default:
+		if !scalarsEqual(desired, live) {
+			klog.V(4).Infof("field %s differs: %v != %v", path, desired, live)
+			return []string{path}
+		}
+		return nil
+	}
+}
+
+// scalarsEqual compares scalar values, treating numbers of different types as equal if they have the same value.
+func scalarsEqual(a interface{}, b interface{}) bool {
+	af, aIsNumber := toFloat(a)
+	bf, bIsNumber := toFloat(b)
+	if aIsNumber && bIsNumber {
+		return af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channels
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	fakekubernetes "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kops/upup/pkg/fi/utils"
+)
+
+func Test_DiffObject(t *testing.T) {
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":   "test",
+			"labels": map[string]interface{}{"k8s-addon": "test"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "test", "image": "test:1.0"},
+					},
+				},
+			},
+		},
+	}}
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":            "test",
+			"namespace":       "default",
+			"resourceVersion": "42",
+			"labels":          map[string]interface{}{"k8s-addon": "test"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": float64(2),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "test", "image": "test:1.0", "imagePullPolicy": "IfNotPresent"},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{"replicas": int64(2)},
+	}}
+	assert.Empty(t, diffObject(desired, live), "defaulted fields")
+
+	containers := []interface{}{
+		map[string]interface{}{"name": "test", "image": "test:1.1"},
+		map[string]interface{}{"name": "sidecar", "image": "sidecar:1.0"},
+	}
+	require.NoError(t, unstructured.SetNestedSlice(live.Object, containers[:1], "spec", "template", "spec", "containers"))
+	require.NoError(t, unstructured.SetNestedField(live.Object, "other", "metadata", "labels", "k8s-addon"))
+	assert.Equal(t, []string{"metadata.labels.k8s-addon", "spec.template.spec.containers[0].image"}, diffObject(desired, live), "modified fields")
+
+	require.NoError(t, unstructured.SetNestedSlice(live.Object, containers, "spec", "template", "spec", "containers"))
+	assert.Equal(t, []string{"metadata.labels.k8s-addon", "spec.template.spec.containers"}, diffObject(desired, live), "added list item")
+}
+
+func Test_DiffObjectSecretStringData(t *testing.T) {
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "test"},
+		"data":       map[string]interface{}{"a": "YQ=="},
+		"stringData": map[string]interface{}{"b": "b"},
+	}}
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "test", "namespace": "default"},
+		"data":       map[string]interface{}{"a": "YQ==", "b": "Yg=="},
+		"type":       "Opaque",
+	}}
+	assert.Empty(t, diffObject(desired, live), "stringData stored in data")
+	assert.Contains(t, desired.Object, "stringData", "desired object is not modified")
+
+	require.NoError(t, unstructured.SetNestedField(live.Object, "Yw==", "data", "b"))
+	assert.Equal(t, []string{"data.b"}, diffObject(desired, live), "modified stringData")
+}
+
+func Test_FindInstalledManifest(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "channels")
+	require.NoError(t, err)
+
+	manifest := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"
+	channelYAML := `
+kind: Addons
+metadata:
+  name: test
+spec:
+  addons:
+  - version: 1.0.0
+    selector:
+      k8s-addon: test
+    manifest: v1.0.0.yaml
+  - version: 1.1.0
+    selector:
+      k8s-addon: test
+    manifest: v1.1.0.yaml
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "addon.yaml"), []byte(channelYAML), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "v1.0.0.yaml"), []byte(manifest), 0644))
+	hash, err := utils.HashString(manifest)
+	require.NoError(t, err)
+
+	channelName := "file://" + filepath.Join(dir, "addon.yaml")
+	version := &ChannelVersion{
+		Channel:      &channelName,
+		Version:      s("1.0.0"),
+		ManifestHash: hash,
+	}
+	channel := &Channel{Namespace: "kube-system", Name: "test"}
+	fakek8s := fakekubernetes.NewSimpleClientset()
+
+	installed, err := FindInstalledManifest(ctx, fakek8s, channel, version, url.Parse)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, string(installed.Manifest), "manifest from channel")
+	assert.Equal(t, map[string]string{"k8s-addon": "test"}, installed.Selector, "selector from channel")
+
+	version.ManifestHash = "changed"
+	_, err = FindInstalledManifest(ctx, fakek8s, channel, version, url.Parse)
+	assert.Error(t, err, "changed manifest")
+
+	recorded := &InstalledManifest{Manifest: []byte("kind: Secret\n")}
+	require.NoError(t, channel.SetInstalledManifest(ctx, fakek8s, recorded))
+	installed, err = FindInstalledManifest(ctx, fakek8s, channel, version, url.Parse)
+	require.NoError(t, err)
+	assert.Equal(t, recorded, installed, "recorded manifest")
+}
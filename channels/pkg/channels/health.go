@@ -0,0 +1,162 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channels
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultHealthCheckTimeout is how long we wait for the workloads of an addon to become ready,
+	// if the addon does not set a timeout
+	defaultHealthCheckTimeout = 5 * time.Minute
+)
+
+// healthCheckInterval is how often we check whether the workloads of an addon are ready
+var healthCheckInterval = 5 * time.Second
+
+// healthCheckTimeout returns how long to wait for the addon to become healthy
+func (a *Addon) healthCheckTimeout() time.Duration {
+	if a.Spec.HealthCheck != nil && a.Spec.HealthCheck.Timeout != nil {
+		return a.Spec.HealthCheck.Timeout.Duration
+	}
+	return defaultHealthCheckTimeout
+}
+
+// WaitForHealthy waits for the Deployments, DaemonSets and StatefulSets matching the selector of the addon to become ready.
+// An addon without a selector is considered healthy.
+func (a *Addon) WaitForHealthy(ctx context.Context, k8sClient kubernetes.Interface) error {
+	if len(a.Spec.Selector) == 0 {
+		klog.V(2).Infof("addon %q has no selector; assuming it is healthy", a.Name)
+		return nil
+	}
+
+	timeout := a.healthCheckTimeout()
+	klog.Infof("waiting up to %v for addon %q to become healthy", timeout, a.Name)
+
+	var unready []string
+	err := wait.PollImmediate(healthCheckInterval, timeout, func() (bool, error) {
+		var err error
+		unready, err = a.unreadyWorkloads(ctx, k8sClient)
+		if err != nil {
+			klog.Warningf("error checking health of addon %q: %v", a.Name, err)
+			return false, nil
+		}
+		if len(unready) != 0 {
+			klog.V(2).Infof("addon %q has workloads which are not ready: %s", a.Name, strings.Join(unready, ", "))
+			return false, nil
+		}
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		if len(unready) != 0 {
+			return fmt.Errorf("addon %q did not become healthy within %v; workloads not ready: %s", a.Name, timeout, strings.Join(unready, ", "))
+		}
+		return fmt.Errorf("addon %q did not become healthy within %v", a.Name, timeout)
+	}
+	return err
+}
+
+// unreadyWorkloads returns the workloads of the addon which are not ready.
+func (a *Addon) unreadyWorkloads(ctx context.Context, k8sClient kubernetes.Interface) ([]string, error) {
+	options := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(a.Spec.Selector).String()}
+	var unready []string
+
+	deployments, err := k8sClient.AppsV1().Deployments("").List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments: %v", err)
+	}
+	for i := range deployments.Items {
+		if d := &deployments.Items[i]; !isDeploymentReady(d) {
+			unready = append(unready, "Deployment "+d.Namespace+"/"+d.Name)
+		}
+	}
+
+	daemonSets, err := k8sClient.AppsV1().DaemonSets("").List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("error listing daemonsets: %v", err)
+	}
+	for i := range daemonSets.Items {
+		if ds := &daemonSets.Items[i]; !isDaemonSetReady(ds) {
+			unready = append(unready, "DaemonSet "+ds.Namespace+"/"+ds.Name)
+		}
+	}
+
+	statefulSets, err := k8sClient.AppsV1().StatefulSets("").List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("error listing statefulsets: %v", err)
+	}
+	for i := range statefulSets.Items {
+		if ss := &statefulSets.Items[i]; !isStatefulSetReady(ss) {
+			unready = append(unready, "StatefulSet "+ss.Namespace+"/"+ss.Name)
+		}
+	}
+
+	return unready, nil
+}
+
+func isDeploymentReady(d *appsv1.Deployment) bool {
+	if d.Status.ObservedGeneration < d.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Status.UpdatedReplicas >= replicas && d.Status.AvailableReplicas >= replicas && d.Status.Replicas == d.Status.UpdatedReplicas
+}
+
+func isDaemonSetReady(ds *appsv1.DaemonSet) bool {
+	if ds.Status.ObservedGeneration < ds.Generation {
+		return false
+	}
+	if ds.Spec.UpdateStrategy.Type != appsv1.OnDeleteDaemonSetStrategyType && ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled {
+		return false
+	}
+	return ds.Status.NumberAvailable >= ds.Status.DesiredNumberScheduled
+}
+
+func isStatefulSetReady(ss *appsv1.StatefulSet) bool {
+	if ss.Status.ObservedGeneration < ss.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if ss.Spec.Replicas != nil {
+		replicas = *ss.Spec.Replicas
+	}
+	if ss.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType {
+		// Pods with an ordinal below the partition are not updated
+		partition := int32(0)
+		if ss.Spec.UpdateStrategy.RollingUpdate != nil && ss.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+			partition = *ss.Spec.UpdateStrategy.RollingUpdate.Partition
+		}
+		if ss.Status.UpdatedReplicas < replicas-partition {
+			return false
+		}
+	}
+	return ss.Status.ReadyReplicas >= replicas
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package channels
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubernetes "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kops/channels/pkg/api"
+)
+
+func Test_WaitForHealthy(t *testing.T) {
+	healthCheckInterval = time.Millisecond
+	ctx := context.Background()
+
+	replicas := int32(2)
+	labels := map[string]string{"k8s-addon": "test"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Namespace:  "kube-system",
+			Labels:     labels,
+			Generation: 2,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+		},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           2,
+			UpdatedReplicas:    2,
+			AvailableReplicas:  2,
+		},
+	}
+	daemonSet := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "kube-system",
+			Labels:    labels,
+		},
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 3,
+			NumberAvailable:        2,
+		},
+	}
+	otherDaemonSet := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "other",
+			Namespace: "kube-system",
+		},
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: 3,
+		},
+	}
+	fakek8s := fakekubernetes.NewSimpleClientset(deployment, daemonSet, otherDaemonSet)
+
+	addon := &Addon{
+		Name: "test",
+		Spec: &api.AddonSpec{
+			Selector: labels,
+			HealthCheck: &api.HealthCheckSpec{
+				Timeout: &metav1.Duration{Duration: 10 * time.Millisecond},
+			},
+		},
+	}
+	err := addon.WaitForHealthy(ctx, fakek8s)
+	assert.EqualError(t, err, "addon \"test\" did not become healthy within 10ms; workloads not ready: DaemonSet kube-system/test")
+
+	daemonSet.Status.NumberAvailable = 3
+	_, err = fakek8s.AppsV1().DaemonSets("kube-system").Update(ctx, daemonSet, metav1.UpdateOptions{})
+	assert.NoError(t, err, "updating daemonset")
+	assert.NoError(t, addon.WaitForHealthy(ctx, fakek8s), "healthy addon")
+
+	// Without a selector, workloads of other addons are not waited for
+	addon.Spec.Selector = nil
+	assert.NoError(t, addon.WaitForHealthy(ctx, fakek8s), "addon without selector")
+}
+
+func Test_HandleUnhealthyFirstInstall(t *testing.T) {
+	ctx := context.Background()
+	fakek8s := fakekubernetes.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-system"},
+	})
+
+	addon := &Addon{
+		Name: "test",
+		Spec: &api.AddonSpec{},
+	}
+	objects := []ObjectRef{
+		{Group: "apps", Kind: "Deployment", Namespace: "kube-system", Name: "test"},
+	}
+	err := addon.handleUnhealthy(ctx, fakek8s, nil, nil, nil, nil, objects, errors.New("addon \"test\" did not become healthy"))
+	assert.EqualError(t, err, "addon \"test\" did not become healthy; not rolled back, as no previous version of the addon was installed")
+
+	recorded, err := addon.buildChannel().GetInstalledObjects(ctx, fakek8s)
+	assert.NoError(t, err, "getting installed objects")
+	assert.Equal(t, objects, recorded, "objects applied by the failed install are recorded for pruning")
+}
+
+func Test_IsDeploymentReady(t *testing.T) {
+	replicas := int32(3)
+	grid := []struct {
+		Status   appsv1.DeploymentStatus
+		Expected bool
+	}{
+		{
+			Status:   appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+			Expected: true,
+		},
+		{
+			// Generation not yet observed
+			Status:   appsv1.DeploymentStatus{ObservedGeneration: 0, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+			Expected: false,
+		},
+		{
+			// Old pods still running
+			Status:   appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 4, UpdatedReplicas: 3, AvailableReplicas: 4},
+			Expected: false,
+		},
+		{
+			Status:   appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2},
+			Expected: false,
+		},
+	}
+	for _, g := range grid {
+		d := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Generation: 1},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     g.Status,
+		}
+		assert.Equal(t, g.Expected, isDeploymentReady(d), "status %+v", g.Status)
+	}
+}
+
+func Test_IsStatefulSetReady(t *testing.T) {
+	replicas := int32(3)
+	partition := int32(2)
+	ss := &appsv1.StatefulSet{
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+					Partition: &partition,
+				},
+			},
+		},
+		Status: appsv1.StatefulSetStatus{
+			ReadyReplicas:   3,
+			UpdatedReplicas: 1,
+		},
+	}
+	assert.True(t, isStatefulSetReady(ss), "partitioned statefulset")
+
+	ss.Spec.UpdateStrategy.RollingUpdate = nil
+	assert.False(t, isStatefulSetReady(ss), "statefulset with pods to update")
+}
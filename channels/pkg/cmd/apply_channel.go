@@ -22,7 +22,6 @@ import (
 	"io"
 	"net/url"
 	"os"
-	"strings"
 
 	"github.com/blang/semver/v4"
 	"github.com/spf13/cobra"
@@ -64,6 +63,11 @@ func RunApplyChannel(ctx context.Context, f Factory, out io.Writer, options *App
 		return err
 	}
 
+	dynamicClient, err := f.DynamicClient()
+	if err != nil {
+		return err
+	}
+
 	kubernetesVersionInfo, err := k8sClient.Discovery().ServerVersion()
 	if err != nil {
 		return fmt.Errorf("error querying kubernetes version: %v", err)
@@ -80,21 +84,9 @@ func RunApplyChannel(ctx context.Context, f Factory, out io.Writer, options *App
 	menu := channels.NewAddonMenu()
 
 	for _, name := range args {
-		location, err := url.Parse(name)
+		location, err := channels.ResolveChannel(name)
 		if err != nil {
-			return fmt.Errorf("unable to parse argument %q as url", name)
-		}
-		if !location.IsAbs() {
-			// We recognize the following "well-known" format:
-			// <name> with no slashes ->
-			if strings.Contains(name, "/") {
-				return fmt.Errorf("Channel format not recognized (did you mean to use `-f` to specify a local file?): %q", name)
-			}
-			expanded := "https://raw.githubusercontent.com/kubernetes/kops/master/addons/" + name + "/addon.yaml"
-			location, err = url.Parse(expanded)
-			if err != nil {
-				return fmt.Errorf("unable to parse expanded argument %q as url", expanded)
-			}
+			return err
 		}
 		o, err := channels.LoadAddons(name, location)
 		if err != nil {
@@ -136,9 +128,14 @@ func RunApplyChannel(ctx context.Context, f Factory, out io.Writer, options *App
 		menu.MergeAddons(current)
 	}
 
+	addons, err := menu.SortedAddons()
+	if err != nil {
+		return err
+	}
+
 	var updates []*channels.AddonUpdate
 	var needUpdates []*channels.Addon
-	for _, addon := range menu.Addons {
+	for _, addon := range addons {
 		// TODO: Cache lookups to prevent repeated lookups?
 		update, err := addon.GetRequiredUpdates(ctx, k8sClient, cmClient)
 		if err != nil {
@@ -197,8 +194,15 @@ func RunApplyChannel(ctx context.Context, f Factory, out io.Writer, options *App
 		return nil
 	}
 
+	applier := channels.NewApplier(dynamicClient, k8sClient.Discovery())
 	for _, needUpdate := range needUpdates {
-		update, err := needUpdate.EnsureUpdated(ctx, k8sClient, cmClient)
+		for _, dependency := range menu.Dependencies(needUpdate) {
+			if err := dependency.WaitForHealthy(ctx, k8sClient); err != nil {
+				return fmt.Errorf("error waiting for dependency of %q: %v", needUpdate.Name, err)
+			}
+		}
+
+		update, err := needUpdate.EnsureUpdated(ctx, k8sClient, cmClient, applier)
 		if err != nil {
 			return fmt.Errorf("error updating %q: %v", needUpdate.Name, err)
 		}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/channels/pkg/channels"
+	"k8s.io/kops/util/pkg/tables"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+type DiffOptions struct {
+	Output string
+}
+
+func NewCmdDiff(f Factory, out io.Writer) *cobra.Command {
+	options := &DiffOptions{
+		Output: OutputTable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare installed addons with the cluster",
+		Long:  `Compare the objects of installed addons with the manifests of their installed versions, reporting objects which were modified, are missing, or are extra.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.TODO()
+			return RunDiff(ctx, f, out, options)
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format: table or json")
+
+	return cmd
+}
+
+func RunDiff(ctx context.Context, f Factory, out io.Writer, options *DiffOptions) error {
+	if options.Output != OutputTable && options.Output != OutputJSON {
+		return fmt.Errorf("unknown output format: %q", options.Output)
+	}
+
+	k8sClient, err := f.KubernetesClient()
+	if err != nil {
+		return err
+	}
+
+	dynamicClient, err := f.DynamicClient()
+	if err != nil {
+		return err
+	}
+
+	addons, err := channels.FindInstalledAddons(ctx, k8sClient)
+	if err != nil {
+		return err
+	}
+
+	applier := channels.NewApplier(dynamicClient, k8sClient.Discovery())
+	drifts := []*channels.Drift{}
+	for _, addon := range addons {
+		d, err := applier.FindDrift(ctx, k8sClient, addon, channels.ResolveChannel)
+		if err != nil {
+			return fmt.Errorf("error comparing addon %q: %v", addon.Name, err)
+		}
+		drifts = append(drifts, d...)
+	}
+
+	if options.Output == OutputJSON {
+		b, err := json.MarshalIndent(drifts, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling drift: %v", err)
+		}
+		b = append(b, '\n')
+		_, err = out.Write(b)
+		return err
+	}
+
+	if len(drifts) == 0 {
+		fmt.Fprintf(out, "No drift found\n")
+		return nil
+	}
+
+	t := &tables.Table{}
+	t.AddColumn("ADDON", func(r *channels.Drift) string {
+		return r.Addon
+	})
+	t.AddColumn("STATUS", func(r *channels.Drift) string {
+		return string(r.Status)
+	})
+	t.AddColumn("KIND", func(r *channels.Drift) string {
+		if r.Object.Group == "" {
+			return r.Object.Kind
+		}
+		return r.Object.Kind + "." + r.Object.Group
+	})
+	t.AddColumn("NAMESPACE", func(r *channels.Drift) string {
+		return r.Object.Namespace
+	})
+	t.AddColumn("NAME", func(r *channels.Drift) string {
+		return r.Object.Name
+	})
+	t.AddColumn("FIELDS", func(r *channels.Drift) string {
+		return strings.Join(r.Fields, ",")
+	})
+	return t.Render(drifts, out, "ADDON", "STATUS", "KIND", "NAMESPACE", "NAME", "FIELDS")
+}
@@ -37,15 +37,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
-	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kops/channels/pkg/channels"
 	"k8s.io/kops/util/pkg/tables"
 )
 
 type GetAddonsOptions struct {
+	// Drift compares each addon with the cluster
+	Drift bool
 }
 
 func NewCmdGetAddons(f Factory, out io.Writer) *cobra.Command {
@@ -62,13 +63,14 @@ func NewCmdGetAddons(f Factory, out io.Writer) *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&options.Drift, "drift", options.Drift, "Compare each addon with the cluster, summarizing objects which were modified, are missing, or are extra")
+
 	return cmd
 }
 
 type addonInfo struct {
-	Name      string
-	Version   *channels.ChannelVersion
-	Namespace *v1.Namespace
+	*channels.InstalledAddon
+	Drift string
 }
 
 func RunGetAddons(ctx context.Context, f Factory, out io.Writer, options *GetAddonsOptions) error {
@@ -77,23 +79,30 @@ func RunGetAddons(ctx context.Context, f Factory, out io.Writer, options *GetAdd
 		return err
 	}
 
-	namespaces, err := k8sClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	installed, err := channels.FindInstalledAddons(ctx, k8sClient)
 	if err != nil {
-		return fmt.Errorf("error listing namespaces: %v", err)
+		return err
 	}
-
 	var info []*addonInfo
+	var failed []string
+	for _, addon := range installed {
+		info = append(info, &addonInfo{InstalledAddon: addon})
+	}
 
-	for i := range namespaces.Items {
-		ns := &namespaces.Items[i]
-		addons := channels.FindAddons(ns)
-		for name, version := range addons {
-			i := &addonInfo{
-				Name:      name,
-				Version:   version,
-				Namespace: ns,
+	if options.Drift {
+		dynamicClient, err := f.DynamicClient()
+		if err != nil {
+			return err
+		}
+		applier := channels.NewApplier(dynamicClient, k8sClient.Discovery())
+		for _, i := range info {
+			drifts, err := applier.FindDrift(ctx, k8sClient, i.InstalledAddon, channels.ResolveChannel)
+			if err != nil {
+				i.Drift = fmt.Sprintf("error: %v", err)
+				failed = append(failed, i.Namespace+"/"+i.Name)
+				continue
 			}
-			info = append(info, i)
+			i.Drift = channels.SummarizeDrift(drifts)
 		}
 	}
 
@@ -108,7 +117,7 @@ func RunGetAddons(ctx context.Context, f Factory, out io.Writer, options *GetAdd
 			return r.Name
 		})
 		t.AddColumn("NAMESPACE", func(r *addonInfo) string {
-			return r.Namespace.Name
+			return r.Namespace
 		})
 		t.AddColumn("VERSION", func(r *addonInfo) string {
 			if r.Version == nil {
@@ -129,7 +138,14 @@ func RunGetAddons(ctx context.Context, f Factory, out io.Writer, options *GetAdd
 			return "?"
 		})
 
+		t.AddColumn("DRIFT", func(r *addonInfo) string {
+			return r.Drift
+		})
+
 		columns := []string{"NAMESPACE", "NAME", "VERSION", "CHANNEL"}
+		if options.Drift {
+			columns = append(columns, "DRIFT")
+		}
 		err := t.Render(info, os.Stdout, columns...)
 		if err != nil {
 			return err
@@ -138,5 +154,9 @@ func RunGetAddons(ctx context.Context, f Factory, out io.Writer, options *GetAdd
 
 	fmt.Printf("\n")
 
+	// A comparison which failed must not look like an addon which has not drifted
+	if len(failed) != 0 {
+		return fmt.Errorf("unable to compare addons with the cluster: %s", strings.Join(failed, ", "))
+	}
 	return nil
 }
@@ -38,6 +38,10 @@ import (
 	nodeidentityos "k8s.io/kops/pkg/nodeidentity/openstack"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/do"
+	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/yaml"
@@ -88,15 +92,24 @@ func main() {
 	if opt.Server != nil {
 		var verifier fi.Verifier
 		var err error
-		if opt.Server.Provider.AWS != nil {
+		switch {
+		case opt.Server.Provider.AWS != nil:
 			verifier, err = awsup.NewAWSVerifier(opt.Server.Provider.AWS)
-			if err != nil {
-				setupLog.Error(err, "unable to create verifier")
-				os.Exit(1)
-			}
-		} else {
+		case opt.Server.Provider.GCE != nil:
+			verifier, err = gce.NewGCEVerifier(opt.Server.Provider.GCE)
+		case opt.Server.Provider.OpenStack != nil:
+			verifier, err = openstack.NewOpenstackVerifier(opt.Server.Provider.OpenStack)
+		case opt.Server.Provider.Azure != nil:
+			verifier, err = azure.NewAzureVerifier(opt.Server.Provider.Azure)
+		case opt.Server.Provider.DO != nil:
+			verifier, err = do.NewDOVerifier(opt.Server.Provider.DO)
+		default:
 			klog.Fatalf("server cloud provider config not provided")
 		}
+		if err != nil {
+			setupLog.Error(err, "unable to create verifier")
+			os.Exit(1)
+		}
 
 		srv, err := server.NewServer(&opt, verifier)
 		if err != nil {
@@ -16,7 +16,13 @@ limitations under the License.
 
 package config
 
-import "k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+import (
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/do"
+	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
+)
 
 type Options struct {
 	Cloud                 string         `json:"cloud,omitempty"`
@@ -49,5 +55,9 @@ type ServerOptions struct {
 }
 
 type ServerProviderOptions struct {
-	AWS *awsup.AWSVerifierOptions `json:"aws,omitempty"`
+	AWS       *awsup.AWSVerifierOptions           `json:"aws,omitempty"`
+	GCE       *gce.GCEVerifierOptions             `json:"gce,omitempty"`
+	OpenStack *openstack.OpenstackVerifierOptions `json:"openstack,omitempty"`
+	Azure     *azure.AzureVerifierOptions         `json:"azure,omitempty"`
+	DO        *do.DOVerifierOptions               `json:"do,omitempty"`
 }
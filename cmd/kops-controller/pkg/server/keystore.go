@@ -31,6 +31,8 @@ type keystore struct {
 type keystoreEntry struct {
 	certificate *pki.Certificate
 	key         *pki.PrivateKey
+	// bundle holds all the certificates which should be trusted, with certificate first
+	bundle []*pki.Certificate
 }
 
 var _ pki.Keystore = keystore{}
@@ -43,7 +45,17 @@ func (k keystore) FindKeypair(name string) (*pki.Certificate, *pki.PrivateKey, b
 	return entry.certificate, entry.key, false, nil
 }
 
-func newKeystore(basePath string, cas []string) (pki.Keystore, error) {
+// FindCertificateBundle returns the named certificate, followed by any others which should also be trusted,
+// such as the previous CA during a rotation.
+func (k keystore) FindCertificateBundle(name string) ([]*pki.Certificate, error) {
+	entry, ok := k.keys[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown CA %q", name)
+	}
+	return entry.bundle, nil
+}
+
+func newKeystore(basePath string, cas []string) (*keystore, error) {
 	keystore := &keystore{
 		keys: map[string]keystoreEntry{},
 	}
@@ -52,7 +64,7 @@ func newKeystore(basePath string, cas []string) (pki.Keystore, error) {
 		if err != nil {
 			return nil, fmt.Errorf("reading %q certificate: %v", name, err)
 		}
-		certificate, err := pki.ParsePEMCertificate(certBytes)
+		bundle, err := pki.ParsePEMCertificates(certBytes)
 		if err != nil {
 			return nil, fmt.Errorf("parsing %q certificate: %v", name, err)
 		}
@@ -67,8 +79,9 @@ func newKeystore(basePath string, cas []string) (pki.Keystore, error) {
 		}
 
 		keystore.keys[name] = keystoreEntry{
-			certificate: certificate,
+			certificate: bundle[0],
 			key:         key,
+			bundle:      bundle,
 		}
 	}
 
@@ -62,19 +62,20 @@ func (s *Server) getNodeConfig(ctx context.Context, req *nodeup.BootstrapRequest
 	}
 
 	// We populate some certificates that we know the node will need.
+	// Any other CAs being rotated in or out are included, so the node trusts them too.
 	for _, name := range []string{"ca"} {
-		cert, _, _, err := s.keystore.FindKeypair(name)
+		certs, err := s.keystore.FindCertificateBundle(name)
 		if err != nil {
 			return nil, fmt.Errorf("error getting certificate %q: %w", name, err)
 		}
 
-		if cert == nil {
-			return nil, fmt.Errorf("certificate %q not found", name)
-		}
-
-		certData, err := cert.AsString()
-		if err != nil {
-			return nil, fmt.Errorf("error marshalling certificate %q: %w", name, err)
+		var certData string
+		for _, cert := range certs {
+			data, err := cert.AsString()
+			if err != nil {
+				return nil, fmt.Errorf("error marshalling certificate %q: %w", name, err)
+			}
+			certData += data
 		}
 
 		nodeConfig.Certificates = append(nodeConfig.Certificates, &nodeup.NodeConfigCertificate{
@@ -17,6 +17,9 @@ limitations under the License.
 package server
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -44,7 +47,7 @@ type Server struct {
 	certNames sets.String
 	server    *http.Server
 	verifier  fi.Verifier
-	keystore  pki.Keystore
+	keystore  *keystore
 
 	// configBase is the base of the configuration storage.
 	configBase vfs.Path
@@ -74,6 +77,7 @@ func NewServer(opt *config.Options, verifier fi.Verifier) (*Server, error) {
 
 	r := http.NewServeMux()
 	r.Handle("/bootstrap", http.HandlerFunc(s.bootstrap))
+	r.Handle("/renew", http.HandlerFunc(s.renew))
 	server.Handler = recovery(r)
 
 	return s, nil
@@ -86,64 +90,133 @@ func (s *Server) Start() error {
 		return err
 	}
 
+	// Nodes renewing their certificates authenticate with their current kubelet client certificate
+	caCertificates, err := s.keystore.FindCertificateBundle(fi.CertificateIDCA)
+	if err != nil {
+		return err
+	}
+	clientCAs := x509.NewCertPool()
+	for _, caCertificate := range caCertificates {
+		clientCAs.AddCert(caCertificate.Certificate)
+	}
+	s.server.TLSConfig.ClientCAs = clientCAs
+	s.server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
 	return s.server.ListenAndServeTLS(s.opt.Server.ServerCertificatePath, s.opt.Server.ServerKeyPath)
 }
 
 func (s *Server) bootstrap(w http.ResponseWriter, r *http.Request) {
+	req, id, ok := s.verifyRequest(w, r, "bootstrap")
+	if !ok {
+		return
+	}
+
+	resp := &nodeup.BootstrapResponse{
+		Certs: map[string]string{},
+	}
+
+	// Support for nodes that have no access to the state store
+	if req.IncludeNodeConfig {
+		nodeConfig, err := s.getNodeConfig(r.Context(), req, id)
+		if err != nil {
+			klog.Infof("bootstrap failed to build node config: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("failed to build node config"))
+			return
+		}
+		resp.NodeConfig = nodeConfig
+	}
+
+	s.issueCerts(w, r, "bootstrap", req, id, resp)
+}
+
+// renew issues fresh certificates to a node which has already bootstrapped.
+// The node must present its current kubelet client certificate as well as proving its identity to the cloud provider.
+func (s *Server) renew(w http.ResponseWriter, r *http.Request) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		klog.Infof("renew %s no client certificate", r.RemoteAddr)
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("client certificate required"))
+		return
+	}
+	clientCert := r.TLS.VerifiedChains[0][0]
+
+	req, id, ok := s.verifyRequest(w, r, "renew")
+	if !ok {
+		return
+	}
+
+	if err := checkRenewIdentity(clientCert, id); err != nil {
+		klog.Infof("renew %s identity err: %v", r.RemoteAddr, err)
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(fmt.Sprintf("failed to verify client certificate: %v", err)))
+		return
+	}
+
+	resp := &nodeup.BootstrapResponse{
+		Certs: map[string]string{},
+	}
+	s.issueCerts(w, r, "renew", req, id, resp)
+}
+
+// checkRenewIdentity checks that the client certificate is the kubelet client certificate of the verified node.
+func checkRenewIdentity(clientCert *x509.Certificate, id *fi.VerifyResult) error {
+	expected := fmt.Sprintf("system:node:%s", id.NodeName)
+	if clientCert.Subject.CommonName != expected {
+		return fmt.Errorf("certificate is for %q, not %q", clientCert.Subject.CommonName, expected)
+	}
+	if !sets.NewString(clientCert.Subject.Organization...).Has(rbac.NodesGroup) {
+		return fmt.Errorf("certificate is not in the %q group", rbac.NodesGroup)
+	}
+	return nil
+}
+
+// verifyRequest reads the request, verifies the identity of the node and decodes the request body.
+// If verification fails, the response is written and ok is false.
+func (s *Server) verifyRequest(w http.ResponseWriter, r *http.Request, action string) (req *nodeup.BootstrapRequest, id *fi.VerifyResult, ok bool) {
 	if r.Body == nil {
-		klog.Infof("bootstrap %s no body", r.RemoteAddr)
+		klog.Infof("%s %s no body", action, r.RemoteAddr)
 		w.WriteHeader(http.StatusBadRequest)
-		return
+		return nil, nil, false
 	}
 
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		klog.Infof("bootstrap %s read err: %v", r.RemoteAddr, err)
+		klog.Infof("%s %s read err: %v", action, r.RemoteAddr, err)
 		w.WriteHeader(http.StatusBadRequest)
-		_, _ = w.Write([]byte(fmt.Sprintf("bootstrap %s failed to read body: %v", r.RemoteAddr, err)))
-		return
+		_, _ = w.Write([]byte(fmt.Sprintf("%s %s failed to read body: %v", action, r.RemoteAddr, err)))
+		return nil, nil, false
 	}
 
-	id, err := s.verifier.VerifyToken(r.Header.Get("Authorization"), body)
+	id, err = s.verifier.VerifyToken(r.Context(), r, r.Header.Get("Authorization"), body)
 	if err != nil {
-		klog.Infof("bootstrap %s verify err: %v", r.RemoteAddr, err)
+		klog.Infof("%s %s verify err: %v", action, r.RemoteAddr, err)
 		w.WriteHeader(http.StatusForbidden)
 		_, _ = w.Write([]byte(fmt.Sprintf("failed to verify token: %v", err)))
-		return
+		return nil, nil, false
 	}
 
-	req := &nodeup.BootstrapRequest{}
+	req = &nodeup.BootstrapRequest{}
 	err = json.Unmarshal(body, req)
 	if err != nil {
-		klog.Infof("bootstrap %s decode err: %v", r.RemoteAddr, err)
+		klog.Infof("%s %s decode err: %v", action, r.RemoteAddr, err)
 		w.WriteHeader(http.StatusBadRequest)
 		_, _ = w.Write([]byte(fmt.Sprintf("failed to decode: %v", err)))
-		return
+		return nil, nil, false
 	}
 
 	if req.APIVersion != nodeup.BootstrapAPIVersion {
-		klog.Infof("bootstrap %s wrong APIVersion", r.RemoteAddr)
+		klog.Infof("%s %s wrong APIVersion", action, r.RemoteAddr)
 		w.WriteHeader(http.StatusBadRequest)
 		_, _ = w.Write([]byte("unexpected APIVersion"))
-		return
-	}
-
-	resp := &nodeup.BootstrapResponse{
-		Certs: map[string]string{},
+		return nil, nil, false
 	}
 
-	// Support for nodes that have no access to the state store
-	if req.IncludeNodeConfig {
-		nodeConfig, err := s.getNodeConfig(r.Context(), req, id)
-		if err != nil {
-			klog.Infof("bootstrap failed to build node config: %v", err)
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write([]byte("failed to build node config"))
-			return
-		}
-		resp.NodeConfig = nodeConfig
-	}
+	return req, id, true
+}
 
+// issueCerts issues the requested certificates and writes the response.
+func (s *Server) issueCerts(w http.ResponseWriter, r *http.Request, action string, req *nodeup.BootstrapRequest, id *fi.VerifyResult, resp *nodeup.BootstrapResponse) {
 	// Skew the certificate lifetime by up to 30 days based on information about the requesting node.
 	// This is so that different nodes created at the same time have the certificates they generated
 	// expire at different times, but all certificates on a given node expire around the same time.
@@ -154,7 +227,7 @@ func (s *Server) bootstrap(w http.ResponseWriter, r *http.Request) {
 	for name, pubKey := range req.Certs {
 		cert, err := s.issueCert(name, pubKey, id, validHours)
 		if err != nil {
-			klog.Infof("bootstrap %s cert %q issue err: %v", r.RemoteAddr, name, err)
+			klog.Infof("%s %s cert %q issue err: %v", action, r.RemoteAddr, name, err)
 			w.WriteHeader(http.StatusBadRequest)
 			_, _ = w.Write([]byte(fmt.Sprintf("failed to issue %q: %v", name, err)))
 			return
@@ -164,18 +237,27 @@ func (s *Server) bootstrap(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
-	klog.Infof("bootstrap %s %s success", r.RemoteAddr, id.NodeName)
+	klog.Infof("%s %s %s success", action, r.RemoteAddr, id.NodeName)
 }
 
 func (s *Server) issueCert(name string, pubKey string, id *fi.VerifyResult, validHours uint32) (string, error) {
 	block, _ := pem.Decode([]byte(pubKey))
-	if block.Type != "RSA PUBLIC KEY" {
+	if block == nil {
+		return "", fmt.Errorf("could not parse public key")
+	}
+	// Nodes send RSA keys as "RSA PUBLIC KEY" and other keys as "PUBLIC KEY", both in PKIX form
+	if block.Type != "RSA PUBLIC KEY" && block.Type != "PUBLIC KEY" {
 		return "", fmt.Errorf("unexpected key type %q", block.Type)
 	}
 	key, err := x509.ParsePKIXPublicKey(block.Bytes)
 	if err != nil {
 		return "", fmt.Errorf("parsing key: %v", err)
 	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return "", fmt.Errorf("unsupported key type %T", key)
+	}
 
 	issueReq := &pki.IssueCertRequest{
 		Signer:    fi.CertificateIDCA,
@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/kops/pkg/rbac"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+type fakeVerifier struct {
+	result *fi.VerifyResult
+}
+
+func (v *fakeVerifier) VerifyToken(ctx context.Context, rawRequest *http.Request, token string, body []byte) (*fi.VerifyResult, error) {
+	return v.result, nil
+}
+
+func TestCheckRenewIdentity(t *testing.T) {
+	id := &fi.VerifyResult{NodeName: "node-1"}
+
+	grid := []struct {
+		name    string
+		subject pkix.Name
+		valid   bool
+	}{
+		{
+			name:    "kubelet certificate of node",
+			subject: pkix.Name{CommonName: "system:node:node-1", Organization: []string{rbac.NodesGroup}},
+			valid:   true,
+		},
+		{
+			name:    "kubelet certificate of other node",
+			subject: pkix.Name{CommonName: "system:node:node-2", Organization: []string{rbac.NodesGroup}},
+		},
+		{
+			name:    "not in nodes group",
+			subject: pkix.Name{CommonName: "system:node:node-1"},
+		},
+		{
+			name:    "kube-proxy certificate",
+			subject: pkix.Name{CommonName: rbac.KubeProxy},
+		},
+	}
+
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			err := checkRenewIdentity(&x509.Certificate{Subject: g.subject}, id)
+			if g.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !g.valid && err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestRenewRequiresClientCertificate(t *testing.T) {
+	s := &Server{
+		verifier: &fakeVerifier{result: &fi.VerifyResult{NodeName: "node-1"}},
+	}
+	body := []byte(`{"apiVersion":"bootstrap.kops.k8s.io/v1alpha1","certs":{}}`)
+
+	grid := []struct {
+		name     string
+		tls      *tls.ConnectionState
+		expected int
+	}{
+		{
+			name:     "no tls",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "no client certificate",
+			tls:      &tls.ConnectionState{},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name: "client certificate of other node",
+			tls: &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{
+					{Subject: pkix.Name{CommonName: "system:node:node-2", Organization: []string{rbac.NodesGroup}}},
+				}},
+			},
+			expected: http.StatusForbidden,
+		},
+		{
+			name: "client certificate of node",
+			tls: &tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{
+					{Subject: pkix.Name{CommonName: "system:node:node-1", Organization: []string{rbac.NodesGroup}}},
+				}},
+			},
+			expected: http.StatusOK,
+		},
+	}
+
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/renew", bytes.NewReader(body))
+			r.TLS = g.tls
+			w := httptest.NewRecorder()
+			s.renew(w, r)
+			if w.Code != g.expected {
+				t.Errorf("expected status %d, got %d: %s", g.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -193,6 +193,8 @@ func describeKeypair(keyStore fi.CAStore, item *fi.KeystoreItem, w *bytes.Buffer
 		if rsaPrivateKey, ok := key.Key.(*rsa.PrivateKey); ok {
 			fmt.Fprintf(w, "PrivateKeyType:\t%v\n", "rsa")
 			fmt.Fprintf(w, "KeyLength:\t%v\n", rsaPrivateKey.N.BitLen())
+		} else if algorithm, err := key.Algorithm(); err == nil {
+			fmt.Fprintf(w, "PrivateKeyType:\t%v\n", algorithm)
 		} else {
 			fmt.Fprintf(w, "PrivateKeyType:\tunknown (%T)\n", key.Key)
 		}
@@ -0,0 +1,221 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kops/channels/pkg/channels"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+type GetAddonsOptions struct {
+	*GetOptions
+
+	// Drift compares each addon with the cluster
+	Drift bool
+}
+
+var (
+	getAddonsLong = templates.LongDesc(i18n.T(`
+	Display the addons installed in a cluster by kops.
+
+	With --drift, each addon is compared with the manifest it was installed from,
+	summarizing the objects which were modified, are missing, or are extra.
+	The JSON and YAML output list the individual objects and fields. If any addon
+	cannot be compared, the command exits with an error after printing the others.`))
+
+	getAddonsExample = templates.Examples(i18n.T(`
+	# Display the addons installed in a cluster.
+	kops get addons --name k8s-cluster.example.com
+
+	# Display the addons installed in a cluster, and whether they have drifted.
+	kops get addons --name k8s-cluster.example.com --drift
+
+	# List the objects of each addon which have drifted as JSON.
+	kops get addons --name k8s-cluster.example.com --drift -o json
+	`))
+
+	getAddonsShort = i18n.T(`Display installed addons.`)
+)
+
+// addonItem is an addon in the output of get addons
+type addonItem struct {
+	Name      string                   `json:"name"`
+	Namespace string                   `json:"namespace"`
+	Version   *channels.ChannelVersion `json:"version,omitempty"`
+	// Drift lists the objects which differ from the manifest of the addon, when checked with --drift
+	Drift []*channels.Drift `json:"drift,omitempty"`
+	// DriftError is why the addon could not be compared with the cluster
+	DriftError string `json:"driftError,omitempty"`
+}
+
+func NewCmdGetAddons(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetAddonsOptions{
+		GetOptions: getOptions,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "addons",
+		Aliases: []string{"addon"},
+		Short:   getAddonsShort,
+		Long:    getAddonsLong,
+		Example: getAddonsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.TODO()
+
+			if err := rootCommand.ProcessArgs(args); err != nil {
+				exitWithError(err)
+			}
+
+			err := RunGetAddons(ctx, f, out, &options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.Drift, "drift", options.Drift, "Compare each addon with the cluster, summarizing objects which were modified, are missing, or are extra")
+
+	return cmd
+}
+
+func RunGetAddons(ctx context.Context, f *util.Factory, out io.Writer, options *GetAddonsOptions) error {
+	switch options.output {
+	case OutputTable, OutputYaml, OutputJSON:
+	default:
+		return fmt.Errorf("Unknown output format: %q", options.output)
+	}
+
+	cluster, err := rootCommand.Cluster(ctx)
+	if err != nil {
+		return err
+	}
+
+	config, err := createK8sRESTConfig(cluster)
+	if err != nil {
+		return err
+	}
+	k8sClient, err := createK8sClient(cluster)
+	if err != nil {
+		return err
+	}
+
+	installed, err := channels.FindInstalledAddons(ctx, k8sClient)
+	if err != nil {
+		return err
+	}
+	items := []*addonItem{}
+	var failed []string
+	for _, addon := range installed {
+		items = append(items, &addonItem{Name: addon.Name, Namespace: addon.Namespace, Version: addon.Version})
+	}
+
+	if options.Drift {
+		dynamicClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return fmt.Errorf("cannot build dynamic kubernetes api client for %q: %v", cluster.ObjectMeta.Name, err)
+		}
+		applier := channels.NewApplier(dynamicClient, k8sClient.Discovery())
+		for i, addon := range installed {
+			drifts, err := applier.FindDrift(ctx, k8sClient, addon, channels.ResolveChannel)
+			if err != nil {
+				items[i].DriftError = err.Error()
+				failed = append(failed, addon.Namespace+"/"+addon.Name)
+				continue
+			}
+			items[i].Drift = drifts
+		}
+	}
+
+	if err := renderAddons(items, out, options); err != nil {
+		return err
+	}
+
+	// A comparison which failed must not look like an addon which has not drifted
+	if len(failed) != 0 {
+		return fmt.Errorf("unable to compare addons with the cluster: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func renderAddons(items []*addonItem, out io.Writer, options *GetAddonsOptions) error {
+	switch options.output {
+	case OutputYaml:
+		y, err := yaml.Marshal(items)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		_, err = out.Write(y)
+		return err
+
+	case OutputJSON:
+		j, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		_, err = fmt.Fprintf(out, "%s\n", j)
+		return err
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintf(out, "No addons found\n")
+		return nil
+	}
+
+	t := &tables.Table{}
+	t.AddColumn("NAME", func(i *addonItem) string {
+		return i.Name
+	})
+	t.AddColumn("NAMESPACE", func(i *addonItem) string {
+		return i.Namespace
+	})
+	t.AddColumn("VERSION", func(i *addonItem) string {
+		if i.Version == nil || i.Version.Version == nil {
+			return "-"
+		}
+		return *i.Version.Version
+	})
+	t.AddColumn("CHANNEL", func(i *addonItem) string {
+		if i.Version == nil || i.Version.Channel == nil {
+			return "-"
+		}
+		return *i.Version.Channel
+	})
+	t.AddColumn("DRIFT", func(i *addonItem) string {
+		if i.DriftError != "" {
+			return "error: " + i.DriftError
+		}
+		return channels.SummarizeDrift(i.Drift)
+	})
+
+	columns := []string{"NAMESPACE", "NAME", "VERSION", "CHANNEL"}
+	if options.Drift {
+		columns = append(columns, "DRIFT")
+	}
+	return t.Render(items, out, columns...)
+}
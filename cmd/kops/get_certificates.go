@@ -0,0 +1,185 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/certificates"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	getCertificatesLong = templates.LongDesc(i18n.T(`
+	Display the certificates in the keystore of a cluster, with their subject,
+	issuer, serial number and expiry, and which component uses them.`))
+
+	getCertificatesExample = templates.Examples(i18n.T(`
+	# Get all the certificates of a cluster
+	kops get certificates
+
+	# Get the certificates which expire within the next 30 days
+	kops get certificates --expiring-within=30d
+
+	# Get the certificates of the cluster CA
+	kops get certificates ca`))
+
+	getCertificatesShort = i18n.T(`Get the certificates of a cluster.`)
+)
+
+type GetCertificatesOptions struct {
+	*GetOptions
+
+	// ExpiringWithin limits the output to certificates which expire within this duration, such as "30d"
+	ExpiringWithin string
+}
+
+func NewCmdGetCertificates(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetCertificatesOptions{
+		GetOptions: getOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "certificates",
+		Aliases: []string{"certificate", "certs", "cert"},
+		Short:   getCertificatesShort,
+		Long:    getCertificatesLong,
+		Example: getCertificatesExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.TODO()
+			err := RunGetCertificates(ctx, out, &options, args)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.ExpiringWithin, "expiring-within", options.ExpiringWithin, "Only show certificates which expire within this duration, for example 30d or 12h")
+
+	return cmd
+}
+
+func RunGetCertificates(ctx context.Context, out io.Writer, options *GetCertificatesOptions, args []string) error {
+	var expiringWithin time.Duration
+	if options.ExpiringWithin != "" {
+		var err error
+		expiringWithin, err = certificates.ParseDuration(options.ExpiringWithin)
+		if err != nil {
+			return fmt.Errorf("invalid --expiring-within: %v", err)
+		}
+	}
+
+	cluster, err := rootCommand.Cluster(ctx)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := rootCommand.Clientset()
+	if err != nil {
+		return err
+	}
+
+	keyStore, err := clientset.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+
+	entries, err := certificates.List(keyStore)
+	if err != nil {
+		return err
+	}
+
+	if len(args) != 0 {
+		names := make(map[string]bool)
+		for _, arg := range args {
+			names[arg] = true
+		}
+		var filtered []*certificates.Entry
+		for _, entry := range entries {
+			if names[entry.Keyset] {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+
+	if options.ExpiringWithin != "" {
+		entries = certificates.ExpiringWithin(entries, expiringWithin, time.Now())
+	}
+
+	switch options.output {
+
+	case OutputTable:
+		if len(entries) == 0 {
+			fmt.Fprintf(out, "No certificates found\n")
+			return nil
+		}
+		t := &tables.Table{}
+		t.AddColumn("KEYSET", func(e *certificates.Entry) string {
+			return e.Keyset
+		})
+		t.AddColumn("ID", func(e *certificates.Entry) string {
+			return e.ID
+		})
+		t.AddColumn("COMPONENT", func(e *certificates.Entry) string {
+			return e.Component
+		})
+		t.AddColumn("SUBJECT", func(e *certificates.Entry) string {
+			return e.Subject
+		})
+		t.AddColumn("ISSUER", func(e *certificates.Entry) string {
+			return e.Issuer
+		})
+		t.AddColumn("SERIAL", func(e *certificates.Entry) string {
+			return e.Serial
+		})
+		t.AddColumn("NOTAFTER", func(e *certificates.Entry) string {
+			return e.NotAfter.UTC().Format(time.RFC3339)
+		})
+		t.AddColumn("INUSE", func(e *certificates.Entry) string {
+			return fmt.Sprintf("%t", e.InUse)
+		})
+		return t.Render(entries, out, "KEYSET", "ID", "COMPONENT", "SUBJECT", "ISSUER", "SERIAL", "NOTAFTER", "INUSE")
+
+	case OutputYaml:
+		y, err := yaml.Marshal(entries)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		_, err = out.Write(y)
+		return err
+
+	case OutputJSON:
+		j, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		_, err = fmt.Fprintf(out, "%s\n", j)
+		return err
+
+	default:
+		return fmt.Errorf("Unknown output format: %q", options.output)
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -183,6 +184,19 @@ func instanceOutputTable(instances []*cloudinstances.CloudInstance, out io.Write
 }
 
 func createK8sClient(cluster *kops.Cluster) (*kubernetes.Clientset, error) {
+	config, err := createK8sRESTConfig(cluster)
+	if err != nil {
+		return nil, err
+	}
+	k8sClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build kubernetes api client for %q: %v", cluster.ObjectMeta.Name, err)
+	}
+	return k8sClient, nil
+
+}
+
+func createK8sRESTConfig(cluster *kops.Cluster) (*rest.Config, error) {
 	contextName := cluster.ObjectMeta.Name
 	clientGetter := genericclioptions.NewConfigFlags(true)
 	clientGetter.Context = &contextName
@@ -191,10 +205,5 @@ func createK8sClient(cluster *kops.Cluster) (*kubernetes.Clientset, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot load kubecfg settings for %q: %v", contextName, err)
 	}
-	k8sClient, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, fmt.Errorf("cannot build kubernetes api client for %q: %v", contextName, err)
-	}
-	return k8sClient, nil
-
+	return config, nil
 }
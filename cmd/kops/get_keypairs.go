@@ -0,0 +1,180 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	getKeypairsLong = templates.LongDesc(i18n.T(`
+	Display one or many keypairs, including which key is primary
+	and the stage of any CA rotation in progress.`))
+
+	getKeypairsExample = templates.Examples(i18n.T(`
+	# Get all the keypairs in a cluster
+	kops get keypairs
+
+	# Get the keys of the cluster CA
+	kops get keypairs ca`))
+
+	getKeypairsShort = i18n.T(`Get one or many keypairs.`)
+)
+
+type GetKeypairsOptions struct {
+	*GetOptions
+}
+
+// keypairItem is a row of the output of get keypairs
+type keypairItem struct {
+	Name     string
+	ID       string
+	Primary  bool
+	Rotation *kops.KeysetRotation
+}
+
+func NewCmdGetKeypairs(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetKeypairsOptions{
+		GetOptions: getOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "keypairs",
+		Aliases: []string{"keypair"},
+		Short:   getKeypairsShort,
+		Long:    getKeypairsLong,
+		Example: getKeypairsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.TODO()
+			err := RunGetKeypairs(ctx, out, &options, args)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+// listKeypairs returns the items of the named keypairs, or of all keypairs if no names are given
+func listKeypairs(keyStore fi.CAStore, names []string) ([]*keypairItem, error) {
+	if len(names) == 0 {
+		keysets, err := keyStore.ListKeysets()
+		if err != nil {
+			return nil, fmt.Errorf("error listing Keysets: %v", err)
+		}
+		for _, keyset := range keysets {
+			if keyset.Spec.Type == kops.SecretTypeKeypair {
+				names = append(names, keyset.Name)
+			}
+		}
+		sort.Strings(names)
+	}
+
+	var items []*keypairItem
+	for _, name := range names {
+		keyset, err := keyStore.FindCertificateKeyset(name)
+		if err != nil {
+			return nil, err
+		}
+		if keyset == nil {
+			return nil, fmt.Errorf("Keypair not found: %q", name)
+		}
+
+		primary := fi.FindPrimary(keyset)
+		for _, key := range keyset.Spec.Keys {
+			items = append(items, &keypairItem{
+				Name:     name,
+				ID:       key.Id,
+				Primary:  primary != nil && primary.Id == key.Id,
+				Rotation: keyset.Spec.Rotation,
+			})
+		}
+	}
+	return items, nil
+}
+
+func RunGetKeypairs(ctx context.Context, out io.Writer, options *GetKeypairsOptions, args []string) error {
+	cluster, err := rootCommand.Cluster(ctx)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := rootCommand.Clientset()
+	if err != nil {
+		return err
+	}
+
+	keyStore, err := clientset.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+
+	items, err := listKeypairs(keyStore, args)
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		return fmt.Errorf("No keypairs found")
+	}
+	switch options.output {
+
+	case OutputTable:
+		t := &tables.Table{}
+		t.AddColumn("NAME", func(i *keypairItem) string {
+			return i.Name
+		})
+		t.AddColumn("ID", func(i *keypairItem) string {
+			return i.ID
+		})
+		t.AddColumn("PRIMARY", func(i *keypairItem) string {
+			return fmt.Sprintf("%t", i.Primary)
+		})
+		t.AddColumn("ROTATION", func(i *keypairItem) string {
+			if i.Rotation == nil {
+				return ""
+			}
+			switch i.ID {
+			case i.Rotation.PreviousId:
+				return string(i.Rotation.Stage) + " (previous)"
+			case i.Rotation.NewId:
+				return string(i.Rotation.Stage) + " (new)"
+			}
+			return string(i.Rotation.Stage)
+		})
+		return t.Render(items, out, "NAME", "ID", "PRIMARY", "ROTATION")
+
+	case OutputYaml:
+		return fmt.Errorf("yaml output format is not (currently) supported for keypairs")
+	case OutputJSON:
+		return fmt.Errorf("json output format is not (currently) supported for keypairs")
+
+	default:
+		return fmt.Errorf("Unknown output format: %q", options.output)
+	}
+}
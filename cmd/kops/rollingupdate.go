@@ -33,6 +33,7 @@ func NewCmdRollingUpdate(f *util.Factory, out io.Writer) *cobra.Command {
 
 	// create subcommands
 	cmd.AddCommand(NewCmdRollingUpdateCluster(f, out))
+	cmd.AddCommand(NewCmdRollingUpdateStatus(f, out))
 
 	return cmd
 }
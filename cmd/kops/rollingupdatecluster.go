@@ -18,6 +18,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -31,9 +32,13 @@ import (
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/klog/v2"
 	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/acls"
 	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
 	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/eventstream"
 	"k8s.io/kops/pkg/instancegroups"
 	"k8s.io/kops/pkg/pretty"
 	"k8s.io/kops/pkg/validation"
@@ -41,6 +46,7 @@ import (
 	"k8s.io/kops/util/pkg/tables"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
 )
 
 var (
@@ -95,6 +101,10 @@ var (
 		  --fail-on-validate-error="false" \
 		  --node-interval 8m \
 		  --instance-group nodes
+
+		# Resume an interrupted rolling-update of the k8s-cluster.example.com kOps cluster,
+		# using the settings it was started with.
+		kops rolling-update cluster k8s-cluster.example.com --yes --resume
 		`))
 
 	rollingupdateShort = i18n.T(`Rolling update a cluster.`)
@@ -137,6 +147,10 @@ type RollingUpdateOptions struct {
 	// Interactive rolling-update prompts user to continue after each instances is updated.
 	Interactive bool
 
+	// MaxConcurrentGroups is the number of node instance groups to update at the same time;
+	// if zero, the cluster's rollingUpdateMaxConcurrentGroups setting is used
+	MaxConcurrentGroups int32
+
 	ClusterName string
 
 	// InstanceGroups is the list of instance groups to rolling-update;
@@ -146,6 +160,18 @@ type RollingUpdateOptions struct {
 	// InstanceGroupRoles is the list of roles we should rolling-update
 	// if not specified, all instance groups will be updated
 	InstanceGroupRoles []string
+
+	// Resume continues an interrupted rolling-update from the progress recorded in the state store.
+	Resume bool
+
+	// DiscardProgress starts a new rolling-update, discarding the progress of an interrupted one.
+	DiscardProgress bool
+
+	// Output is the format of the rolling-update plan
+	Output string
+
+	// EventsOut is the file to write a JSON-lines stream of rolling-update events to, or "-" for stdout
+	EventsOut string
 }
 
 func (o *RollingUpdateOptions) InitDefaults() {
@@ -163,6 +189,8 @@ func (o *RollingUpdateOptions) InitDefaults() {
 	o.PostDrainDelay = 5 * time.Second
 	o.ValidationTimeout = 15 * time.Minute
 	o.ValidateCount = 2
+
+	o.Output = OutputTable
 }
 
 func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
@@ -193,11 +221,16 @@ func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().DurationVar(&options.BastionInterval, "bastion-interval", options.BastionInterval, "Time to wait between restarting bastions")
 	cmd.Flags().DurationVar(&options.PostDrainDelay, "post-drain-delay", options.PostDrainDelay, "Time to wait after draining each node")
 	cmd.Flags().BoolVarP(&options.Interactive, "interactive", "i", options.Interactive, "Prompt to continue after each instance is updated")
+	cmd.Flags().Int32Var(&options.MaxConcurrentGroups, "max-concurrent-groups", options.MaxConcurrentGroups, "Maximum number of node instance groups to update at the same time (defaults to the cluster's rollingUpdateMaxConcurrentGroups, or 1)")
 	cmd.Flags().StringSliceVar(&options.InstanceGroups, "instance-group", options.InstanceGroups, "List of instance groups to update (defaults to all if not specified)")
 	cmd.Flags().StringSliceVar(&options.InstanceGroupRoles, "instance-group-roles", options.InstanceGroupRoles, "If specified, only instance groups of the specified role will be updated ("+strings.Join(allRoles, ",")+")")
 
 	cmd.Flags().BoolVar(&options.FailOnDrainError, "fail-on-drain-error", true, "The rolling-update will fail if draining a node fails.")
 	cmd.Flags().BoolVar(&options.FailOnValidate, "fail-on-validate-error", true, "The rolling-update will fail if the cluster fails to validate.")
+	cmd.Flags().BoolVar(&options.Resume, "resume", options.Resume, "Resume an interrupted rolling-update with the settings it was started with, skipping completed work")
+	cmd.Flags().BoolVar(&options.DiscardProgress, "discard-progress", options.DiscardProgress, "Start a new rolling-update, discarding the recorded progress of an interrupted rolling-update")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format of the rolling-update plan. One of json|yaml|table.")
+	cmd.Flags().StringVar(&options.EventsOut, "events-out", options.EventsOut, "Write a JSON-lines stream of rolling-update events to this file, or - for stdout, in which case other output goes to stderr")
 
 	cmd.Run = func(cmd *cobra.Command, args []string) {
 		ctx := context.TODO()
@@ -228,6 +261,21 @@ func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 }
 
 func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer, options *RollingUpdateOptions) error {
+	// Other output goes to stderr when the event stream is written to stdout, so that it can be parsed
+	stdout := out
+	if options.EventsOut == stdoutPath {
+		out = os.Stderr
+	}
+
+	switch options.Output {
+	case OutputTable, OutputYaml, OutputJSON:
+	default:
+		return fmt.Errorf("unknown output format: %q", options.Output)
+	}
+
+	if options.Resume && options.DiscardProgress {
+		return fmt.Errorf("cannot specify both --resume and --discard-progress")
+	}
 
 	clientset, err := f.Clientset()
 	if err != nil {
@@ -239,6 +287,25 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 		return err
 	}
 
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return err
+	}
+	progressPath := configBase.Join(registry.PathRollingUpdateProgress)
+
+	var progress *instancegroups.RollingUpdateProgress
+	if options.Resume {
+		progress, err = instancegroups.ReadProgress(progressPath)
+		if err != nil {
+			return err
+		}
+		if progress == nil || progress.Completed {
+			return fmt.Errorf("no interrupted rolling-update found for cluster %q", options.ClusterName)
+		}
+		options.applyProgressOptions(progress.Options)
+		klog.Infof("Resuming rolling-update started at %s", progress.StartedAt)
+	}
+
 	contextName := cluster.ObjectMeta.Name
 	clientGetter := genericclioptions.NewConfigFlags(true)
 	clientGetter.Context = &contextName
@@ -336,34 +403,69 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 	}
 
 	d := &instancegroups.RollingUpdateCluster{
-		Clientset:         clientset,
-		Ctx:               ctx,
-		Cluster:           cluster,
-		MasterInterval:    options.MasterInterval,
-		NodeInterval:      options.NodeInterval,
-		BastionInterval:   options.BastionInterval,
-		Interactive:       options.Interactive,
-		Force:             options.Force,
-		Cloud:             cloud,
-		K8sClient:         k8sClient,
-		FailOnDrainError:  options.FailOnDrainError,
-		FailOnValidate:    options.FailOnValidate,
-		CloudOnly:         options.CloudOnly,
-		ClusterName:       options.ClusterName,
-		PostDrainDelay:    options.PostDrainDelay,
-		ValidationTimeout: options.ValidationTimeout,
-		ValidateCount:     int(options.ValidateCount),
+		Clientset:           clientset,
+		Ctx:                 ctx,
+		Cluster:             cluster,
+		MasterInterval:      options.MasterInterval,
+		NodeInterval:        options.NodeInterval,
+		BastionInterval:     options.BastionInterval,
+		Interactive:         options.Interactive,
+		Force:               options.Force,
+		Cloud:               cloud,
+		K8sClient:           k8sClient,
+		FailOnDrainError:    options.FailOnDrainError,
+		FailOnValidate:      options.FailOnValidate,
+		CloudOnly:           options.CloudOnly,
+		ClusterName:         options.ClusterName,
+		PostDrainDelay:      options.PostDrainDelay,
+		ValidationTimeout:   options.ValidationTimeout,
+		ValidateCount:       int(options.ValidateCount),
+		MaxConcurrentGroups: int(options.MaxConcurrentGroups),
 		// TODO should we expose this to the UI?
 		ValidateTickDuration:    30 * time.Second,
 		ValidateSuccessDuration: 10 * time.Second,
+		Out:                     out,
 	}
 
+	acl, err := acls.GetACL(progressPath, cluster)
+	if err != nil {
+		return err
+	}
+	d.Progress = instancegroups.NewProgressTracker(progressPath, acl, options.progressOptions(), progress)
+
 	err = d.AdjustNeedUpdate(groups)
 	if err != nil {
 		return err
 	}
 
-	{
+	plan, err := d.Plan(groups)
+	if err != nil {
+		return err
+	}
+
+	// Messages go to stderr when the plan is machine-readable
+	var msgOut io.Writer = os.Stderr
+
+	switch options.Output {
+	case OutputYaml:
+		y, err := yaml.Marshal(plan)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.Marshal(plan)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := fmt.Fprintf(out, "%s\n", j); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputTable:
+		msgOut = out
+
 		t := &tables.Table{}
 		t.AddColumn("NAME", func(r *cloudinstances.CloudInstanceGroup) string {
 			return r.InstanceGroup.ObjectMeta.Name
@@ -413,6 +515,10 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 		if err != nil {
 			return err
 		}
+
+		if err := rollingUpdatePlanOutputTable(plan, out); err != nil {
+			return err
+		}
 	}
 
 	needUpdate := false
@@ -423,15 +529,25 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 	}
 
 	if !needUpdate && !options.Force {
-		fmt.Printf("\nNo rolling-update required.\n")
+		fmt.Fprintf(msgOut, "\nNo rolling-update required.\n")
 		return nil
 	}
 
 	if !options.Yes {
-		fmt.Printf("\nMust specify --yes to rolling-update.\n")
+		fmt.Fprintf(msgOut, "\nMust specify --yes to rolling-update.\n")
 		return nil
 	}
 
+	if !options.Resume && !options.DiscardProgress {
+		existing, err := instancegroups.ReadProgress(progressPath)
+		if err != nil {
+			return err
+		}
+		if existing != nil && !existing.Completed {
+			return fmt.Errorf("the rolling-update started at %s did not finish; specify --resume to continue it, or --discard-progress to start a new rolling-update", existing.StartedAt)
+		}
+	}
+
 	var clusterValidator validation.ClusterValidator
 	if !options.CloudOnly {
 		clusterValidator, err = validation.NewClusterValidator(cluster, cloud, list, config.Host, k8sClient)
@@ -441,5 +557,101 @@ func RunRollingUpdateCluster(ctx context.Context, f *util.Factory, out io.Writer
 	}
 	d.ClusterValidator = clusterValidator
 
+	if options.EventsOut != "" {
+		events, err := eventstream.Open(options.EventsOut, stdout)
+		if err != nil {
+			return err
+		}
+		defer events.Close()
+		d.Events = events
+	}
+
 	return d.RollingUpdate(groups, list)
 }
+
+// progressOptions returns the settings to record, so that the rolling-update can be resumed with them.
+func (o *RollingUpdateOptions) progressOptions() instancegroups.ProgressOptions {
+	return instancegroups.ProgressOptions{
+		Force:               o.Force,
+		CloudOnly:           o.CloudOnly,
+		FailOnDrainError:    o.FailOnDrainError,
+		FailOnValidate:      o.FailOnValidate,
+		MasterInterval:      metav1.Duration{Duration: o.MasterInterval},
+		NodeInterval:        metav1.Duration{Duration: o.NodeInterval},
+		BastionInterval:     metav1.Duration{Duration: o.BastionInterval},
+		PostDrainDelay:      metav1.Duration{Duration: o.PostDrainDelay},
+		ValidationTimeout:   metav1.Duration{Duration: o.ValidationTimeout},
+		ValidateCount:       int(o.ValidateCount),
+		InstanceGroups:      o.InstanceGroups,
+		InstanceGroupRoles:  o.InstanceGroupRoles,
+		MaxConcurrentGroups: int(o.MaxConcurrentGroups),
+	}
+}
+
+// applyProgressOptions restores the settings recorded when the rolling-update was started.
+func (o *RollingUpdateOptions) applyProgressOptions(p instancegroups.ProgressOptions) {
+	o.Force = p.Force
+	o.CloudOnly = p.CloudOnly
+	o.FailOnDrainError = p.FailOnDrainError
+	o.FailOnValidate = p.FailOnValidate
+	o.MasterInterval = p.MasterInterval.Duration
+	o.NodeInterval = p.NodeInterval.Duration
+	o.BastionInterval = p.BastionInterval.Duration
+	o.PostDrainDelay = p.PostDrainDelay.Duration
+	o.ValidationTimeout = p.ValidationTimeout.Duration
+	o.ValidateCount = int32(p.ValidateCount)
+	o.InstanceGroups = p.InstanceGroups
+	o.InstanceGroupRoles = p.InstanceGroupRoles
+	o.MaxConcurrentGroups = int32(p.MaxConcurrentGroups)
+}
+
+type rollingUpdatePlanRow struct {
+	Group    *instancegroups.GroupPlan
+	Batch    string
+	Instance *instancegroups.InstancePlan
+}
+
+// rollingUpdatePlanOutputTable renders the instances to be updated, in the order they will be updated.
+func rollingUpdatePlanOutputTable(plan *instancegroups.RollingUpdatePlan, out io.Writer) error {
+	var rows []*rollingUpdatePlanRow
+	for _, g := range plan.Groups {
+		for _, i := range g.WarmPool {
+			rows = append(rows, &rollingUpdatePlanRow{Group: g, Batch: "-", Instance: i})
+		}
+		for n, batch := range g.Batches {
+			for _, i := range batch {
+				rows = append(rows, &rollingUpdatePlanRow{Group: g, Batch: strconv.Itoa(n + 1), Instance: i})
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(out, "\n")
+	t := &tables.Table{}
+	t.AddColumn("INSTANCEGROUP", func(r *rollingUpdatePlanRow) string {
+		return r.Group.Name
+	})
+	t.AddColumn("BATCH", func(r *rollingUpdatePlanRow) string {
+		return r.Batch
+	})
+	t.AddColumn("INSTANCE", func(r *rollingUpdatePlanRow) string {
+		return r.Instance.ID
+	})
+	t.AddColumn("NODE", func(r *rollingUpdatePlanRow) string {
+		return r.Instance.NodeName
+	})
+	t.AddColumn("STATUS", func(r *rollingUpdatePlanRow) string {
+		return r.Instance.Status
+	})
+	t.AddColumn("ACTION", func(r *rollingUpdatePlanRow) string {
+		return string(r.Instance.Action)
+	})
+	if err := t.Render(rows, out, "INSTANCEGROUP", "BATCH", "INSTANCE", "NODE", "STATUS", "ACTION"); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\nEstimated minimum duration: %s (excluding drain time and time for replacements to join the cluster)\n", plan.EstimatedDuration.Duration)
+	return nil
+}
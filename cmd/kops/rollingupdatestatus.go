@@ -0,0 +1,182 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/instancegroups"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	rollingUpdateStatusLong = templates.LongDesc(i18n.T(`
+	Display the progress of the last rolling-update of a cluster, as recorded in the state store.`))
+
+	rollingUpdateStatusExample = templates.Examples(i18n.T(`
+	# Display the progress of the rolling-update of the k8s-cluster.example.com cluster.
+	kops rolling-update status k8s-cluster.example.com
+	`))
+
+	rollingUpdateStatusShort = i18n.T(`Display the progress of a rolling update.`)
+)
+
+type RollingUpdateStatusOptions struct {
+	ClusterName string
+	Output      string
+}
+
+func NewCmdRollingUpdateStatus(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RollingUpdateStatusOptions{
+		Output: OutputTable,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "status",
+		Short:   rollingUpdateStatusShort,
+		Long:    rollingUpdateStatusLong,
+		Example: rollingUpdateStatusExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.TODO()
+
+			if err := rootCommand.ProcessArgs(args); err != nil {
+				exitWithError(err)
+			}
+
+			options.ClusterName = rootCommand.ClusterName()
+			if options.ClusterName == "" {
+				exitWithError(fmt.Errorf("--name is required"))
+			}
+
+			if err := RunRollingUpdateStatus(ctx, f, out, options); err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format. One of json|yaml|table.")
+
+	return cmd
+}
+
+func RunRollingUpdateStatus(ctx context.Context, f *util.Factory, out io.Writer, options *RollingUpdateStatusOptions) error {
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return err
+	}
+
+	progress, err := instancegroups.ReadProgress(configBase.Join(registry.PathRollingUpdateProgress))
+	if err != nil {
+		return err
+	}
+	if progress == nil {
+		return fmt.Errorf("no rolling-update recorded for cluster %q", options.ClusterName)
+	}
+
+	switch options.Output {
+	case OutputTable:
+		return rollingUpdateStatusOutputTable(progress, out)
+	case OutputYaml:
+		y, err := yaml.Marshal(progress)
+		if err != nil {
+			return fmt.Errorf("unable to marshal YAML: %v", err)
+		}
+		if _, err := out.Write(y); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	case OutputJSON:
+		j, err := json.Marshal(progress)
+		if err != nil {
+			return fmt.Errorf("unable to marshal JSON: %v", err)
+		}
+		if _, err := out.Write(j); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+		if _, err := out.Write([]byte("\n")); err != nil {
+			return fmt.Errorf("error writing to output: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown output format: %q", options.Output)
+	}
+	return nil
+}
+
+type rollingUpdateStatusRow struct {
+	Name     string
+	Progress *instancegroups.GroupProgress
+}
+
+func rollingUpdateStatusOutputTable(progress *instancegroups.RollingUpdateProgress, out io.Writer) error {
+	state := "In progress"
+	if progress.Completed {
+		state = "Completed"
+	}
+	fmt.Fprintf(out, "Rolling update started %s, last updated %s: %s\n\n", progress.StartedAt, progress.UpdatedAt, state)
+
+	var rows []*rollingUpdateStatusRow
+	for name, g := range progress.Groups {
+		rows = append(rows, &rollingUpdateStatusRow{Name: name, Progress: g})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Name < rows[j].Name
+	})
+
+	t := &tables.Table{}
+	t.AddColumn("NAME", func(r *rollingUpdateStatusRow) string {
+		return r.Name
+	})
+	t.AddColumn("ROLE", func(r *rollingUpdateStatusRow) string {
+		return r.Progress.Role
+	})
+	t.AddColumn("STATUS", func(r *rollingUpdateStatusRow) string {
+		return string(r.Progress.Status)
+	})
+	t.AddColumn("PLANNED", func(r *rollingUpdateStatusRow) string {
+		return strconv.Itoa(len(r.Progress.Instances))
+	})
+	t.AddColumn("PENDING", func(r *rollingUpdateStatusRow) string {
+		return strconv.Itoa(r.Progress.Count(instancegroups.InstanceProgressPending))
+	})
+	t.AddColumn("DRAINED", func(r *rollingUpdateStatusRow) string {
+		return strconv.Itoa(r.Progress.Count(instancegroups.InstanceProgressDrained))
+	})
+	t.AddColumn("TERMINATED", func(r *rollingUpdateStatusRow) string {
+		return strconv.Itoa(r.Progress.Count(instancegroups.InstanceProgressTerminated))
+	})
+	return t.Render(rows, out, "NAME", "ROLE", "STATUS", "PLANNED", "PENDING", "DRAINED", "TERMINATED")
+}
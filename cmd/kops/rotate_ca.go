@@ -0,0 +1,379 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/instancegroups"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	rotateCALong = templates.LongDesc(i18n.T(`
+	Rotate the cluster CA in stages, so that the cluster keeps working throughout:
+
+	1. A new CA is added to the keystore alongside the current one, and the cluster
+	is updated and rolled so that every component trusts both CAs.
+
+	2. The new CA is promoted to primary, and the certificates in the keystore which
+	were issued by the previous CA are reissued.
+
+	3. The cluster is updated and rolled, so that the certificates issued on the nodes
+	are issued by the new CA.
+
+	4. The previous CA is removed from the keystore.
+
+	The stage of the rotation is recorded in the keystore as each stage completes, and is
+	shown by kops get keypairs ca. If a stage fails, running the command again resumes
+	the rotation from that stage.`))
+
+	rotateCAExample = templates.Examples(i18n.T(`
+	# Show the stage of the CA rotation, and what the next stage will do
+	kops rotate ca --name k8s-cluster.example.com
+
+	# Rotate the CA, running all the remaining stages
+	kops rotate ca --name k8s-cluster.example.com --yes
+
+	# Run only the next stage of the rotation
+	kops rotate ca --name k8s-cluster.example.com --yes --step
+	`))
+
+	rotateCAShort = i18n.T(`Rotate the cluster CA.`)
+)
+
+type RotateCAOptions struct {
+	ClusterName string
+	Yes         bool
+
+	// Step runs only the next stage of the rotation
+	Step bool
+
+	// ValidationTimeout is the timeout for the cluster to validate during the rolling updates
+	ValidationTimeout time.Duration
+}
+
+func NewCmdRotateCA(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RotateCAOptions{
+		ValidationTimeout: 15 * time.Minute,
+	}
+
+	cmd := &cobra.Command{
+		Use:     "ca",
+		Short:   rotateCAShort,
+		Long:    rotateCALong,
+		Example: rotateCAExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.TODO()
+
+			err := rootCommand.ProcessArgs(args)
+			if err != nil {
+				exitWithError(err)
+				return
+			}
+
+			options.ClusterName = rootCommand.ClusterName()
+			if options.ClusterName == "" {
+				exitWithError(fmt.Errorf("--name is required"))
+				return
+			}
+
+			err = RunRotateCA(ctx, f, out, options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Rotate the CA, without --yes the stage of the rotation is shown")
+	cmd.Flags().BoolVar(&options.Step, "step", options.Step, "Run only the next stage of the rotation")
+	cmd.Flags().DurationVar(&options.ValidationTimeout, "validation-timeout", options.ValidationTimeout, "Maximum time to wait for the cluster to validate during the rolling updates")
+
+	return cmd
+}
+
+// caRotationNextStages describes the stage which follows each stage of a CA rotation
+var caRotationNextStages = map[kops.KeysetRotationStage]string{
+	"":                                       "add a new CA alongside the current one",
+	kops.KeysetRotationStageAdded:            "update and roll the cluster, so that it trusts both CAs",
+	kops.KeysetRotationStageTrustDistributed: "promote the new CA to primary and reissue the certificates issued by the previous CA",
+	kops.KeysetRotationStagePromoted:         "update and roll the cluster, so that it uses certificates issued by the new CA",
+	kops.KeysetRotationStageRolled:           "remove the previous CA and update the cluster",
+}
+
+func RunRotateCA(ctx context.Context, f *util.Factory, out io.Writer, options *RotateCAOptions) error {
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	keyStore, err := clientset.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+
+	for {
+		keyset, err := keyStore.FindCertificateKeyset(fi.CertificateIDCA)
+		if err != nil {
+			return err
+		}
+		if keyset == nil {
+			return fmt.Errorf("CA keypair not found")
+		}
+
+		var stage kops.KeysetRotationStage
+		if keyset.Spec.Rotation != nil {
+			stage = keyset.Spec.Rotation.Stage
+		}
+		next, ok := caRotationNextStages[stage]
+		if !ok {
+			return fmt.Errorf("unknown CA rotation stage %q", stage)
+		}
+
+		if stage == "" {
+			fmt.Fprintf(out, "No CA rotation is in progress\n")
+		} else {
+			fmt.Fprintf(out, "CA rotation has completed stage %s\n", stage)
+		}
+		fmt.Fprintf(out, "The next stage will %s\n", next)
+
+		if !options.Yes {
+			fmt.Fprintf(out, "\nMust specify --yes to rotate the CA\n")
+			return nil
+		}
+
+		switch stage {
+		case "":
+			err = startCARotation(cluster, keyStore, keyset)
+		case kops.KeysetRotationStageAdded:
+			err = rollForCARotation(ctx, f, out, options, keyStore, keyset, kops.KeysetRotationStageTrustDistributed)
+		case kops.KeysetRotationStageTrustDistributed:
+			err = promoteCA(out, keyStore, keyset)
+		case kops.KeysetRotationStagePromoted:
+			err = rollForCARotation(ctx, f, out, options, keyStore, keyset, kops.KeysetRotationStageRolled)
+		case kops.KeysetRotationStageRolled:
+			err = finishCARotation(ctx, f, out, options, keyStore, keyset)
+			if err == nil {
+				fmt.Fprintf(out, "CA rotation is complete\n")
+				return nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if options.Step {
+			return nil
+		}
+	}
+}
+
+// startCARotation adds a new CA to the keyset, keeping the current CA as primary
+func startCARotation(cluster *kops.Cluster, keyStore fi.CAStore, keyset *kops.Keyset) error {
+	primary := fi.FindPrimary(keyset)
+	if primary == nil {
+		return fmt.Errorf("CA keyset has no primary keypair")
+	}
+	previous, err := pki.ParsePEMCertificate(primary.PublicMaterial)
+	if err != nil {
+		return fmt.Errorf("error parsing CA certificate %q: %v", primary.Id, err)
+	}
+
+	// The new CA will have the highest id, so we pin the current CA as primary before storing it
+	if err := keyStore.UpdateKeysetRotation(fi.CertificateIDCA, primary.Id, nil); err != nil {
+		return err
+	}
+
+	req := pki.IssueCertRequest{
+		Type:         "ca",
+		Subject:      previous.Subject,
+		KeyAlgorithm: cluster.KeyAlgorithm(fi.CertificateIDCA),
+		Serial:       pki.BuildPKISerial(time.Now().UnixNano()),
+	}
+	cert, privateKey, _, err := pki.IssueCert(&req, keyStore)
+	if err != nil {
+		return err
+	}
+	if err := keyStore.StoreKeypair(fi.CertificateIDCA, cert, privateKey); err != nil {
+		return err
+	}
+
+	rotation := &kops.KeysetRotation{
+		Stage:      kops.KeysetRotationStageAdded,
+		PreviousId: primary.Id,
+		NewId:      cert.Certificate.SerialNumber.String(),
+	}
+	return keyStore.UpdateKeysetRotation(fi.CertificateIDCA, primary.Id, rotation)
+}
+
+// rollForCARotation updates the cluster and replaces every instance, then records the stage as completed
+func rollForCARotation(ctx context.Context, f *util.Factory, out io.Writer, options *RotateCAOptions, keyStore fi.CAStore, keyset *kops.Keyset, completed kops.KeysetRotationStage) error {
+	updateOptions := &UpdateClusterOptions{}
+	updateOptions.InitDefaults()
+	updateOptions.Yes = true
+	if _, err := RunUpdateCluster(ctx, f, options.ClusterName, out, updateOptions); err != nil {
+		return err
+	}
+
+	rollingUpdateOptions := &RollingUpdateOptions{}
+	rollingUpdateOptions.InitDefaults()
+	rollingUpdateOptions.ClusterName = options.ClusterName
+	rollingUpdateOptions.Yes = true
+	rollingUpdateOptions.Force = true
+	rollingUpdateOptions.FailOnDrainError = true
+	rollingUpdateOptions.ValidationTimeout = options.ValidationTimeout
+
+	// Resume a roll of the whole cluster interrupted by an earlier attempt at this stage,
+	// rather than replacing the instances it has already replaced
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+	cluster, err := GetCluster(ctx, f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return err
+	}
+	progress, err := instancegroups.ReadProgress(configBase.Join(registry.PathRollingUpdateProgress))
+	if err != nil {
+		return err
+	}
+	if progress != nil && !progress.Completed && progress.Options.Force && len(progress.Options.InstanceGroups) == 0 && len(progress.Options.InstanceGroupRoles) == 0 {
+		rollingUpdateOptions.Resume = true
+	}
+
+	if err := RunRollingUpdateCluster(ctx, f, out, rollingUpdateOptions); err != nil {
+		return err
+	}
+
+	rotation := *keyset.Spec.Rotation
+	rotation.Stage = completed
+	return keyStore.UpdateKeysetRotation(fi.CertificateIDCA, keyset.Spec.PrimaryId, &rotation)
+}
+
+// promoteCA makes the new CA primary, and reissues the certificates in the keystore which were issued by the previous CA
+func promoteCA(out io.Writer, keyStore fi.CAStore, keyset *kops.Keyset) error {
+	rotation := *keyset.Spec.Rotation
+
+	var previous *pki.Certificate
+	for _, key := range keyset.Spec.Keys {
+		if key.Id == rotation.PreviousId {
+			cert, err := pki.ParsePEMCertificate(key.PublicMaterial)
+			if err != nil {
+				return fmt.Errorf("error parsing CA certificate %q: %v", key.Id, err)
+			}
+			previous = cert
+		}
+	}
+	if previous == nil {
+		return fmt.Errorf("previous CA %q not found", rotation.PreviousId)
+	}
+
+	if err := keyStore.UpdateKeysetRotation(fi.CertificateIDCA, rotation.NewId, &rotation); err != nil {
+		return err
+	}
+
+	keysets, err := keyStore.ListKeysets()
+	if err != nil {
+		return fmt.Errorf("error listing Keysets: %v", err)
+	}
+	for _, k := range keysets {
+		if k.Name == fi.CertificateIDCA || k.Spec.Type != kops.SecretTypeKeypair {
+			continue
+		}
+
+		cert, privateKey, _, err := keyStore.FindKeypair(k.Name)
+		if err != nil {
+			return err
+		}
+		if cert == nil || privateKey == nil {
+			continue
+		}
+		if cert.Certificate.CheckSignatureFrom(previous.Certificate) != nil {
+			// Not issued by the previous CA
+			continue
+		}
+
+		var alternateNames []string
+		alternateNames = append(alternateNames, cert.Certificate.DNSNames...)
+		for _, ip := range cert.Certificate.IPAddresses {
+			alternateNames = append(alternateNames, ip.String())
+		}
+
+		req := pki.IssueCertRequest{
+			Signer:         fi.CertificateIDCA,
+			Type:           pki.BuildTypeDescription(cert.Certificate),
+			Subject:        cert.Certificate.Subject,
+			AlternateNames: alternateNames,
+			PrivateKey:     privateKey,
+			Serial:         pki.BuildPKISerial(time.Now().UnixNano()),
+		}
+		reissued, _, _, err := pki.IssueCert(&req, keyStore)
+		if err != nil {
+			return fmt.Errorf("error reissuing keypair %q: %v", k.Name, err)
+		}
+		if err := keyStore.StoreKeypair(k.Name, reissued, privateKey); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Reissued keypair %q\n", k.Name)
+	}
+
+	rotation.Stage = kops.KeysetRotationStagePromoted
+	return keyStore.UpdateKeysetRotation(fi.CertificateIDCA, rotation.NewId, &rotation)
+}
+
+// finishCARotation removes every CA other than the new one, and ends the rotation
+func finishCARotation(ctx context.Context, f *util.Factory, out io.Writer, options *RotateCAOptions, keyStore fi.CAStore, keyset *kops.Keyset) error {
+	rotation := keyset.Spec.Rotation
+	for _, key := range keyset.Spec.Keys {
+		if key.Id == rotation.NewId {
+			continue
+		}
+		if err := keyStore.DeleteKeysetItem(keyset, key.Id); err != nil {
+			return fmt.Errorf("error removing CA %q: %v", key.Id, err)
+		}
+		fmt.Fprintf(out, "Removed CA %q\n", key.Id)
+	}
+
+	if err := keyStore.UpdateKeysetRotation(fi.CertificateIDCA, "", nil); err != nil {
+		return err
+	}
+
+	updateOptions := &UpdateClusterOptions{}
+	updateOptions.InitDefaults()
+	updateOptions.Yes = true
+	_, err := RunUpdateCluster(ctx, f, options.ClusterName, out, updateOptions)
+	return err
+}
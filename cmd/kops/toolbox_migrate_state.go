@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/commands"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	toolboxMigrateStateLong = templates.LongDesc(i18n.T(`
+	Copies the state of a cluster to another state store.
+
+	The cluster spec, instance groups, addons, keystore, secrets, SSH keys and mirrored data
+	are copied and verified, and the state store paths in the cluster spec are updated to the
+	new state store. Without --yes, the files which would be copied are listed.`))
+
+	toolboxMigrateStateExample = templates.Examples(i18n.T(`
+	# List the files which would be copied
+	kops toolbox migrate-state --name k8s-cluster.example.com --from s3://old-state-store --to gs://new-state-store
+
+	# Copy the state to the new state store
+	kops toolbox migrate-state --name k8s-cluster.example.com --from s3://old-state-store --to gs://new-state-store --yes
+	`))
+
+	toolboxMigrateStateShort = i18n.T(`Copy the state of a cluster to another state store`)
+)
+
+func NewCmdToolboxMigrateState(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &commands.MigrateStateOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "migrate-state",
+		Short:   toolboxMigrateStateShort,
+		Long:    toolboxMigrateStateLong,
+		Example: toolboxMigrateStateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.TODO()
+
+			if err := rootCommand.ProcessArgs(args); err != nil {
+				exitWithError(err)
+			}
+
+			options.ClusterName = rootCommand.ClusterName()
+			if options.From == "" {
+				options.From = rootCommand.RegistryPath
+			}
+
+			err := commands.RunMigrateState(ctx, out, options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.From, "from", options.From, "state store to copy the cluster from; defaults to the current state store")
+	cmd.Flags().StringVar(&options.To, "to", options.To, "state store to copy the cluster to")
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Specify --yes to copy the files")
+
+	return cmd
+}
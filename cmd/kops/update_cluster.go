@@ -22,7 +22,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,6 +35,7 @@ import (
 	"k8s.io/kops/cmd/kops/util"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/commands"
+	"k8s.io/kops/pkg/eventstream"
 	"k8s.io/kops/pkg/kubeconfig"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup"
@@ -77,6 +80,24 @@ type UpdateClusterOptions struct {
 	// LifecycleOverrides is a slice of taskName=lifecycle name values.  This slice is used
 	// to populate the LifecycleOverrides struct member in ApplyClusterCmd struct.
 	LifecycleOverrides []string
+
+	// EventsOut is the file to write a JSON-lines stream of task events to, or "-" for stdout
+	EventsOut string
+
+	// TraceOut is the file to write a trace of task execution to, or "-" for stdout
+	TraceOut string
+	// TraceFormat is the format of the trace: chrome or text
+	TraceFormat string
+
+	// GraphOut is the file to write the graph of tasks and their dependencies to, or "-" for stdout
+	GraphOut string
+	// GraphFormat is the format of the graph: dot or json
+	GraphFormat string
+
+	// TaskConcurrencyLimits is the maximum number of tasks of each type to run at the same time
+	TaskConcurrencyLimits map[string]int
+	// TaskRateLimits is the maximum number of tasks of each type to start per second
+	TaskRateLimits map[string]string
 }
 
 func (o *UpdateClusterOptions) InitDefaults() {
@@ -89,6 +110,9 @@ func (o *UpdateClusterOptions) InitDefaults() {
 	o.CreateKubecfg = true
 
 	o.RunTasksOptions.InitDefaults()
+
+	o.TraceFormat = traceFormatChrome
+	o.GraphFormat = graphFormatDOT
 }
 
 func NewCmdUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
@@ -130,6 +154,14 @@ func NewCmdUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().StringSliceVar(&options.LifecycleOverrides, "lifecycle-overrides", options.LifecycleOverrides, "comma separated list of phase overrides, example: SecurityGroups=Ignore,InternetGateway=ExistsAndWarnIfChanges")
 	viper.BindPFlag("lifecycle-overrides", cmd.Flags().Lookup("lifecycle-overrides"))
 	viper.BindEnv("lifecycle-overrides", "KOPS_LIFECYCLE_OVERRIDES")
+	cmd.Flags().StringVar(&options.EventsOut, "events-out", options.EventsOut, "Write a JSON-lines stream of task events to this file, or - for stdout, in which case other output goes to stderr")
+	cmd.Flags().StringVar(&options.TraceOut, "trace-out", options.TraceOut, "Write a trace of task execution to this file, or - for stdout, in which case other output goes to stderr")
+	cmd.Flags().StringVar(&options.TraceFormat, "trace-format", options.TraceFormat, "Format of the trace of task execution: chrome (trace event JSON) or text (summary of the slowest tasks)")
+	cmd.Flags().StringVar(&options.GraphOut, "graph-out", options.GraphOut, "Write the graph of tasks and their dependencies to this file, or - for stdout, in which case other output goes to stderr")
+	cmd.Flags().StringVar(&options.GraphFormat, "graph-format", options.GraphFormat, "Format of the graph of tasks: dot (Graphviz) or json")
+	cmd.Flags().IntVar(&options.RunTasksOptions.MaxConcurrentTasks, "max-concurrent-tasks", options.RunTasksOptions.MaxConcurrentTasks, "Maximum number of tasks to run at the same time (0 for no limit)")
+	cmd.Flags().StringToIntVar(&options.TaskConcurrencyLimits, "task-concurrency-limits", options.TaskConcurrencyLimits, "Maximum number of tasks of a type to run at the same time, example: SecurityGroupRule=5,awstasks.Subnet=2")
+	cmd.Flags().StringToStringVar(&options.TaskRateLimits, "task-rate-limits", options.TaskRateLimits, "Maximum number of tasks of a type to start per second, example: SecurityGroupRule=2.5")
 
 	return cmd
 }
@@ -152,6 +184,29 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, clusterName string,
 		return nil, fmt.Errorf("cannot use both --admin and --user")
 	}
 
+	if c.TraceOut != "" && c.TraceFormat != traceFormatChrome && c.TraceFormat != traceFormatText {
+		return nil, fmt.Errorf("unknown trace format: %q", c.TraceFormat)
+	}
+
+	if c.GraphOut != "" && c.GraphFormat != graphFormatDOT && c.GraphFormat != graphFormatJSON {
+		return nil, fmt.Errorf("unknown graph format: %q", c.GraphFormat)
+	}
+
+	stdoutOutputs := 0
+	for _, p := range []string{c.EventsOut, c.TraceOut, c.GraphOut} {
+		if p == stdoutPath {
+			stdoutOutputs++
+		}
+	}
+	if stdoutOutputs > 1 {
+		return nil, fmt.Errorf("only one of --events-out, --trace-out and --graph-out may be written to stdout")
+	}
+	// Other output goes to stderr when a machine-readable output is written to stdout, so that it can be parsed
+	stdout := out
+	if stdoutOutputs != 0 {
+		out = os.Stderr
+	}
+
 	if c.admin != 0 && !c.CreateKubecfg {
 		klog.Info("--admin implies --create-kube-config")
 		c.CreateKubecfg = true
@@ -270,20 +325,56 @@ func RunUpdateCluster(ctx context.Context, f *util.Factory, clusterName string,
 		return nil, err
 	}
 
+	runTasksOptions := c.RunTasksOptions
+	if c.EventsOut != "" {
+		events, err := eventstream.Open(c.EventsOut, stdout)
+		if err != nil {
+			return nil, err
+		}
+		defer events.Close()
+		runTasksOptions.Events = events
+	}
+	if c.TraceOut != "" {
+		runTasksOptions.Trace = fi.NewTrace()
+	}
+	if len(c.TaskConcurrencyLimits) != 0 || len(c.TaskRateLimits) != 0 {
+		qps := make(map[string]float32)
+		for taskType, s := range c.TaskRateLimits {
+			v, err := strconv.ParseFloat(s, 32)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing rate limit of task type %q: %v", taskType, err)
+			}
+			qps[taskType] = float32(v)
+		}
+		limiter, err := fi.NewTaskTypeLimits(c.TaskConcurrencyLimits, qps)
+		if err != nil {
+			return nil, err
+		}
+		runTasksOptions.Limiter = limiter
+	}
+
 	applyCmd := &cloudup.ApplyClusterCmd{
 		Cloud:              cloud,
 		Clientset:          clientset,
 		Cluster:            cluster,
 		DryRun:             isDryrun,
 		AllowKopsDowngrade: c.AllowKopsDowngrade,
-		RunTasksOptions:    &c.RunTasksOptions,
+		RunTasksOptions:    &runTasksOptions,
 		OutDir:             c.OutDir,
 		Phase:              phase,
 		TargetName:         targetName,
 		LifecycleOverrides: lifecycleOverrideMap,
+		Out:                out,
 	}
 
-	if err := applyCmd.Run(ctx); err != nil {
+	err = applyCmd.Run(ctx)
+	// The trace is most useful when tasks failed, so write it regardless
+	err = writeUpdateTrace(c, runTasksOptions.Trace, err, stdout)
+	if applyCmd.TaskMap != nil {
+		// Also written on failure, to help debug circular dependencies
+		err = writeUpdateGraph(c, applyCmd.TaskMap, applyCmd.Target, err, stdout)
+	}
+	if err != nil {
 		return results, err
 	}
 
@@ -417,6 +508,102 @@ func parseLifecycle(lifecycle string) (fi.Lifecycle, error) {
 	return "", fmt.Errorf("unknown lifecycle %q, available lifecycle: %s", lifecycle, strings.Join(fi.Lifecycles.List(), ","))
 }
 
+const (
+	traceFormatChrome = "chrome"
+	traceFormatText   = "text"
+
+	// traceSummaryTasks is the number of tasks listed in the text summary of a trace
+	traceSummaryTasks = 20
+)
+
+// writeTrace writes the trace of task execution to the file at path, or to stdout if path is "-".
+func writeTrace(trace *fi.Trace, path string, format string, stdout io.Writer) error {
+	out := stdout
+	if path != stdoutPath {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("error creating trace file %q: %v", path, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var err error
+	switch format {
+	case traceFormatText:
+		err = trace.WriteSummary(out, traceSummaryTasks)
+	default:
+		err = trace.WriteChromeTrace(out)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing trace: %v", err)
+	}
+	return nil
+}
+
+const (
+	graphFormatDOT  = "dot"
+	graphFormatJSON = "json"
+)
+
+// writeUpdateTrace writes the trace of an update, if requested, and returns the error of the update.
+// If the update succeeded, an error writing the trace is returned instead, so that a missing trace is not mistaken for success.
+func writeUpdateTrace(c *UpdateClusterOptions, trace *fi.Trace, updateErr error, stdout io.Writer) error {
+	if trace == nil {
+		return updateErr
+	}
+	if err := writeTrace(trace, c.TraceOut, c.TraceFormat, stdout); err != nil {
+		if updateErr != nil {
+			klog.Warningf("%v", err)
+			return updateErr
+		}
+		return err
+	}
+	return updateErr
+}
+
+// writeUpdateGraph writes the graph of the tasks of an update, if requested, and returns the error of the update.
+// If the update succeeded, an error writing the graph is returned instead.
+func writeUpdateGraph(c *UpdateClusterOptions, taskMap map[string]fi.Task, target fi.Target, updateErr error, stdout io.Writer) error {
+	if c.GraphOut == "" {
+		return updateErr
+	}
+	graph := fi.BuildTaskGraph(taskMap, target)
+	if err := writeGraph(graph, c.GraphOut, c.GraphFormat, stdout); err != nil {
+		if updateErr != nil {
+			klog.Warningf("%v", err)
+			return updateErr
+		}
+		return err
+	}
+	return updateErr
+}
+
+// writeGraph writes the graph of tasks to the file at path, or to stdout if path is "-".
+func writeGraph(graph *fi.TaskGraph, path string, format string, stdout io.Writer) error {
+	out := stdout
+	if path != stdoutPath {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("error creating graph file %q: %v", path, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var err error
+	switch format {
+	case graphFormatJSON:
+		err = graph.WriteJSON(out)
+	default:
+		err = graph.WriteDOT(out)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing graph: %v", err)
+	}
+	return nil
+}
+
 func usesBastion(instanceGroups []*kops.InstanceGroup) bool {
 	for _, ig := range instanceGroups {
 		if ig.Spec.Role == kops.InstanceGroupRoleBastion {
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func TestWriteUpdateTrace(t *testing.T) {
+	unwritable := filepath.Join(t.TempDir(), "missing", "trace.json")
+	updateErr := errors.New("update failed")
+
+	grid := []struct {
+		name        string
+		traceOut    string
+		updateErr   error
+		expectedErr string
+	}{
+		{
+			name:     "written",
+			traceOut: filepath.Join(t.TempDir(), "trace.json"),
+		},
+		{
+			name:        "unwritable after a successful update",
+			traceOut:    unwritable,
+			expectedErr: "error creating trace file",
+		},
+		{
+			name:        "unwritable after a failed update",
+			traceOut:    unwritable,
+			updateErr:   updateErr,
+			expectedErr: "update failed",
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			c := &UpdateClusterOptions{TraceOut: g.traceOut, TraceFormat: traceFormatChrome}
+			var stdout bytes.Buffer
+			err := writeUpdateTrace(c, fi.NewTrace(), g.updateErr, &stdout)
+			if g.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), g.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", g.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestWriteUpdateGraph(t *testing.T) {
+	unwritable := filepath.Join(t.TempDir(), "missing", "graph.dot")
+
+	c := &UpdateClusterOptions{GraphOut: filepath.Join(t.TempDir(), "graph.dot"), GraphFormat: graphFormatDOT}
+	var stdout bytes.Buffer
+	if err := writeUpdateGraph(c, map[string]fi.Task{}, nil, nil, &stdout); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	c.GraphOut = unwritable
+	if err := writeUpdateGraph(c, map[string]fi.Task{}, nil, nil, &stdout); err == nil || !strings.Contains(err.Error(), "error creating graph file") {
+		t.Errorf("expected error creating graph file after a successful update, got %v", err)
+	}
+
+	updateErr := errors.New("update failed")
+	if err := writeUpdateGraph(c, map[string]fi.Task{}, nil, updateErr, &stdout); err != updateErr {
+		t.Errorf("expected the error of the failed update, got %v", err)
+	}
+}
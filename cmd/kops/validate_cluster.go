@@ -37,7 +37,9 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/cmd/kops/util"
 	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/certificates"
 	"k8s.io/kops/pkg/validation"
+	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/util/pkg/tables"
 	"sigs.k8s.io/yaml"
 )
@@ -47,6 +49,9 @@ type ValidateClusterOptions struct {
 	wait       time.Duration
 	count      int
 	kubeconfig string
+
+	// certificateExpiryThreshold is how soon before expiry a certificate in use by the cluster fails validation
+	certificateExpiryThreshold string
 }
 
 func (o *ValidateClusterOptions) InitDefaults() {
@@ -64,6 +69,9 @@ func NewCmdValidateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	2. All worker nodes are running and have "Ready" status.
 	3. All control plane nodes have the expected pods.
 	4. All pods with a critical priority are running and have "Ready" status.
+
+	If --certificate-expiry-threshold is set, it also validates that no certificate
+	in use by the cluster expires within the threshold.
 	`))
 
 	cmd := &cobra.Command{
@@ -90,6 +98,7 @@ func NewCmdValidateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().DurationVar(&options.wait, "wait", options.wait, "If set, will wait for cluster to be ready")
 	cmd.Flags().IntVar(&options.count, "count", options.count, "If set, will validate the cluster consecutive times")
 	cmd.Flags().StringVar(&options.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	cmd.Flags().StringVar(&options.certificateExpiryThreshold, "certificate-expiry-threshold", options.certificateExpiryThreshold, "If set, fail validation if a certificate in use by the cluster expires within this duration, for example 30d")
 
 	return cmd
 }
@@ -120,6 +129,19 @@ func RunValidateCluster(ctx context.Context, f *util.Factory, cmd *cobra.Command
 		return nil, fmt.Errorf("cannot get InstanceGroups for %q: %v", cluster.ObjectMeta.Name, err)
 	}
 
+	var keyStore fi.CAStore
+	var certificateExpiryThreshold time.Duration
+	if options.certificateExpiryThreshold != "" {
+		certificateExpiryThreshold, err = certificates.ParseDuration(options.certificateExpiryThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --certificate-expiry-threshold: %v", err)
+		}
+		keyStore, err = clientSet.KeyStore(cluster)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if options.output == OutputTable {
 		fmt.Fprintf(out, "Validating cluster %v\n\n", cluster.ObjectMeta.Name)
 	}
@@ -178,6 +200,14 @@ func RunValidateCluster(ctx context.Context, f *util.Factory, cmd *cobra.Command
 			}
 		}
 
+		if keyStore != nil {
+			failures, err := validation.ValidateCertificateExpiry(keyStore, certificateExpiryThreshold)
+			if err != nil {
+				return nil, err
+			}
+			result.Failures = append(result.Failures, failures...)
+		}
+
 		switch options.output {
 		case OutputTable:
 			if err := validateClusterOutputTable(result, cluster, instanceGroups, out); err != nil {
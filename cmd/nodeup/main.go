@@ -38,7 +38,7 @@ func main() {
 
 	var flagConf, flagCacheDir, gitVersion string
 	var flagRetries int
-	var dryrun, installSystemdUnit bool
+	var dryrun, installSystemdUnit, renewCertificates bool
 	target := "direct"
 
 	if kops.GitVersion != "" {
@@ -51,6 +51,7 @@ func main() {
 	flag.BoolVar(&dryrun, "dryrun", false, "Don't create cloud resources; just show what would be done")
 	flag.StringVar(&target, "target", target, "Target - direct, cloudinit")
 	flag.BoolVar(&installSystemdUnit, "install-systemd-unit", installSystemdUnit, "If true, will install a systemd unit instead of running directly")
+	flag.BoolVar(&renewCertificates, "renew-certificates", renewCertificates, "If true, will renew the certificates issued by kops-controller if they are about to expire")
 
 	if dryrun {
 		target = "dryrun"
@@ -110,9 +111,10 @@ func main() {
 			}
 		} else {
 			cmd := &nodeup.NodeUpCommand{
-				ConfigLocation: flagConf,
-				Target:         target,
-				CacheDir:       flagCacheDir,
+				ConfigLocation:    flagConf,
+				Target:            target,
+				CacheDir:          flagCacheDir,
+				RenewCertificates: renewCertificates,
 			}
 			err = cmd.Run(os.Stdout)
 			if err == nil {
@@ -26,6 +26,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/pflag"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	_ "k8s.io/component-base/metrics/prometheus/restclient" // for client metric registration
@@ -36,6 +37,7 @@ import (
 	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
 	"k8s.io/kops/dnsprovider/pkg/dnsprovider/providers/aws/route53"
 	_ "k8s.io/kops/dnsprovider/pkg/dnsprovider/providers/google/clouddns"
+	_ "k8s.io/kops/dnsprovider/pkg/dnsprovider/providers/rfc2136"
 	_ "k8s.io/kops/pkg/resources/digitalocean/dns"
 	"k8s.io/kops/pkg/wellknownports"
 	"k8s.io/kops/protokube/pkg/gossip"
@@ -52,9 +54,9 @@ var (
 
 func main() {
 	fmt.Printf("dns-controller version %s\n", BuildVersion)
-	var dnsServer, dnsProviderID, gossipListen, gossipSecret, watchNamespace, metricsListen, gossipProtocol, gossipSecretSecondary, gossipListenSecondary, gossipProtocolSecondary string
+	var dnsServer, dnsProviderID, dnsProviderConfig, ownerID, gossipListen, gossipSecret, watchNamespace, metricsListen, gossipProtocol, gossipSecretSecondary, gossipListenSecondary, gossipProtocolSecondary string
 	var gossipSeeds, gossipSeedsSecondary, zones []string
-	var watchIngress bool
+	var watchIngress, watchGateway, adoptUnowned bool
 	var updateInterval int
 
 	// Be sure to get the glog flags
@@ -63,9 +65,11 @@ func main() {
 
 	flag.StringVar(&dnsServer, "dns-server", "", "DNS Server")
 	flags.BoolVar(&watchIngress, "watch-ingress", true, "Configure hostnames found in ingress resources")
+	flags.BoolVar(&watchGateway, "watch-gateway", false, "Configure hostnames found in Gateway API gateway and httproute resources")
 	flags.StringSliceVar(&gossipSeeds, "gossip-seed", gossipSeeds, "If set, will enable gossip zones and seed using the provided addresses")
 	flags.StringSliceVarP(&zones, "zone", "z", []string{}, "Configure permitted zones and their mappings")
-	flags.StringVar(&dnsProviderID, "dns", "aws-route53", "DNS provider we should use (aws-route53, google-clouddns, digitalocean, gossip)")
+	flags.StringVar(&dnsProviderID, "dns", "aws-route53", "DNS provider we should use (aws-route53, google-clouddns, digitalocean, rfc2136, gossip)")
+	flags.StringVar(&dnsProviderConfig, "dns-config", "", "Path to the configuration file of the DNS provider, if it needs one")
 	flag.StringVar(&gossipProtocol, "gossip-protocol", "mesh", "mesh/memberlist")
 	flags.StringVar(&gossipListen, "gossip-listen", fmt.Sprintf("0.0.0.0:%d", wellknownports.DNSControllerGossipWeaveMesh), "The address on which to listen if gossip is enabled")
 	flags.StringVar(&gossipSecret, "gossip-secret", gossipSecret, "Secret to use to secure gossip")
@@ -75,8 +79,10 @@ func main() {
 	flags.StringSliceVar(&gossipSeedsSecondary, "gossip-seed-secondary", gossipSeedsSecondary, "If set, will enable gossip zones and seed using the provided addresses")
 	flags.StringVar(&watchNamespace, "watch-namespace", "", "Limits the functionality for pods, services and ingress to specific namespace, by default all")
 	flag.IntVar(&route53.MaxBatchSize, "route53-batch-size", route53.MaxBatchSize, "Maximum number of operations performed per changeset batch")
-	flag.StringVar(&metricsListen, "metrics-listen", "", "The address on which to listen for Prometheus metrics.")
+	flag.StringVar(&metricsListen, "metrics-listen", "", "The address on which to listen for Prometheus metrics and the /healthz and /readyz health checks.")
 	flags.IntVar(&updateInterval, "update-interval", 5, "Configure interval at which to update DNS records.")
+	flags.StringVar(&ownerID, "owner-id", "", "If set, create TXT records recording this owner (normally the cluster name) for each record, and do not change records with another owner")
+	flags.BoolVar(&adoptUnowned, "adopt-unowned", false, "Take ownership of existing records without an ownership record, when migrating to --owner-id")
 
 	// Trick to avoid 'logging before flag.Parse' warning
 	flag.CommandLine.Parse([]string{})
@@ -85,13 +91,6 @@ func main() {
 	flags.AddGoFlagSet(flag.CommandLine)
 	flags.Parse(os.Args)
 
-	if metricsListen != "" {
-		go func() {
-			http.Handle("/metrics", promhttp.Handler())
-			log.Fatal(http.ListenAndServe(metricsListen, nil))
-		}()
-	}
-
 	zoneRules, err := dns.ParseZoneRules(zones)
 	if err != nil {
 		klog.Errorf("unexpected zone flags: %q", err)
@@ -109,9 +108,23 @@ func main() {
 		klog.Fatalf("error building REST client: %v", err)
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("error building dynamic client: %v", err)
+	}
+
 	var dnsProviders []dnsprovider.Interface
 	if dnsProviderID != "gossip" {
 		var file io.Reader
+		if dnsProviderConfig != "" {
+			f, err := os.Open(dnsProviderConfig)
+			if err != nil {
+				klog.Errorf("Error opening DNS provider configuration %q: %v", dnsProviderConfig, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			file = f
+		}
 
 		dnsProvider, err := dnsprovider.GetDnsProvider(dnsProviderID, file)
 		if err != nil {
@@ -178,14 +191,31 @@ func main() {
 		dnsProviders = append(dnsProviders, dnsProvider)
 	}
 
-	dnsController, err := dns.NewDNSController(dnsProviders, zoneRules, updateInterval)
+	var ownership *dns.OwnershipConfig
+	if ownerID != "" {
+		ownership = &dns.OwnershipConfig{
+			OwnerID:      ownerID,
+			AdoptUnowned: adoptUnowned,
+		}
+	}
+
+	dnsController, err := dns.NewDNSController(dnsProviders, zoneRules, updateInterval, ownership)
 	if err != nil {
 		klog.Errorf("Error building DNS controller: %v", err)
 		os.Exit(1)
 	}
 
+	if metricsListen != "" {
+		go func() {
+			http.Handle("/metrics", promhttp.Handler())
+			http.HandleFunc("/healthz", dnsController.ServeHealthz)
+			http.HandleFunc("/readyz", dnsController.ServeReadyz)
+			log.Fatal(http.ListenAndServe(metricsListen, nil))
+		}()
+	}
+
 	// @step: initialize the watchers
-	if err := initializeWatchers(client, dnsController, watchNamespace, watchIngress); err != nil {
+	if err := initializeWatchers(client, dynamicClient, dnsController, watchNamespace, watchIngress, watchGateway); err != nil {
 		klog.Errorf("%s", err)
 		os.Exit(1)
 	}
@@ -195,7 +225,7 @@ func main() {
 }
 
 // initializeWatchers is responsible for creating the watchers
-func initializeWatchers(client kubernetes.Interface, dnsctl *dns.DNSController, namespace string, watchIngress bool) error {
+func initializeWatchers(client kubernetes.Interface, dynamicClient dynamic.Interface, dnsctl *dns.DNSController, namespace string, watchIngress, watchGateway bool) error {
 	klog.V(1).Infof("initializing the watch controllers, namespace: %q", namespace)
 
 	nodeController, err := watchers.NewNodeController(client, dnsctl)
@@ -223,6 +253,16 @@ func initializeWatchers(client kubernetes.Interface, dnsctl *dns.DNSController,
 		klog.Infof("Ingress controller disabled")
 	}
 
+	var gatewayController *watchers.GatewayController
+	if watchGateway {
+		gatewayController, err = watchers.NewGatewayController(dynamicClient, client.Discovery(), dnsctl, namespace)
+		if err != nil {
+			return fmt.Errorf("failed to initialize the gateway controller, error: %v", err)
+		}
+	} else {
+		klog.Infof("Gateway controller disabled")
+	}
+
 	go nodeController.Run()
 	go podController.Run()
 	go serviceController.Run()
@@ -231,5 +271,9 @@ func initializeWatchers(client kubernetes.Interface, dnsctl *dns.DNSController,
 		go ingressController.Run()
 	}
 
+	if watchGateway {
+		go gatewayController.Run()
+	}
+
 	return nil
 }
@@ -61,6 +61,14 @@ type DNSController struct {
 	failCount uint64
 	// update loop frequency (seconds)
 	updateInterval time.Duration
+
+	// ownership configures ownership records; if nil we do not create or check them
+	ownership *OwnershipConfig
+
+	// started is when the controller was created, which is the baseline for liveness before the first update
+	started time.Time
+	// syncStatus is the outcome of the update loop, for health checks
+	syncStatus syncStatus
 }
 
 // DNSController is a Context
@@ -86,8 +94,9 @@ type DNSControllerScope struct {
 // DNSControllerScope is a Scope
 var _ Scope = &DNSControllerScope{}
 
-// NewDNSController creates a DnsController
-func NewDNSController(dnsProviders []dnsprovider.Interface, zoneRules *ZoneRules, updateInterval int) (*DNSController, error) {
+// NewDNSController creates a DnsController. If ownership is not nil, ownership records are used
+// to avoid changing records created by others.
+func NewDNSController(dnsProviders []dnsprovider.Interface, zoneRules *ZoneRules, updateInterval int, ownership *OwnershipConfig) (*DNSController, error) {
 	dnsCache, err := newDNSCache(dnsProviders)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing DNS cache: %v", err)
@@ -98,6 +107,8 @@ func NewDNSController(dnsProviders []dnsprovider.Interface, zoneRules *ZoneRules
 		zoneRules:      zoneRules,
 		dnsCache:       dnsCache,
 		updateInterval: time.Duration(updateInterval) * time.Second,
+		ownership:      ownership,
+		started:        time.Now(),
 	}
 
 	return c, nil
@@ -116,11 +127,12 @@ func (c *DNSController) Run() {
 
 func (c *DNSController) runWatcher(stopCh <-chan struct{}) {
 	for {
-		err := c.runOnce()
+		synced, err := c.runOnce()
 		if c.StopRequested() {
 			klog.Infof("exiting dns controller loop")
 			return
 		}
+		c.recordSync(time.Now(), synced, err)
 
 		if err != nil {
 			// Increment the update failure counter
@@ -143,13 +155,21 @@ func (c *DNSController) runWatcher(stopCh <-chan struct{}) {
 
 type snapshot struct {
 	changeCount  uint64
-	records      []Record
+	records      []scopedRecord
 	aliasTargets map[string][]Record
 
 	recordValues map[recordKey][]string
 }
 
-func (c *DNSController) snapshotIfChangedAndReady() *snapshot {
+// scopedRecord is a Record with the name of the scope defining it
+type scopedRecord struct {
+	Record
+	Scope string
+}
+
+// snapshotIfChangedAndReady returns a snapshot of the records if they have changed since they were last applied
+// and all the scopes are ready. It also returns true if the records have not changed since they were last applied.
+func (c *DNSController) snapshotIfChangedAndReady() (*snapshot, bool) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
@@ -161,21 +181,21 @@ func (c *DNSController) snapshotIfChangedAndReady() *snapshot {
 
 	if c.lastSuccessfulSnapshot != nil && s.changeCount == c.lastSuccessfulSnapshot.changeCount {
 		klog.V(6).Infof("No changes since DNS values last successfully applied")
-		return nil
+		return nil, true
 	}
 
 	recordCount := 0
 	for _, scope := range c.scopes {
 		if !scope.Ready {
 			klog.Infof("scope not yet ready: %s", scope.ScopeName)
-			return nil
+			return nil, false
 		}
 		for _, scopeRecords := range scope.Records {
 			recordCount += len(scopeRecords)
 		}
 	}
 
-	records := make([]Record, 0, recordCount)
+	records := make([]scopedRecord, 0, recordCount)
 	for _, scope := range c.scopes {
 		for _, scopeRecords := range scope.Records {
 			for i := range scopeRecords {
@@ -183,7 +203,7 @@ func (c *DNSController) snapshotIfChangedAndReady() *snapshot {
 				if r.AliasTarget {
 					aliasTargets[r.FQDN] = append(aliasTargets[r.FQDN], *r)
 				} else {
-					records = append(records, *r)
+					records = append(records, scopedRecord{Record: *r, Scope: scope.ScopeName})
 				}
 			}
 		}
@@ -192,7 +212,7 @@ func (c *DNSController) snapshotIfChangedAndReady() *snapshot {
 	s.records = records
 	s.aliasTargets = aliasTargets
 
-	return s
+	return s, false
 }
 
 type recordKey struct {
@@ -200,16 +220,23 @@ type recordKey struct {
 	FQDN       string
 }
 
-func (c *DNSController) runOnce() error {
+// runOnce applies any changes to the records, returning true if DNS then matches the desired records.
+func (c *DNSController) runOnce() (bool, error) {
 	ctx := context.TODO()
 
-	snapshot := c.snapshotIfChangedAndReady()
+	snapshot, unchanged := c.snapshotIfChangedAndReady()
 	if snapshot == nil {
 		// Unchanged / not ready
-		return nil
+		return unchanged, nil
 	}
 
+	start := time.Now()
+	defer func() {
+		syncDuration.Observe(time.Since(start).Seconds())
+	}()
+
 	newValueMap := make(map[recordKey][]string)
+	scopeMap := make(map[recordKey][]string)
 	{
 		// Resolve and build map
 		for _, r := range snapshot.records {
@@ -225,6 +252,7 @@ func (c *DNSController) runOnce() error {
 					}
 					// TODO: Support chains: alias of alias (etc)
 					newValueMap[key] = append(newValueMap[key], aliasRecord.Value)
+					scopeMap[key] = appendUnique(scopeMap[key], r.Scope)
 				}
 				continue
 			} else {
@@ -233,6 +261,7 @@ func (c *DNSController) runOnce() error {
 					FQDN:       r.FQDN,
 				}
 				newValueMap[key] = append(newValueMap[key], r.Value)
+				scopeMap[key] = appendUnique(scopeMap[key], r.Scope)
 				continue
 			}
 		}
@@ -242,6 +271,9 @@ func (c *DNSController) runOnce() error {
 			sort.Strings(values)
 			newValueMap[k] = values
 		}
+		for _, scopes := range scopeMap {
+			sort.Strings(scopes)
+		}
 		snapshot.recordValues = newValueMap
 	}
 
@@ -250,9 +282,22 @@ func (c *DNSController) runOnce() error {
 		oldValueMap = c.lastSuccessfulSnapshot.recordValues
 	}
 
-	op, err := newDNSOp(c.zoneRules, c.dnsCache)
+	pending := 0
+	for k, newValues := range newValueMap {
+		if !util.StringSlicesEqual(newValues, oldValueMap[k]) {
+			pending++
+		}
+	}
+	for k := range oldValueMap {
+		if newValueMap[k] == nil {
+			pending++
+		}
+	}
+	pendingChanges.Set(float64(pending))
+
+	op, err := newDNSOp(c.zoneRules, c.dnsCache, c.ownership)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	// Store a list of all the errors, so that one bad apple doesn't block every other request
@@ -261,7 +306,7 @@ func (c *DNSController) runOnce() error {
 	// Check each hostname for changes and apply them
 	for k, newValues := range newValueMap {
 		if c.StopRequested() {
-			return fmt.Errorf("stop requested")
+			return false, fmt.Errorf("stop requested")
 		}
 		oldValues := oldValueMap[k]
 
@@ -292,7 +337,7 @@ func (c *DNSController) runOnce() error {
 			dedup = append(dedup, s)
 		}
 
-		err := op.updateRecords(k, dedup, int64(ttl.Seconds()))
+		err := op.updateRecords(k, dedup, int64(ttl.Seconds()), scopeMap[k])
 		if err != nil {
 			klog.Infof("error updating records for %s: %v", k, err)
 			errors = append(errors, err)
@@ -302,7 +347,7 @@ func (c *DNSController) runOnce() error {
 	// Look for deleted hostnames
 	for k := range oldValueMap {
 		if c.StopRequested() {
-			return fmt.Errorf("stop requested")
+			return false, fmt.Errorf("stop requested")
 		}
 
 		newValues := newValueMap[k]
@@ -323,25 +368,34 @@ func (c *DNSController) runOnce() error {
 		klog.V(2).Infof("Applying DNS changeset for zone %s", key)
 		if err := changeset.Apply(ctx); err != nil {
 			klog.Warningf("error applying DNS changeset for zone %s: %v", key, err)
+			providerErrors.WithLabelValues(operationApply).Inc()
 			errors = append(errors, fmt.Errorf("error applying DNS changeset for zone %s: %v", key, err))
 		}
 	}
 
 	if len(errors) != 0 {
-		return errors[0]
+		return false, errors[0]
+	}
+
+	pendingChanges.Set(0)
+	recordsManaged.Reset()
+	for k := range newValueMap {
+		if zone := op.findZone(k.FQDN); zone != nil {
+			recordsManaged.WithLabelValues(EnsureDotSuffix(zone.Name())).Inc()
+		}
 	}
 
 	// Success!  Store the snapshot as our new baseline
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	c.lastSuccessfulSnapshot = snapshot
-	return nil
+	return true, nil
 }
 
 func (c *DNSController) RemoveRecordsImmediate(records []Record) error {
 	ctx := context.TODO()
 
-	op, err := newDNSOp(c.zoneRules, c.dnsCache)
+	op, err := newDNSOp(c.zoneRules, c.dnsCache, c.ownership)
 	if err != nil {
 		return err
 	}
@@ -366,6 +420,7 @@ func (c *DNSController) RemoveRecordsImmediate(records []Record) error {
 		klog.V(2).Infof("Applying DNS changeset for zone %s", key)
 		if err := changeset.Apply(ctx); err != nil {
 			klog.Warningf("error applying DNS changeset for zone %s: %v", key, err)
+			providerErrors.WithLabelValues(operationApply).Inc()
 			errors = append(errors, fmt.Errorf("error applying DNS changeset for zone %s: %v", key, err))
 		}
 	}
@@ -382,13 +437,15 @@ type dnsOp struct {
 	dnsCache     *dnsCache
 	zones        map[string]dnsprovider.Zone
 	recordsCache map[string][]dnsprovider.ResourceRecordSet
+	ownership    *OwnershipConfig
 
 	changesets map[string]dnsprovider.ResourceRecordChangeset
 }
 
-func newDNSOp(zoneRules *ZoneRules, dnsCache *dnsCache) (*dnsOp, error) {
+func newDNSOp(zoneRules *ZoneRules, dnsCache *dnsCache, ownership *OwnershipConfig) (*dnsOp, error) {
 	zones, err := dnsCache.ListZones(zoneListCacheValidity)
 	if err != nil {
+		providerErrors.WithLabelValues(operationListZones).Inc()
 		return nil, fmt.Errorf("error querying for zones: %v", err)
 	}
 
@@ -426,6 +483,7 @@ func newDNSOp(zoneRules *ZoneRules, dnsCache *dnsCache) (*dnsOp, error) {
 		zones:        zoneMap,
 		changesets:   make(map[string]dnsprovider.ResourceRecordChangeset),
 		recordsCache: make(map[string][]dnsprovider.ResourceRecordSet),
+		ownership:    ownership,
 	}
 
 	return o, nil
@@ -483,6 +541,7 @@ func (o *dnsOp) listRecords(zone dnsprovider.Zone) ([]dnsprovider.ResourceRecord
 		var err error
 		rrs, err = rrsProvider.List()
 		if err != nil {
+			providerErrors.WithLabelValues(operationListRecords).Inc()
 			return nil, fmt.Errorf("error querying resource records for zone %q: %v", zone.Name(), err)
 		}
 
@@ -509,11 +568,7 @@ func (o *dnsOp) deleteRecords(k recordKey) error {
 		return fmt.Errorf("error querying resource records for zone %q: %v", zone.Name(), err)
 	}
 
-	cs, err := o.getChangeset(zone)
-	if err != nil {
-		return err
-	}
-
+	var matches []dnsprovider.ResourceRecordSet
 	for _, rr := range rrs {
 		rrName := EnsureDotSuffix(rr.Name())
 		if rrName != fqdn {
@@ -524,10 +579,30 @@ func (o *dnsOp) deleteRecords(k recordKey) error {
 			klog.V(8).Infof("Skipping delete of record %q (type %s != %s)", rrName, rr.Type(), k.RecordType)
 			continue
 		}
+		matches = append(matches, rr)
+	}
+
+	var ownershipRecord dnsprovider.ResourceRecordSet
+	if o.ownership != nil {
+		ownershipRecord, err = o.checkOwnership(zone, k, len(matches) != 0)
+		if err != nil {
+			return err
+		}
+	}
 
-		klog.V(2).Infof("Deleting resource record %s %s", rrName, rr.Type())
+	cs, err := o.getChangeset(zone)
+	if err != nil {
+		return err
+	}
+
+	for _, rr := range matches {
+		klog.V(2).Infof("Deleting resource record %s %s", rr.Name(), rr.Type())
 		cs.Remove(rr)
 	}
+	if ownershipRecord != nil {
+		klog.V(2).Infof("Deleting ownership record %s", ownershipRecord.Name())
+		cs.Remove(ownershipRecord)
+	}
 
 	return nil
 }
@@ -536,7 +611,8 @@ func FixWildcards(s string) string {
 	return strings.Replace(s, "\\052", "*", 1)
 }
 
-func (o *dnsOp) updateRecords(k recordKey, newRecords []string, ttl int64) error {
+// updateRecords sets the values of the records, which are defined by the named scopes.
+func (o *dnsOp) updateRecords(k recordKey, newRecords []string, ttl int64, scopes []string) error {
 	fqdn := EnsureDotSuffix(k.FQDN)
 
 	zone := o.findZone(fqdn)
@@ -577,6 +653,12 @@ func (o *dnsOp) updateRecords(k recordKey, newRecords []string, ttl int64) error
 		existing = rr
 	}
 
+	if o.ownership != nil {
+		if _, err := o.checkOwnership(zone, k, existing != nil); err != nil {
+			return err
+		}
+	}
+
 	cs, err := o.getChangeset(zone)
 	if err != nil {
 		return err
@@ -586,6 +668,10 @@ func (o *dnsOp) updateRecords(k recordKey, newRecords []string, ttl int64) error
 	rr := rrsProvider.New(fqdn, newRecords, ttl, rrstype.RrsType(k.RecordType))
 	cs.Upsert(rr)
 
+	if o.ownership != nil {
+		cs.Upsert(rrsProvider.New(ownershipRecordName(k), []string{o.ownership.ownershipRecordValue(scopes)}, ttl, rrstype.TXT))
+	}
+
 	return nil
 }
 
@@ -638,6 +724,16 @@ func (s *DNSControllerScope) AllKeys() []string {
 	return keys
 }
 
+// appendUnique appends s to the slice, unless it is already present
+func appendUnique(slice []string, s string) []string {
+	for _, v := range slice {
+		if v == s {
+			return slice
+		}
+	}
+	return append(slice, s)
+}
+
 // recordsSliceEquals compares two []Record
 func recordsSliceEquals(l, r []Record) bool {
 	if len(l) != len(r) {
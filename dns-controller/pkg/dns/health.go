@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// syncStatus records the outcome of the update loop
+type syncStatus struct {
+	// lastAttempt is when the update loop last completed an iteration
+	lastAttempt time.Time
+	// lastSuccess is when DNS was last known to match the desired records
+	lastSuccess time.Time
+	// lastError is the error from the last iteration, if it failed
+	lastError error
+}
+
+// livenessTimeout is how long the update loop may go without completing an iteration before we consider it stuck.
+// It allows for the longest backoff after repeated failures.
+func (c *DNSController) livenessTimeout() time.Duration {
+	return 2 * (1 << MaxFailures) * c.updateInterval
+}
+
+// recordSync records the outcome of an iteration of the update loop; synced is true if DNS matches the desired records
+func (c *DNSController) recordSync(now time.Time, synced bool, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.syncStatus.lastAttempt = now
+	c.syncStatus.lastError = err
+	if err == nil && synced {
+		c.syncStatus.lastSuccess = now
+		lastSuccessfulSync.Set(float64(now.Unix()))
+	}
+}
+
+// CheckLive returns an error if the update loop has not completed an iteration recently
+func (c *DNSController) CheckLive(now time.Time) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	last := c.syncStatus.lastAttempt
+	if last.IsZero() {
+		last = c.started
+	}
+	if timeout := c.livenessTimeout(); now.Sub(last) > timeout {
+		return fmt.Errorf("DNS update loop has not completed within %v", timeout)
+	}
+	return nil
+}
+
+// CheckReady returns an error if a scope has not yet performed its initial synchronization,
+// or if the last attempt to apply changes to DNS failed
+func (c *DNSController) CheckReady() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var notReady []string
+	for name, scope := range c.scopes {
+		scope.mutex.Lock()
+		if !scope.Ready {
+			notReady = append(notReady, name)
+		}
+		scope.mutex.Unlock()
+	}
+	if len(notReady) != 0 {
+		sort.Strings(notReady)
+		return fmt.Errorf("scopes not yet ready: %s", strings.Join(notReady, ", "))
+	}
+
+	if c.syncStatus.lastError != nil {
+		return fmt.Errorf("last DNS update failed: %v", c.syncStatus.lastError)
+	}
+	if c.syncStatus.lastSuccess.IsZero() {
+		return fmt.Errorf("DNS records not yet synchronized")
+	}
+	return nil
+}
+
+// ServeHealthz is an http.HandlerFunc reporting whether the update loop is running
+func (c *DNSController) ServeHealthz(w http.ResponseWriter, r *http.Request) {
+	serveCheck(w, c.CheckLive(time.Now()))
+}
+
+// ServeReadyz is an http.HandlerFunc reporting whether all scopes are ready and DNS has been synchronized
+func (c *DNSController) ServeReadyz(w http.ResponseWriter, r *http.Request) {
+	serveCheck(w, c.CheckReady())
+}
+
+func serveCheck(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "%v\n", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "ok\n")
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+)
+
+func TestHealthChecks(t *testing.T) {
+	zone := &fakeZone{}
+	c := &DNSController{
+		scopes:    make(map[string]*DNSControllerScope),
+		zoneRules: &ZoneRules{Wildcard: true},
+		dnsCache: &dnsCache{
+			cachedZones:          []dnsprovider.Zone{zone},
+			cachedZonesTimestamp: nanoTime(),
+		},
+		updateInterval: 5 * time.Second,
+		started:        time.Now(),
+	}
+
+	scope, err := c.CreateScope("service")
+	if err != nil {
+		t.Fatalf("unexpected error creating scope: %v", err)
+	}
+	if err := c.CheckReady(); err == nil || err.Error() != "scopes not yet ready: service" {
+		t.Errorf("unexpected readiness before scope is ready: %v", err)
+	}
+	assertStatus(t, c.ServeReadyz, http.StatusServiceUnavailable)
+
+	scope.Replace("default/api", []Record{{RecordType: RecordTypeA, FQDN: "api.example.com", Value: "10.0.0.1"}})
+	scope.MarkReady()
+	if err := c.CheckReady(); err == nil {
+		t.Errorf("expected controller not to be ready before records are synchronized")
+	}
+
+	synced, err := c.runOnce()
+	if err != nil {
+		t.Fatalf("unexpected error updating records: %v", err)
+	}
+	if !synced {
+		t.Errorf("expected records to be synchronized")
+	}
+	if expected := []string{"A api.example.com. 10.0.0.1"}; !reflect.DeepEqual(zone.upserted, expected) {
+		t.Errorf("unexpected changes %v, expected %v", zone.upserted, expected)
+	}
+	c.recordSync(time.Now(), synced, err)
+	if err := c.CheckReady(); err != nil {
+		t.Errorf("unexpected readiness error: %v", err)
+	}
+	assertStatus(t, c.ServeReadyz, http.StatusOK)
+
+	synced, err = c.runOnce()
+	if err != nil || !synced {
+		t.Errorf("expected unchanged records to be synchronized, got %v, %v", synced, err)
+	}
+
+	c.recordSync(time.Now(), false, errors.New("zone not found"))
+	if err := c.CheckReady(); err == nil || err.Error() != "last DNS update failed: zone not found" {
+		t.Errorf("unexpected readiness after failed update: %v", err)
+	}
+
+	if err := c.CheckLive(time.Now()); err != nil {
+		t.Errorf("unexpected liveness error: %v", err)
+	}
+	assertStatus(t, c.ServeHealthz, http.StatusOK)
+	if err := c.CheckLive(time.Now().Add(c.livenessTimeout() + time.Second)); err == nil {
+		t.Errorf("expected liveness error when update loop is stuck")
+	}
+}
+
+func assertStatus(t *testing.T, handler http.HandlerFunc, expected int) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != expected {
+		t.Errorf("unexpected status %d, expected %d: %s", w.Code, expected, w.Body.String())
+	}
+}
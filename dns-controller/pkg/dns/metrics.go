@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "dns_controller"
+
+var (
+	// recordsManaged is the number of records set by the last successful sync, by zone
+	recordsManaged = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "records",
+			Help:      "Number of DNS records managed by dns-controller, by zone, as of the last successful sync.",
+		},
+		[]string{"zone"},
+	)
+
+	syncDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "sync_duration_seconds",
+			Help:      "Time taken to apply changes to DNS.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 10),
+		},
+	)
+
+	// providerErrors counts the errors returned by the DNS provider, by the operation which failed
+	providerErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "provider_errors_total",
+			Help:      "Number of errors returned by the DNS provider, by operation.",
+		},
+		[]string{"operation"},
+	)
+
+	lastSuccessfulSync = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "last_successful_sync_timestamp_seconds",
+			Help:      "Time at which DNS was last known to match the desired records.",
+		},
+	)
+
+	// pendingChanges is the number of records which are known to differ from the desired state
+	pendingChanges = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "pending_changes",
+			Help:      "Number of DNS records with changes which have not yet been applied.",
+		},
+	)
+)
+
+// Operations of the DNS provider, used as the operation label of the provider errors metric
+const (
+	operationListZones   = "list_zones"
+	operationListRecords = "list_records"
+	operationApply       = "apply"
+)
+
+func init() {
+	prometheus.MustRegister(recordsManaged, syncDuration, providerErrors, lastSuccessfulSync, pendingChanges)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider/rrstype"
+)
+
+const (
+	// ownershipHeritage identifies ownership records created by dns-controller
+	ownershipHeritage = "heritage=kops"
+	// ownershipOwnerPrefix prefixes the owner in an ownership record
+	ownershipOwnerPrefix = "kops/owner="
+	// ownershipScopePrefix prefixes the scopes which define the record in an ownership record
+	ownershipScopePrefix = "kops/scope="
+)
+
+// OwnershipConfig configures the TXT records which record the owner of each DNS record,
+// so that dns-controllers sharing a zone do not change each other's records.
+type OwnershipConfig struct {
+	// OwnerID identifies the owner of the records we create, normally the cluster name
+	OwnerID string
+	// AdoptUnowned takes ownership of existing records which have no ownership record,
+	// for migrating records created before ownership records were enabled
+	AdoptUnowned bool
+}
+
+// ownershipRecordName returns the name of the TXT record recording the owner of a record.
+// The name is a subdomain of the record, so it can coexist with a CNAME.
+func ownershipRecordName(k recordKey) string {
+	fqdn := EnsureDotSuffix(k.FQDN)
+	if strings.HasPrefix(fqdn, "*.") {
+		fqdn = "_wildcard" + fqdn[1:]
+	}
+	return "_owner-" + strings.ToLower(string(k.RecordType)) + "." + fqdn
+}
+
+// ownershipRecordValue returns the value of the TXT record recording that we own a record defined by the scopes.
+func (c *OwnershipConfig) ownershipRecordValue(scopes []string) string {
+	return `"` + ownershipHeritage + "," + ownershipOwnerPrefix + c.OwnerID + "," + ownershipScopePrefix + strings.Join(scopes, ";") + `"`
+}
+
+// parseOwner returns the owner in the values of an ownership record, or false if it is not an ownership record.
+func parseOwner(rrdatas []string) (string, bool) {
+	for _, rrdata := range rrdatas {
+		fields := strings.Split(strings.Trim(rrdata, `"`), ",")
+		if fields[0] != ownershipHeritage {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, ownershipOwnerPrefix) {
+				return strings.TrimPrefix(field, ownershipOwnerPrefix), true
+			}
+		}
+	}
+	return "", false
+}
+
+// checkOwnership returns an error if we must not change the record, because it is owned by someone else,
+// or exists without an ownership record and we do not adopt unowned records.
+// It returns the ownership record, if there is one.
+func (o *dnsOp) checkOwnership(zone dnsprovider.Zone, k recordKey, exists bool) (dnsprovider.ResourceRecordSet, error) {
+	rrs, err := o.listRecords(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	name := ownershipRecordName(k)
+	for _, rr := range rrs {
+		if rr.Type() != rrstype.TXT || EnsureDotSuffix(FixWildcards(rr.Name())) != name {
+			continue
+		}
+		owner, ok := parseOwner(rr.Rrdatas())
+		if !ok {
+			return nil, fmt.Errorf("refusing to change records for %s: %s is not an ownership record", k, name)
+		}
+		if owner != o.ownership.OwnerID {
+			return nil, fmt.Errorf("refusing to change records for %s: they are owned by %q", k, owner)
+		}
+		return rr, nil
+	}
+
+	if exists {
+		if !o.ownership.AdoptUnowned {
+			return nil, fmt.Errorf("refusing to change records for %s: they have no ownership record", k)
+		}
+		klog.Infof("adopting existing records for %s", k)
+	}
+	return nil, nil
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider/rrstype"
+)
+
+// fakeZone is a zone which records the changes applied to it
+type fakeZone struct {
+	records  []dnsprovider.ResourceRecordSet
+	upserted []string
+	removed  []string
+}
+
+var _ dnsprovider.Zone = &fakeZone{}
+var _ dnsprovider.ResourceRecordSets = &fakeZone{}
+var _ dnsprovider.ResourceRecordChangeset = &fakeChangeset{}
+
+func (z *fakeZone) Name() string { return "example.com." }
+func (z *fakeZone) ID() string   { return "example" }
+func (z *fakeZone) ResourceRecordSets() (dnsprovider.ResourceRecordSets, bool) {
+	return z, true
+}
+func (z *fakeZone) List() ([]dnsprovider.ResourceRecordSet, error) { return z.records, nil }
+func (z *fakeZone) Get(name string) ([]dnsprovider.ResourceRecordSet, error) {
+	panic("not implemented")
+}
+func (z *fakeZone) New(name string, rrdatas []string, ttl int64, rrsType rrstype.RrsType) dnsprovider.ResourceRecordSet {
+	return &fakeRecord{name: name, rrdatas: rrdatas, ttl: ttl, rrsType: rrsType}
+}
+func (z *fakeZone) StartChangeset() dnsprovider.ResourceRecordChangeset { return &fakeChangeset{z} }
+func (z *fakeZone) Zone() dnsprovider.Zone                              { return z }
+
+// fakeChangeset applies changes to its zone immediately
+type fakeChangeset struct {
+	zone *fakeZone
+}
+
+func (c *fakeChangeset) Add(rrset dnsprovider.ResourceRecordSet) dnsprovider.ResourceRecordChangeset {
+	panic("not implemented")
+}
+func (c *fakeChangeset) Remove(rrset dnsprovider.ResourceRecordSet) dnsprovider.ResourceRecordChangeset {
+	c.zone.removed = append(c.zone.removed, string(rrset.Type())+" "+rrset.Name())
+	return c
+}
+func (c *fakeChangeset) Upsert(rrset dnsprovider.ResourceRecordSet) dnsprovider.ResourceRecordChangeset {
+	c.zone.upserted = append(c.zone.upserted, string(rrset.Type())+" "+rrset.Name()+" "+rrset.Rrdatas()[0])
+	return c
+}
+func (c *fakeChangeset) Apply(ctx context.Context) error { return nil }
+func (c *fakeChangeset) IsEmpty() bool {
+	return len(c.zone.upserted) == 0 && len(c.zone.removed) == 0
+}
+func (c *fakeChangeset) ResourceRecordSets() dnsprovider.ResourceRecordSets { return c.zone }
+
+type fakeRecord struct {
+	name    string
+	rrdatas []string
+	ttl     int64
+	rrsType rrstype.RrsType
+}
+
+func (r *fakeRecord) Name() string          { return r.name }
+func (r *fakeRecord) Rrdatas() []string     { return r.rrdatas }
+func (r *fakeRecord) Ttl() int64            { return r.ttl }
+func (r *fakeRecord) Type() rrstype.RrsType { return r.rrsType }
+
+func TestOwnership(t *testing.T) {
+	apiKey := recordKey{RecordType: RecordTypeA, FQDN: "api.example.com"}
+	apiRecord := &fakeRecord{name: "api.example.com.", rrdatas: []string{"10.0.0.1"}, ttl: 60, rrsType: rrstype.A}
+	ownedBy := func(owner string) *fakeRecord {
+		return &fakeRecord{name: "_owner-a.api.example.com.", rrdatas: []string{`"heritage=kops,kops/owner=` + owner + `,kops/scope=service"`}, ttl: 60, rrsType: rrstype.TXT}
+	}
+
+	cases := []struct {
+		name         string
+		records      []dnsprovider.ResourceRecordSet
+		adoptUnowned bool
+		delete       bool
+		expectError  bool
+		expected     []string
+	}{
+		{
+			name:     "create new record",
+			expected: []string{`A api.example.com. 10.0.0.2`, `TXT _owner-a.api.example.com. "heritage=kops,kops/owner=cluster-a,kops/scope=pod;service"`},
+		},
+		{
+			name:     "update owned record",
+			records:  []dnsprovider.ResourceRecordSet{apiRecord, ownedBy("cluster-a")},
+			expected: []string{`A api.example.com. 10.0.0.2`, `TXT _owner-a.api.example.com. "heritage=kops,kops/owner=cluster-a,kops/scope=pod;service"`},
+		},
+		{
+			name:        "update record owned by another cluster",
+			records:     []dnsprovider.ResourceRecordSet{apiRecord, ownedBy("cluster-b")},
+			expectError: true,
+		},
+		{
+			name:        "update unowned record",
+			records:     []dnsprovider.ResourceRecordSet{apiRecord},
+			expectError: true,
+		},
+		{
+			name:         "adopt unowned record",
+			records:      []dnsprovider.ResourceRecordSet{apiRecord},
+			adoptUnowned: true,
+			expected:     []string{`A api.example.com. 10.0.0.2`, `TXT _owner-a.api.example.com. "heritage=kops,kops/owner=cluster-a,kops/scope=pod;service"`},
+		},
+		{
+			name:     "delete owned record",
+			records:  []dnsprovider.ResourceRecordSet{apiRecord, ownedBy("cluster-a")},
+			delete:   true,
+			expected: []string{"A api.example.com.", "TXT _owner-a.api.example.com."},
+		},
+		{
+			name:        "delete record owned by another cluster",
+			records:     []dnsprovider.ResourceRecordSet{apiRecord, ownedBy("cluster-b")},
+			delete:      true,
+			expectError: true,
+		},
+		{
+			name:         "adopt unowned record owned by another cluster",
+			records:      []dnsprovider.ResourceRecordSet{apiRecord, ownedBy("cluster-b")},
+			adoptUnowned: true,
+			expectError:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			zone := &fakeZone{records: c.records}
+			op := &dnsOp{
+				zones:        map[string]dnsprovider.Zone{"example.com.": zone},
+				recordsCache: make(map[string][]dnsprovider.ResourceRecordSet),
+				changesets:   make(map[string]dnsprovider.ResourceRecordChangeset),
+				ownership:    &OwnershipConfig{OwnerID: "cluster-a", AdoptUnowned: c.adoptUnowned},
+			}
+
+			var err error
+			if c.delete {
+				err = op.deleteRecords(apiKey)
+			} else {
+				err = op.updateRecords(apiKey, []string{"10.0.0.2"}, 60, []string{"pod", "service"})
+			}
+			if c.expectError {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				if len(zone.upserted) != 0 || len(zone.removed) != 0 {
+					t.Errorf("unexpected changes %v %v", zone.upserted, zone.removed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			actual := zone.upserted
+			if c.delete {
+				actual = zone.removed
+			}
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("unexpected changes %v, expected %v", actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestOwnershipRecordName(t *testing.T) {
+	cases := []struct {
+		key      recordKey
+		expected string
+	}{
+		{recordKey{RecordType: RecordTypeA, FQDN: "api.example.com"}, "_owner-a.api.example.com."},
+		{recordKey{RecordType: RecordTypeCNAME, FQDN: "api.example.com."}, "_owner-cname.api.example.com."},
+		{recordKey{RecordType: RecordTypeA, FQDN: "*.apps.example.com"}, "_owner-a._wildcard.apps.example.com."},
+	}
+
+	for _, c := range cases {
+		if actual := ownershipRecordName(c.key); actual != c.expected {
+			t.Errorf("ownershipRecordName(%v) expected %q, but got %q", c.key, c.expected, actual)
+		}
+	}
+}
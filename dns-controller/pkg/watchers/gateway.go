@@ -0,0 +1,403 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"k8s.io/kops/dns-controller/pkg/dns"
+	"k8s.io/kops/dns-controller/pkg/util"
+)
+
+const (
+	// GatewayAPIGroup is the API group of the Gateway API
+	GatewayAPIGroup = "gateway.networking.k8s.io"
+)
+
+// gatewayAPIVersions are the versions of the Gateway API we support, in order of preference
+var gatewayAPIVersions = []string{"v1", "v1beta1"}
+
+// GatewayController watches for Gateway API Gateways and HTTPRoutes.
+// Records are created for the names in the external annotation of Gateways, and for the hostnames
+// and names in the external annotation of HTTPRoutes, pointing to the addresses of their Gateways.
+type GatewayController struct {
+	util.Stoppable
+	client    dynamic.Interface
+	discovery discovery.DiscoveryInterface
+	namespace string
+	scope     dns.Scope
+
+	// mutex protects the following mutable state
+	mutex          sync.Mutex
+	gateways       map[string]*gatewayInfo
+	routes         map[string]*routeInfo
+	gatewaysListed bool
+	routesListed   bool
+	ready          bool
+}
+
+// gatewayInfo holds the fields of a Gateway we use
+type gatewayInfo struct {
+	// hostnames are the names in the external annotation
+	hostnames []string
+	// addresses are records without names for the addresses of the Gateway
+	addresses []dns.Record
+}
+
+// routeInfo holds the fields of an HTTPRoute we use
+type routeInfo struct {
+	// hostnames are the hostnames of the route and the names in the external annotation
+	hostnames []string
+	// parents are the keys of the Gateways the route is attached to
+	parents []string
+}
+
+// NewGatewayController creates a GatewayController
+func NewGatewayController(client dynamic.Interface, discovery discovery.DiscoveryInterface, dns dns.Context, namespace string) (*GatewayController, error) {
+	scope, err := dns.CreateScope("gateway")
+	if err != nil {
+		return nil, fmt.Errorf("error building dns scope: %v", err)
+	}
+	c := &GatewayController{
+		client:    client,
+		discovery: discovery,
+		namespace: namespace,
+		scope:     scope,
+		gateways:  make(map[string]*gatewayInfo),
+		routes:    make(map[string]*routeInfo),
+	}
+
+	return c, nil
+}
+
+// Run starts the GatewayController.
+func (c *GatewayController) Run() {
+	klog.Infof("starting gateway controller")
+
+	stopCh := c.StopChannel()
+	go c.runWatcher(stopCh, "gateways", c.replaceGateways, c.setGateway)
+	go c.runWatcher(stopCh, "httproutes", c.replaceRoutes, c.setRoute)
+
+	<-stopCh
+	klog.Infof("shutting down gateway controller")
+}
+
+// findGatewayAPIVersion returns the preferred version of the Gateway API which serves the resource,
+// or nil if no supported version serves it
+func (c *GatewayController) findGatewayAPIVersion(resource string) (*schema.GroupVersionResource, error) {
+	for _, version := range gatewayAPIVersions {
+		gv := schema.GroupVersion{Group: GatewayAPIGroup, Version: version}
+		resources, err := c.discovery.ServerResourcesForGroupVersion(gv.String())
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error discovering %s resources: %v", gv, err)
+		}
+		for _, r := range resources.APIResources {
+			if r.Name == resource {
+				gvr := gv.WithResource(resource)
+				return &gvr, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// runWatcher lists and watches the resource, calling replace with the listed objects,
+// then set with changed objects, or with nil when they are deleted.
+func (c *GatewayController) runWatcher(stopCh <-chan struct{}, resource string, replace func([]unstructured.Unstructured), set func(key string, obj *unstructured.Unstructured)) {
+	runOnce := func() (bool, error) {
+		ctx := context.TODO()
+
+		gvr, err := c.findGatewayAPIVersion(resource)
+		if err != nil {
+			return false, err
+		}
+		if gvr == nil {
+			// There can be no objects, so we must not hold up the records of the other scopes.
+			// We keep checking, in case the Gateway API is installed later.
+			replace(nil)
+			return false, fmt.Errorf("%s.%s are not served; is the Gateway API installed?", resource, GatewayAPIGroup)
+		}
+		client := c.client.Resource(*gvr).Namespace(c.namespace)
+
+		var listOpts metav1.ListOptions
+		list, err := client.List(ctx, listOpts)
+		if err != nil {
+			return false, fmt.Errorf("error listing %s: %v", resource, err)
+		}
+		klog.V(4).Infof("found %d %s", len(list.Items), resource)
+		replace(list.Items)
+
+		listOpts.Watch = true
+		listOpts.ResourceVersion = list.GetResourceVersion()
+		watcher, err := client.Watch(ctx, listOpts)
+		if err != nil {
+			return false, fmt.Errorf("error watching %s: %v", resource, err)
+		}
+		ch := watcher.ResultChan()
+		for {
+			select {
+			case <-stopCh:
+				klog.Infof("Got stop signal")
+				return true, nil
+			case event, ok := <-ch:
+				if !ok {
+					klog.Infof("%s watch channel closed", resource)
+					return false, nil
+				}
+
+				obj, ok := event.Object.(*unstructured.Unstructured)
+				if !ok {
+					klog.Warningf("unexpected object in %s watch: %T", resource, event.Object)
+					continue
+				}
+				key := obj.GetNamespace() + "/" + obj.GetName()
+				klog.V(4).Infof("%s changed: %s %v", resource, event.Type, key)
+
+				switch event.Type {
+				case watch.Added, watch.Modified:
+					set(key, obj)
+
+				case watch.Deleted:
+					set(key, nil)
+
+				default:
+					klog.Warningf("Unknown event type: %v", event.Type)
+				}
+			}
+		}
+	}
+
+	for {
+		stop, err := runOnce()
+		if stop {
+			return
+		}
+
+		if err != nil {
+			klog.Warningf("Unexpected error in event watch, will retry: %v", err)
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(10 * time.Second):
+			}
+		}
+	}
+}
+
+// replaceGateways replaces all the Gateways with the listed Gateways
+func (c *GatewayController) replaceGateways(items []unstructured.Unstructured) {
+	gateways := make(map[string]*gatewayInfo)
+	for i := range items {
+		obj := &items[i]
+		gateways[obj.GetNamespace()+"/"+obj.GetName()] = parseGateway(obj)
+	}
+
+	c.mutex.Lock()
+	c.gateways = gateways
+	c.gatewaysListed = true
+	c.mutex.Unlock()
+
+	c.updateRecords()
+}
+
+// replaceRoutes replaces all the HTTPRoutes with the listed HTTPRoutes
+func (c *GatewayController) replaceRoutes(items []unstructured.Unstructured) {
+	routes := make(map[string]*routeInfo)
+	for i := range items {
+		obj := &items[i]
+		routes[obj.GetNamespace()+"/"+obj.GetName()] = parseHTTPRoute(obj)
+	}
+
+	c.mutex.Lock()
+	c.routes = routes
+	c.routesListed = true
+	c.mutex.Unlock()
+
+	c.updateRecords()
+}
+
+// setGateway records the state of a Gateway, or its removal if obj is nil
+func (c *GatewayController) setGateway(key string, obj *unstructured.Unstructured) {
+	c.mutex.Lock()
+	if obj == nil {
+		delete(c.gateways, key)
+	} else {
+		c.gateways[key] = parseGateway(obj)
+	}
+	c.mutex.Unlock()
+
+	c.updateRecords()
+}
+
+// setRoute records the state of an HTTPRoute, or its removal if obj is nil
+func (c *GatewayController) setRoute(key string, obj *unstructured.Unstructured) {
+	c.mutex.Lock()
+	if obj == nil {
+		delete(c.routes, key)
+	} else {
+		c.routes[key] = parseHTTPRoute(obj)
+	}
+	c.mutex.Unlock()
+
+	c.updateRecords()
+}
+
+// updateRecords replaces the records in the scope with those for the current Gateways and HTTPRoutes.
+// A change to a Gateway can change the records of any of its routes, so we rebuild all the records;
+// the scope ignores records which are unchanged.
+func (c *GatewayController) updateRecords() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.gatewaysListed || !c.routesListed {
+		return
+	}
+
+	records := c.buildRecords()
+	for _, key := range c.scope.AllKeys() {
+		if _, found := records[key]; !found {
+			c.scope.Replace(key, nil)
+		}
+	}
+	for key, r := range records {
+		c.scope.Replace(key, r)
+	}
+
+	if !c.ready {
+		c.ready = true
+		c.scope.MarkReady()
+	}
+}
+
+// buildRecords returns the records for the Gateways and HTTPRoutes, by scope key
+func (c *GatewayController) buildRecords() map[string][]dns.Record {
+	records := make(map[string][]dns.Record)
+
+	for key, gateway := range c.gateways {
+		records["gateway/"+key] = namedRecords(gateway.hostnames, gateway.addresses)
+	}
+
+	for key, route := range c.routes {
+		var addresses []dns.Record
+		for _, parent := range route.parents {
+			if gateway := c.gateways[parent]; gateway != nil {
+				addresses = append(addresses, gateway.addresses...)
+			}
+		}
+		records["httproute/"+key] = namedRecords(route.hostnames, addresses)
+	}
+
+	return records
+}
+
+// namedRecords returns a copy of the records without names for each of the hostnames
+func namedRecords(hostnames []string, addresses []dns.Record) []dns.Record {
+	var records []dns.Record
+	for _, hostname := range hostnames {
+		fqdn := dns.EnsureDotSuffix(hostname)
+		for _, address := range addresses {
+			r := address
+			r.FQDN = fqdn
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// parseGateway extracts the fields we use from a Gateway
+func parseGateway(obj *unstructured.Unstructured) *gatewayInfo {
+	gateway := &gatewayInfo{
+		hostnames: annotationHostnames(obj.GetAnnotations()),
+	}
+
+	addresses, _, _ := unstructured.NestedSlice(obj.Object, "status", "addresses")
+	for _, a := range addresses {
+		address, ok := a.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		addressType, _, _ := unstructured.NestedString(address, "type")
+		value, _, _ := unstructured.NestedString(address, "value")
+		if value == "" {
+			continue
+		}
+		switch addressType {
+		case "", "IPAddress":
+			gateway.addresses = append(gateway.addresses, dns.Record{RecordType: dns.RecordTypeA, Value: value})
+		case "Hostname":
+			gateway.addresses = append(gateway.addresses, dns.Record{RecordType: dns.RecordTypeCNAME, Value: value})
+		default:
+			klog.V(2).Infof("ignoring address %q of gateway %s/%s with unsupported type %q", value, obj.GetNamespace(), obj.GetName(), addressType)
+		}
+	}
+	sort.Slice(gateway.addresses, func(i, j int) bool {
+		return gateway.addresses[i].Value < gateway.addresses[j].Value
+	})
+
+	return gateway
+}
+
+// parseHTTPRoute extracts the fields we use from an HTTPRoute
+func parseHTTPRoute(obj *unstructured.Unstructured) *routeInfo {
+	route := &routeInfo{}
+
+	hostnames, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "hostnames")
+	route.hostnames = append(hostnames, annotationHostnames(obj.GetAnnotations())...)
+
+	parentRefs, _, _ := unstructured.NestedSlice(obj.Object, "spec", "parentRefs")
+	for _, p := range parentRefs {
+		parentRef, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, found, _ := unstructured.NestedString(parentRef, "group")
+		if !found {
+			group = GatewayAPIGroup
+		}
+		kind, found, _ := unstructured.NestedString(parentRef, "kind")
+		if !found {
+			kind = "Gateway"
+		}
+		if group != GatewayAPIGroup || kind != "Gateway" {
+			continue
+		}
+		namespace, _, _ := unstructured.NestedString(parentRef, "namespace")
+		if namespace == "" {
+			namespace = obj.GetNamespace()
+		}
+		name, _, _ := unstructured.NestedString(parentRef, "name")
+		route.parents = append(route.parents, namespace+"/"+name)
+	}
+
+	return route
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/kops/dns-controller/pkg/dns"
+)
+
+// notServedDiscovery is a discovery client for a cluster which serves no Gateway API versions
+type notServedDiscovery struct {
+	discovery.DiscoveryInterface
+}
+
+func (d *notServedDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	gv, err := schema.ParseGroupVersion(groupVersion)
+	if err != nil {
+		return nil, err
+	}
+	return nil, errors.NewNotFound(gv.WithResource("").GroupResource(), "")
+}
+
+func TestGatewayRecords(t *testing.T) {
+	gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "Gateway",
+		"metadata": map[string]interface{}{
+			"name":      "gw",
+			"namespace": "infra",
+			"annotations": map[string]interface{}{
+				"dns.alpha.kubernetes.io/external": "gw.foo.com",
+			},
+		},
+		"status": map[string]interface{}{
+			"addresses": []interface{}{
+				map[string]interface{}{"type": "IPAddress", "value": "10.0.0.2"},
+				map[string]interface{}{"value": "10.0.0.1"},
+				map[string]interface{}{"type": "NamedAddress", "value": "internal"},
+			},
+		},
+	}}
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata": map[string]interface{}{
+			"name":      "app",
+			"namespace": "apps",
+		},
+		"spec": map[string]interface{}{
+			"hostnames": []interface{}{"app.foo.com"},
+			"parentRefs": []interface{}{
+				map[string]interface{}{"name": "gw", "namespace": "infra"},
+				map[string]interface{}{"name": "other"},
+				map[string]interface{}{"kind": "Service", "name": "svc"},
+			},
+		},
+	}}
+
+	ch := make(chan struct{})
+	scope := &fakeScope{
+		readyCh: ch,
+		records: make(map[string][]dns.Record),
+	}
+	c, err := NewGatewayController(nil, nil, &fakeDNSContext{scope: scope}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.replaceGateways([]unstructured.Unstructured{*gateway})
+	if len(scope.records) != 0 {
+		t.Fatalf("records set before routes were listed: %v", scope.records)
+	}
+	c.replaceRoutes([]unstructured.Unstructured{*route})
+	select {
+	case <-ch:
+	default:
+		t.Fatalf("scope was not marked as ready")
+	}
+
+	want := map[string][]dns.Record{
+		"gateway/infra/gw": {
+			{RecordType: "A", FQDN: "gw.foo.com.", Value: "10.0.0.1"},
+			{RecordType: "A", FQDN: "gw.foo.com.", Value: "10.0.0.2"},
+		},
+		"httproute/apps/app": {
+			{RecordType: "A", FQDN: "app.foo.com.", Value: "10.0.0.1"},
+			{RecordType: "A", FQDN: "app.foo.com.", Value: "10.0.0.2"},
+		},
+	}
+	if diff := cmp.Diff(scope.records, want); diff != "" {
+		t.Fatalf("generated records did not match expected; diff=%s", diff)
+	}
+
+	// Removing the gateway removes the records of its routes
+	c.setGateway("infra/gw", nil)
+	want = map[string][]dns.Record{
+		"gateway/infra/gw":   nil,
+		"httproute/apps/app": nil,
+	}
+	if diff := cmp.Diff(scope.records, want); diff != "" {
+		t.Fatalf("generated records did not match expected after removing gateway; diff=%s", diff)
+	}
+}
+
+func TestGatewayAPINotServed(t *testing.T) {
+	ch := make(chan struct{})
+	scope := &fakeScope{
+		readyCh: ch,
+		records: make(map[string][]dns.Record),
+	}
+	c, err := NewGatewayController(nil, &notServedDiscovery{}, &fakeDNSContext{scope: scope}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go c.runWatcher(stopCh, "gateways", c.replaceGateways, c.setGateway)
+	go c.runWatcher(stopCh, "httproutes", c.replaceRoutes, c.setRoute)
+
+	select {
+	case <-ch:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("scope was not marked as ready")
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(scope.records) != 0 {
+		t.Fatalf("unexpected records: %v", scope.records)
+	}
+}
@@ -19,10 +19,15 @@ package watchers
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
@@ -31,7 +36,8 @@ import (
 	"k8s.io/kops/dns-controller/pkg/util"
 )
 
-// IngressController watches for Ingress objects with dns labels
+// IngressController watches for Ingress objects with dns labels.
+// It watches networking.k8s.io/v1 Ingresses, or extensions/v1beta1 Ingresses on clusters which do not serve v1.
 type IngressController struct {
 	util.Stoppable
 	client    kubernetes.Interface
@@ -65,21 +71,102 @@ func (c *IngressController) Run() {
 	klog.Infof("shutting down ingress controller")
 }
 
+// ingressV1Served returns true if the cluster serves networking.k8s.io/v1 Ingresses
+func ingressV1Served(client kubernetes.Interface) (bool, error) {
+	resources, err := client.Discovery().ServerResourcesForGroupVersion(networkingv1.SchemeGroupVersion.String())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error discovering %s resources: %v", networkingv1.SchemeGroupVersion, err)
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "ingresses" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// listIngresses lists the ingresses with the served version, returning them as v1 Ingresses
+func (c *IngressController) listIngresses(ctx context.Context, v1 bool, listOpts metav1.ListOptions) ([]*networkingv1.Ingress, string, error) {
+	var ingresses []*networkingv1.Ingress
+	if v1 {
+		ingressList, err := c.client.NetworkingV1().Ingresses(c.namespace).List(ctx, listOpts)
+		if err != nil {
+			return nil, "", err
+		}
+		for i := range ingressList.Items {
+			ingresses = append(ingresses, &ingressList.Items[i])
+		}
+		return ingresses, ingressList.ResourceVersion, nil
+	}
+
+	ingressList, err := c.client.ExtensionsV1beta1().Ingresses(c.namespace).List(ctx, listOpts)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range ingressList.Items {
+		ingresses = append(ingresses, convertIngress(&ingressList.Items[i]))
+	}
+	return ingresses, ingressList.ResourceVersion, nil
+}
+
+func (c *IngressController) watchIngresses(ctx context.Context, v1 bool, listOpts metav1.ListOptions) (watch.Interface, error) {
+	if v1 {
+		return c.client.NetworkingV1().Ingresses(c.namespace).Watch(ctx, listOpts)
+	}
+	return c.client.ExtensionsV1beta1().Ingresses(c.namespace).Watch(ctx, listOpts)
+}
+
+// toIngress returns the object from a watch event as a v1 Ingress
+func toIngress(obj runtime.Object) (*networkingv1.Ingress, error) {
+	switch ingress := obj.(type) {
+	case *networkingv1.Ingress:
+		return ingress, nil
+	case *v1beta1.Ingress:
+		return convertIngress(ingress), nil
+	default:
+		return nil, fmt.Errorf("unexpected object in ingress watch: %T", obj)
+	}
+}
+
+// convertIngress converts the fields of an extensions/v1beta1 Ingress we use to a v1 Ingress
+func convertIngress(in *v1beta1.Ingress) *networkingv1.Ingress {
+	out := &networkingv1.Ingress{
+		ObjectMeta: in.ObjectMeta,
+		Status: networkingv1.IngressStatus{
+			LoadBalancer: in.Status.LoadBalancer,
+		},
+	}
+	for _, rule := range in.Spec.Rules {
+		out.Spec.Rules = append(out.Spec.Rules, networkingv1.IngressRule{Host: rule.Host})
+	}
+	return out
+}
+
 func (c *IngressController) runWatcher(stopCh <-chan struct{}) {
 	runOnce := func() (bool, error) {
 		ctx := context.TODO()
 
+		v1, err := ingressV1Served(c.client)
+		if err != nil {
+			return false, err
+		}
+		if !v1 {
+			klog.Infof("%s Ingresses are not served; watching extensions/v1beta1 Ingresses", networkingv1.SchemeGroupVersion)
+		}
+
 		var listOpts metav1.ListOptions
 		klog.V(4).Infof("querying without label filter")
 
 		allKeys := c.scope.AllKeys()
-		ingressList, err := c.client.ExtensionsV1beta1().Ingresses(c.namespace).List(ctx, listOpts)
+		ingresses, resourceVersion, err := c.listIngresses(ctx, v1, listOpts)
 		if err != nil {
 			return false, fmt.Errorf("error listing ingresses: %v", err)
 		}
 		foundKeys := make(map[string]bool)
-		for i := range ingressList.Items {
-			ingress := &ingressList.Items[i]
+		for _, ingress := range ingresses {
 			klog.V(4).Infof("found ingress: %v", ingress.Name)
 			key := c.updateIngressRecords(ingress)
 			foundKeys[key] = true
@@ -94,8 +181,8 @@ func (c *IngressController) runWatcher(stopCh <-chan struct{}) {
 		c.scope.MarkReady()
 
 		listOpts.Watch = true
-		listOpts.ResourceVersion = ingressList.ResourceVersion
-		watcher, err := c.client.ExtensionsV1beta1().Ingresses(c.namespace).Watch(ctx, listOpts)
+		listOpts.ResourceVersion = resourceVersion
+		watcher, err := c.watchIngresses(ctx, v1, listOpts)
 		if err != nil {
 			return false, fmt.Errorf("error watching ingresses: %v", err)
 		}
@@ -111,7 +198,11 @@ func (c *IngressController) runWatcher(stopCh <-chan struct{}) {
 					return false, nil
 				}
 
-				ingress := event.Object.(*v1beta1.Ingress)
+				ingress, err := toIngress(event.Object)
+				if err != nil {
+					klog.Warningf("%v", err)
+					continue
+				}
 				klog.V(4).Infof("ingress changed: %s %v", event.Type, ingress.Name)
 
 				switch event.Type {
@@ -142,41 +233,64 @@ func (c *IngressController) runWatcher(stopCh <-chan struct{}) {
 }
 
 // updateIngressRecords will apply the records for the specified ingress.  It returns the key that was set.
-func (c *IngressController) updateIngressRecords(ingress *v1beta1.Ingress) string {
+// Records are created for the hosts of the rules, and the names in the external annotation.
+func (c *IngressController) updateIngressRecords(ingress *networkingv1.Ingress) string {
 	var records []dns.Record
 
-	var ingresses []dns.Record
-	for i := range ingress.Status.LoadBalancer.Ingress {
-		ingress := &ingress.Status.LoadBalancer.Ingress[i]
+	ingresses := loadBalancerRecords(ingress.Status.LoadBalancer.Ingress)
+
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	hosts = append(hosts, annotationHostnames(ingress.Annotations)...)
+
+	for _, host := range hosts {
+		fqdn := dns.EnsureDotSuffix(host)
+		for _, ingress := range ingresses {
+			r := ingress
+			r.FQDN = fqdn
+			records = append(records, r)
+		}
+	}
+
+	key := ingress.Namespace + "/" + ingress.Name
+	c.scope.Replace(key, records)
+	return key
+}
+
+// loadBalancerRecords returns records without names for the load balancer hostnames and IPs
+func loadBalancerRecords(lbIngresses []corev1.LoadBalancerIngress) []dns.Record {
+	var records []dns.Record
+	for i := range lbIngresses {
+		ingress := &lbIngresses[i]
 		if ingress.Hostname != "" {
 			// TODO: Support ELB aliases
-			ingresses = append(ingresses, dns.Record{
+			records = append(records, dns.Record{
 				RecordType: dns.RecordTypeCNAME,
 				Value:      ingress.Hostname,
 			})
 		}
 		if ingress.IP != "" {
-			ingresses = append(ingresses, dns.Record{
+			records = append(records, dns.Record{
 				RecordType: dns.RecordTypeA,
 				Value:      ingress.IP,
 			})
 		}
 	}
+	return records
+}
 
-	for _, rule := range ingress.Spec.Rules {
-		if rule.Host == "" {
-			continue
-		}
-
-		fqdn := dns.EnsureDotSuffix(rule.Host)
-		for _, ingress := range ingresses {
-			r := ingress
-			r.FQDN = fqdn
-			records = append(records, r)
+// annotationHostnames returns the comma-separated names in the external annotation
+func annotationHostnames(annotations map[string]string) []string {
+	var hostnames []string
+	for _, token := range strings.Split(annotations[AnnotationNameDNSExternal], ",") {
+		token = strings.TrimSpace(token)
+		if token != "" {
+			hostnames = append(hostnames, token)
 		}
 	}
-
-	key := ingress.Namespace + "/" + ingress.Name
-	c.scope.Replace(key, records)
-	return key
+	return hostnames
 }
@@ -0,0 +1,122 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kops/dns-controller/pkg/dns"
+)
+
+func TestIngressController(t *testing.T) {
+	ctx := context.Background()
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "someingress",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"dns.alpha.kubernetes.io/external": "b.foo.com",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{Host: "a.foo.com"}, {}},
+		},
+		Status: networkingv1.IngressStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}},
+			},
+		},
+	}
+
+	client := fake.NewSimpleClientset()
+	client.Fake.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "networking.k8s.io/v1",
+			APIResources: []metav1.APIResource{{Name: "ingresses", Namespaced: true, Kind: "Ingress"}},
+		},
+	}
+	_, err := client.NetworkingV1().Ingresses("default").Create(ctx, ingress, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ch := make(chan struct{})
+	scope := &fakeScope{
+		readyCh: ch,
+		records: make(map[string][]dns.Record),
+	}
+
+	c, err := NewIngressController(client, &fakeDNSContext{scope: scope}, "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	go c.Run()
+
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatalf("update was not marked as complete")
+	}
+
+	c.Stop()
+
+	want := map[string][]dns.Record{
+		"default/someingress": {
+			{RecordType: "CNAME", FQDN: "a.foo.com.", Value: "lb.example.com"},
+			{RecordType: "CNAME", FQDN: "b.foo.com.", Value: "lb.example.com"},
+		},
+	}
+	if diff := cmp.Diff(scope.records, want); diff != "" {
+		t.Fatalf("generated records did not match expected; diff=%s", diff)
+	}
+}
+
+func TestConvertIngress(t *testing.T) {
+	in := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "someingress", Namespace: "default"},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{Host: "a.foo.com"}},
+		},
+		Status: v1beta1.IngressStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}},
+			},
+		},
+	}
+
+	scope := &fakeScope{records: make(map[string][]dns.Record)}
+	c := &IngressController{scope: scope}
+	c.updateIngressRecords(convertIngress(in))
+
+	want := map[string][]dns.Record{
+		"default/someingress": {
+			{RecordType: "A", FQDN: "a.foo.com.", Value: "10.0.0.1"},
+		},
+	}
+	if diff := cmp.Diff(scope.records, want); diff != "" {
+		t.Fatalf("generated records did not match expected; diff=%s", diff)
+	}
+}
@@ -34,8 +34,12 @@ func (f *fakeScope) MarkReady() {
 	close(f.readyCh)
 }
 
-func (*fakeScope) AllKeys() []string {
-	return []string{}
+func (f *fakeScope) AllKeys() []string {
+	var keys []string
+	for k := range f.records {
+		keys = append(keys, k)
+	}
+	return keys
 }
 
 type fakeDNSContext struct {
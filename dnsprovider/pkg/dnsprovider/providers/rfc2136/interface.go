@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rfc2136
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"k8s.io/klog/v2"
+
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+)
+
+// tsigFudge is the permitted clock skew, in seconds, when validating signed messages
+const tsigFudge = 300
+
+var _ dnsprovider.Interface = &Interface{}
+
+// TSIGKey is a key used to sign messages (RFC 2845).
+type TSIGKey struct {
+	Name      string
+	Secret    string
+	Algorithm string
+}
+
+type Interface struct {
+	server string
+	zones  []string
+	key    *TSIGKey
+}
+
+// New builds an Interface managing the zones on the DNS server, signing messages with the key if it is not nil.
+// This is useful for testing purposes, but also if we want an instance without a config file.
+func New(server string, zones []string, key *TSIGKey) *Interface {
+	if key != nil {
+		key = &TSIGKey{
+			Name:      dns.Fqdn(key.Name),
+			Secret:    key.Secret,
+			Algorithm: dns.Fqdn(strings.ToLower(key.Algorithm)),
+		}
+	}
+	return &Interface{
+		server: server,
+		zones:  zones,
+		key:    key,
+	}
+}
+
+func (i *Interface) Zones() (zones dnsprovider.Zones, supported bool) {
+	return &Zones{i}, true
+}
+
+func (i *Interface) tsigSecret() map[string]string {
+	if i.key == nil {
+		return nil
+	}
+	return map[string]string{i.key.Name: i.key.Secret}
+}
+
+func (i *Interface) sign(msg *dns.Msg) {
+	if i.key != nil {
+		msg.SetTsig(i.key.Name, i.key.Algorithm, tsigFudge, time.Now().Unix())
+	}
+}
+
+// transfer returns the records of the zone, using a zone transfer (AXFR).
+func (i *Interface) transfer(zone string) ([]dns.RR, error) {
+	msg := new(dns.Msg)
+	msg.SetAxfr(zone)
+	i.sign(msg)
+
+	t := &dns.Transfer{TsigSecret: i.tsigSecret()}
+	envelopes, err := t.In(msg, i.server)
+	if err != nil {
+		return nil, fmt.Errorf("error transferring zone %q from %s: %v", zone, i.server, err)
+	}
+
+	var records []dns.RR
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, fmt.Errorf("error transferring zone %q from %s: %v", zone, i.server, envelope.Error)
+		}
+		records = append(records, envelope.RR...)
+	}
+	klog.V(4).Infof("transferred %d records of zone %q", len(records), zone)
+	return records, nil
+}
+
+// update sends the update message to the server, failing if it is not applied.
+func (i *Interface) update(ctx context.Context, msg *dns.Msg) error {
+	i.sign(msg)
+
+	// Updates may be too large for UDP, and are not retried, so we always use TCP
+	c := &dns.Client{Net: "tcp", TsigSecret: i.tsigSecret()}
+	reply, _, err := c.ExchangeContext(ctx, msg, i.server)
+	if err != nil {
+		return fmt.Errorf("error sending DNS update to %s: %v", i.server, err)
+	}
+	if reply.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("DNS update of zone %q was rejected by %s: %s", msg.Question[0].Name, i.server, dns.RcodeToString[reply.Rcode])
+	}
+	return nil
+}
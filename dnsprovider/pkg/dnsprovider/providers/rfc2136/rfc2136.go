@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// rfc2136 is the implementation of pkg/dnsprovider interface for DNS servers supporting
+// dynamic updates (RFC 2136) and zone transfers, such as BIND and PowerDNS.
+package rfc2136
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/miekg/dns"
+	gcfg "gopkg.in/gcfg.v1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+)
+
+const (
+	// ProviderName is the name of this DNS provider
+	ProviderName = "rfc2136"
+
+	// defaultTSIGAlgorithm is used to sign messages if no algorithm is configured
+	defaultTSIGAlgorithm = dns.HmacSHA256
+)
+
+// The environment variables override the config file, so that the TSIG secret can be read from a Kubernetes Secret
+const (
+	envServer        = "RFC2136_SERVER"
+	envZones         = "RFC2136_ZONES"
+	envTSIGKeyName   = "RFC2136_TSIG_KEY_NAME"
+	envTSIGSecret    = "RFC2136_TSIG_SECRET"
+	envTSIGAlgorithm = "RFC2136_TSIG_ALGORITHM"
+)
+
+func init() {
+	dnsprovider.RegisterDNSProvider(ProviderName, func(config io.Reader) (dnsprovider.Interface, error) {
+		return newRFC2136(config)
+	})
+}
+
+// Config is the configuration of the provider, in gcfg format:
+//
+//	[global]
+//	server = ns1.example.com:53
+//	zone = example.com
+//	tsig-key-name = kops
+//	tsig-secret = <base64 encoded secret>
+type Config struct {
+	Global struct {
+		// Server is the address of the DNS server which accepts updates and zone transfers
+		Server string `gcfg:"server"`
+		// Zones are the zones we manage; zones cannot be discovered with RFC 2136
+		Zones []string `gcfg:"zone"`
+		// TSIGKeyName is the name of the key used to sign messages; messages are not signed if it is empty
+		TSIGKeyName string `gcfg:"tsig-key-name"`
+		// TSIGSecret is the base64 encoded secret of the key
+		TSIGSecret string `gcfg:"tsig-secret"`
+		// TSIGAlgorithm is the algorithm of the key, defaulting to hmac-sha256
+		TSIGAlgorithm string `gcfg:"tsig-algorithm"`
+	}
+}
+
+// newRFC2136 creates an Interface from the config and environment variables.
+func newRFC2136(config io.Reader) (*Interface, error) {
+	var cfg Config
+	if config != nil {
+		if err := gcfg.ReadInto(&cfg, config); err != nil {
+			return nil, fmt.Errorf("error reading RFC2136 config: %v", err)
+		}
+	}
+	if s := os.Getenv(envServer); s != "" {
+		cfg.Global.Server = s
+	}
+	if s := os.Getenv(envZones); s != "" {
+		cfg.Global.Zones = strings.Split(s, ",")
+	}
+	if s := os.Getenv(envTSIGKeyName); s != "" {
+		cfg.Global.TSIGKeyName = s
+	}
+	if s := os.Getenv(envTSIGSecret); s != "" {
+		cfg.Global.TSIGSecret = s
+	}
+	if s := os.Getenv(envTSIGAlgorithm); s != "" {
+		cfg.Global.TSIGAlgorithm = s
+	}
+	return NewFromConfig(&cfg)
+}
+
+// NewFromConfig builds an Interface from the config.
+func NewFromConfig(cfg *Config) (*Interface, error) {
+	server := cfg.Global.Server
+	if server == "" {
+		return nil, fmt.Errorf("the DNS server must be configured for the RFC2136 DNS provider")
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	var zones []string
+	for _, zone := range cfg.Global.Zones {
+		zone = strings.TrimSpace(zone)
+		if zone != "" {
+			zones = append(zones, dns.Fqdn(strings.ToLower(zone)))
+		}
+	}
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("at least one zone must be configured for the RFC2136 DNS provider")
+	}
+
+	var key *TSIGKey
+	if cfg.Global.TSIGKeyName != "" {
+		key = &TSIGKey{
+			Name:      cfg.Global.TSIGKeyName,
+			Secret:    cfg.Global.TSIGSecret,
+			Algorithm: cfg.Global.TSIGAlgorithm,
+		}
+		if key.Algorithm == "" {
+			key.Algorithm = defaultTSIGAlgorithm
+		}
+		if key.Secret == "" {
+			return nil, fmt.Errorf("TSIG key %q has no secret", key.Name)
+		}
+		switch dns.Fqdn(strings.ToLower(key.Algorithm)) {
+		case dns.HmacSHA1, dns.HmacSHA224, dns.HmacSHA256, dns.HmacSHA384, dns.HmacSHA512:
+		default:
+			return nil, fmt.Errorf("unsupported TSIG algorithm %q", key.Algorithm)
+		}
+	} else {
+		klog.Warningf("no TSIG key configured; DNS updates to %s will not be signed", server)
+	}
+
+	klog.Infof("Using RFC2136 DNS server %s for zones %v", server, zones)
+	return New(server, zones, key), nil
+}
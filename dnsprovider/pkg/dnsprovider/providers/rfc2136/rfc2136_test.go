@@ -0,0 +1,258 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rfc2136
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider/rrstype"
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider/tests"
+)
+
+const (
+	testZone      = "test.com."
+	testKeyName   = "kops."
+	testKeySecret = "c2VjcmV0LWtleS1mb3ItdGVzdGluZw=="
+)
+
+// fakeServer is an in-process authoritative DNS server for a single zone, supporting zone transfers and dynamic updates.
+type fakeServer struct {
+	mutex   sync.Mutex
+	records []dns.RR
+}
+
+func (s *fakeServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	reply := new(dns.Msg)
+	reply.SetReply(r)
+	if tsig := r.IsTsig(); tsig != nil {
+		if w.TsigStatus() != nil {
+			reply.SetRcode(r, dns.RcodeNotAuth)
+			w.WriteMsg(reply)
+			return
+		}
+		reply.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+	}
+	if len(r.Question) != 1 || r.Question[0].Name != testZone {
+		reply.SetRcode(r, dns.RcodeNotZone)
+		w.WriteMsg(reply)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case r.Opcode == dns.OpcodeUpdate:
+		for _, rr := range r.Ns {
+			s.update(rr)
+		}
+		w.WriteMsg(reply)
+
+	case r.Question[0].Qtype == dns.TypeAXFR:
+		soa, _ := dns.NewRR(testZone + " 3600 IN SOA ns1.test.com. admin.test.com. 1 3600 600 86400 60")
+		rrs := append([]dns.RR{soa}, s.records...)
+		rrs = append(rrs, soa)
+		ch := make(chan *dns.Envelope, 1)
+		ch <- &dns.Envelope{RR: rrs}
+		close(ch)
+		t := &dns.Transfer{TsigSecret: map[string]string{testKeyName: testKeySecret}}
+		t.Out(w, r, ch)
+
+	default:
+		reply.SetRcode(r, dns.RcodeNotImplemented)
+		w.WriteMsg(reply)
+	}
+}
+
+// update applies a record from the update section of a message, as described in RFC 2136 section 3.4.2
+func (s *fakeServer) update(rr dns.RR) {
+	hdr := rr.Header()
+	var kept []dns.RR
+	for _, existing := range s.records {
+		e := existing.Header()
+		sameName := strings.EqualFold(e.Name, hdr.Name)
+		switch hdr.Class {
+		case dns.ClassANY:
+			if sameName && (hdr.Rrtype == dns.TypeANY || hdr.Rrtype == e.Rrtype) {
+				continue
+			}
+		case dns.ClassNONE:
+			removed := dns.Copy(rr)
+			removed.Header().Class = dns.ClassINET
+			removed.Header().Ttl = e.Ttl
+			if dns.IsDuplicate(existing, removed) {
+				continue
+			}
+		default:
+			// Adding a record to a set updates the TTL of the set
+			if sameName && e.Rrtype == hdr.Rrtype {
+				e.Ttl = hdr.Ttl
+			}
+		}
+		kept = append(kept, existing)
+	}
+	s.records = kept
+	if hdr.Class == dns.ClassINET {
+		s.records = append(s.records, rr)
+	}
+}
+
+func newTestInterface(t *testing.T, key *TSIGKey) *Interface {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	server := &dns.Server{
+		Listener:   listener,
+		Handler:    &fakeServer{},
+		TsigSecret: map[string]string{testKeyName: testKeySecret},
+		MsgAcceptFunc: func(dh dns.Header) dns.MsgAcceptAction {
+			return dns.MsgAccept
+		},
+	}
+	started := make(chan struct{})
+	server.NotifyStartedFunc = func() { close(started) }
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+
+	return New(listener.Addr().String(), []string{testZone}, key)
+}
+
+func firstZone(t *testing.T, iface dnsprovider.Interface) dnsprovider.Zone {
+	zones, supported := iface.Zones()
+	if !supported {
+		t.Fatalf("zones not supported")
+	}
+	list, err := zones.List()
+	if err != nil {
+		t.Fatalf("error listing zones: %v", err)
+	}
+	if len(list) != 1 || list[0].Name() != testZone {
+		t.Fatalf("unexpected zones %v", list)
+	}
+	return list[0]
+}
+
+// TestContract verifies the general interface contract
+func TestContract(t *testing.T) {
+	zone := firstZone(t, newTestInterface(t, &TSIGKey{Name: testKeyName, Secret: testKeySecret, Algorithm: dns.HmacSHA256}))
+	sets, _ := zone.ResourceRecordSets()
+
+	tests.TestContract(t, sets)
+}
+
+func TestResourceRecordSetsReplace(t *testing.T) {
+	zone := firstZone(t, newTestInterface(t, &TSIGKey{Name: testKeyName, Secret: testKeySecret, Algorithm: dns.HmacSHA256}))
+	tests.CommonTestResourceRecordSetsReplace(t, zone)
+}
+
+func TestResourceRecordSetsReplaceAll(t *testing.T) {
+	zone := firstZone(t, newTestInterface(t, &TSIGKey{Name: testKeyName, Secret: testKeySecret, Algorithm: dns.HmacSHA256}))
+	tests.CommonTestResourceRecordSetsReplaceAll(t, zone)
+}
+
+func TestResourceRecordSetsDifferentTypes(t *testing.T) {
+	zone := firstZone(t, newTestInterface(t, &TSIGKey{Name: testKeyName, Secret: testKeySecret, Algorithm: dns.HmacSHA256}))
+	tests.CommonTestResourceRecordSetsDifferentTypes(t, zone)
+}
+
+func TestResourceRecordSetsUpsert(t *testing.T) {
+	ctx := context.Background()
+	zone := firstZone(t, newTestInterface(t, &TSIGKey{Name: testKeyName, Secret: testKeySecret, Algorithm: dns.HmacSHA256}))
+	sets, _ := zone.ResourceRecordSets()
+
+	for _, rrdatas := range [][]string{{"10.0.0.1", "10.0.0.2"}, {"10.0.0.3"}} {
+		rrset := sets.New("api.test.com.", rrdatas, 60, rrstype.A)
+		if err := sets.StartChangeset().Upsert(rrset).Apply(ctx); err != nil {
+			t.Fatalf("error upserting %v: %v", rrdatas, err)
+		}
+		found, err := sets.Get("api.test.com")
+		if err != nil {
+			t.Fatalf("error getting records: %v", err)
+		}
+		if len(found) != 1 || strings.Join(found[0].Rrdatas(), ",") != strings.Join(rrdatas, ",") || found[0].Ttl() != 60 {
+			t.Errorf("unexpected records after upsert of %v: %v", rrdatas, found)
+		}
+	}
+
+	txt := sets.New("txt.test.com", []string{`"heritage=kops"`}, 60, rrstype.TXT)
+	if err := sets.StartChangeset().Add(txt).Apply(ctx); err != nil {
+		t.Fatalf("error adding TXT record: %v", err)
+	}
+	found, err := sets.Get("txt.test.com.")
+	if err != nil {
+		t.Fatalf("error getting records: %v", err)
+	}
+	if len(found) != 1 || !dnsprovider.ResourceRecordSetsEquivalent(found[0], txt) {
+		t.Errorf("unexpected TXT records %v", found)
+	}
+}
+
+func TestTSIG(t *testing.T) {
+	ctx := context.Background()
+
+	zone := firstZone(t, newTestInterface(t, &TSIGKey{Name: testKeyName, Secret: "d3Jvbmc=", Algorithm: dns.HmacSHA256}))
+	sets, _ := zone.ResourceRecordSets()
+	if _, err := sets.List(); err == nil {
+		t.Errorf("expected zone transfer with the wrong key to fail")
+	}
+	if err := sets.StartChangeset().Add(sets.New("a.test.com", []string{"10.0.0.1"}, 60, rrstype.A)).Apply(ctx); err == nil {
+		t.Errorf("expected update with the wrong key to fail")
+	}
+
+	zone = firstZone(t, newTestInterface(t, nil))
+	sets, _ = zone.ResourceRecordSets()
+	if err := sets.StartChangeset().Add(sets.New("a.test.com", []string{"10.0.0.1"}, 60, rrstype.A)).Apply(ctx); err != nil {
+		t.Errorf("unexpected error from unsigned update: %v", err)
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	var cfg Config
+	cfg.Global.Server = "10.0.0.53"
+	cfg.Global.Zones = []string{"Example.com", ""}
+	cfg.Global.TSIGKeyName = "kops"
+	cfg.Global.TSIGSecret = testKeySecret
+
+	iface, err := NewFromConfig(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if iface.server != "10.0.0.53:53" {
+		t.Errorf("unexpected server %q", iface.server)
+	}
+	if len(iface.zones) != 1 || iface.zones[0] != "example.com." {
+		t.Errorf("unexpected zones %v", iface.zones)
+	}
+	if iface.key == nil || iface.key.Name != "kops." || iface.key.Algorithm != dns.HmacSHA256 {
+		t.Errorf("unexpected key %v", iface.key)
+	}
+
+	cfg.Global.TSIGAlgorithm = "hmac-md5"
+	if _, err := NewFromConfig(&cfg); err == nil {
+		t.Errorf("expected unsupported algorithm to be rejected")
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rfc2136
+
+import (
+	"context"
+
+	"github.com/miekg/dns"
+
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+)
+
+var _ dnsprovider.ResourceRecordChangeset = &ResourceRecordChangeset{}
+
+type ResourceRecordChangeset struct {
+	zone   *Zone
+	rrsets *ResourceRecordSets
+
+	additions []dnsprovider.ResourceRecordSet
+	removals  []dnsprovider.ResourceRecordSet
+	upserts   []dnsprovider.ResourceRecordSet
+}
+
+func (c *ResourceRecordChangeset) Add(rrset dnsprovider.ResourceRecordSet) dnsprovider.ResourceRecordChangeset {
+	c.additions = append(c.additions, rrset)
+	return c
+}
+
+func (c *ResourceRecordChangeset) Remove(rrset dnsprovider.ResourceRecordSet) dnsprovider.ResourceRecordChangeset {
+	c.removals = append(c.removals, rrset)
+	return c
+}
+
+func (c *ResourceRecordChangeset) Upsert(rrset dnsprovider.ResourceRecordSet) dnsprovider.ResourceRecordChangeset {
+	c.upserts = append(c.upserts, rrset)
+	return c
+}
+
+// Apply sends all the changes in a single update, which the server applies atomically.
+func (c *ResourceRecordChangeset) Apply(ctx context.Context) error {
+	// Empty changesets should be a relatively quick no-op
+	if c.IsEmpty() {
+		return nil
+	}
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(c.zone.name)
+
+	// The server applies the changes in order, so we remove records before adding their replacements
+	for _, removal := range c.removals {
+		rrs, err := toRRs(removal)
+		if err != nil {
+			return err
+		}
+		msg.Remove(rrs)
+	}
+
+	for _, upsert := range c.upserts {
+		header, err := rrsetHeader(upsert)
+		if err != nil {
+			return err
+		}
+		msg.RemoveRRset([]dns.RR{header})
+	}
+
+	for _, addition := range append(c.additions, c.upserts...) {
+		rrs, err := toRRs(addition)
+		if err != nil {
+			return err
+		}
+		msg.Insert(rrs)
+	}
+
+	return c.zone.zones.iface.update(ctx, msg)
+}
+
+func (c *ResourceRecordChangeset) IsEmpty() bool {
+	return len(c.removals) == 0 && len(c.additions) == 0 && len(c.upserts) == 0
+}
+
+// ResourceRecordSets returns the parent ResourceRecordSets
+func (c *ResourceRecordChangeset) ResourceRecordSets() dnsprovider.ResourceRecordSets {
+	return c.rrsets
+}
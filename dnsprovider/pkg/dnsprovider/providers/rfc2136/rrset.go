@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rfc2136
+
+import (
+	"fmt"
+
+	"github.com/miekg/dns"
+
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider/rrstype"
+)
+
+var _ dnsprovider.ResourceRecordSet = &ResourceRecordSet{}
+
+type ResourceRecordSet struct {
+	name    string
+	rrdatas []string
+	ttl     int64
+	rrsType rrstype.RrsType
+	rrsets  *ResourceRecordSets
+}
+
+func (rrset *ResourceRecordSet) Name() string {
+	return rrset.name
+}
+
+func (rrset *ResourceRecordSet) Rrdatas() []string {
+	return rrset.rrdatas
+}
+
+func (rrset *ResourceRecordSet) Ttl() int64 {
+	return rrset.ttl
+}
+
+func (rrset *ResourceRecordSet) Type() rrstype.RrsType {
+	return rrset.rrsType
+}
+
+// toRRs parses the records of a record set, given in presentation format.
+func toRRs(rrset dnsprovider.ResourceRecordSet) ([]dns.RR, error) {
+	var rrs []dns.RR
+	for _, rrdata := range rrset.Rrdatas() {
+		s := fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(rrset.Name()), rrset.Ttl(), rrset.Type(), rrdata)
+		rr, err := dns.NewRR(s)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing DNS record %q: %v", s, err)
+		}
+		rrs = append(rrs, rr)
+	}
+	return rrs, nil
+}
+
+// rrsetHeader returns a record identifying the name and type of a record set, for deleting the whole set.
+func rrsetHeader(rrset dnsprovider.ResourceRecordSet) (dns.RR, error) {
+	rrType, ok := dns.StringToType[string(rrset.Type())]
+	if !ok {
+		return nil, fmt.Errorf("unknown DNS record type %q", rrset.Type())
+	}
+	return &dns.ANY{Hdr: dns.RR_Header{Name: dns.Fqdn(rrset.Name()), Rrtype: rrType, Class: dns.ClassINET}}, nil
+}
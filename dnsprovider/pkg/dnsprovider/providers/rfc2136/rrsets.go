@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rfc2136
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider/rrstype"
+)
+
+var _ dnsprovider.ResourceRecordSets = &ResourceRecordSets{}
+
+type ResourceRecordSets struct {
+	zone *Zone
+}
+
+// List returns the records of the zone, grouped into record sets by name and type.
+func (rrsets *ResourceRecordSets) List() ([]dnsprovider.ResourceRecordSet, error) {
+	records, err := rrsets.zone.zones.iface.transfer(rrsets.zone.name)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []dnsprovider.ResourceRecordSet
+	sets := make(map[string]*ResourceRecordSet)
+	for _, rr := range records {
+		hdr := rr.Header()
+		rrsType := rrstype.RrsType(dns.TypeToString[hdr.Rrtype])
+		key := strings.ToLower(hdr.Name) + "::" + string(rrsType)
+		rrdata := strings.TrimPrefix(rr.String(), hdr.String())
+
+		rrset := sets[key]
+		if rrset == nil {
+			rrset = &ResourceRecordSet{
+				name:    strings.TrimSuffix(hdr.Name, "."),
+				ttl:     int64(hdr.Ttl),
+				rrsType: rrsType,
+				rrsets:  rrsets,
+			}
+			sets[key] = rrset
+			list = append(list, rrset)
+		}
+		// A zone transfer ends with the SOA record it starts with
+		if !containsString(rrset.rrdatas, rrdata) {
+			rrset.rrdatas = append(rrset.rrdatas, rrdata)
+		}
+	}
+	return list, nil
+}
+
+// Get returns the record sets with the name; there is no way to query a name
+// for all types, so we list the whole zone.
+func (rrsets *ResourceRecordSets) Get(name string) ([]dnsprovider.ResourceRecordSet, error) {
+	all, err := rrsets.List()
+	if err != nil {
+		return nil, err
+	}
+	var list []dnsprovider.ResourceRecordSet
+	for _, rrset := range all {
+		if sameName(rrset.Name(), name) {
+			list = append(list, rrset)
+		}
+	}
+	return list, nil
+}
+
+func (rrsets *ResourceRecordSets) StartChangeset() dnsprovider.ResourceRecordChangeset {
+	return &ResourceRecordChangeset{
+		zone:   rrsets.zone,
+		rrsets: rrsets,
+	}
+}
+
+func (rrsets *ResourceRecordSets) New(name string, rrdatas []string, ttl int64, rrsType rrstype.RrsType) dnsprovider.ResourceRecordSet {
+	return &ResourceRecordSet{
+		name:    name,
+		rrdatas: rrdatas,
+		ttl:     ttl,
+		rrsType: rrsType,
+		rrsets:  rrsets,
+	}
+}
+
+// Zone returns the parent zone
+func (rrsets *ResourceRecordSets) Zone() dnsprovider.Zone {
+	return rrsets.zone
+}
+
+// sameName returns true if the DNS names are equal, ignoring case and a trailing dot
+func sameName(a, b string) bool {
+	return strings.EqualFold(dns.Fqdn(a), dns.Fqdn(b))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
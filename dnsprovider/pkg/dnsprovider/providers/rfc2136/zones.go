@@ -0,0 +1,52 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rfc2136
+
+import (
+	"fmt"
+
+	"github.com/miekg/dns"
+
+	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+)
+
+var _ dnsprovider.Zones = &Zones{}
+
+type Zones struct {
+	iface *Interface
+}
+
+// List returns the configured zones, as zones cannot be discovered with RFC 2136.
+func (kzs *Zones) List() ([]dnsprovider.Zone, error) {
+	var zoneList []dnsprovider.Zone
+	for _, name := range kzs.iface.zones {
+		zoneList = append(zoneList, &Zone{name: name, zones: kzs})
+	}
+	return zoneList, nil
+}
+
+func (kzs *Zones) Add(zone dnsprovider.Zone) (dnsprovider.Zone, error) {
+	return nil, fmt.Errorf("cannot create zone %q: zones must be created on the DNS server", zone.Name())
+}
+
+func (kzs *Zones) Remove(zone dnsprovider.Zone) error {
+	return fmt.Errorf("cannot remove zone %q: zones must be removed on the DNS server", zone.Name())
+}
+
+func (kzs *Zones) New(name string) (dnsprovider.Zone, error) {
+	return &Zone{name: dns.Fqdn(name), zones: kzs}, nil
+}
@@ -94,6 +94,8 @@ func (i *Installation) Run() error {
 func (i *Installation) Build(c *fi.ModelBuilderContext) {
 	c.AddTask(i.buildEnvFile())
 	c.AddTask(i.buildSystemdJob())
+	c.AddTask(i.buildRenewCertificatesJob())
+	c.AddTask(i.buildRenewCertificatesTimer())
 }
 
 func (i *Installation) buildEnvFile() *nodetasks.File {
@@ -191,3 +193,66 @@ func (i *Installation) buildSystemdJob() *nodetasks.Service {
 
 	return service
 }
+
+// buildRenewCertificatesJob builds a systemd unit which runs nodeup to renew the certificates issued by kops-controller
+func (i *Installation) buildRenewCertificatesJob() *nodetasks.Service {
+	var args []string
+	args = append(args, i.Command...)
+	args = append(args, "--renew-certificates", "--retries=10")
+	command := strings.Join(args, " ")
+
+	serviceName := "kops-renew-certificates.service"
+
+	manifest := &systemd.Manifest{}
+	manifest.Set("Unit", "Description", "Renew certificates issued by kops-controller (nodeup)")
+	manifest.Set("Unit", "Documentation", "https://github.com/kubernetes/kops")
+	manifest.Set("Unit", "After", "kops-configuration.service")
+
+	manifest.Set("Service", "EnvironmentFile", "/etc/sysconfig/kops-configuration")
+	manifest.Set("Service", "EnvironmentFile", "/etc/environment")
+	manifest.Set("Service", "ExecStart", command)
+	manifest.Set("Service", "Type", "oneshot")
+
+	manifestString := manifest.Render()
+	klog.V(8).Infof("Built service manifest %q\n%s", serviceName, manifestString)
+
+	// The service is only started by its timer
+	service := &nodetasks.Service{
+		Name:        serviceName,
+		Definition:  fi.String(manifestString),
+		Running:     fi.Bool(false),
+		ManageState: fi.Bool(false),
+	}
+
+	service.InitDefaults()
+
+	return service
+}
+
+// buildRenewCertificatesTimer builds a systemd timer which periodically checks whether the certificates need renewing
+func (i *Installation) buildRenewCertificatesTimer() *nodetasks.Service {
+	timerName := "kops-renew-certificates.timer"
+
+	manifest := &systemd.Manifest{}
+	manifest.Set("Unit", "Description", "Trigger renewal of certificates issued by kops-controller daily")
+	manifest.Set("Unit", "Documentation", "https://github.com/kubernetes/kops")
+
+	manifest.Set("Timer", "OnCalendar", "daily")
+	manifest.Set("Timer", "RandomizedDelaySec", "6h")
+	manifest.Set("Timer", "Persistent", "true")
+	manifest.Set("Timer", "Unit", "kops-renew-certificates.service")
+
+	manifest.Set("Install", "WantedBy", "multi-user.target")
+
+	manifestString := manifest.Render()
+	klog.V(8).Infof("Built timer manifest %q\n%s", timerName, manifestString)
+
+	service := &nodetasks.Service{
+		Name:       timerName,
+		Definition: fi.String(manifestString),
+	}
+
+	service.InitDefaults()
+
+	return service
+}
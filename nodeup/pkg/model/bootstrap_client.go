@@ -17,15 +17,23 @@ limitations under the License.
 package model
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/url"
 	"strconv"
 
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/kubeconfig"
 	"k8s.io/kops/pkg/wellknownports"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/do"
+	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 	"k8s.io/kops/upup/pkg/fi/nodeup/nodetasks"
 )
 
@@ -48,6 +56,14 @@ func (b BootstrapClientBuilder) Build(c *fi.ModelBuilderContext) error {
 			return fmt.Errorf("querying AWS region: %v", regionErr)
 		}
 		authenticator, err = awsup.NewAWSAuthenticator(region)
+	case kops.CloudProviderGCE:
+		authenticator, err = gce.NewGCEAuthenticator()
+	case kops.CloudProviderOpenstack:
+		authenticator, err = openstack.NewOpenstackAuthenticator()
+	case kops.CloudProviderAzure:
+		authenticator, err = azure.NewAzureAuthenticator()
+	case kops.CloudProviderDO:
+		authenticator, err = do.NewDOAuthenticator()
 	default:
 		return fmt.Errorf("unsupported cloud provider %s", b.Cluster.Spec.CloudProvider)
 	}
@@ -55,7 +71,7 @@ func (b BootstrapClientBuilder) Build(c *fi.ModelBuilderContext) error {
 		return err
 	}
 
-	cert, err := b.GetCert(fi.CertificateIDCA)
+	cert, err := b.GetCertificatePool(fi.CertificateIDCA)
 	if err != nil {
 		return err
 	}
@@ -72,6 +88,10 @@ func (b BootstrapClientBuilder) Build(c *fi.ModelBuilderContext) error {
 		BaseURL:       baseURL,
 	}
 
+	if b.KubeletClientCertificate != nil {
+		bootstrapClient.ClientCertificate = b.KubeletClientCertificate
+	}
+
 	bootstrapClientTask := &nodetasks.BootstrapClientTask{
 		Client: bootstrapClient,
 		Certs:  b.bootstrapCerts,
@@ -87,3 +107,30 @@ func (b BootstrapClientBuilder) Build(c *fi.ModelBuilderContext) error {
 }
 
 var _ fi.ModelBuilder = &BootstrapClientBuilder{}
+
+// LoadKubeconfigCertificate reads the client certificate and key from a kubeconfig file written by nodeup.
+func LoadKubeconfigCertificate(p string) (*tls.Certificate, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("error reading kubeconfig %q: %v", p, err)
+	}
+
+	config := &kubeconfig.KubectlConfig{}
+	if err := kops.ParseRawYaml(b, config); err != nil {
+		return nil, fmt.Errorf("error parsing kubeconfig %q: %v", p, err)
+	}
+	if len(config.Users) != 1 {
+		return nil, fmt.Errorf("expected one user in kubeconfig %q, found %d", p, len(config.Users))
+	}
+	user := config.Users[0].User
+
+	certificate, err := tls.X509KeyPair(user.ClientCertificateData, user.ClientKeyData)
+	if err != nil {
+		return nil, fmt.Errorf("error loading client certificate from kubeconfig %q: %v", p, err)
+	}
+	certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("error parsing client certificate from kubeconfig %q: %v", p, err)
+	}
+	return &certificate, nil
+}
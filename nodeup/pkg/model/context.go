@@ -17,6 +17,7 @@ limitations under the License.
 package model
 
 import (
+	"crypto/tls"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -71,6 +72,10 @@ type NodeupModelContext struct {
 	// ConfigurationMode determines if we are prewarming an instance or running it live
 	ConfigurationMode string
 	InstanceID        string
+
+	// KubeletClientCertificate is the current client certificate of the kubelet, if nodeup is renewing the
+	// certificates which kops-controller issued to the node. It authenticates the renewal.
+	KubeletClientCertificate *tls.Certificate
 }
 
 // Init completes initialization of the object, for example pre-parsing the kubernetes version
@@ -256,7 +261,7 @@ func (c *NodeupModelContext) BuildBootstrapKubeconfig(name string, ctx *fi.Model
 	if c.UseKopsControllerForNodeBootstrap() {
 		cert, key := c.GetBootstrapCert(name)
 
-		ca, err := c.GetCert(fi.CertificateIDCA)
+		ca, err := c.GetCertificatePool(fi.CertificateIDCA)
 		if err != nil {
 			return nil, err
 		}
@@ -281,7 +286,7 @@ func (c *NodeupModelContext) BuildBootstrapKubeconfig(name string, ctx *fi.Model
 
 		return kubeConfig.GetConfig(), nil
 	} else {
-		ca, err := c.GetCert(fi.CertificateIDCA)
+		ca, err := c.GetCertificatePool(fi.CertificateIDCA)
 		if err != nil {
 			return nil, err
 		}
@@ -461,6 +466,30 @@ func (c *NodeupModelContext) BuildCertificateTask(ctx *fi.ModelBuilderContext, n
 	return nil
 }
 
+// BuildCertificatePoolTask builds a task to create a file with all the certificates in a keyset, primary first,
+// so that certificates issued by any of them are trusted.
+func (c *NodeupModelContext) BuildCertificatePoolTask(ctx *fi.ModelBuilderContext, name, filename string, owner *string) error {
+	serialized, err := c.GetCertificatePool(name)
+	if err != nil {
+		return err
+	}
+
+	p := filename
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(c.PathSrvKubernetes(), filename)
+	}
+
+	ctx.AddTask(&nodetasks.File{
+		Path:     p,
+		Contents: fi.NewStringResource(string(serialized)),
+		Type:     nodetasks.FileType_File,
+		Mode:     s("0600"),
+		Owner:    owner,
+	})
+
+	return nil
+}
+
 // BuildPrivateKeyTask builds a task to create a private key file.
 func (c *NodeupModelContext) BuildPrivateKeyTask(ctx *fi.ModelBuilderContext, name, filename string, owner *string) error {
 	cert, err := c.KeyStore.FindPrivateKey(name)
@@ -582,6 +611,23 @@ func (c *NodeupModelContext) GetCert(name string) ([]byte, error) {
 	return cert.AsBytes()
 }
 
+// GetCertificatePool is a helper method to retrieve all the certificates in a keyset from the store, primary first
+func (c *NodeupModelContext) GetCertificatePool(name string) ([]byte, error) {
+	pool, err := c.KeyStore.FindCertificatePool(name)
+	if err != nil {
+		return []byte{}, fmt.Errorf("error fetching certificate: %v from keystore: %v", name, err)
+	}
+	if pool == nil || pool.Primary == nil {
+		return []byte{}, fmt.Errorf("unable to find certificate: %s", name)
+	}
+
+	serialized, err := pool.AsString()
+	if err != nil {
+		return []byte{}, err
+	}
+	return []byte(serialized), nil
+}
+
 // GetPrivateKey is a helper method to retrieve a private key from the store
 func (c *NodeupModelContext) GetPrivateKey(name string) ([]byte, error) {
 	key, err := c.KeyStore.FindPrivateKey(name)
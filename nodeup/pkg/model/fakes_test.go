@@ -61,7 +61,11 @@ type fakeCAStore struct {
 var _ fi.CAStore = &fakeCAStore{}
 
 func (k fakeCAStore) FindCertificatePool(name string) (*fi.CertificatePool, error) {
-	panic("fakeCAStore does not implement FindCertificatePool")
+	cert := k.certs[name]
+	if cert == nil {
+		return nil, nil
+	}
+	return &fi.CertificatePool{Primary: cert}, nil
 }
 
 func (k fakeCAStore) FindCertificateKeyset(name string) (*kops.Keyset, error) {
@@ -91,3 +95,7 @@ func (k fakeCAStore) AddCert(name string, cert *pki.Certificate) error {
 func (k fakeCAStore) DeleteKeysetItem(item *kops.Keyset, id string) error {
 	panic("fakeCAStore does not implement DeleteKeysetItem")
 }
+
+func (k fakeCAStore) UpdateKeysetRotation(name string, primaryID string, rotation *kops.KeysetRotation) error {
+	panic("fakeCAStore does not implement UpdateKeysetRotation")
+}
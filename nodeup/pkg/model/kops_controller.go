@@ -84,8 +84,11 @@ func (b *KopsControllerBuilder) Build(c *fi.ModelBuilderContext) error {
 	}
 	for _, cert := range caList {
 		owner := wellknownusers.KopsControllerName
-		err := b.BuildCertificatePairTask(c, cert, pkiDir, cert, &owner)
-		if err != nil {
+		// kops-controller signs with the primary certificate, and passes the others on to nodes to trust
+		if err := b.BuildCertificatePoolTask(c, cert, filepath.Join(pkiDir, cert+".pem"), &owner); err != nil {
+			return err
+		}
+		if err := b.BuildPrivateKeyTask(c, cert, filepath.Join(pkiDir, cert+"-key.pem"), &owner); err != nil {
 			return err
 		}
 	}
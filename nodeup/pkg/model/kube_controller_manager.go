@@ -57,6 +57,11 @@ func (b *KubeControllerManagerBuilder) Build(c *fi.ModelBuilderContext) error {
 		return err
 	}
 
+	// ca.crt may hold more than one CA during a rotation, but the signing certificate must match the key
+	if err := b.BuildCertificateTask(c, fi.CertificateIDCA, "ca-signing.crt", nil); err != nil {
+		return err
+	}
+
 	{
 		pod, err := b.buildPod()
 		if err != nil {
@@ -121,7 +126,7 @@ func (b *KubeControllerManagerBuilder) buildPod() (*v1.Pod, error) {
 
 	// Configure CA certificate to be used to sign keys
 	flags = append(flags, []string{
-		"--cluster-signing-cert-file=" + filepath.Join(b.PathSrvKubernetes(), "ca.crt"),
+		"--cluster-signing-cert-file=" + filepath.Join(b.PathSrvKubernetes(), "ca-signing.crt"),
 		"--cluster-signing-key-file=" + filepath.Join(b.PathSrvKubernetes(), "ca.key")}...)
 
 	pod := &v1.Pod{
@@ -46,21 +46,10 @@ var _ fi.ModelBuilder = &KubeProxyBuilder{}
 // Build is responsible for building the kube-proxy manifest
 // @TODO we should probably change this to a daemonset in the future and follow the kubeadm path
 func (b *KubeProxyBuilder) Build(c *fi.ModelBuilderContext) error {
-
-	if b.Cluster.Spec.KubeProxy.Enabled != nil && !*b.Cluster.Spec.KubeProxy.Enabled {
-		klog.V(2).Infof("Kube-proxy is disabled, will not create configuration for it.")
+	if !b.isEnabled() {
 		return nil
 	}
 
-	if b.IsMaster {
-		// If this is a master that is not isolated, run it as a normal node also (start kube-proxy etc)
-		// This lets e.g. daemonset pods communicate with other pods in the system
-		if fi.BoolValue(b.Cluster.Spec.IsolateMasters) {
-			klog.V(2).Infof("Running on Master with IsolateMaster=true; skipping kube-proxy installation")
-			return nil
-		}
-	}
-
 	b.WarmPullImage(c, kubeProxyImage(b.NodeupModelContext))
 
 	{
@@ -82,25 +71,11 @@ func (b *KubeProxyBuilder) Build(c *fi.ModelBuilderContext) error {
 	}
 
 	{
-		var kubeconfig fi.Resource
-		var err error
-
-		if b.HasAPIServer {
-			kubeconfig = b.BuildIssuedKubeconfig("kube-proxy", nodetasks.PKIXName{CommonName: rbac.KubeProxy}, c)
-		} else {
-			kubeconfig, err = b.BuildBootstrapKubeconfig("kube-proxy", c)
-			if err != nil {
-				return err
-			}
+		t, err := b.buildKubeconfigFile(c)
+		if err != nil {
+			return err
 		}
-
-		c.AddTask(&nodetasks.File{
-			Path:           "/var/lib/kube-proxy/kubeconfig",
-			Contents:       kubeconfig,
-			Type:           nodetasks.FileType_File,
-			Mode:           s("0400"),
-			BeforeServices: []string{kubeletService},
-		})
+		c.AddTask(t)
 	}
 
 	{
@@ -116,6 +91,61 @@ func (b *KubeProxyBuilder) Build(c *fi.ModelBuilderContext) error {
 	return nil
 }
 
+// isEnabled returns true if kube-proxy runs on the node
+func (b *KubeProxyBuilder) isEnabled() bool {
+	if b.Cluster.Spec.KubeProxy.Enabled != nil && !*b.Cluster.Spec.KubeProxy.Enabled {
+		klog.V(2).Infof("Kube-proxy is disabled, will not create configuration for it.")
+		return false
+	}
+
+	if b.IsMaster {
+		// If this is a master that is not isolated, run it as a normal node also (start kube-proxy etc)
+		// This lets e.g. daemonset pods communicate with other pods in the system
+		if fi.BoolValue(b.Cluster.Spec.IsolateMasters) {
+			klog.V(2).Infof("Running on Master with IsolateMaster=true; skipping kube-proxy installation")
+			return false
+		}
+	}
+
+	return true
+}
+
+// buildKubeconfigFile builds the task which writes the kubeconfig of kube-proxy
+func (b *KubeProxyBuilder) buildKubeconfigFile(c *fi.ModelBuilderContext) (*nodetasks.File, error) {
+	var kubeconfig fi.Resource
+	var err error
+
+	if b.HasAPIServer {
+		kubeconfig = b.BuildIssuedKubeconfig("kube-proxy", nodetasks.PKIXName{CommonName: rbac.KubeProxy}, c)
+	} else {
+		kubeconfig, err = b.BuildBootstrapKubeconfig("kube-proxy", c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &nodetasks.File{
+		Path:           "/var/lib/kube-proxy/kubeconfig",
+		Contents:       kubeconfig,
+		Type:           nodetasks.FileType_File,
+		Mode:           s("0400"),
+		BeforeServices: []string{kubeletService},
+	}, nil
+}
+
+// restartCommand returns the command which stops the running kube-proxy container, so that kubelet restarts it.
+// kube-proxy only loads its kubeconfig when it starts, and restarting kubelet does not restart a running static pod.
+func (b *KubeProxyBuilder) restartCommand() []string {
+	var stop string
+	switch b.Cluster.Spec.ContainerRuntime {
+	case "docker":
+		stop = "docker ps --quiet --filter label=io.kubernetes.container.name=kube-proxy | xargs --no-run-if-empty docker stop"
+	default:
+		stop = "crictl ps --quiet --name '^kube-proxy$' | xargs --no-run-if-empty crictl stop"
+	}
+	return []string{"sh", "-c", stop}
+}
+
 // buildPod is responsible constructing the pod spec
 func (b *KubeProxyBuilder) buildPod() (*v1.Pod, error) {
 	c := b.Cluster.Spec.KubeProxy
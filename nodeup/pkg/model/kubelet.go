@@ -115,24 +115,12 @@ func (b *KubeletBuilder) Build(c *fi.ModelBuilderContext) error {
 			Mode: s("0755"),
 		})
 
-		if b.HasAPIServer || !b.UseBootstrapTokens() {
-			var kubeconfig fi.Resource
-			if b.HasAPIServer && (b.IsKubernetesGTE("1.19") || b.UseBootstrapTokens()) {
-				kubeconfig, err = b.buildMasterKubeletKubeconfig(c)
-			} else {
-				kubeconfig, err = b.BuildBootstrapKubeconfig("kubelet", c)
-			}
-			if err != nil {
-				return err
-			}
-
-			c.AddTask(&nodetasks.File{
-				Path:           b.KubeletKubeConfig(),
-				Contents:       kubeconfig,
-				Type:           nodetasks.FileType_File,
-				Mode:           s("0400"),
-				BeforeServices: []string{kubeletService},
-			})
+		t, err := b.buildKubeconfigFile(c)
+		if err != nil {
+			return err
+		}
+		if t != nil {
+			c.AddTask(t)
 		}
 	}
 
@@ -164,6 +152,33 @@ func (b *KubeletBuilder) kubeletPath() string {
 	return kubeletCommand
 }
 
+// buildKubeconfigFile builds the task which writes the kubeconfig of the kubelet,
+// or returns nil if the kubelet bootstraps its own kubeconfig
+func (b *KubeletBuilder) buildKubeconfigFile(c *fi.ModelBuilderContext) (*nodetasks.File, error) {
+	if !b.HasAPIServer && b.UseBootstrapTokens() {
+		return nil, nil
+	}
+
+	var kubeconfig fi.Resource
+	var err error
+	if b.HasAPIServer && (b.IsKubernetesGTE("1.19") || b.UseBootstrapTokens()) {
+		kubeconfig, err = b.buildMasterKubeletKubeconfig(c)
+	} else {
+		kubeconfig, err = b.BuildBootstrapKubeconfig("kubelet", c)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodetasks.File{
+		Path:           b.KubeletKubeConfig(),
+		Contents:       kubeconfig,
+		Type:           nodetasks.FileType_File,
+		Mode:           s("0400"),
+		BeforeServices: []string{kubeletService},
+	}, nil
+}
+
 // buildManifestDirectory creates the directory where kubelet expects static manifests to reside
 func (b *KubeletBuilder) buildManifestDirectory(kubeletConfig *kops.KubeletConfigSpec) (*nodetasks.File, error) {
 	directory := &nodetasks.File{
@@ -217,6 +217,9 @@ type ProtokubeFlags struct {
 	GossipProtocolSecondary *string `json:"gossip-protocol-secondary" flag:"gossip-protocol-secondary" flag-include-empty:"true"`
 	GossipListenSecondary   *string `json:"gossip-listen-secondary" flag:"gossip-listen-secondary"`
 	GossipSecretSecondary   *string `json:"gossip-secret-secondary" flag:"gossip-secret-secondary"`
+
+	GossipDNSListen    *string `json:"gossip-dns-listen,omitempty" flag:"gossip-dns-listen"`
+	GossipDNSHostsFile *bool   `json:"gossip-dns-hosts-file,omitempty" flag:"gossip-dns-hosts-file"`
 }
 
 // ProtokubeFlags is responsible for building the command line flags for protokube
@@ -329,6 +332,11 @@ func (t *ProtokubeBuilder) ProtokubeFlags(k8sVersion semver.Version) (*Protokube
 				f.GossipListenSecondary = t.Cluster.Spec.GossipConfig.Secondary.Listen
 				f.GossipSecretSecondary = t.Cluster.Spec.GossipConfig.Secondary.Secret
 			}
+
+			if t.Cluster.Spec.GossipConfig.DNS != nil {
+				f.GossipDNSListen = t.Cluster.Spec.GossipConfig.DNS.Listen
+				f.GossipDNSHostsFile = t.Cluster.Spec.GossipConfig.DNS.HostsFile
+			}
 		}
 
 		// @TODO: This is hacky, but we want it so that we can have a different internal & external name
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// CertificateRenewalBuilder renews the certificates which kops-controller issued to the node.
+// It only writes the files containing those certificates, so that a renewal does not apply
+// any other change to the configuration of the node; those are applied by a rolling update.
+type CertificateRenewalBuilder struct {
+	*NodeupModelContext
+}
+
+var _ fi.ModelBuilder = &CertificateRenewalBuilder{}
+
+func (b *CertificateRenewalBuilder) Build(c *fi.ModelBuilderContext) error {
+	if b.KubeletClientCertificate == nil {
+		return fmt.Errorf("the kubelet client certificate is required to renew certificates")
+	}
+
+	kubelet := &KubeletBuilder{NodeupModelContext: b.NodeupModelContext}
+	if err := kubelet.buildKubeletServingCertificate(c); err != nil {
+		return fmt.Errorf("error building kubelet server cert: %v", err)
+	}
+	t, err := kubelet.buildKubeconfigFile(c)
+	if err != nil {
+		return err
+	}
+	if t != nil {
+		c.AddTask(t)
+	}
+
+	kubeProxy := &KubeProxyBuilder{NodeupModelContext: b.NodeupModelContext}
+	if kubeProxy.isEnabled() {
+		t, err := kubeProxy.buildKubeconfigFile(c)
+		if err != nil {
+			return err
+		}
+		t.OnChangeExecute = [][]string{kubeProxy.restartCommand()}
+		c.AddTask(t)
+	}
+
+	bootstrapClient := &BootstrapClientBuilder{NodeupModelContext: b.NodeupModelContext}
+	return bootstrapClient.Build(c)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"crypto/tls"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/nodeup/nodetasks"
+)
+
+func TestCertificateRenewalBuilder(t *testing.T) {
+	basedir := "tests/renewcertificates"
+
+	nodeupModelContext, err := BuildNodeupModelContext(basedir)
+	if err != nil {
+		t.Fatalf("error loading model %q: %v", basedir, err)
+	}
+	nodeupModelContext.KeyStore = &fakeCAStore{
+		certs: map[string]*pki.Certificate{
+			"ca": mustParseCertificate(dummyCertificate),
+		},
+	}
+	nodeupModelContext.KubeletClientCertificate = &tls.Certificate{}
+
+	context := &fi.ModelBuilderContext{
+		Tasks: make(map[string]fi.Task),
+	}
+	builder := CertificateRenewalBuilder{NodeupModelContext: nodeupModelContext}
+	if err := builder.Build(context); err != nil {
+		t.Fatalf("error from Build: %v", err)
+	}
+
+	// Only the certificates issued by kops-controller are written, and nothing else on the node is changed
+	var keys []string
+	for key := range context.Tasks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	expected := []string{
+		"BootstrapClientTask/BootstrapClient",
+		"File//srv/kubernetes/kubelet-server.crt",
+		"File//srv/kubernetes/kubelet-server.key",
+		"File//var/lib/kube-proxy/kubeconfig",
+		"File//var/lib/kubelet/kubeconfig",
+		"KubeConfig/kube-proxy",
+		"KubeConfig/kubelet",
+	}
+	if diff := cmp.Diff(expected, keys); diff != "" {
+		t.Errorf("unexpected tasks; diff=%s", diff)
+	}
+
+	// kube-proxy only loads its kubeconfig when it starts
+	kubeProxyKubeconfig := context.Tasks["File//var/lib/kube-proxy/kubeconfig"].(*nodetasks.File)
+	expectedOnChange := [][]string{{"sh", "-c", "docker ps --quiet --filter label=io.kubernetes.container.name=kube-proxy | xargs --no-run-if-empty docker stop"}}
+	if diff := cmp.Diff(expectedOnChange, kubeProxyKubeconfig.OnChangeExecute); diff != "" {
+		t.Errorf("unexpected commands run when the kube-proxy kubeconfig changes; diff=%s", diff)
+	}
+
+	var certs []string
+	for name := range nodeupModelContext.bootstrapCerts {
+		certs = append(certs, name)
+	}
+	sort.Strings(certs)
+	if diff := cmp.Diff([]string{"kube-proxy", "kubelet", "kubelet-server"}, certs); diff != "" {
+		t.Errorf("unexpected certificates requested from kops-controller; diff=%s", diff)
+	}
+}
@@ -49,8 +49,8 @@ func (b *SecretBuilder) Build(c *fi.ModelBuilderContext) error {
 		return fmt.Errorf("KeyStore not set")
 	}
 
-	// @step: retrieve the platform ca
-	if err := b.BuildCertificateTask(c, fi.CertificateIDCA, "ca.crt", nil); err != nil {
+	// @step: retrieve the platform ca, along with any CA being rotated in or out
+	if err := b.BuildCertificatePoolTask(c, fi.CertificateIDCA, "ca.crt", nil); err != nil {
 		return err
 	}
 
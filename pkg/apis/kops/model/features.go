@@ -22,7 +22,15 @@ import (
 
 // UseKopsControllerForNodeBootstrap is true if nodeup should use kops-controller for bootstrapping.
 func UseKopsControllerForNodeBootstrap(cluster *kops.Cluster) bool {
-	return kops.CloudProviderID(cluster.Spec.CloudProvider) == kops.CloudProviderAWS && cluster.IsKubernetesGTE("1.19")
+	switch kops.CloudProviderID(cluster.Spec.CloudProvider) {
+	case kops.CloudProviderAWS:
+		return cluster.IsKubernetesGTE("1.19")
+	case kops.CloudProviderGCE, kops.CloudProviderOpenstack, kops.CloudProviderAzure, kops.CloudProviderDO:
+		// Existing clusters on these clouds switch to kops-controller when they are upgraded to Kubernetes 1.22
+		return cluster.IsKubernetesGTE("1.22")
+	default:
+		return false
+	}
 }
 
 // UseCiliumEtcd is true if we are using the Cilium etcd cluster.
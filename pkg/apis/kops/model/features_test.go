@@ -81,3 +81,30 @@ func TestUseCiliumEtcd(t *testing.T) {
 		}
 	}
 }
+
+func TestUseKopsControllerForNodeBootstrap(t *testing.T) {
+	for _, tc := range []struct {
+		cloudProvider     kops.CloudProviderID
+		kubernetesVersion string
+		expected          bool
+	}{
+		{cloudProvider: kops.CloudProviderAWS, kubernetesVersion: "1.18.0", expected: false},
+		{cloudProvider: kops.CloudProviderAWS, kubernetesVersion: "1.19.0", expected: true},
+		{cloudProvider: kops.CloudProviderGCE, kubernetesVersion: "1.21.0", expected: false},
+		{cloudProvider: kops.CloudProviderGCE, kubernetesVersion: "1.22.0", expected: true},
+		{cloudProvider: kops.CloudProviderOpenstack, kubernetesVersion: "1.22.0", expected: true},
+		{cloudProvider: kops.CloudProviderAzure, kubernetesVersion: "1.22.0", expected: true},
+		{cloudProvider: kops.CloudProviderDO, kubernetesVersion: "1.22.0", expected: true},
+		{cloudProvider: kops.CloudProviderALI, kubernetesVersion: "1.22.0", expected: false},
+	} {
+		cluster := &kops.Cluster{
+			Spec: kops.ClusterSpec{
+				CloudProvider:     string(tc.cloudProvider),
+				KubernetesVersion: tc.kubernetesVersion,
+			},
+		}
+		if actual := UseKopsControllerForNodeBootstrap(cluster); actual != tc.expected {
+			t.Errorf("%s %s: expected %v, but got %v", tc.cloudProvider, tc.kubernetesVersion, tc.expected, actual)
+		}
+	}
+}
@@ -31,6 +31,8 @@ const (
 	PathClusterCompleted = "cluster.spec"
 	// PathKopsVersionUpdated is the path for the version of kops last used to apply the cluster.
 	PathKopsVersionUpdated = "kops-version.txt"
+	// PathRollingUpdateProgress is the path for the checkpoint of the last rolling update.
+	PathRollingUpdateProgress = "rolling-update/progress.yaml"
 )
 
 func ConfigBase(c *api.Cluster) (vfs.Path, error) {
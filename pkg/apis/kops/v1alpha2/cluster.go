@@ -87,6 +87,10 @@ type ClusterSpec struct {
 	SecretStore string `json:"secretStore,omitempty"`
 	// KeyStore is the VFS path to where SSL keys and certificates are stored
 	KeyStore string `json:"keyStore,omitempty"`
+	// PKI configures the private keys which kOps generates for certificate authorities and certificates
+	PKI *PKISpec `json:"pki,omitempty"`
+	// StateStoreEncryption configures envelope encryption of the secrets and private keys in the SecretStore and KeyStore
+	StateStoreEncryption *StateStoreEncryptionSpec `json:"stateStoreEncryption,omitempty"`
 	// ConfigStore is the VFS path to where the configuration (Cluster, InstanceGroups etc) is stored
 	ConfigStore string `json:"configStore,omitempty"`
 	// DNSZone is the DNS zone we should use when configuring DNS
@@ -200,6 +204,10 @@ type ClusterSpec struct {
 	SysctlParameters []string `json:"sysctlParameters,omitempty"`
 	// RollingUpdate defines the default rolling-update settings for instance groups
 	RollingUpdate *RollingUpdate `json:"rollingUpdate,omitempty"`
+	// RollingUpdateMaxConcurrentGroups is the maximum number of instance groups with role "Node"
+	// that are updated at the same time by a rolling update.
+	// Defaults to 1.
+	RollingUpdateMaxConcurrentGroups *int32 `json:"rollingUpdateMaxConcurrentGroups,omitempty"`
 	// ClusterAutoscaler defines the cluaster autoscaler configuration.
 	ClusterAutoscaler *ClusterAutoscalerConfig `json:"clusterAutoscaler,omitempty"`
 	// WarmPool defines the default warm pool settings for instance groups (AWS only).
@@ -217,6 +225,29 @@ type ServiceAccountIssuerDiscoveryConfig struct {
 	EnableAWSOIDCProvider bool `json:"enableAWSOIDCProvider,omitempty"`
 }
 
+// PKISpec configures the private keys which kOps generates.
+// Existing private keys are not replaced when the configuration changes.
+type PKISpec struct {
+	// KeyAlgorithm is the algorithm of generated private keys: RSA, ECDSA-P256, ECDSA-P384 or Ed25519.
+	// The default is RSA.
+	KeyAlgorithm string `json:"keyAlgorithm,omitempty"`
+	// KeysetKeyAlgorithms overrides KeyAlgorithm for the named keysets, for example "kubernetes-ca".
+	KeysetKeyAlgorithms map[string]string `json:"keysetKeyAlgorithms,omitempty"`
+}
+
+// StateStoreEncryptionSpec configures envelope encryption of the key material in the state store
+type StateStoreEncryptionSpec struct {
+	// KeyEncryptionKey is the URI of the key used to encrypt the data encryption keys, one of
+	// file:///path/to/key, pgp:///path/to/key, awskms://<key ARN>, gcpkms://projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>
+	// or vault://<host>[:<port>]/<transit mount>/<key>.
+	// Anything which reads secrets or private keys from the state store, including nodeup, must have access to it.
+	KeyEncryptionKey string `json:"keyEncryptionKey,omitempty"`
+	// PreviousKeyEncryptionKeys are the URIs of key encryption keys which were previously configured.
+	// Encrypted files are only read with the configured keys, and files encrypted with a previous key
+	// are encrypted with KeyEncryptionKey by the next update, after which the previous key can be removed.
+	PreviousKeyEncryptionKeys []string `json:"previousKeyEncryptionKeys,omitempty"`
+}
+
 // NodeAuthorizationSpec is used to node authorization
 type NodeAuthorizationSpec struct {
 	// NodeAuthorizer defined the configuration for the node authorizer
@@ -654,6 +685,18 @@ type GossipConfig struct {
 	Listen    *string                `json:"listen,omitempty"`
 	Secret    *string                `json:"secret,omitempty"`
 	Secondary *GossipConfigSecondary `json:"secondary,omitempty"`
+	// DNS configures how the gossip DNS records are made available on each node
+	DNS *GossipConfigDNS `json:"dns,omitempty"`
+}
+
+// GossipConfigDNS configures how protokube makes the gossip DNS records available
+type GossipConfigDNS struct {
+	// Listen is a link-local IPv4 address on which protokube serves the gossip DNS records, for example 169.254.20.11.
+	// If set, CoreDNS forwards queries for names in the cluster domain to it.
+	Listen *string `json:"listen,omitempty"`
+	// HostsFile is whether protokube also writes the gossip DNS records to /etc/hosts, which is used by processes on the node.
+	// Defaults to true.
+	HostsFile *bool `json:"hostsFile,omitempty"`
 }
 
 type GossipConfigSecondary struct {
@@ -706,6 +749,123 @@ type RollingUpdate struct {
 	// nodes.
 	// +optional
 	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// Hooks are checks run before each instance is drained and after the cluster validates
+	// following the instance's replacement.
+	// Hooks set on an InstanceGroup replace those set on the Cluster.
+	// +optional
+	Hooks []RollingUpdateHook `json:"hooks,omitempty"`
+	// Canary is the number of instances to replace before the rest of the InstanceGroup.
+	// The value can be an absolute number (for example 1) or a percentage of
+	// desired nodes (for example 10%).
+	// The absolute number is calculated from a percentage by rounding up.
+	// If the cluster fails validation or a PostCanary hook fails while the canary
+	// instances bake, the rolling update stops before updating any other instance.
+	// Only applies to instance groups with role "Node".
+	// Defaults to 0, which disables the canary phase.
+	// +optional
+	Canary *intstr.IntOrString `json:"canary,omitempty"`
+	// CanaryBakeTime is the duration the cluster must keep validating after the canary
+	// instances have been replaced.
+	// Defaults to 0, in which case the cluster is validated once.
+	// +optional
+	CanaryBakeTime *metav1.Duration `json:"canaryBakeTime,omitempty"`
+}
+
+// RollingUpdateHookPhase is the point in the update of an instance at which a hook runs.
+type RollingUpdateHookPhase string
+
+const (
+	// RollingUpdateHookPhasePreDrain runs the hook before the instance is drained.
+	RollingUpdateHookPhasePreDrain RollingUpdateHookPhase = "PreDrain"
+	// RollingUpdateHookPhasePostValidate runs the hook after the instance has been replaced
+	// and the cluster has validated.
+	RollingUpdateHookPhasePostValidate RollingUpdateHookPhase = "PostValidate"
+	// RollingUpdateHookPhasePostCanary runs the hook for each canary instance once the canary
+	// instances have baked.
+	RollingUpdateHookPhasePostCanary RollingUpdateHookPhase = "PostCanary"
+)
+
+// RollingUpdateHookFailurePolicy is what happens when a rolling update hook fails.
+type RollingUpdateHookFailurePolicy string
+
+const (
+	// RollingUpdateHookFailurePolicyFail stops the rolling update.
+	RollingUpdateHookFailurePolicyFail RollingUpdateHookFailurePolicy = "Fail"
+	// RollingUpdateHookFailurePolicyIgnore logs the failure and continues the rolling update.
+	RollingUpdateHookFailurePolicyIgnore RollingUpdateHookFailurePolicy = "Ignore"
+)
+
+// RollingUpdateHook is a check run during the rolling update of each instance.
+// Exactly one of Exec, HTTP, Job and PodDisruptionBudgets must be set.
+type RollingUpdateHook struct {
+	// Name identifies the hook.
+	Name string `json:"name"`
+	// Phase is when the hook runs, either PreDrain, PostValidate or PostCanary.
+	Phase RollingUpdateHookPhase `json:"phase"`
+	// Timeout is the maximum time the hook may take. Defaults to 5 minutes.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// FailurePolicy is either Fail, which stops the rolling update if the hook fails, or Ignore.
+	// Defaults to Fail.
+	// +optional
+	FailurePolicy RollingUpdateHookFailurePolicy `json:"failurePolicy,omitempty"`
+	// Exec runs a command on the machine running the rolling update.
+	// +optional
+	Exec *RollingUpdateExecHook `json:"exec,omitempty"`
+	// HTTP sends a request to an endpoint.
+	// +optional
+	HTTP *RollingUpdateHTTPHook `json:"http,omitempty"`
+	// Job runs a Kubernetes Job in the cluster.
+	// +optional
+	Job *RollingUpdateJobHook `json:"job,omitempty"`
+	// PodDisruptionBudgets waits until no PodDisruptionBudget has pods missing.
+	// +optional
+	PodDisruptionBudgets *RollingUpdatePodDisruptionBudgetsHook `json:"podDisruptionBudgets,omitempty"`
+}
+
+// RollingUpdateExecHook runs a command; the hook succeeds if the command exits with status 0.
+// The instance is described to the command through the KOPS_CLUSTER_NAME, KOPS_INSTANCE_GROUP,
+// KOPS_INSTANCE_ID, KOPS_NODE_NAME and KOPS_HOOK_PHASE environment variables.
+type RollingUpdateExecHook struct {
+	// Command is the command and its arguments.
+	Command []string `json:"command"`
+}
+
+// RollingUpdateHTTPHook sends a request describing the instance as JSON;
+// the hook succeeds if the endpoint responds with a 2xx status.
+type RollingUpdateHTTPHook struct {
+	// URL is the endpoint to send the request to.
+	URL string `json:"url"`
+	// Method is the HTTP method. Defaults to POST.
+	// +optional
+	Method string `json:"method,omitempty"`
+}
+
+// RollingUpdateJobHook runs a single-container Job; the hook succeeds if the Job completes.
+// The instance is described to the container through the same environment variables as for exec hooks.
+type RollingUpdateJobHook struct {
+	// Namespace is the namespace to run the Job in. Defaults to kube-system.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Image is the container image to run.
+	Image string `json:"image"`
+	// Command is the command to run in the container.
+	// +optional
+	Command []string `json:"command,omitempty"`
+	// ServiceAccountName is the service account to run the Job as.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// RollingUpdatePodDisruptionBudgetsHook waits until every matching PodDisruptionBudget has
+// at least as many healthy pods as it requires.
+type RollingUpdatePodDisruptionBudgetsHook struct {
+	// Namespace restricts the PodDisruptionBudgets checked to a namespace. Defaults to all namespaces.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Selector is a label selector restricting the PodDisruptionBudgets checked.
+	// +optional
+	Selector string `json:"selector,omitempty"`
 }
 
 type PackagesConfig struct {
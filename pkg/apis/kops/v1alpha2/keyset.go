@@ -70,4 +70,34 @@ type KeysetSpec struct {
 
 	// Keys is the set of keys that make up the keyset
 	Keys []KeysetItem `json:"keys,omitempty"`
+
+	// PrimaryId is the id of the key used for signing. If not set, the key with the highest id is the primary.
+	PrimaryId string `json:"primaryId,omitempty"`
+
+	// Rotation records the progress of a staged rotation of the keyset, if one is in progress
+	Rotation *KeysetRotation `json:"rotation,omitempty"`
+}
+
+// KeysetRotationStage is a stage of a staged rotation of a Keyset
+type KeysetRotationStage string
+
+const (
+	// KeysetRotationStageAdded means the new key has been added to the keyset, but is not yet primary
+	KeysetRotationStageAdded KeysetRotationStage = "Added"
+	// KeysetRotationStageTrustDistributed means the cluster trusts both the previous and the new key
+	KeysetRotationStageTrustDistributed KeysetRotationStage = "TrustDistributed"
+	// KeysetRotationStagePromoted means the new key is primary and certificates in the keystore have been reissued
+	KeysetRotationStagePromoted KeysetRotationStage = "Promoted"
+	// KeysetRotationStageRolled means the cluster has been rolled, so that nothing uses the previous key
+	KeysetRotationStageRolled KeysetRotationStage = "Rolled"
+)
+
+// KeysetRotation is the state of a staged rotation of a Keyset
+type KeysetRotation struct {
+	// Stage is the last stage of the rotation which was completed
+	Stage KeysetRotationStage `json:"stage,omitempty"`
+	// PreviousId is the id of the key being replaced
+	PreviousId string `json:"previousId,omitempty"`
+	// NewId is the id of the key replacing it
+	NewId string `json:"newId,omitempty"`
 }
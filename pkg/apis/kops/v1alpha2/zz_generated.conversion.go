@@ -483,6 +483,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*GossipConfigDNS)(nil), (*kops.GossipConfigDNS)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_GossipConfigDNS_To_kops_GossipConfigDNS(a.(*GossipConfigDNS), b.(*kops.GossipConfigDNS), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.GossipConfigDNS)(nil), (*GossipConfigDNS)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_GossipConfigDNS_To_v1alpha2_GossipConfigDNS(a.(*kops.GossipConfigDNS), b.(*GossipConfigDNS), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*GossipConfigSecondary)(nil), (*kops.GossipConfigSecondary)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_GossipConfigSecondary_To_kops_GossipConfigSecondary(a.(*GossipConfigSecondary), b.(*kops.GossipConfigSecondary), scope)
 	}); err != nil {
@@ -613,6 +623,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*KeysetRotation)(nil), (*kops.KeysetRotation)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_KeysetRotation_To_kops_KeysetRotation(a.(*KeysetRotation), b.(*kops.KeysetRotation), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.KeysetRotation)(nil), (*KeysetRotation)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_KeysetRotation_To_v1alpha2_KeysetRotation(a.(*kops.KeysetRotation), b.(*KeysetRotation), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*KeysetSpec)(nil), (*kops.KeysetSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_KeysetSpec_To_kops_KeysetSpec(a.(*KeysetSpec), b.(*kops.KeysetSpec), scope)
 	}); err != nil {
@@ -913,6 +933,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PKISpec)(nil), (*kops.PKISpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_PKISpec_To_kops_PKISpec(a.(*PKISpec), b.(*kops.PKISpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.PKISpec)(nil), (*PKISpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_PKISpec_To_v1alpha2_PKISpec(a.(*kops.PKISpec), b.(*PKISpec), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*PackagesConfig)(nil), (*kops.PackagesConfig)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_PackagesConfig_To_kops_PackagesConfig(a.(*PackagesConfig), b.(*kops.PackagesConfig), scope)
 	}); err != nil {
@@ -943,6 +973,56 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdateExecHook)(nil), (*kops.RollingUpdateExecHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(a.(*RollingUpdateExecHook), b.(*kops.RollingUpdateExecHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.RollingUpdateExecHook)(nil), (*RollingUpdateExecHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook(a.(*kops.RollingUpdateExecHook), b.(*RollingUpdateExecHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdateHTTPHook)(nil), (*kops.RollingUpdateHTTPHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_RollingUpdateHTTPHook_To_kops_RollingUpdateHTTPHook(a.(*RollingUpdateHTTPHook), b.(*kops.RollingUpdateHTTPHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.RollingUpdateHTTPHook)(nil), (*RollingUpdateHTTPHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_RollingUpdateHTTPHook_To_v1alpha2_RollingUpdateHTTPHook(a.(*kops.RollingUpdateHTTPHook), b.(*RollingUpdateHTTPHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdateHook)(nil), (*kops.RollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(a.(*RollingUpdateHook), b.(*kops.RollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.RollingUpdateHook)(nil), (*RollingUpdateHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(a.(*kops.RollingUpdateHook), b.(*RollingUpdateHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdateJobHook)(nil), (*kops.RollingUpdateJobHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_RollingUpdateJobHook_To_kops_RollingUpdateJobHook(a.(*RollingUpdateJobHook), b.(*kops.RollingUpdateJobHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.RollingUpdateJobHook)(nil), (*RollingUpdateJobHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_RollingUpdateJobHook_To_v1alpha2_RollingUpdateJobHook(a.(*kops.RollingUpdateJobHook), b.(*RollingUpdateJobHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdatePodDisruptionBudgetsHook)(nil), (*kops.RollingUpdatePodDisruptionBudgetsHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_RollingUpdatePodDisruptionBudgetsHook_To_kops_RollingUpdatePodDisruptionBudgetsHook(a.(*RollingUpdatePodDisruptionBudgetsHook), b.(*kops.RollingUpdatePodDisruptionBudgetsHook), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.RollingUpdatePodDisruptionBudgetsHook)(nil), (*RollingUpdatePodDisruptionBudgetsHook)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_RollingUpdatePodDisruptionBudgetsHook_To_v1alpha2_RollingUpdatePodDisruptionBudgetsHook(a.(*kops.RollingUpdatePodDisruptionBudgetsHook), b.(*RollingUpdatePodDisruptionBudgetsHook), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*RomanaNetworkingSpec)(nil), (*kops.RomanaNetworkingSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_RomanaNetworkingSpec_To_kops_RomanaNetworkingSpec(a.(*RomanaNetworkingSpec), b.(*kops.RomanaNetworkingSpec), scope)
 	}); err != nil {
@@ -993,6 +1073,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*StateStoreEncryptionSpec)(nil), (*kops.StateStoreEncryptionSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec(a.(*StateStoreEncryptionSpec), b.(*kops.StateStoreEncryptionSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*kops.StateStoreEncryptionSpec)(nil), (*StateStoreEncryptionSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec(a.(*kops.StateStoreEncryptionSpec), b.(*StateStoreEncryptionSpec), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*TargetSpec)(nil), (*kops.TargetSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha2_TargetSpec_To_kops_TargetSpec(a.(*TargetSpec), b.(*kops.TargetSpec), scope)
 	}); err != nil {
@@ -2202,6 +2292,24 @@ func autoConvert_v1alpha2_ClusterSpec_To_kops_ClusterSpec(in *ClusterSpec, out *
 	}
 	out.SecretStore = in.SecretStore
 	out.KeyStore = in.KeyStore
+	if in.PKI != nil {
+		in, out := &in.PKI, &out.PKI
+		*out = new(kops.PKISpec)
+		if err := Convert_v1alpha2_PKISpec_To_kops_PKISpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PKI = nil
+	}
+	if in.StateStoreEncryption != nil {
+		in, out := &in.StateStoreEncryption, &out.StateStoreEncryption
+		*out = new(kops.StateStoreEncryptionSpec)
+		if err := Convert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.StateStoreEncryption = nil
+	}
 	out.ConfigStore = in.ConfigStore
 	out.DNSZone = in.DNSZone
 	if in.DNSControllerGossipConfig != nil {
@@ -2507,6 +2615,7 @@ func autoConvert_v1alpha2_ClusterSpec_To_kops_ClusterSpec(in *ClusterSpec, out *
 	} else {
 		out.RollingUpdate = nil
 	}
+	out.RollingUpdateMaxConcurrentGroups = in.RollingUpdateMaxConcurrentGroups
 	if in.ClusterAutoscaler != nil {
 		in, out := &in.ClusterAutoscaler, &out.ClusterAutoscaler
 		*out = new(kops.ClusterAutoscalerConfig)
@@ -2596,6 +2705,24 @@ func autoConvert_kops_ClusterSpec_To_v1alpha2_ClusterSpec(in *kops.ClusterSpec,
 	}
 	out.SecretStore = in.SecretStore
 	out.KeyStore = in.KeyStore
+	if in.PKI != nil {
+		in, out := &in.PKI, &out.PKI
+		*out = new(PKISpec)
+		if err := Convert_kops_PKISpec_To_v1alpha2_PKISpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PKI = nil
+	}
+	if in.StateStoreEncryption != nil {
+		in, out := &in.StateStoreEncryption, &out.StateStoreEncryption
+		*out = new(StateStoreEncryptionSpec)
+		if err := Convert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.StateStoreEncryption = nil
+	}
 	out.ConfigStore = in.ConfigStore
 	out.DNSZone = in.DNSZone
 	if in.DNSControllerGossipConfig != nil {
@@ -2901,6 +3028,7 @@ func autoConvert_kops_ClusterSpec_To_v1alpha2_ClusterSpec(in *kops.ClusterSpec,
 	} else {
 		out.RollingUpdate = nil
 	}
+	out.RollingUpdateMaxConcurrentGroups = in.RollingUpdateMaxConcurrentGroups
 	if in.ClusterAutoscaler != nil {
 		in, out := &in.ClusterAutoscaler, &out.ClusterAutoscaler
 		*out = new(ClusterAutoscalerConfig)
@@ -3635,6 +3763,15 @@ func autoConvert_v1alpha2_GossipConfig_To_kops_GossipConfig(in *GossipConfig, ou
 	} else {
 		out.Secondary = nil
 	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(kops.GossipConfigDNS)
+		if err := Convert_v1alpha2_GossipConfigDNS_To_kops_GossipConfigDNS(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.DNS = nil
+	}
 	return nil
 }
 
@@ -3656,6 +3793,15 @@ func autoConvert_kops_GossipConfig_To_v1alpha2_GossipConfig(in *kops.GossipConfi
 	} else {
 		out.Secondary = nil
 	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(GossipConfigDNS)
+		if err := Convert_kops_GossipConfigDNS_To_v1alpha2_GossipConfigDNS(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.DNS = nil
+	}
 	return nil
 }
 
@@ -3664,6 +3810,28 @@ func Convert_kops_GossipConfig_To_v1alpha2_GossipConfig(in *kops.GossipConfig, o
 	return autoConvert_kops_GossipConfig_To_v1alpha2_GossipConfig(in, out, s)
 }
 
+func autoConvert_v1alpha2_GossipConfigDNS_To_kops_GossipConfigDNS(in *GossipConfigDNS, out *kops.GossipConfigDNS, s conversion.Scope) error {
+	out.Listen = in.Listen
+	out.HostsFile = in.HostsFile
+	return nil
+}
+
+// Convert_v1alpha2_GossipConfigDNS_To_kops_GossipConfigDNS is an autogenerated conversion function.
+func Convert_v1alpha2_GossipConfigDNS_To_kops_GossipConfigDNS(in *GossipConfigDNS, out *kops.GossipConfigDNS, s conversion.Scope) error {
+	return autoConvert_v1alpha2_GossipConfigDNS_To_kops_GossipConfigDNS(in, out, s)
+}
+
+func autoConvert_kops_GossipConfigDNS_To_v1alpha2_GossipConfigDNS(in *kops.GossipConfigDNS, out *GossipConfigDNS, s conversion.Scope) error {
+	out.Listen = in.Listen
+	out.HostsFile = in.HostsFile
+	return nil
+}
+
+// Convert_kops_GossipConfigDNS_To_v1alpha2_GossipConfigDNS is an autogenerated conversion function.
+func Convert_kops_GossipConfigDNS_To_v1alpha2_GossipConfigDNS(in *kops.GossipConfigDNS, out *GossipConfigDNS, s conversion.Scope) error {
+	return autoConvert_kops_GossipConfigDNS_To_v1alpha2_GossipConfigDNS(in, out, s)
+}
+
 func autoConvert_v1alpha2_GossipConfigSecondary_To_kops_GossipConfigSecondary(in *GossipConfigSecondary, out *kops.GossipConfigSecondary, s conversion.Scope) error {
 	out.Protocol = in.Protocol
 	out.Listen = in.Listen
@@ -4356,6 +4524,30 @@ func Convert_kops_KeysetList_To_v1alpha2_KeysetList(in *kops.KeysetList, out *Ke
 	return autoConvert_kops_KeysetList_To_v1alpha2_KeysetList(in, out, s)
 }
 
+func autoConvert_v1alpha2_KeysetRotation_To_kops_KeysetRotation(in *KeysetRotation, out *kops.KeysetRotation, s conversion.Scope) error {
+	out.Stage = kops.KeysetRotationStage(in.Stage)
+	out.PreviousId = in.PreviousId
+	out.NewId = in.NewId
+	return nil
+}
+
+// Convert_v1alpha2_KeysetRotation_To_kops_KeysetRotation is an autogenerated conversion function.
+func Convert_v1alpha2_KeysetRotation_To_kops_KeysetRotation(in *KeysetRotation, out *kops.KeysetRotation, s conversion.Scope) error {
+	return autoConvert_v1alpha2_KeysetRotation_To_kops_KeysetRotation(in, out, s)
+}
+
+func autoConvert_kops_KeysetRotation_To_v1alpha2_KeysetRotation(in *kops.KeysetRotation, out *KeysetRotation, s conversion.Scope) error {
+	out.Stage = KeysetRotationStage(in.Stage)
+	out.PreviousId = in.PreviousId
+	out.NewId = in.NewId
+	return nil
+}
+
+// Convert_kops_KeysetRotation_To_v1alpha2_KeysetRotation is an autogenerated conversion function.
+func Convert_kops_KeysetRotation_To_v1alpha2_KeysetRotation(in *kops.KeysetRotation, out *KeysetRotation, s conversion.Scope) error {
+	return autoConvert_kops_KeysetRotation_To_v1alpha2_KeysetRotation(in, out, s)
+}
+
 func autoConvert_v1alpha2_KeysetSpec_To_kops_KeysetSpec(in *KeysetSpec, out *kops.KeysetSpec, s conversion.Scope) error {
 	out.Type = kops.KeysetType(in.Type)
 	if in.Keys != nil {
@@ -4369,6 +4561,16 @@ func autoConvert_v1alpha2_KeysetSpec_To_kops_KeysetSpec(in *KeysetSpec, out *kop
 	} else {
 		out.Keys = nil
 	}
+	out.PrimaryId = in.PrimaryId
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(kops.KeysetRotation)
+		if err := Convert_v1alpha2_KeysetRotation_To_kops_KeysetRotation(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Rotation = nil
+	}
 	return nil
 }
 
@@ -4390,6 +4592,16 @@ func autoConvert_kops_KeysetSpec_To_v1alpha2_KeysetSpec(in *kops.KeysetSpec, out
 	} else {
 		out.Keys = nil
 	}
+	out.PrimaryId = in.PrimaryId
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(KeysetRotation)
+		if err := Convert_kops_KeysetRotation_To_v1alpha2_KeysetRotation(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Rotation = nil
+	}
 	return nil
 }
 
@@ -6088,6 +6300,28 @@ func Convert_kops_OpenstackRouter_To_v1alpha2_OpenstackRouter(in *kops.Openstack
 	return autoConvert_kops_OpenstackRouter_To_v1alpha2_OpenstackRouter(in, out, s)
 }
 
+func autoConvert_v1alpha2_PKISpec_To_kops_PKISpec(in *PKISpec, out *kops.PKISpec, s conversion.Scope) error {
+	out.KeyAlgorithm = in.KeyAlgorithm
+	out.KeysetKeyAlgorithms = in.KeysetKeyAlgorithms
+	return nil
+}
+
+// Convert_v1alpha2_PKISpec_To_kops_PKISpec is an autogenerated conversion function.
+func Convert_v1alpha2_PKISpec_To_kops_PKISpec(in *PKISpec, out *kops.PKISpec, s conversion.Scope) error {
+	return autoConvert_v1alpha2_PKISpec_To_kops_PKISpec(in, out, s)
+}
+
+func autoConvert_kops_PKISpec_To_v1alpha2_PKISpec(in *kops.PKISpec, out *PKISpec, s conversion.Scope) error {
+	out.KeyAlgorithm = in.KeyAlgorithm
+	out.KeysetKeyAlgorithms = in.KeysetKeyAlgorithms
+	return nil
+}
+
+// Convert_kops_PKISpec_To_v1alpha2_PKISpec is an autogenerated conversion function.
+func Convert_kops_PKISpec_To_v1alpha2_PKISpec(in *kops.PKISpec, out *PKISpec, s conversion.Scope) error {
+	return autoConvert_kops_PKISpec_To_v1alpha2_PKISpec(in, out, s)
+}
+
 func autoConvert_v1alpha2_PackagesConfig_To_kops_PackagesConfig(in *PackagesConfig, out *kops.PackagesConfig, s conversion.Scope) error {
 	out.HashAmd64 = in.HashAmd64
 	out.HashArm64 = in.HashArm64
@@ -6136,6 +6370,19 @@ func autoConvert_v1alpha2_RollingUpdate_To_kops_RollingUpdate(in *RollingUpdate,
 	out.DrainAndTerminate = in.DrainAndTerminate
 	out.MaxUnavailable = in.MaxUnavailable
 	out.MaxSurge = in.MaxSurge
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]kops.RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Hooks = nil
+	}
+	out.Canary = in.Canary
+	out.CanaryBakeTime = in.CanaryBakeTime
 	return nil
 }
 
@@ -6148,6 +6395,19 @@ func autoConvert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in *kops.RollingUp
 	out.DrainAndTerminate = in.DrainAndTerminate
 	out.MaxUnavailable = in.MaxUnavailable
 	out.MaxSurge = in.MaxSurge
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Hooks = nil
+	}
+	out.Canary = in.Canary
+	out.CanaryBakeTime = in.CanaryBakeTime
 	return nil
 }
 
@@ -6156,6 +6416,194 @@ func Convert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in *kops.RollingUpdate
 	return autoConvert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in, out, s)
 }
 
+func autoConvert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(in *RollingUpdateExecHook, out *kops.RollingUpdateExecHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(in *RollingUpdateExecHook, out *kops.RollingUpdateExecHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook(in *kops.RollingUpdateExecHook, out *RollingUpdateExecHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook(in *kops.RollingUpdateExecHook, out *RollingUpdateExecHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook(in, out, s)
+}
+
+func autoConvert_v1alpha2_RollingUpdateHTTPHook_To_kops_RollingUpdateHTTPHook(in *RollingUpdateHTTPHook, out *kops.RollingUpdateHTTPHook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Method = in.Method
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdateHTTPHook_To_kops_RollingUpdateHTTPHook is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdateHTTPHook_To_kops_RollingUpdateHTTPHook(in *RollingUpdateHTTPHook, out *kops.RollingUpdateHTTPHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdateHTTPHook_To_kops_RollingUpdateHTTPHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateHTTPHook_To_v1alpha2_RollingUpdateHTTPHook(in *kops.RollingUpdateHTTPHook, out *RollingUpdateHTTPHook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Method = in.Method
+	return nil
+}
+
+// Convert_kops_RollingUpdateHTTPHook_To_v1alpha2_RollingUpdateHTTPHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateHTTPHook_To_v1alpha2_RollingUpdateHTTPHook(in *kops.RollingUpdateHTTPHook, out *RollingUpdateHTTPHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateHTTPHook_To_v1alpha2_RollingUpdateHTTPHook(in, out, s)
+}
+
+func autoConvert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = kops.RollingUpdateHookPhase(in.Phase)
+	out.Timeout = in.Timeout
+	out.FailurePolicy = kops.RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(kops.RollingUpdateExecHook)
+		if err := Convert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(kops.RollingUpdateHTTPHook)
+		if err := Convert_v1alpha2_RollingUpdateHTTPHook_To_kops_RollingUpdateHTTPHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.HTTP = nil
+	}
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(kops.RollingUpdateJobHook)
+		if err := Convert_v1alpha2_RollingUpdateJobHook_To_kops_RollingUpdateJobHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Job = nil
+	}
+	if in.PodDisruptionBudgets != nil {
+		in, out := &in.PodDisruptionBudgets, &out.PodDisruptionBudgets
+		*out = new(kops.RollingUpdatePodDisruptionBudgetsHook)
+		if err := Convert_v1alpha2_RollingUpdatePodDisruptionBudgetsHook_To_kops_RollingUpdatePodDisruptionBudgetsHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PodDisruptionBudgets = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Phase = RollingUpdateHookPhase(in.Phase)
+	out.Timeout = in.Timeout
+	out.FailurePolicy = RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(RollingUpdateExecHook)
+		if err := Convert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(RollingUpdateHTTPHook)
+		if err := Convert_kops_RollingUpdateHTTPHook_To_v1alpha2_RollingUpdateHTTPHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.HTTP = nil
+	}
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(RollingUpdateJobHook)
+		if err := Convert_kops_RollingUpdateJobHook_To_v1alpha2_RollingUpdateJobHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Job = nil
+	}
+	if in.PodDisruptionBudgets != nil {
+		in, out := &in.PodDisruptionBudgets, &out.PodDisruptionBudgets
+		*out = new(RollingUpdatePodDisruptionBudgetsHook)
+		if err := Convert_kops_RollingUpdatePodDisruptionBudgetsHook_To_v1alpha2_RollingUpdatePodDisruptionBudgetsHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.PodDisruptionBudgets = nil
+	}
+	return nil
+}
+
+// Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in, out, s)
+}
+
+func autoConvert_v1alpha2_RollingUpdateJobHook_To_kops_RollingUpdateJobHook(in *RollingUpdateJobHook, out *kops.RollingUpdateJobHook, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Image = in.Image
+	out.Command = in.Command
+	out.ServiceAccountName = in.ServiceAccountName
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdateJobHook_To_kops_RollingUpdateJobHook is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdateJobHook_To_kops_RollingUpdateJobHook(in *RollingUpdateJobHook, out *kops.RollingUpdateJobHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdateJobHook_To_kops_RollingUpdateJobHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateJobHook_To_v1alpha2_RollingUpdateJobHook(in *kops.RollingUpdateJobHook, out *RollingUpdateJobHook, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Image = in.Image
+	out.Command = in.Command
+	out.ServiceAccountName = in.ServiceAccountName
+	return nil
+}
+
+// Convert_kops_RollingUpdateJobHook_To_v1alpha2_RollingUpdateJobHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateJobHook_To_v1alpha2_RollingUpdateJobHook(in *kops.RollingUpdateJobHook, out *RollingUpdateJobHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateJobHook_To_v1alpha2_RollingUpdateJobHook(in, out, s)
+}
+
+func autoConvert_v1alpha2_RollingUpdatePodDisruptionBudgetsHook_To_kops_RollingUpdatePodDisruptionBudgetsHook(in *RollingUpdatePodDisruptionBudgetsHook, out *kops.RollingUpdatePodDisruptionBudgetsHook, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Selector = in.Selector
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdatePodDisruptionBudgetsHook_To_kops_RollingUpdatePodDisruptionBudgetsHook is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdatePodDisruptionBudgetsHook_To_kops_RollingUpdatePodDisruptionBudgetsHook(in *RollingUpdatePodDisruptionBudgetsHook, out *kops.RollingUpdatePodDisruptionBudgetsHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdatePodDisruptionBudgetsHook_To_kops_RollingUpdatePodDisruptionBudgetsHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdatePodDisruptionBudgetsHook_To_v1alpha2_RollingUpdatePodDisruptionBudgetsHook(in *kops.RollingUpdatePodDisruptionBudgetsHook, out *RollingUpdatePodDisruptionBudgetsHook, s conversion.Scope) error {
+	out.Namespace = in.Namespace
+	out.Selector = in.Selector
+	return nil
+}
+
+// Convert_kops_RollingUpdatePodDisruptionBudgetsHook_To_v1alpha2_RollingUpdatePodDisruptionBudgetsHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdatePodDisruptionBudgetsHook_To_v1alpha2_RollingUpdatePodDisruptionBudgetsHook(in *kops.RollingUpdatePodDisruptionBudgetsHook, out *RollingUpdatePodDisruptionBudgetsHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdatePodDisruptionBudgetsHook_To_v1alpha2_RollingUpdatePodDisruptionBudgetsHook(in, out, s)
+}
+
 func autoConvert_v1alpha2_RomanaNetworkingSpec_To_kops_RomanaNetworkingSpec(in *RomanaNetworkingSpec, out *kops.RomanaNetworkingSpec, s conversion.Scope) error {
 	out.DaemonServiceIP = in.DaemonServiceIP
 	out.EtcdServiceIP = in.EtcdServiceIP
@@ -6288,6 +6736,28 @@ func Convert_kops_ServiceAccountIssuerDiscoveryConfig_To_v1alpha2_ServiceAccount
 	return autoConvert_kops_ServiceAccountIssuerDiscoveryConfig_To_v1alpha2_ServiceAccountIssuerDiscoveryConfig(in, out, s)
 }
 
+func autoConvert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec(in *StateStoreEncryptionSpec, out *kops.StateStoreEncryptionSpec, s conversion.Scope) error {
+	out.KeyEncryptionKey = in.KeyEncryptionKey
+	out.PreviousKeyEncryptionKeys = in.PreviousKeyEncryptionKeys
+	return nil
+}
+
+// Convert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec is an autogenerated conversion function.
+func Convert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec(in *StateStoreEncryptionSpec, out *kops.StateStoreEncryptionSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha2_StateStoreEncryptionSpec_To_kops_StateStoreEncryptionSpec(in, out, s)
+}
+
+func autoConvert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec(in *kops.StateStoreEncryptionSpec, out *StateStoreEncryptionSpec, s conversion.Scope) error {
+	out.KeyEncryptionKey = in.KeyEncryptionKey
+	out.PreviousKeyEncryptionKeys = in.PreviousKeyEncryptionKeys
+	return nil
+}
+
+// Convert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec is an autogenerated conversion function.
+func Convert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec(in *kops.StateStoreEncryptionSpec, out *StateStoreEncryptionSpec, s conversion.Scope) error {
+	return autoConvert_kops_StateStoreEncryptionSpec_To_v1alpha2_StateStoreEncryptionSpec(in, out, s)
+}
+
 func autoConvert_v1alpha2_TargetSpec_To_kops_TargetSpec(in *TargetSpec, out *kops.TargetSpec, s conversion.Scope) error {
 	if in.Terraform != nil {
 		in, out := &in.Terraform, &out.Terraform
@@ -845,6 +845,16 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = new(TopologySpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PKI != nil {
+		in, out := &in.PKI, &out.PKI
+		*out = new(PKISpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StateStoreEncryption != nil {
+		in, out := &in.StateStoreEncryption, &out.StateStoreEncryption
+		*out = new(StateStoreEncryptionSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.DNSControllerGossipConfig != nil {
 		in, out := &in.DNSControllerGossipConfig, &out.DNSControllerGossipConfig
 		*out = new(DNSControllerGossipConfig)
@@ -1093,6 +1103,11 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = new(RollingUpdate)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RollingUpdateMaxConcurrentGroups != nil {
+		in, out := &in.RollingUpdateMaxConcurrentGroups, &out.RollingUpdateMaxConcurrentGroups
+		*out = new(int32)
+		**out = **in
+	}
 	if in.ClusterAutoscaler != nil {
 		in, out := &in.ClusterAutoscaler, &out.ClusterAutoscaler
 		*out = new(ClusterAutoscalerConfig)
@@ -1793,6 +1808,11 @@ func (in *GossipConfig) DeepCopyInto(out *GossipConfig) {
 		*out = new(GossipConfigSecondary)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(GossipConfigDNS)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1806,6 +1826,32 @@ func (in *GossipConfig) DeepCopy() *GossipConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GossipConfigDNS) DeepCopyInto(out *GossipConfigDNS) {
+	*out = *in
+	if in.Listen != nil {
+		in, out := &in.Listen, &out.Listen
+		*out = new(string)
+		**out = **in
+	}
+	if in.HostsFile != nil {
+		in, out := &in.HostsFile, &out.HostsFile
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GossipConfigDNS.
+func (in *GossipConfigDNS) DeepCopy() *GossipConfigDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(GossipConfigDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GossipConfigSecondary) DeepCopyInto(out *GossipConfigSecondary) {
 	*out = *in
@@ -2364,6 +2410,22 @@ func (in *KeysetList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeysetRotation) DeepCopyInto(out *KeysetRotation) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeysetRotation.
+func (in *KeysetRotation) DeepCopy() *KeysetRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(KeysetRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeysetSpec) DeepCopyInto(out *KeysetSpec) {
 	*out = *in
@@ -2374,6 +2436,11 @@ func (in *KeysetSpec) DeepCopyInto(out *KeysetSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(KeysetRotation)
+		**out = **in
+	}
 	return
 }
 
@@ -4200,6 +4267,29 @@ func (in *OpenstackRouter) DeepCopy() *OpenstackRouter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PKISpec) DeepCopyInto(out *PKISpec) {
+	*out = *in
+	if in.KeysetKeyAlgorithms != nil {
+		in, out := &in.KeysetKeyAlgorithms, &out.KeysetKeyAlgorithms
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PKISpec.
+func (in *PKISpec) DeepCopy() *PKISpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PKISpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PackagesConfig) DeepCopyInto(out *PackagesConfig) {
 	*out = *in
@@ -4270,6 +4360,23 @@ func (in *RollingUpdate) DeepCopyInto(out *RollingUpdate) {
 		*out = new(intstr.IntOrString)
 		**out = **in
 	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = make([]RollingUpdateHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.CanaryBakeTime != nil {
+		in, out := &in.CanaryBakeTime, &out.CanaryBakeTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -4283,6 +4390,121 @@ func (in *RollingUpdate) DeepCopy() *RollingUpdate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateExecHook) DeepCopyInto(out *RollingUpdateExecHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateExecHook.
+func (in *RollingUpdateExecHook) DeepCopy() *RollingUpdateExecHook {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateExecHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateHTTPHook) DeepCopyInto(out *RollingUpdateHTTPHook) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateHTTPHook.
+func (in *RollingUpdateHTTPHook) DeepCopy() *RollingUpdateHTTPHook {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateHTTPHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateHook) DeepCopyInto(out *RollingUpdateHook) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(RollingUpdateExecHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(RollingUpdateHTTPHook)
+		**out = **in
+	}
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(RollingUpdateJobHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodDisruptionBudgets != nil {
+		in, out := &in.PodDisruptionBudgets, &out.PodDisruptionBudgets
+		*out = new(RollingUpdatePodDisruptionBudgetsHook)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateHook.
+func (in *RollingUpdateHook) DeepCopy() *RollingUpdateHook {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateJobHook) DeepCopyInto(out *RollingUpdateJobHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateJobHook.
+func (in *RollingUpdateJobHook) DeepCopy() *RollingUpdateJobHook {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateJobHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdatePodDisruptionBudgetsHook) DeepCopyInto(out *RollingUpdatePodDisruptionBudgetsHook) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdatePodDisruptionBudgetsHook.
+func (in *RollingUpdatePodDisruptionBudgetsHook) DeepCopy() *RollingUpdatePodDisruptionBudgetsHook {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdatePodDisruptionBudgetsHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RomanaNetworkingSpec) DeepCopyInto(out *RomanaNetworkingSpec) {
 	*out = *in
@@ -4391,6 +4613,27 @@ func (in *ServiceAccountIssuerDiscoveryConfig) DeepCopy() *ServiceAccountIssuerD
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StateStoreEncryptionSpec) DeepCopyInto(out *StateStoreEncryptionSpec) {
+	*out = *in
+	if in.PreviousKeyEncryptionKeys != nil {
+		in, out := &in.PreviousKeyEncryptionKeys, &out.PreviousKeyEncryptionKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StateStoreEncryptionSpec.
+func (in *StateStoreEncryptionSpec) DeepCopy() *StateStoreEncryptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StateStoreEncryptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetSpec) DeepCopyInto(out *TargetSpec) {
 	*out = *in
@@ -29,15 +29,18 @@ import (
 	"golang.org/x/net/ipv4"
 	"golang.org/x/net/ipv6"
 	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/sets"
 	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/envelope"
 	"k8s.io/kops/pkg/featureflag"
 	"k8s.io/kops/pkg/model/components"
 	"k8s.io/kops/pkg/model/iam"
+	"k8s.io/kops/pkg/pki"
 	"k8s.io/kops/upup/pkg/fi"
 )
 
@@ -227,6 +230,9 @@ func validateClusterSpec(spec *kops.ClusterSpec, c *kops.Cluster, fieldPath *fie
 	if spec.RollingUpdate != nil {
 		allErrs = append(allErrs, validateRollingUpdate(spec.RollingUpdate, fieldPath.Child("rollingUpdate"), false)...)
 	}
+	if spec.RollingUpdateMaxConcurrentGroups != nil && *spec.RollingUpdateMaxConcurrentGroups < 1 {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("rollingUpdateMaxConcurrentGroups"), *spec.RollingUpdateMaxConcurrentGroups, "Must be at least 1"))
+	}
 
 	if spec.API != nil && spec.API.LoadBalancer != nil && spec.CloudProvider == "aws" {
 		value := string(spec.API.LoadBalancer.Class)
@@ -246,6 +252,18 @@ func validateClusterSpec(spec *kops.ClusterSpec, c *kops.Cluster, fieldPath *fie
 		allErrs = append(allErrs, validateCloudConfiguration(spec.CloudConfig, fieldPath.Child("cloudConfig"))...)
 	}
 
+	if spec.GossipConfig != nil && spec.GossipConfig.DNS != nil {
+		allErrs = append(allErrs, validateGossipConfigDNS(spec, spec.GossipConfig.DNS, fieldPath.Child("gossipConfig", "dns"))...)
+	}
+
+	if spec.PKI != nil {
+		allErrs = append(allErrs, validatePKI(spec.PKI, fieldPath.Child("pki"))...)
+	}
+
+	if spec.StateStoreEncryption != nil {
+		allErrs = append(allErrs, validateStateStoreEncryption(spec.StateStoreEncryption, fieldPath.Child("stateStoreEncryption"))...)
+	}
+
 	if spec.WarmPool != nil {
 		if kops.CloudProviderID(spec.CloudProvider) != kops.CloudProviderAWS {
 			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "warmPool"), "warm pool only supported on AWS"))
@@ -257,6 +275,45 @@ func validateClusterSpec(spec *kops.ClusterSpec, c *kops.Cluster, fieldPath *fie
 	return allErrs
 }
 
+func validatePKI(spec *kops.PKISpec, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.KeyAlgorithm != "" {
+		allErrs = append(allErrs, IsValidValue(fieldPath.Child("keyAlgorithm"), &spec.KeyAlgorithm, pki.KeyAlgorithms)...)
+	}
+	for keyset, algorithm := range spec.KeysetKeyAlgorithms {
+		allErrs = append(allErrs, IsValidValue(fieldPath.Child("keysetKeyAlgorithms").Key(keyset), &algorithm, pki.KeyAlgorithms)...)
+	}
+
+	// kube-apiserver cannot sign service account tokens with Ed25519 keys
+	serviceAccountAlgorithm := spec.KeysetKeyAlgorithms["service-account"]
+	if serviceAccountAlgorithm == "" {
+		serviceAccountAlgorithm = spec.KeyAlgorithm
+	}
+	if serviceAccountAlgorithm == pki.KeyAlgorithmEd25519 {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("keysetKeyAlgorithms").Key("service-account"), "the service-account keyset must use an RSA or ECDSA key"))
+	}
+
+	return allErrs
+}
+
+func validateStateStoreEncryption(spec *kops.StateStoreEncryptionSpec, fieldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.KeyEncryptionKey == "" {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("keyEncryptionKey"), "a key encryption key is required"))
+	} else if err := envelope.ValidateKEK(spec.KeyEncryptionKey); err != nil {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("keyEncryptionKey"), spec.KeyEncryptionKey, err.Error()))
+	}
+	for i, uri := range spec.PreviousKeyEncryptionKeys {
+		if err := envelope.ValidateKEK(uri); err != nil {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("previousKeyEncryptionKeys").Index(i), uri, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
 func validateCIDR(cidr string, fieldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 
@@ -1280,6 +1337,82 @@ func validateRollingUpdate(rollingUpdate *kops.RollingUpdate, fldpath *field.Pat
 			allErrs = append(allErrs, field.Forbidden(fldpath.Child("maxSurge"), "Cannot be zero if maxUnavailable is zero"))
 		}
 	}
+	if rollingUpdate.Canary != nil {
+		canary, err := intstr.GetValueFromIntOrPercent(rollingUpdate.Canary, 1000, true)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("canary"), rollingUpdate.Canary,
+				fmt.Sprintf("Unable to parse: %v", err)))
+		} else if canary < 0 {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("canary"), rollingUpdate.Canary, "Cannot be negative"))
+		}
+	}
+	if rollingUpdate.CanaryBakeTime != nil && rollingUpdate.CanaryBakeTime.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldpath.Child("canaryBakeTime"), rollingUpdate.CanaryBakeTime, "Cannot be negative"))
+	}
+	names := sets.NewString()
+	for i := range rollingUpdate.Hooks {
+		hook := &rollingUpdate.Hooks[i]
+		allErrs = append(allErrs, validateRollingUpdateHook(hook, fldpath.Child("hooks").Index(i))...)
+		if names.Has(hook.Name) {
+			allErrs = append(allErrs, field.Duplicate(fldpath.Child("hooks").Index(i).Child("name"), hook.Name))
+		}
+		names.Insert(hook.Name)
+	}
+	return allErrs
+}
+
+func validateRollingUpdateHook(hook *kops.RollingUpdateHook, fldpath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if hook.Name == "" {
+		allErrs = append(allErrs, field.Required(fldpath.Child("name"), ""))
+	}
+
+	phase := string(hook.Phase)
+	allErrs = append(allErrs, IsValidValue(fldpath.Child("phase"), &phase, []string{string(kops.RollingUpdateHookPhasePreDrain), string(kops.RollingUpdateHookPhasePostValidate), string(kops.RollingUpdateHookPhasePostCanary)})...)
+
+	if hook.FailurePolicy != "" {
+		failurePolicy := string(hook.FailurePolicy)
+		allErrs = append(allErrs, IsValidValue(fldpath.Child("failurePolicy"), &failurePolicy, []string{string(kops.RollingUpdateHookFailurePolicyFail), string(kops.RollingUpdateHookFailurePolicyIgnore)})...)
+	}
+
+	if hook.Timeout != nil && hook.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldpath.Child("timeout"), hook.Timeout, "Must be positive"))
+	}
+
+	count := 0
+	if hook.Exec != nil {
+		count++
+		if len(hook.Exec.Command) == 0 {
+			allErrs = append(allErrs, field.Required(fldpath.Child("exec", "command"), ""))
+		}
+	}
+	if hook.HTTP != nil {
+		count++
+		if !isValidAPIServersURL(hook.HTTP.URL) {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("http", "url"), hook.HTTP.URL, "Must be an absolute URL"))
+		}
+	}
+	if hook.Job != nil {
+		count++
+		if hook.Job.Image == "" {
+			allErrs = append(allErrs, field.Required(fldpath.Child("job", "image"), ""))
+		}
+		// The hook name is used as a prefix of the Job's name
+		for _, msg := range utilvalidation.IsDNS1123Label(hook.Name) {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("name"), hook.Name, msg))
+		}
+	}
+	if hook.PodDisruptionBudgets != nil {
+		count++
+		if _, err := labels.Parse(hook.PodDisruptionBudgets.Selector); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("podDisruptionBudgets", "selector"), hook.PodDisruptionBudgets.Selector, fmt.Sprintf("Unable to parse: %v", err)))
+		}
+	}
+	if count != 1 {
+		allErrs = append(allErrs, field.Forbidden(fldpath, "Exactly one of exec, http, job and podDisruptionBudgets must be specified"))
+	}
+
 	return allErrs
 }
 
@@ -1307,6 +1440,21 @@ func validateNodeLocalDNS(spec *kops.ClusterSpec, fldpath *field.Path) field.Err
 	return allErrs
 }
 
+func validateGossipConfigDNS(spec *kops.ClusterSpec, v *kops.GossipConfigDNS, fldPath *field.Path) (allErrs field.ErrorList) {
+	if v.Listen != nil {
+		ip := net.ParseIP(*v.Listen)
+		if ip == nil || ip.To4() == nil || !ip.IsLinkLocalUnicast() {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("listen"), *v.Listen, "must be a link-local IPv4 address"))
+		}
+		if spec.KubeDNS != nil && spec.KubeDNS.Provider != "" && spec.KubeDNS.Provider != "CoreDNS" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("listen"), "serving gossip DNS records requires CoreDNS"))
+		}
+	} else if v.HostsFile != nil && !*v.HostsFile {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("hostsFile"), "the hosts file can only be disabled when listen is set"))
+	}
+	return allErrs
+}
+
 func validateClusterAutoscaler(cluster *kops.Cluster, spec *kops.ClusterAutoscalerConfig, fldPath *field.Path) (allErrs field.ErrorList) {
 	allErrs = append(allErrs, IsValidValue(fldPath.Child("expander"), spec.Expander, []string{"least-waste", "random", "most-pods"})...)
 
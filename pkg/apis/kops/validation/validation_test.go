@@ -18,7 +18,9 @@ package validation
 
 import (
 	"testing"
+	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -1003,6 +1005,99 @@ func Test_Validate_RollingUpdate(t *testing.T) {
 			},
 			ExpectedErrors: []string{"Forbidden::testField.maxSurge"},
 		},
+		{
+			Input: kops.RollingUpdate{
+				Hooks: []kops.RollingUpdateHook{
+					{
+						Name:  "exec",
+						Phase: kops.RollingUpdateHookPhasePreDrain,
+						Exec:  &kops.RollingUpdateExecHook{Command: []string{"true"}},
+					},
+					{
+						Name:          "http",
+						Phase:         kops.RollingUpdateHookPhasePostValidate,
+						FailurePolicy: kops.RollingUpdateHookFailurePolicyIgnore,
+						HTTP:          &kops.RollingUpdateHTTPHook{URL: "https://example.com/ready"},
+					},
+					{
+						Name:  "job",
+						Phase: kops.RollingUpdateHookPhasePostValidate,
+						Job:   &kops.RollingUpdateJobHook{Image: "busybox"},
+					},
+					{
+						Name:                 "pdbs",
+						Phase:                kops.RollingUpdateHookPhasePreDrain,
+						PodDisruptionBudgets: &kops.RollingUpdatePodDisruptionBudgetsHook{Selector: "app=kafka"},
+					},
+				},
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Hooks: []kops.RollingUpdateHook{
+					{
+						Phase: "Sometime",
+						Exec:  &kops.RollingUpdateExecHook{},
+					},
+				},
+			},
+			ExpectedErrors: []string{
+				"Required value::testField.hooks[0].name",
+				"Unsupported value::testField.hooks[0].phase",
+				"Required value::testField.hooks[0].exec.command",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Hooks: []kops.RollingUpdateHook{
+					{
+						Name:          "both",
+						Phase:         kops.RollingUpdateHookPhasePreDrain,
+						FailurePolicy: "Maybe",
+						Exec:          &kops.RollingUpdateExecHook{Command: []string{"true"}},
+						HTTP:          &kops.RollingUpdateHTTPHook{URL: "/ready"},
+					},
+					{
+						Name:  "both",
+						Phase: kops.RollingUpdateHookPhasePreDrain,
+					},
+				},
+			},
+			ExpectedErrors: []string{
+				"Unsupported value::testField.hooks[0].failurePolicy",
+				"Invalid value::testField.hooks[0].http.url",
+				"Forbidden::testField.hooks[0]",
+				"Forbidden::testField.hooks[1]",
+				"Duplicate value::testField.hooks[1].name",
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Canary:         intStr(intstr.FromInt(1)),
+				CanaryBakeTime: &metav1.Duration{Duration: 30 * time.Minute},
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Canary: intStr(intstr.FromString("10%")),
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Canary: intStr(intstr.FromString("nope")),
+			},
+			ExpectedErrors: []string{"Invalid value::testField.canary"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Canary:         intStr(intstr.FromInt(-1)),
+				CanaryBakeTime: &metav1.Duration{Duration: -time.Minute},
+			},
+			ExpectedErrors: []string{
+				"Invalid value::testField.canary",
+				"Invalid value::testField.canaryBakeTime",
+			},
+		},
 	}
 	for _, g := range grid {
 		errs := validateRollingUpdate(&g.Input, field.NewPath("testField"), g.OnMasterIG)
@@ -1010,6 +1105,46 @@ func Test_Validate_RollingUpdate(t *testing.T) {
 	}
 }
 
+func Test_Validate_RollingUpdateMaxConcurrentGroups(t *testing.T) {
+	grid := []struct {
+		Input          *int32
+		ExpectedErrors []string
+	}{
+		{
+			Input: nil,
+		},
+		{
+			Input: fi.Int32(4),
+		},
+		{
+			Input:          fi.Int32(0),
+			ExpectedErrors: []string{"Invalid value::spec.rollingUpdateMaxConcurrentGroups"},
+		},
+	}
+	for _, g := range grid {
+		clusterSpec := &kops.ClusterSpec{
+			RollingUpdateMaxConcurrentGroups: g.Input,
+			Subnets: []kops.ClusterSubnetSpec{
+				{Name: "subnet1"},
+			},
+			EtcdClusters: []kops.EtcdClusterSpec{
+				{
+					Name: "main",
+					Members: []kops.EtcdMemberSpec{
+						{
+							Name:          "us-test-1a",
+							InstanceGroup: fi.String("master-us-test-1a"),
+						},
+					},
+				},
+			},
+			IAM: &kops.IAMSpec{},
+		}
+		errs := validateClusterSpec(clusterSpec, &kops.Cluster{Spec: *clusterSpec}, field.NewPath("spec"))
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
+
 func intStr(i intstr.IntOrString) *intstr.IntOrString {
 	return &i
 }
@@ -1099,6 +1234,52 @@ func Test_Validate_NodeLocalDNS(t *testing.T) {
 	}
 }
 
+func Test_Validate_GossipConfigDNS(t *testing.T) {
+	grid := []struct {
+		Input          kops.GossipConfigDNS
+		KubeDNS        *kops.KubeDNSConfig
+		ExpectedErrors []string
+	}{
+		{
+			Input: kops.GossipConfigDNS{
+				Listen: fi.String("169.254.20.11"),
+			},
+		},
+		{
+			Input: kops.GossipConfigDNS{
+				Listen:    fi.String("169.254.20.11"),
+				HostsFile: fi.Bool(false),
+			},
+			KubeDNS: &kops.KubeDNSConfig{Provider: "CoreDNS"},
+		},
+		{
+			Input: kops.GossipConfigDNS{
+				Listen: fi.String("10.0.0.1"),
+			},
+			ExpectedErrors: []string{"Invalid value::spec.gossipConfig.dns.listen"},
+		},
+		{
+			Input: kops.GossipConfigDNS{
+				Listen: fi.String("169.254.20.11"),
+			},
+			KubeDNS:        &kops.KubeDNSConfig{Provider: "KubeDNS"},
+			ExpectedErrors: []string{"Forbidden::spec.gossipConfig.dns.listen"},
+		},
+		{
+			Input: kops.GossipConfigDNS{
+				HostsFile: fi.Bool(false),
+			},
+			ExpectedErrors: []string{"Forbidden::spec.gossipConfig.dns.hostsFile"},
+		},
+	}
+
+	for _, g := range grid {
+		spec := &kops.ClusterSpec{KubeDNS: g.KubeDNS}
+		errs := validateGossipConfigDNS(spec, &g.Input, field.NewPath("spec", "gossipConfig", "dns"))
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
+
 func Test_Validate_CloudConfiguration(t *testing.T) {
 	grid := []struct {
 		Description    string
@@ -1191,3 +1372,100 @@ func Test_Validate_CloudConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func Test_Validate_PKI(t *testing.T) {
+	grid := []struct {
+		Description    string
+		Input          kops.PKISpec
+		ExpectedErrors []string
+	}{
+		{
+			Description: "empty",
+			Input:       kops.PKISpec{},
+		},
+		{
+			Description: "ECDSA with RSA CA",
+			Input: kops.PKISpec{
+				KeyAlgorithm:        "ECDSA-P256",
+				KeysetKeyAlgorithms: map[string]string{"kubernetes-ca": "RSA"},
+			},
+		},
+		{
+			Description: "unknown algorithm",
+			Input: kops.PKISpec{
+				KeyAlgorithm: "DSA",
+			},
+			ExpectedErrors: []string{"Unsupported value::pki.keyAlgorithm"},
+		},
+		{
+			Description: "unknown keyset algorithm",
+			Input: kops.PKISpec{
+				KeysetKeyAlgorithms: map[string]string{"kubernetes-ca": "ECDSA-P521"},
+			},
+			ExpectedErrors: []string{"Unsupported value::pki.keysetKeyAlgorithms[kubernetes-ca]"},
+		},
+		{
+			Description: "Ed25519",
+			Input: kops.PKISpec{
+				KeyAlgorithm: "Ed25519",
+			},
+			ExpectedErrors: []string{"Forbidden::pki.keysetKeyAlgorithms[service-account]"},
+		},
+		{
+			Description: "Ed25519 with ECDSA service account",
+			Input: kops.PKISpec{
+				KeyAlgorithm:        "Ed25519",
+				KeysetKeyAlgorithms: map[string]string{"service-account": "ECDSA-P256"},
+			},
+		},
+	}
+
+	for _, g := range grid {
+		fldPath := field.NewPath("pki")
+		t.Run(g.Description, func(t *testing.T) {
+			errs := validatePKI(&g.Input, fldPath)
+			testErrors(t, g.Input, errs, g.ExpectedErrors)
+		})
+	}
+}
+
+func Test_Validate_StateStoreEncryption(t *testing.T) {
+	grid := []struct {
+		Input          kops.StateStoreEncryptionSpec
+		ExpectedErrors []string
+	}{
+		{
+			Input:          kops.StateStoreEncryptionSpec{},
+			ExpectedErrors: []string{"Required value::stateStoreEncryption.keyEncryptionKey"},
+		},
+		{
+			Input: kops.StateStoreEncryptionSpec{KeyEncryptionKey: "awskms://arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"},
+		},
+		{
+			Input: kops.StateStoreEncryptionSpec{KeyEncryptionKey: "vault://vault.example.com:8200/transit/kops"},
+		},
+		{
+			Input:          kops.StateStoreEncryptionSpec{KeyEncryptionKey: "awskms://alias/kops"},
+			ExpectedErrors: []string{"Invalid value::stateStoreEncryption.keyEncryptionKey"},
+		},
+		{
+			Input: kops.StateStoreEncryptionSpec{KeyEncryptionKey: "pgp:///etc/kops/key.asc"},
+		},
+		{
+			Input:          kops.StateStoreEncryptionSpec{KeyEncryptionKey: "age:///etc/kops/key.txt"},
+			ExpectedErrors: []string{"Invalid value::stateStoreEncryption.keyEncryptionKey"},
+		},
+		{
+			Input: kops.StateStoreEncryptionSpec{
+				KeyEncryptionKey:          "vault://vault.example.com:8200/transit/kops",
+				PreviousKeyEncryptionKeys: []string{"file:///etc/kops/kek", "vault://vault.example.com/kops"},
+			},
+			ExpectedErrors: []string{"Invalid value::stateStoreEncryption.previousKeyEncryptionKeys[1]"},
+		},
+	}
+
+	for _, g := range grid {
+		errs := validateStateStoreEncryption(&g.Input, field.NewPath("stateStoreEncryption"))
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
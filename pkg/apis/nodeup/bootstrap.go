@@ -56,6 +56,6 @@ type NodeConfigCertificate struct {
 	// Name identifies the certificate.
 	Name string `json:"name,omitempty"`
 
-	// Cert is the certificate data.
+	// Cert is the certificate data. For CAs, it may be a bundle of certificates, with the primary first.
 	Cert string `json:"cert,omitempty"`
 }
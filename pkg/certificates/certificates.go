@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// Entry describes a certificate in the keystore of a cluster
+type Entry struct {
+	// Keyset is the name of the keyset containing the certificate
+	Keyset string `json:"keyset"`
+	// ID is the id of the certificate in the keyset
+	ID string `json:"id"`
+	// Component describes what uses the certificate
+	Component string `json:"component,omitempty"`
+
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	Serial   string    `json:"serial"`
+	NotAfter time.Time `json:"notAfter"`
+
+	// Primary is true if the certificate is the primary certificate of the keyset
+	Primary bool `json:"primary"`
+	// InUse is true if the certificate is used by the cluster: either it is the primary
+	// certificate of the keyset, or it is still trusted while the keyset is being rotated
+	InUse bool `json:"inUse"`
+}
+
+// components describes the well-known keysets
+var components = map[string]string{
+	fi.CertificateIDCA:        "Kubernetes CA",
+	"apiserver-aggregator-ca": "kube-apiserver aggregation layer CA",
+	"service-account":         "service account token signing",
+	"etcd-clients-ca":         "etcd clients CA",
+	"etcd-clients-ca-cilium":  "Cilium etcd clients CA",
+	"kubelet":                 "kubelet client",
+	"kube-proxy":              "kube-proxy client",
+	"kube-router":             "kube-router client",
+	"kubecfg":                 "kubecfg client",
+	"etcd":                    "etcd server",
+	"etcd-peer":               "etcd peers",
+	"etcd-client":             "etcd client",
+	"node-authorizer":         "node-authorizer server",
+	"node-authorizer-client":  "node-authorizer client",
+}
+
+// Component returns a description of what uses the certificates of the named keyset,
+// or "" if the keyset is not well-known
+func Component(keyset string) string {
+	if component, found := components[keyset]; found {
+		return component
+	}
+	if etcdCluster := strings.TrimPrefix(keyset, "etcd-manager-ca-"); etcdCluster != keyset {
+		return "etcd-manager CA (" + etcdCluster + ")"
+	}
+	if etcdCluster := strings.TrimPrefix(keyset, "etcd-peers-ca-"); etcdCluster != keyset {
+		return "etcd peers CA (" + etcdCluster + ")"
+	}
+	return ""
+}
+
+// List returns the certificates in all the keypairs of the keystore, sorted by keyset and id
+func List(keyStore fi.CAStore) ([]*Entry, error) {
+	keysets, err := keyStore.ListKeysets()
+	if err != nil {
+		return nil, fmt.Errorf("error listing Keysets: %v", err)
+	}
+
+	var names []string
+	for _, keyset := range keysets {
+		if keyset.Spec.Type == kops.SecretTypeKeypair {
+			names = append(names, keyset.Name)
+		}
+	}
+	sort.Strings(names)
+
+	var entries []*Entry
+	for _, name := range names {
+		keyset, err := keyStore.FindCertificateKeyset(name)
+		if err != nil {
+			return nil, err
+		}
+		if keyset == nil {
+			continue
+		}
+		keysetEntries, err := FromKeyset(keyset)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, keysetEntries...)
+	}
+	return entries, nil
+}
+
+// FromKeyset decodes the certificates in the keyset, sorted by id
+func FromKeyset(keyset *kops.Keyset) ([]*Entry, error) {
+	primary := fi.FindPrimary(keyset)
+
+	var entries []*Entry
+	for _, item := range keyset.Spec.Keys {
+		if len(item.PublicMaterial) == 0 {
+			continue
+		}
+		cert, err := pki.ParsePEMCertificate(item.PublicMaterial)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing certificate %q in keyset %q: %v", item.Id, keyset.Name, err)
+		}
+
+		isPrimary := primary != nil && primary.Id == item.Id
+		entries = append(entries, &Entry{
+			Keyset:    keyset.Name,
+			ID:        item.Id,
+			Component: Component(keyset.Name),
+			Subject:   cert.Certificate.Subject.String(),
+			Issuer:    cert.Certificate.Issuer.String(),
+			Serial:    cert.Certificate.SerialNumber.String(),
+			NotAfter:  cert.Certificate.NotAfter,
+			Primary:   isPrimary,
+			InUse:     isPrimary || keyset.Spec.Rotation != nil,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+// ExpiringWithin returns the entries which expire before now plus the duration, including those which have expired
+func ExpiringWithin(entries []*Entry, within time.Duration, now time.Time) []*Entry {
+	deadline := now.Add(within)
+	var expiring []*Entry
+	for _, entry := range entries {
+		if entry.NotAfter.Before(deadline) {
+			expiring = append(expiring, entry)
+		}
+	}
+	return expiring
+}
+
+// ParseDuration parses a duration, additionally accepting a number of days such as "30d"
+func ParseDuration(s string) (time.Duration, error) {
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificates
+
+import (
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/pki"
+)
+
+func issueCA(t *testing.T, serial int64, validity time.Duration) []byte {
+	cert, _, _, err := pki.IssueCert(&pki.IssueCertRequest{
+		Type:     "ca",
+		Subject:  pkix.Name{CommonName: "kubernetes"},
+		Serial:   big.NewInt(serial),
+		Validity: validity,
+	}, nil)
+	if err != nil {
+		t.Fatalf("error issuing certificate: %v", err)
+	}
+	data, err := cert.AsBytes()
+	if err != nil {
+		t.Fatalf("error serializing certificate: %v", err)
+	}
+	return data
+}
+
+func TestFromKeyset(t *testing.T) {
+	keyset := &kops.Keyset{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca"},
+		Spec: kops.KeysetSpec{
+			Type: kops.SecretTypeKeypair,
+			Keys: []kops.KeysetItem{
+				{Id: "2", PublicMaterial: issueCA(t, 2, 10*24*time.Hour)},
+				{Id: "1", PublicMaterial: issueCA(t, 1, 100*24*time.Hour)},
+				{Id: "0"},
+			},
+		},
+	}
+
+	entries, err := FromKeyset(keyset)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		id := []string{"1", "2"}[i]
+		if entry.ID != id || entry.Serial != id {
+			t.Errorf("entry %d has id %q and serial %q, expected %q", i, entry.ID, entry.Serial, id)
+		}
+		if entry.Component != "Kubernetes CA" {
+			t.Errorf("unexpected component %q", entry.Component)
+		}
+		if entry.Subject != "CN=kubernetes" || entry.Issuer != "CN=kubernetes" {
+			t.Errorf("unexpected subject %q and issuer %q", entry.Subject, entry.Issuer)
+		}
+		inUse := id == "2"
+		if entry.Primary != inUse || entry.InUse != inUse {
+			t.Errorf("entry %q has primary %v and in use %v, expected %v", id, entry.Primary, entry.InUse, inUse)
+		}
+	}
+
+	now := time.Now()
+	expiring := ExpiringWithin(entries, 30*24*time.Hour, now)
+	if len(expiring) != 1 || expiring[0].ID != "2" {
+		t.Errorf("unexpected expiring entries %v", expiring)
+	}
+	if expiring := ExpiringWithin(entries, 0, now); len(expiring) != 0 {
+		t.Errorf("unexpected expiring entries %v", expiring)
+	}
+
+	keyset.Spec.Rotation = &kops.KeysetRotation{Stage: kops.KeysetRotationStageAdded, PreviousId: "1", NewId: "2"}
+	entries, err = FromKeyset(keyset)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, entry := range entries {
+		if !entry.InUse {
+			t.Errorf("expected %q to be in use during a rotation", entry.ID)
+		}
+	}
+}
+
+func TestComponent(t *testing.T) {
+	grid := map[string]string{
+		"ca":                   "Kubernetes CA",
+		"kubelet":              "kubelet client",
+		"etcd-manager-ca-main": "etcd-manager CA (main)",
+		"etcd-peers-ca-events": "etcd peers CA (events)",
+		"etcd-clients-ca":      "etcd clients CA",
+		"something-else":       "",
+	}
+	for keyset, expected := range grid {
+		if actual := Component(keyset); actual != expected {
+			t.Errorf("Component(%q) = %q, expected %q", keyset, actual, expected)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	grid := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"0d":  0,
+		"12h": 12 * time.Hour,
+		"1m":  time.Minute,
+	}
+	for s, expected := range grid {
+		actual, err := ParseDuration(s)
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", s, err)
+		} else if actual != expected {
+			t.Errorf("ParseDuration(%q) = %v, expected %v", s, actual, expected)
+		}
+	}
+	for _, s := range []string{"", "d", "-1d", "1.5d", "thirty"} {
+		if _, err := ParseDuration(s); err == nil {
+			t.Errorf("expected error parsing %q", s)
+		}
+	}
+}
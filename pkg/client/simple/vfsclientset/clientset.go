@@ -164,6 +164,9 @@ func DeleteAllClusterState(basePath vfs.Path) error {
 		if strings.HasPrefix(relativePath, "manifests/") {
 			continue
 		}
+		if strings.HasPrefix(relativePath, "rolling-update/") {
+			continue
+		}
 		// TODO: offer an option _not_ to delete backups?
 		if strings.HasPrefix(relativePath, "backups/") {
 			continue
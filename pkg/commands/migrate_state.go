@@ -0,0 +1,248 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kops/pkg/acls"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/util/pkg/vfs"
+	"sigs.k8s.io/yaml"
+)
+
+// MigrateStateOptions holds the options for migrating the state of a cluster to another state store
+type MigrateStateOptions struct {
+	ClusterName string
+	// From is the state store the cluster is migrated from
+	From string
+	// To is the state store the cluster is migrated to
+	To string
+	// Yes copies the files; otherwise the files which would be copied are listed
+	Yes bool
+}
+
+// clusterStoreFields are the fields of the cluster spec which hold paths into the state store
+var clusterStoreFields = []string{"configBase", "configStore", "keyStore", "secretStore"}
+
+// RunMigrateState copies the state of a cluster, including its instance groups, addons, keystore,
+// secrets, SSH keys and mirrored data, from one state store to another.
+func RunMigrateState(ctx context.Context, out io.Writer, options *MigrateStateOptions) error {
+	if options.ClusterName == "" {
+		return fmt.Errorf("ClusterName is required")
+	}
+	if options.From == "" {
+		return fmt.Errorf("the state store to migrate from is required")
+	}
+	if options.To == "" {
+		return fmt.Errorf("the state store to migrate to is required")
+	}
+
+	fromRoot, err := vfs.Context.BuildVfsPath(options.From)
+	if err != nil {
+		return fmt.Errorf("error parsing state store %q: %v", options.From, err)
+	}
+	toRoot, err := vfs.Context.BuildVfsPath(options.To)
+	if err != nil {
+		return fmt.Errorf("error parsing state store %q: %v", options.To, err)
+	}
+	if fromRoot.Path() == toRoot.Path() {
+		return fmt.Errorf("cannot migrate state store %q to itself", options.From)
+	}
+
+	cluster, err := vfsclientset.NewVFSClientset(fromRoot).GetCluster(ctx, options.ClusterName)
+	if err != nil {
+		return fmt.Errorf("error reading cluster %q from %q: %v", options.ClusterName, options.From, err)
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %q not found in %q", options.ClusterName, options.From)
+	}
+
+	return MigrateState(out, cluster, fromRoot.Join(options.ClusterName), toRoot.Join(options.ClusterName), options.Yes)
+}
+
+// MigrateState copies all files under fromBase to toBase, rewriting the state store paths in the cluster spec,
+// and verifies the copied files. Unless yes is set, only the files which would be copied are listed.
+func MigrateState(out io.Writer, cluster *api.Cluster, fromBase vfs.Path, toBase vfs.Path, yes bool) error {
+	files, err := fromBase.ReadTree()
+	if err != nil {
+		return fmt.Errorf("error listing %q: %v", fromBase, err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files found in %q", fromBase)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path() < files[j].Path()
+	})
+
+	if existing, err := toBase.Join(registry.PathCluster).ReadFile(); err == nil && len(existing) != 0 {
+		return fmt.Errorf("state for the cluster already exists in %q", toBase)
+	} else if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error checking for existing state in %q: %v", toBase, err)
+	}
+
+	// The ACLs of the copied files are computed for the migrated cluster
+	migrated := cluster.DeepCopy()
+	migrated.Spec.ConfigBase = rewriteStorePath(migrated.Spec.ConfigBase, fromBase, toBase)
+	migrated.Spec.ConfigStore = rewriteStorePath(migrated.Spec.ConfigStore, fromBase, toBase)
+	migrated.Spec.KeyStore = rewriteStorePath(migrated.Spec.KeyStore, fromBase, toBase)
+	migrated.Spec.SecretStore = rewriteStorePath(migrated.Spec.SecretStore, fromBase, toBase)
+
+	type copiedFile struct {
+		dest vfs.Path
+		data []byte
+	}
+	var copied []copiedFile
+
+	for _, src := range files {
+		relativePath, err := vfs.RelativePath(fromBase, src)
+		if err != nil {
+			return err
+		}
+		dest := toBase.Join(relativePath)
+
+		if !yes {
+			fmt.Fprintf(out, "%s -> %s\n", src.Path(), dest.Path())
+			continue
+		}
+
+		data, err := src.ReadFile()
+		if err != nil {
+			return fmt.Errorf("error reading %q: %v", src, err)
+		}
+		if relativePath == registry.PathCluster || relativePath == registry.PathClusterCompleted {
+			data, err = rewriteClusterStorePaths(out, data, fromBase, toBase)
+			if err != nil {
+				return fmt.Errorf("error rewriting %q: %v", src, err)
+			}
+		}
+
+		acl, err := acls.GetACL(dest, migrated)
+		if err != nil {
+			return err
+		}
+		klog.V(2).Infof("copying %s to %s", src, dest)
+		if err := dest.WriteFile(bytes.NewReader(data), acl); err != nil {
+			return fmt.Errorf("error writing %q: %v", dest, err)
+		}
+		copied = append(copied, copiedFile{dest: dest, data: data})
+	}
+
+	if !yes {
+		fmt.Fprintf(out, "\nMust specify --yes to copy %d files\n", len(files))
+		return nil
+	}
+
+	// Listing the destination populates the hashes of the files for stores such as S3
+	written, err := toBase.ReadTree()
+	if err != nil {
+		return fmt.Errorf("error listing %q: %v", toBase, err)
+	}
+	writtenByPath := make(map[string]vfs.Path)
+	for _, p := range written {
+		writtenByPath[p.Path()] = p
+	}
+	for _, f := range copied {
+		p := writtenByPath[f.dest.Path()]
+		if p == nil {
+			return fmt.Errorf("file %q was not found after copying", f.dest)
+		}
+		if err := verifyCopy(p, f.data); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "Copied and verified %d files from %s to %s\n", len(copied), fromBase.Path(), toBase.Path())
+	fmt.Fprintf(out, "\nThe nodes of the cluster still read their configuration from %s.\n", fromBase.Path())
+	fmt.Fprintf(out, "Run kops update cluster --yes and kops rolling-update cluster --yes with the new state store,\n")
+	fmt.Fprintf(out, "and keep the old state store until all nodes have been replaced.\n")
+	return nil
+}
+
+// verifyCopy checks the contents of the copied file, using its hash if the store provides one
+func verifyCopy(p vfs.Path, expected []byte) error {
+	if hasHash, ok := p.(vfs.HasHash); ok {
+		actual, err := hasHash.PreferredHash()
+		if err != nil {
+			return fmt.Errorf("error getting hash of %q: %v", p, err)
+		}
+		if actual != nil {
+			expectedHash, err := actual.Algorithm.Hash(bytes.NewReader(expected))
+			if err != nil {
+				return err
+			}
+			if actual.Equal(expectedHash) {
+				return nil
+			}
+			// The hash may not be of the contents, for example for S3 objects encrypted with KMS
+			klog.V(2).Infof("hash of %s did not match; comparing contents", p)
+		}
+	}
+
+	actual, err := p.ReadFile()
+	if err != nil {
+		return fmt.Errorf("error reading %q: %v", p, err)
+	}
+	if !bytes.Equal(actual, expected) {
+		return fmt.Errorf("contents of %q do not match the copied file", p)
+	}
+	return nil
+}
+
+// rewriteClusterStorePaths rewrites the state store paths in a serialized cluster which are under fromBase
+func rewriteClusterStorePaths(out io.Writer, data []byte, fromBase vfs.Path, toBase vfs.Path) ([]byte, error) {
+	obj := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+	for _, field := range clusterStoreFields {
+		s, ok := spec[field].(string)
+		if !ok || s == "" {
+			continue
+		}
+		rewritten := rewriteStorePath(s, fromBase, toBase)
+		if rewritten == s {
+			fmt.Fprintf(out, "Warning: spec.%s %q is not in %s and is not migrated\n", field, s, fromBase.Path())
+			continue
+		}
+		spec[field] = rewritten
+	}
+	return yaml.Marshal(obj)
+}
+
+// rewriteStorePath replaces fromBase with toBase in s, if s is fromBase or a path under it
+func rewriteStorePath(s string, fromBase vfs.Path, toBase vfs.Path) string {
+	from := strings.TrimSuffix(fromBase.Path(), "/")
+	to := strings.TrimSuffix(toBase.Path(), "/")
+	if s == from || strings.HasPrefix(s, from+"/") {
+		return to + strings.TrimPrefix(s, from)
+	}
+	return s
+}
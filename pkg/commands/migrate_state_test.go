@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestMigrateState(t *testing.T) {
+	vfs.Context.ResetMemfsContext(true)
+
+	fromBase, err := vfs.Context.BuildVfsPath("memfs://old/cluster.example.com")
+	if err != nil {
+		t.Fatalf("error building path: %v", err)
+	}
+	toBase, err := vfs.Context.BuildVfsPath("memfs://new/cluster.example.com")
+	if err != nil {
+		t.Fatalf("error building path: %v", err)
+	}
+
+	config := "apiVersion: kops.k8s.io/v1alpha2\nkind: Cluster\nmetadata:\n  name: cluster.example.com\nspec:\n" +
+		"  configBase: memfs://old/cluster.example.com\n" +
+		"  keyStore: memfs://old/cluster.example.com/pki\n" +
+		"  secretStore: memfs://elsewhere/secrets\n"
+	files := map[string]string{
+		"config":                        config,
+		"cluster.spec":                  "metadata:\n  name: cluster.example.com\nspec:\n  configBase: memfs://old/cluster.example.com\n",
+		"instancegroup/nodes":           "kind: InstanceGroup\n",
+		"pki/private/ca/keyset.yaml":    "private key material",
+		"secrets/admin":                 "secret",
+		"addons/bootstrap-channel.yaml": "kind: Addons\n",
+	}
+	for name, contents := range files {
+		if err := fromBase.Join(name).WriteFile(strings.NewReader(contents), nil); err != nil {
+			t.Fatalf("error writing %s: %v", name, err)
+		}
+	}
+
+	cluster := &api.Cluster{}
+	cluster.Name = "cluster.example.com"
+	cluster.Spec.ConfigBase = fromBase.Path()
+
+	var out bytes.Buffer
+	if err := MigrateState(&out, cluster, fromBase, toBase, false); err != nil {
+		t.Fatalf("unexpected error in dry run: %v", err)
+	}
+	if !strings.Contains(out.String(), "memfs://old/cluster.example.com/secrets/admin -> memfs://new/cluster.example.com/secrets/admin\n") {
+		t.Errorf("expected dry run to list files, got:\n%s", out.String())
+	}
+	if _, err := toBase.Join("config").ReadFile(); err == nil {
+		t.Fatalf("expected dry run not to write files")
+	}
+
+	out.Reset()
+	if err := MigrateState(&out, cluster, fromBase, toBase, true); err != nil {
+		t.Fatalf("unexpected error migrating state: %v", err)
+	}
+	if !strings.Contains(out.String(), `spec.secretStore "memfs://elsewhere/secrets" is not in memfs://old/cluster.example.com`) {
+		t.Errorf("expected warning about the secret store, got:\n%s", out.String())
+	}
+
+	for name, contents := range files {
+		data, err := toBase.Join(name).ReadFile()
+		if err != nil {
+			t.Fatalf("error reading migrated %s: %v", name, err)
+		}
+		if name == "config" || name == "cluster.spec" {
+			continue
+		}
+		if string(data) != contents {
+			t.Errorf("unexpected contents of migrated %s: %q", name, data)
+		}
+	}
+
+	data, err := toBase.Join("config").ReadFile()
+	if err != nil {
+		t.Fatalf("error reading migrated config: %v", err)
+	}
+	for _, expected := range []string{
+		"configBase: memfs://new/cluster.example.com\n",
+		"keyStore: memfs://new/cluster.example.com/pki\n",
+		"secretStore: memfs://elsewhere/secrets\n",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected migrated config to contain %q, got:\n%s", expected, data)
+		}
+	}
+
+	if err := MigrateState(&out, cluster, fromBase, toBase, true); err == nil {
+		t.Errorf("expected error migrating to a state store which already has the cluster")
+	}
+}
+
+func TestRewriteStorePath(t *testing.T) {
+	vfs.Context.ResetMemfsContext(true)
+	fromBase, _ := vfs.Context.BuildVfsPath("memfs://old/cluster.example.com")
+	toBase, _ := vfs.Context.BuildVfsPath("memfs://new/cluster.example.com")
+
+	grid := []struct {
+		path     string
+		expected string
+	}{
+		{path: "memfs://old/cluster.example.com", expected: "memfs://new/cluster.example.com"},
+		{path: "memfs://old/cluster.example.com/pki", expected: "memfs://new/cluster.example.com/pki"},
+		{path: "memfs://old/cluster.example.com.other", expected: "memfs://old/cluster.example.com.other"},
+		{path: "s3://bucket/cluster.example.com", expected: "s3://bucket/cluster.example.com"},
+	}
+	for _, g := range grid {
+		if actual := rewriteStorePath(g.path, fromBase, toBase); actual != g.expected {
+			t.Errorf("rewriteStorePath(%q) = %q, expected %q", g.path, actual, g.expected)
+		}
+	}
+}
@@ -65,7 +65,21 @@ func (s *configserverKeyStore) CertificatePool(name string, createIfMissing bool
 
 // FindCertificatePool implements fi.CAStore
 func (s *configserverKeyStore) FindCertificatePool(name string) (*fi.CertificatePool, error) {
-	return nil, fmt.Errorf("FindCertificatePool not supported by configserverKeyStore")
+	for _, cert := range s.nodeConfig.Certificates {
+		if cert.Name == name {
+			// The primary certificate is first, followed by any others which should also be trusted
+			certs, err := pki.ParsePEMCertificates([]byte(cert.Cert))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing certificate %q: %w", name, err)
+			}
+			return &fi.CertificatePool{
+				Primary:   certs[0],
+				Secondary: certs[1:],
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("FindCertificatePool(%q) not supported by configserverKeyStore", name)
 }
 
 // FindCertificateKeyset implements fi.CAStore
@@ -113,3 +127,8 @@ func (s *configserverKeyStore) AddCert(name string, cert *pki.Certificate) error
 func (s *configserverKeyStore) DeleteKeysetItem(item *kops.Keyset, id string) error {
 	return fmt.Errorf("DeleteKeysetItem not supported by configserverKeyStore")
 }
+
+// UpdateKeysetRotation implements fi.CAStore
+func (s *configserverKeyStore) UpdateKeysetRotation(name string, primaryID string, rotation *kops.KeysetRotation) error {
+	return fmt.Errorf("UpdateKeysetRotation not supported by configserverKeyStore")
+}
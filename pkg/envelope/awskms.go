@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// awsKMSKEK is a symmetric AWS KMS key, identified by the ARN of the key or of an alias
+type awsKMSKEK struct {
+	uri    string
+	keyARN string
+	client *kms.KMS
+}
+
+var _ KEK = &awsKMSKEK{}
+
+func newAWSKMSKEK(uri string) (KEK, error) {
+	keyARN := strings.TrimPrefix(uri, "awskms://")
+	region, err := awsKMSRegion(keyARN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encryption key %q: %v", uri, err)
+	}
+
+	config := aws.NewConfig().WithRegion(region).WithCredentialsChainVerboseErrors(true)
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error starting new AWS session: %v", err)
+	}
+
+	return &awsKMSKEK{
+		uri:    uri,
+		keyARN: keyARN,
+		client: kms.New(sess, config),
+	}, nil
+}
+
+// awsKMSRegion returns the region of a KMS key or alias ARN
+func awsKMSRegion(keyARN string) (string, error) {
+	// arn:<partition>:kms:<region>:<account>:key/<id> or arn:<partition>:kms:<region>:<account>:alias/<name>
+	tokens := strings.SplitN(keyARN, ":", 6)
+	if len(tokens) != 6 || tokens[0] != "arn" || tokens[2] != "kms" || tokens[3] == "" {
+		return "", fmt.Errorf("expected the ARN of a KMS key or alias")
+	}
+	if !strings.HasPrefix(tokens[5], "key/") && !strings.HasPrefix(tokens[5], "alias/") {
+		return "", fmt.Errorf("expected the ARN of a KMS key or alias")
+	}
+	return tokens[3], nil
+}
+
+// URI implements KEK
+func (k *awsKMSKEK) URI() string {
+	return k.uri
+}
+
+// Encrypt implements KEK
+func (k *awsKMSKEK) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	response, err := k.client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:     aws.String(k.keyARN),
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.CiphertextBlob, nil
+}
+
+// Decrypt implements KEK
+func (k *awsKMSKEK) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	input := &kms.DecryptInput{
+		CiphertextBlob: ciphertext,
+	}
+	// Decrypt only accepts a key ARN, not an alias; the key is recorded in the ciphertext anyway
+	if strings.Contains(k.keyARN, ":key/") {
+		input.KeyId = aws.String(k.keyARN)
+	}
+	response, err := k.client.DecryptWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return response.Plaintext, nil
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package envelope implements client-side envelope encryption of the key material kOps writes to the state store.
+// Data is encrypted with a random data encryption key, which is itself encrypted by a key encryption key (KEK)
+// held by a pluggable provider such as a KMS. The encrypted data key and the URI of the KEK are stored with the data,
+// so that anything with access to the KEK, which is configured for the cluster, can decrypt it.
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+// envelopeVersion is the version of the envelope format
+const envelopeVersion = "v1"
+
+// envelopePrefix is the start of every serialized envelope, used to tell envelopes from plaintext
+var envelopePrefix = []byte(`{"kopsEnvelope":`)
+
+// KEK is a key encryption key, which encrypts and decrypts data encryption keys
+type KEK interface {
+	// URI identifies the key encryption key. It is recorded in envelopes so that they can be opened.
+	URI() string
+	// Encrypt encrypts a data encryption key
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	// Decrypt decrypts a data encryption key
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// Provider builds the KEK with the given URI
+type Provider func(uri string) (KEK, error)
+
+// envelope is the serialized form of encrypted data
+type envelope struct {
+	Version string `json:"kopsEnvelope"`
+	// KEK is the URI of the key encryption key
+	KEK string `json:"kek"`
+	// EncryptedKey is the data encryption key, encrypted by the KEK
+	EncryptedKey []byte `json:"encryptedKey"`
+	// Nonce is the AES-GCM nonce
+	Nonce []byte `json:"nonce"`
+	// Ciphertext is the data, encrypted by the data encryption key with AES-GCM
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+var (
+	providersMutex sync.Mutex
+	providers      = map[string]Provider{
+		"file":   newFileKEK,
+		"pgp":    newPGPKEK,
+		"awskms": newAWSKMSKEK,
+		"gcpkms": newGCPKMSKEK,
+		"vault":  newVaultKEK,
+	}
+	keks = make(map[string]KEK)
+)
+
+// RegisterProvider registers the provider for KEK URIs with the scheme, replacing any existing provider
+func RegisterProvider(scheme string, provider Provider) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+
+	providers[scheme] = provider
+	for uri := range keks {
+		if strings.HasPrefix(uri, scheme+"://") {
+			delete(keks, uri)
+		}
+	}
+}
+
+// NewKEK returns the KEK with the given URI. KEKs are cached, so that credentials are only loaded once.
+func NewKEK(uri string) (KEK, error) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+
+	if kek := keks[uri]; kek != nil {
+		return kek, nil
+	}
+
+	tokens := strings.SplitN(uri, "://", 2)
+	if len(tokens) != 2 || tokens[1] == "" {
+		return nil, fmt.Errorf("invalid key encryption key %q: expected <provider>://<key>", uri)
+	}
+	provider := providers[tokens[0]]
+	if provider == nil {
+		return nil, fmt.Errorf("unknown key encryption key provider %q in %q", tokens[0], uri)
+	}
+	kek, err := provider(uri)
+	if err != nil {
+		return nil, err
+	}
+	keks[uri] = kek
+	return kek, nil
+}
+
+// ValidateKEK checks that the URI of a KEK is well-formed, without accessing the KEK
+func ValidateKEK(uri string) error {
+	tokens := strings.SplitN(uri, "://", 2)
+	if len(tokens) != 2 || tokens[1] == "" {
+		return fmt.Errorf("expected <provider>://<key>")
+	}
+	switch tokens[0] {
+	case "file", "pgp":
+		return nil
+	case "awskms":
+		_, err := awsKMSRegion(tokens[1])
+		return err
+	case "gcpkms":
+		return validateGCPKMSKeyName(tokens[1])
+	case "vault":
+		_, _, err := parseVaultURI(uri)
+		return err
+	}
+
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+	if providers[tokens[0]] == nil {
+		return fmt.Errorf("unknown provider %q", tokens[0])
+	}
+	return nil
+}
+
+// ForCluster returns the KEK configured for the cluster, or nil if state store encryption is not enabled
+func ForCluster(cluster *kops.Cluster) (KEK, error) {
+	if cluster == nil || cluster.Spec.StateStoreEncryption == nil || cluster.Spec.StateStoreEncryption.KeyEncryptionKey == "" {
+		return nil, nil
+	}
+	return NewKEK(cluster.Spec.StateStoreEncryption.KeyEncryptionKey)
+}
+
+// IsEncrypted returns true if the data is an envelope
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, envelopePrefix)
+}
+
+// Seal encrypts the data with a new data encryption key, which is encrypted by the KEK
+func Seal(ctx context.Context, kek KEK, plaintext []byte) ([]byte, error) {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("error generating data encryption key: %v", err)
+	}
+
+	nonce, ciphertext, err := aesGCMSeal(dek, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKey, err := kek.Encrypt(ctx, dek)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting data encryption key with %q: %v", kek.URI(), err)
+	}
+
+	return json.Marshal(&envelope{
+		Version:      envelopeVersion,
+		KEK:          kek.URI(),
+		EncryptedKey: encryptedKey,
+		Nonce:        nonce,
+		Ciphertext:   ciphertext,
+	})
+}
+
+// Open decrypts an envelope with the KEK recorded in it, which must be one of the KEKs configured for the cluster.
+// The recorded KEK is never used otherwise, as anyone able to write to the state store could then choose where
+// credentials for the KEK are sent. Data which is not an envelope is returned unchanged, so that data written
+// before encryption was enabled remains readable.
+func Open(ctx context.Context, cluster *kops.Cluster, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	e, err := parseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, uri := range clusterKEKs(cluster) {
+		if e.KEK == uri {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("data is encrypted with key encryption key %q, which is not configured in spec.stateStoreEncryption of the cluster", e.KEK)
+	}
+
+	kek, err := NewKEK(e.KEK)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := kek.Decrypt(ctx, e.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data encryption key with %q: %v", e.KEK, err)
+	}
+
+	return aesGCMOpen(dek, e.Nonce, e.Ciphertext)
+}
+
+// SealedWith returns the URI of the KEK recorded in an envelope
+func SealedWith(data []byte) (string, error) {
+	e, err := parseEnvelope(data)
+	if err != nil {
+		return "", err
+	}
+	return e.KEK, nil
+}
+
+func parseEnvelope(data []byte) (*envelope, error) {
+	e := &envelope{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("error parsing envelope: %v", err)
+	}
+	if e.Version != envelopeVersion {
+		return nil, fmt.Errorf("unsupported envelope version %q", e.Version)
+	}
+	return e, nil
+}
+
+// clusterKEKs returns the URIs of the KEKs which may have encrypted the key material of the cluster:
+// the configured KEK, and any previous KEKs
+func clusterKEKs(cluster *kops.Cluster) []string {
+	if cluster == nil || cluster.Spec.StateStoreEncryption == nil {
+		return nil
+	}
+	encryption := cluster.Spec.StateStoreEncryption
+	var uris []string
+	if encryption.KeyEncryptionKey != "" {
+		uris = append(uris, encryption.KeyEncryptionKey)
+	}
+	return append(uris, encryption.PreviousKeyEncryptionKeys...)
+}
+
+// SealForCluster encrypts data with the KEK configured for the cluster.
+// The data is returned unchanged if state store encryption is not enabled.
+func SealForCluster(ctx context.Context, cluster *kops.Cluster, plaintext []byte) ([]byte, error) {
+	kek, err := ForCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if kek == nil {
+		return plaintext, nil
+	}
+	return Seal(ctx, kek, plaintext)
+}
+
+func aesGCMSeal(key []byte, plaintext []byte) ([]byte, []byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("error generating nonce: %v", err)
+	}
+	return nonce, gcm.Seal(nil, nonce, plaintext, nil), nil
+}
+
+func aesGCMOpen(key []byte, nonce []byte, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data encryption key: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting data: %v", err)
+	}
+	return plaintext, nil
+}
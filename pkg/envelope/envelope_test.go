@@ -0,0 +1,285 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+func writeKeyFile(t *testing.T, dir string, name string, key []byte) string {
+	p := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(p, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600); err != nil {
+		t.Fatalf("error writing key file: %v", err)
+	}
+	return "file://" + p
+}
+
+func TestSealOpen(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+	uri := writeKeyFile(t, dir, "kek", bytes.Repeat([]byte{1}, 32))
+
+	cluster := &kops.Cluster{}
+	if kek, err := ForCluster(cluster); err != nil || kek != nil {
+		t.Fatalf("expected no KEK without state store encryption, got %v, %v", kek, err)
+	}
+	plaintext := []byte("apiVersion: kops.k8s.io/v1alpha2\nkind: Keyset\n")
+	unencrypted, err := SealForCluster(ctx, cluster, plaintext)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(unencrypted, plaintext) {
+		t.Errorf("expected data to be unchanged without state store encryption")
+	}
+
+	cluster.Spec.StateStoreEncryption = &kops.StateStoreEncryptionSpec{KeyEncryptionKey: uri}
+	sealed, err := SealForCluster(ctx, cluster, plaintext)
+	if err != nil {
+		t.Fatalf("unexpected error sealing: %v", err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Fatalf("expected sealed data to be an envelope: %s", sealed)
+	}
+	if bytes.Contains(sealed, []byte("Keyset")) {
+		t.Errorf("sealed data contains the plaintext: %s", sealed)
+	}
+
+	opened, err := Open(ctx, cluster, sealed)
+	if err != nil {
+		t.Fatalf("unexpected error opening: %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("unexpected opened data %q", opened)
+	}
+
+	// Plaintext written before encryption was enabled is still readable
+	opened, err = Open(ctx, cluster, plaintext)
+	if err != nil {
+		t.Fatalf("unexpected error opening plaintext: %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("unexpected opened plaintext %q", opened)
+	}
+
+	// Tampering is detected
+	tampered := bytes.Replace(sealed, []byte(`"ciphertext":"`), []byte(`"ciphertext":"AAAA`), 1)
+	if _, err := Open(ctx, cluster, tampered); err == nil {
+		t.Errorf("expected error opening tampered envelope")
+	}
+}
+
+func TestOpenWithWrongKey(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+	uri := writeKeyFile(t, dir, "kek", bytes.Repeat([]byte{1}, 32))
+
+	kek, err := NewKEK(uri)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sealed, err := Seal(ctx, kek, []byte("secret"))
+	if err != nil {
+		t.Fatalf("unexpected error sealing: %v", err)
+	}
+
+	// The cached KEK is dropped when the provider is registered again, so the key is reread
+	writeKeyFile(t, dir, "kek", bytes.Repeat([]byte{2}, 32))
+	RegisterProvider("file", newFileKEK)
+	cluster := &kops.Cluster{}
+	cluster.Spec.StateStoreEncryption = &kops.StateStoreEncryptionSpec{KeyEncryptionKey: uri}
+	if _, err := Open(ctx, cluster, sealed); err == nil {
+		t.Errorf("expected error opening with the wrong key")
+	}
+}
+
+// recordingKEK records whether it was used
+type recordingKEK struct {
+	uri  string
+	used bool
+}
+
+func (k *recordingKEK) URI() string {
+	return k.uri
+}
+
+func (k *recordingKEK) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	k.used = true
+	return plaintext, nil
+}
+
+func (k *recordingKEK) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	k.used = true
+	return ciphertext, nil
+}
+
+func TestOpenWithMismatchedKEK(t *testing.T) {
+	ctx := context.TODO()
+
+	configured := &recordingKEK{uri: "recording://configured"}
+	previous := &recordingKEK{uri: "recording://previous"}
+	planted := &recordingKEK{uri: "recording://planted"}
+	RegisterProvider("recording", func(uri string) (KEK, error) {
+		for _, kek := range []*recordingKEK{configured, previous, planted} {
+			if kek.uri == uri {
+				return kek, nil
+			}
+		}
+		t.Fatalf("unexpected KEK %q", uri)
+		return nil, nil
+	})
+
+	cluster := &kops.Cluster{}
+	cluster.Spec.StateStoreEncryption = &kops.StateStoreEncryptionSpec{
+		KeyEncryptionKey:          configured.uri,
+		PreviousKeyEncryptionKeys: []string{previous.uri},
+	}
+
+	for _, kek := range []*recordingKEK{configured, previous} {
+		sealed, err := Seal(ctx, kek, []byte("secret"))
+		if err != nil {
+			t.Fatalf("unexpected error sealing: %v", err)
+		}
+		if _, err := Open(ctx, cluster, sealed); err != nil {
+			t.Errorf("unexpected error opening envelope encrypted with %q: %v", kek.uri, err)
+		}
+	}
+
+	sealed, err := Seal(ctx, planted, []byte("secret"))
+	if err != nil {
+		t.Fatalf("unexpected error sealing: %v", err)
+	}
+	if uri, err := SealedWith(sealed); err != nil || uri != planted.uri {
+		t.Fatalf("expected envelope to record %q, got %q, %v", planted.uri, uri, err)
+	}
+	planted.used = false
+	if _, err := Open(ctx, cluster, sealed); err == nil {
+		t.Errorf("expected error opening envelope encrypted with a KEK which is not configured")
+	}
+	if planted.used {
+		t.Errorf("KEK which is not configured was used")
+	}
+
+	// Without state store encryption, no envelope can be opened
+	if _, err := Open(ctx, &kops.Cluster{}, sealed); err == nil {
+		t.Errorf("expected error opening envelope without state store encryption")
+	}
+}
+
+func TestPGPKEK(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+
+	entity, err := openpgp.NewEntity("kops", "", "kops@example.com", &packet.Config{DefaultHash: crypto.SHA256})
+	if err != nil {
+		t.Fatalf("error generating OpenPGP key: %v", err)
+	}
+	var private bytes.Buffer
+	w, err := armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatalf("error serializing private key: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var public bytes.Buffer
+	if err := entity.Serialize(&public); err != nil {
+		t.Fatalf("error serializing public key: %v", err)
+	}
+	privatePath := filepath.Join(dir, "private.asc")
+	if err := ioutil.WriteFile(privatePath, private.Bytes(), 0600); err != nil {
+		t.Fatalf("error writing key file: %v", err)
+	}
+	publicPath := filepath.Join(dir, "public.gpg")
+	if err := ioutil.WriteFile(publicPath, public.Bytes(), 0600); err != nil {
+		t.Fatalf("error writing key file: %v", err)
+	}
+
+	// A public key is enough to encrypt
+	publicKEK, err := NewKEK("pgp://" + publicPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	encrypted, err := publicKEK.Encrypt(ctx, []byte("data key"))
+	if err != nil {
+		t.Fatalf("unexpected error encrypting: %v", err)
+	}
+	if _, err := publicKEK.Decrypt(ctx, encrypted); err == nil {
+		t.Errorf("expected error decrypting without the private key")
+	}
+
+	privateKEK, err := NewKEK("pgp://" + privatePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decrypted, err := privateKEK.Decrypt(ctx, encrypted)
+	if err != nil {
+		t.Fatalf("unexpected error decrypting: %v", err)
+	}
+	if string(decrypted) != "data key" {
+		t.Errorf("unexpected decrypted data %q", decrypted)
+	}
+
+	writeKeyFile(t, dir, "aes", bytes.Repeat([]byte{1}, 32))
+	if _, err := NewKEK("pgp://" + filepath.Join(dir, "aes")); err == nil {
+		t.Errorf("expected error reading a file which is not an OpenPGP key")
+	}
+}
+
+func TestValidateKEK(t *testing.T) {
+	valid := []string{
+		"file:///etc/kops/kek",
+		"pgp:///etc/kops/kek.asc",
+		"awskms://arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		"awskms://arn:aws:kms:us-east-1:123456789012:alias/kops",
+		"gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k",
+		"vault://vault.example.com:8200/transit/kops",
+	}
+	for _, uri := range valid {
+		if err := ValidateKEK(uri); err != nil {
+			t.Errorf("unexpected error validating %q: %v", uri, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"/etc/kops/kek",
+		"file://",
+		"awskms://1234abcd-12ab-34cd-56ef-1234567890ab",
+		"gcpkms://projects/p/cryptoKeys/k",
+		"vault://vault.example.com/kops",
+		"unknown://key",
+	}
+	for _, uri := range invalid {
+		if err := ValidateKEK(uri); err == nil {
+			t.Errorf("expected error validating %q", uri)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// fileKEK is a 256-bit AES key read from a local file, either as raw bytes or base64 encoded.
+// The file must be present wherever the state store is read, including on nodes.
+type fileKEK struct {
+	uri string
+	key []byte
+}
+
+var _ KEK = &fileKEK{}
+
+func newFileKEK(uri string) (KEK, error) {
+	path := strings.TrimPrefix(uri, "file://")
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key encryption key: %v", err)
+	}
+
+	key := data
+	if len(key) != 32 {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+		if err != nil || len(decoded) != 32 {
+			return nil, fmt.Errorf("key encryption key %q must contain a 256-bit key, either raw or base64 encoded", path)
+		}
+		key = decoded
+	}
+
+	return &fileKEK{uri: uri, key: key}, nil
+}
+
+// URI implements KEK
+func (k *fileKEK) URI() string {
+	return k.uri
+}
+
+// Encrypt implements KEK
+func (k *fileKEK) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	nonce, ciphertext, err := aesGCMSeal(k.key, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, ciphertext...), nil
+}
+
+// Decrypt implements KEK
+func (k *fileKEK) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	// The nonce is the same size as the standard GCM nonce
+	if len(ciphertext) < 12 {
+		return nil, fmt.Errorf("invalid encrypted key")
+	}
+	return aesGCMOpen(k.key, ciphertext[:12], ciphertext[12:])
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+const gcpKMSEndpoint = "https://cloudkms.googleapis.com/v1/"
+
+var gcpKMSKeyNameRegex = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+
+// gcpKMSKEK is a symmetric Google Cloud KMS key, called through the REST API with the application default credentials
+type gcpKMSKEK struct {
+	uri     string
+	keyName string
+	client  *http.Client
+}
+
+var _ KEK = &gcpKMSKEK{}
+
+func newGCPKMSKEK(uri string) (KEK, error) {
+	keyName := strings.TrimPrefix(uri, "gcpkms://")
+	if err := validateGCPKMSKeyName(keyName); err != nil {
+		return nil, fmt.Errorf("invalid key encryption key %q: %v", uri, err)
+	}
+
+	client, err := google.DefaultClient(context.Background(), "https://www.googleapis.com/auth/cloudkms")
+	if err != nil {
+		return nil, fmt.Errorf("error building Google Cloud credentials: %v", err)
+	}
+
+	return &gcpKMSKEK{
+		uri:     uri,
+		keyName: keyName,
+		client:  client,
+	}, nil
+}
+
+func validateGCPKMSKeyName(keyName string) error {
+	if !gcpKMSKeyNameRegex.MatchString(keyName) {
+		return fmt.Errorf("expected projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>")
+	}
+	return nil
+}
+
+// URI implements KEK
+func (k *gcpKMSKEK) URI() string {
+	return k.uri
+}
+
+// Encrypt implements KEK
+func (k *gcpKMSKEK) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	response := struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}{}
+	if err := k.call(ctx, "encrypt", map[string][]byte{"plaintext": plaintext}, &response); err != nil {
+		return nil, err
+	}
+	return response.Ciphertext, nil
+}
+
+// Decrypt implements KEK
+func (k *gcpKMSKEK) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	response := struct {
+		Plaintext []byte `json:"plaintext"`
+	}{}
+	if err := k.call(ctx, "decrypt", map[string][]byte{"ciphertext": ciphertext}, &response); err != nil {
+		return nil, err
+	}
+	return response.Plaintext, nil
+}
+
+// call invokes a method of the key. Byte slices are base64 encoded in both the request and the response.
+func (k *gcpKMSKEK) call(ctx context.Context, method string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, gcpKMSEndpoint+k.keyName+":"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := k.client.Do(httpRequest)
+	if err != nil {
+		return fmt.Errorf("error calling Cloud KMS %s: %v", method, err)
+	}
+	defer httpResponse.Body.Close()
+
+	data, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return fmt.Errorf("error reading Cloud KMS %s response: %v", method, err)
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		return fmt.Errorf("Cloud KMS %s failed with status %d: %s", method, httpResponse.StatusCode, string(data))
+	}
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("error parsing Cloud KMS %s response: %v", method, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// pgpKEK is an OpenPGP key read from a local file, either armored or binary.
+// Data encryption keys are encrypted to the public key, so a file with only the public key can encrypt,
+// but decrypting requires the private key, which must not be protected by a passphrase.
+type pgpKEK struct {
+	uri    string
+	path   string
+	entity *openpgp.Entity
+}
+
+var _ KEK = &pgpKEK{}
+
+func newPGPKEK(uri string) (KEK, error) {
+	path := strings.TrimPrefix(uri, "pgp://")
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key encryption key: %v", err)
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing OpenPGP key %q: %v", path, err)
+		}
+	}
+	if len(entities) != 1 {
+		return nil, fmt.Errorf("key encryption key %q must contain a single OpenPGP key, found %d", path, len(entities))
+	}
+	entity := entities[0]
+
+	if entity.PrivateKey != nil && entity.PrivateKey.Encrypted {
+		return nil, fmt.Errorf("OpenPGP key %q is protected by a passphrase, which is not supported", path)
+	}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			return nil, fmt.Errorf("OpenPGP key %q is protected by a passphrase, which is not supported", path)
+		}
+	}
+
+	return &pgpKEK{uri: uri, path: path, entity: entity}, nil
+}
+
+// URI implements KEK
+func (k *pgpKEK) URI() string {
+	return k.uri
+}
+
+// Encrypt implements KEK
+func (k *pgpKEK) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var ciphertext bytes.Buffer
+	w, err := openpgp.Encrypt(&ciphertext, []*openpgp.Entity{k.entity}, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting to OpenPGP key %q: %v", k.path, err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, fmt.Errorf("error encrypting to OpenPGP key %q: %v", k.path, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error encrypting to OpenPGP key %q: %v", k.path, err)
+	}
+	return ciphertext.Bytes(), nil
+}
+
+// Decrypt implements KEK
+func (k *pgpKEK) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if k.entity.PrivateKey == nil {
+		return nil, fmt.Errorf("OpenPGP key %q does not include the private key", k.path)
+	}
+	md, err := openpgp.ReadMessage(bytes.NewReader(ciphertext), openpgp.EntityList{k.entity}, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting with OpenPGP key %q: %v", k.path, err)
+	}
+	plaintext, err := ioutil.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting with OpenPGP key %q: %v", k.path, err)
+	}
+	return plaintext, nil
+}
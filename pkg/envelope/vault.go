@@ -0,0 +1,117 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package envelope
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// vaultKEK is a key of the HashiCorp Vault transit secrets engine.
+// The Vault token is read from the VAULT_TOKEN environment variable.
+type vaultKEK struct {
+	uri    string
+	mount  string
+	key    string
+	client *vault.Client
+}
+
+var _ KEK = &vaultKEK{}
+
+func newVaultKEK(uri string) (KEK, error) {
+	address, path, err := parseVaultURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encryption key %q: %v", uri, err)
+	}
+
+	config := vault.DefaultConfig()
+	if config.Error != nil {
+		return nil, fmt.Errorf("error building Vault configuration: %v", config.Error)
+	}
+	config.Address = address
+	client, err := vault.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("error building Vault client: %v", err)
+	}
+
+	lastSlash := strings.LastIndex(path, "/")
+	return &vaultKEK{
+		uri:    uri,
+		mount:  path[:lastSlash],
+		key:    path[lastSlash+1:],
+		client: client,
+	}, nil
+}
+
+// parseVaultURI parses vault://<host>[:<port>]/<transit mount>/<key> into the address of Vault and the path of the key
+func parseVaultURI(uri string) (string, string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", err
+	}
+	path := strings.Trim(u.Path, "/")
+	if u.Scheme != "vault" || u.Host == "" || !strings.Contains(path, "/") {
+		return "", "", fmt.Errorf("expected vault://<host>[:<port>]/<transit mount>/<key>")
+	}
+	return "https://" + u.Host, path, nil
+}
+
+// URI implements KEK
+func (k *vaultKEK) URI() string {
+	return k.uri
+}
+
+// Encrypt implements KEK
+func (k *vaultKEK) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	secret, err := k.client.Logical().Write(k.mount+"/encrypt/"+k.key, map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("no response from Vault")
+	}
+	ciphertext, ok := secret.Data["ciphertext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("Vault response did not contain the ciphertext")
+	}
+	return []byte(ciphertext), nil
+}
+
+// Decrypt implements KEK
+func (k *vaultKEK) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	secret, err := k.client.Logical().Write(k.mount+"/decrypt/"+k.key, map[string]interface{}{
+		"ciphertext": string(ciphertext),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("no response from Vault")
+	}
+	plaintext, ok := secret.Data["plaintext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("Vault response did not contain the plaintext")
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
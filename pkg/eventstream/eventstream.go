@@ -0,0 +1,163 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package eventstream records a machine-readable stream of the operations performed by kOps,
+// written as one JSON object per line.
+package eventstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// SchemaVersion is the version of the event schema. Fields may be added to events and new event
+// types may be introduced without changing the version; removing or changing the meaning of a field does.
+const SchemaVersion = 1
+
+// Type identifies the kind of an event.
+type Type string
+
+const (
+	// TaskStarted is recorded when the executor starts running a task.
+	TaskStarted Type = "TaskStarted"
+	// TaskCompleted is recorded when a task has run successfully.
+	TaskCompleted Type = "TaskCompleted"
+	// TaskFailed is recorded when a task returns an error. The executor may retry the task later.
+	TaskFailed Type = "TaskFailed"
+
+	// InstanceDrained is recorded when the node of an instance has been drained.
+	InstanceDrained Type = "InstanceDrained"
+	// InstanceTerminated is recorded when an instance has been terminated.
+	InstanceTerminated Type = "InstanceTerminated"
+	// InstanceValidated is recorded when the cluster has been validated after an instance was terminated.
+	InstanceValidated Type = "InstanceValidated"
+	// ValidationFailed is recorded when the cluster fails to validate during a rolling update.
+	ValidationFailed Type = "ValidationFailed"
+	// InstanceGroupCompleted is recorded when the rolling update of an instance group has completed.
+	InstanceGroupCompleted Type = "InstanceGroupCompleted"
+	// InstanceGroupFailed is recorded when the rolling update of an instance group has failed.
+	InstanceGroupFailed Type = "InstanceGroupFailed"
+)
+
+// Event is a single entry of the event stream. Fields which do not apply to the type of the event are omitted.
+type Event struct {
+	// Version is the SchemaVersion of the event.
+	Version int `json:"version"`
+	// Time is when the event occurred.
+	Time time.Time `json:"time"`
+	// Type is the kind of the event.
+	Type Type `json:"type"`
+
+	// Task is the key of the task, for task events.
+	Task string `json:"task,omitempty"`
+	// TaskType is the type of the task, for task events.
+	TaskType string `json:"taskType,omitempty"`
+	// DurationSeconds is how long the task ran, for TaskCompleted and TaskFailed events.
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+
+	// InstanceGroup is the name of the instance group, for rolling update events.
+	InstanceGroup string `json:"instanceGroup,omitempty"`
+	// InstanceID is the cloud provider ID of the instance, for instance events.
+	InstanceID string `json:"instanceID,omitempty"`
+	// NodeName is the name of the kubernetes node of the instance, if it has one.
+	NodeName string `json:"nodeName,omitempty"`
+
+	// Error is the error message of failure events.
+	Error string `json:"error,omitempty"`
+}
+
+// Recorder receives events. Implementations must be safe for concurrent use.
+type Recorder interface {
+	Record(event Event)
+}
+
+// Record sends the event to the recorder, which may be nil.
+func Record(recorder Recorder, event Event) {
+	if recorder == nil {
+		return
+	}
+	recorder.Record(event)
+}
+
+// Writer is a Recorder which writes events as JSON lines.
+type Writer struct {
+	mutex  sync.Mutex
+	out    io.Writer
+	closer io.Closer
+	failed bool
+}
+
+var _ Recorder = &Writer{}
+
+// NewWriter builds a Writer writing to out.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Open builds a Writer writing to the file at path, or to stdout if path is "-".
+func Open(path string, stdout io.Writer) (*Writer, error) {
+	if path == "-" {
+		return NewWriter(stdout), nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating event stream file %q: %v", path, err)
+	}
+	w := NewWriter(f)
+	w.closer = f
+	return w, nil
+}
+
+// Record writes the event, filling in its version and time. Failures to write are logged,
+// rather than interrupting the operation being recorded.
+func (w *Writer) Record(event Event) {
+	event.Version = SchemaVersion
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	event.Time = event.Time.UTC()
+
+	b, err := json.Marshal(event)
+	if err == nil {
+		b = append(b, '\n')
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if err == nil {
+		_, err = w.out.Write(b)
+	}
+	if err != nil && !w.failed {
+		klog.Warningf("error writing to event stream: %v", err)
+		w.failed = true
+	}
+}
+
+// Close closes the file the events are written to, if the Writer was built with Open.
+func (w *Writer) Close() error {
+	if w.closer == nil {
+		return nil
+	}
+	return w.closer.Close()
+}
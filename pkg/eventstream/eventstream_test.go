@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventstream
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+
+	w.Record(Event{
+		Time:     time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Type:     TaskStarted,
+		Task:     "Keypair/kubernetes-ca",
+		TaskType: "Keypair",
+	})
+	w.Record(Event{
+		Time:          time.Date(2021, 3, 4, 5, 6, 8, 0, time.UTC),
+		Type:          InstanceTerminated,
+		InstanceGroup: "nodes",
+		InstanceID:    "i-1234",
+	})
+
+	expected := `{"version":1,"time":"2021-03-04T05:06:07Z","type":"TaskStarted","task":"Keypair/kubernetes-ca","taskType":"Keypair"}
+{"version":1,"time":"2021-03-04T05:06:08Z","type":"InstanceTerminated","instanceGroup":"nodes","instanceID":"i-1234"}
+`
+	if out.String() != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	w, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("unexpected error opening %q: %v", path, err)
+	}
+	Record(w, Event{Type: ValidationFailed, Error: "node not ready"})
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing %q: %v", path, err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error reading %q: %v", path, err)
+	}
+	if !bytes.Contains(b, []byte(`"type":"ValidationFailed","error":"node not ready"}`)) {
+		t.Errorf("unexpected file contents: %s", string(b))
+	}
+
+	// A nil recorder is ignored
+	Record(nil, Event{Type: TaskStarted})
+
+	var stdout bytes.Buffer
+	w, err = Open("-", &stdout)
+	if err != nil {
+		t.Fatalf("unexpected error opening stdout: %v", err)
+	}
+	Record(w, Event{Type: TaskStarted})
+	if !bytes.Contains(stdout.Bytes(), []byte(`"type":"TaskStarted"`)) {
+		t.Errorf("unexpected stdout: %s", stdout.String())
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/eventstream"
+)
+
+// canaryError is returned when the canary phase of a group fails, stopping the whole rolling update.
+type canaryError struct {
+	group string
+	err   error
+}
+
+func (e *canaryError) Error() string {
+	return fmt.Sprintf("canary of InstanceGroup %q failed: %v", e.group, e.err)
+}
+
+func (e *canaryError) Unwrap() error {
+	return e.err
+}
+
+// canaryCount returns the number of instances of the group to replace as canaries, out of numUpdate.
+func canaryCount(group *cloudinstances.CloudInstanceGroup, settings api.RollingUpdate, numUpdate int) int {
+	if group.InstanceGroup.Spec.Role != api.InstanceGroupRoleNode || !*settings.DrainAndTerminate {
+		return 0
+	}
+	canary := settings.Canary.IntValue()
+	if canary > numUpdate {
+		canary = numUpdate
+	}
+	return canary
+}
+
+// canaryBakeTime returns the time the cluster must keep validating after the canaries have been replaced.
+func canaryBakeTime(settings api.RollingUpdate) time.Duration {
+	if settings.CanaryBakeTime == nil {
+		return 0
+	}
+	return settings.CanaryBakeTime.Duration
+}
+
+// updateCanaries replaces the canary instances of a group one at a time, then checks that the
+// cluster remains healthy for the bake time. Unlike the rest of the rolling update, a failure
+// is returned even if FailOnValidate is false, so that no further instances are updated.
+func (c *RollingUpdateCluster) updateCanaries(group *cloudinstances.CloudInstanceGroup, canaries []*cloudinstances.CloudInstance, bakeTime time.Duration, sleepAfterTerminate time.Duration) error {
+	name := group.InstanceGroup.ObjectMeta.Name
+	if err := c.replaceCanaries(group, canaries, bakeTime, sleepAfterTerminate); err != nil {
+		return &canaryError{group: name, err: err}
+	}
+	klog.Infof("Canary instance(s) of InstanceGroup %q are healthy.", name)
+	return nil
+}
+
+func (c *RollingUpdateCluster) replaceCanaries(group *cloudinstances.CloudInstanceGroup, canaries []*cloudinstances.CloudInstance, bakeTime time.Duration, sleepAfterTerminate time.Duration) error {
+	klog.Infof("Updating %d canary instance(s) of InstanceGroup %q.", len(canaries), group.InstanceGroup.ObjectMeta.Name)
+
+	for _, u := range canaries {
+		if err := c.drainTerminateAndWait(u, sleepAfterTerminate); err != nil {
+			return err
+		}
+
+		if c.CloudOnly {
+			klog.Warningf("Not validating cluster as cloudonly flag is set.")
+		} else {
+			klog.Info("Validating the cluster.")
+			if err := c.validateClusterWithTimeout(c.ValidateCount, group); err != nil {
+				c.recordGroupEvent(eventstream.ValidationFailed, group, err)
+				return fmt.Errorf("error validating cluster after replacing instance %q: %v", u.ID, err)
+			}
+		}
+
+		if err := c.instancesValidated([]*cloudinstances.CloudInstance{u}); err != nil {
+			return err
+		}
+	}
+
+	if err := c.bakeCanaries(group, bakeTime); err != nil {
+		return err
+	}
+
+	for _, u := range canaries {
+		if err := c.runHooks(api.RollingUpdateHookPhasePostCanary, u); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bakeCanaries validates the cluster until the bake time has elapsed, failing on the first
+// validation failure relevant to the group.
+func (c *RollingUpdateCluster) bakeCanaries(group *cloudinstances.CloudInstanceGroup, bakeTime time.Duration) error {
+	if bakeTime <= 0 {
+		return nil
+	}
+	if c.CloudOnly {
+		klog.Warningf("Not validating cluster as cloudonly flag is set; waiting %v for canary instance(s) to bake.", bakeTime)
+		time.Sleep(bakeTime)
+		return nil
+	}
+
+	klog.Infof("Validating the cluster for %v while canary instance(s) bake.", bakeTime)
+	deadline := time.Now().Add(bakeTime)
+	for {
+		result, err := c.ClusterValidator.Validate()
+		if err != nil {
+			return fmt.Errorf("error validating cluster while canary instance(s) baked: %v", err)
+		}
+		if hasFailureRelevantToGroup(result.Failures, group) {
+			var messages []string
+			for _, failure := range result.Failures {
+				messages = append(messages, failure.Message)
+			}
+			return fmt.Errorf("cluster failed validation while canary instance(s) baked: %s", strings.Join(messages, ", "))
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+		if remaining > c.ValidateTickDuration {
+			remaining = c.ValidateTickDuration
+		}
+		time.Sleep(remaining)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/validation"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+)
+
+// failAfterCountClusterValidator passes validation a fixed number of times, then fails.
+type failAfterCountClusterValidator struct {
+	mutex     sync.Mutex
+	successes int
+	calls     int
+}
+
+func (v *failAfterCountClusterValidator) Validate() (*validation.ValidationCluster, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.calls++
+	if v.calls <= v.successes {
+		return &validation.ValidationCluster{}, nil
+	}
+	return &validation.ValidationCluster{
+		Failures: []*validation.ValidationError{
+			{
+				Kind:    "testing",
+				Name:    "testingfailure",
+				Message: "testing failure",
+			},
+		},
+	}, nil
+}
+
+func getCanaryTestSetup(canary intstr.IntOrString, bakeTime time.Duration) (*RollingUpdateCluster, *awsup.MockAWSCloud, *recordingHookRunner) {
+	c, cloud := getTestSetup()
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		Canary:         &canary,
+		CanaryBakeTime: &metav1.Duration{Duration: bakeTime},
+		Hooks: []kopsapi.RollingUpdateHook{
+			{
+				Name:  "pre",
+				Phase: kopsapi.RollingUpdateHookPhasePreDrain,
+				Exec:  &kopsapi.RollingUpdateExecHook{Command: []string{"true"}},
+			},
+			{
+				Name:  "canary",
+				Phase: kopsapi.RollingUpdateHookPhasePostCanary,
+				Exec:  &kopsapi.RollingUpdateExecHook{Command: []string{"true"}},
+			},
+		},
+	}
+	runner := &recordingHookRunner{}
+	c.HookRunner = runner
+	return c, cloud, runner
+}
+
+func TestRollingUpdateCanary(t *testing.T) {
+	c, cloud, runner := getCanaryTestSetup(intstr.FromInt(1), 20*time.Millisecond)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assert.Equal(t, []string{
+		"PreDrain node-1a",
+		"PostCanary node-1a",
+		"PreDrain node-1b",
+		"PreDrain node-1c",
+	}, runner.calls)
+}
+
+func TestRollingUpdateCanaryIgnoredForMasters(t *testing.T) {
+	c, cloud, runner := getCanaryTestSetup(intstr.FromInt(1), 0)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "master-1", kopsapi.InstanceGroupRoleMaster, 2, 2)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Equal(t, []string{"PreDrain master-1a", "PreDrain master-1b"}, runner.calls)
+}
+
+func TestRollingUpdateCanaryFailsValidation(t *testing.T) {
+	c, cloud, _ := getCanaryTestSetup(intstr.FromInt(1), 0)
+	c.FailOnValidate = false
+	c.ClusterValidator = &failAfterOneNodeClusterValidator{
+		Cloud: cloud,
+		Group: "node-1",
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	makeGroup(groups, c.K8sClient, cloud, "node-2", kopsapi.InstanceGroupRoleNode, 3, 3)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 2)
+	assertGroupInstanceCount(t, cloud, "node-2", 3)
+}
+
+func TestRollingUpdateCanaryFailsWhileBaking(t *testing.T) {
+	c, cloud, runner := getCanaryTestSetup(intstr.FromInt(1), time.Minute)
+	// One validation before the group is updated and two after the canary is replaced
+	c.ClusterValidator = &failAfterCountClusterValidator{successes: 3}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 2)
+	assert.Equal(t, []string{"PreDrain node-1a"}, runner.calls)
+}
+
+func TestRollingUpdateCanaryHookFails(t *testing.T) {
+	c, cloud, runner := getCanaryTestSetup(intstr.FromString("50%"), 0)
+	runner.err = errors.New("hook failed")
+	c.Cluster.Spec.RollingUpdate.Hooks[0].FailurePolicy = kopsapi.RollingUpdateHookFailurePolicyIgnore
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 1)
+	assert.Equal(t, []string{
+		"PreDrain node-1a",
+		"PreDrain node-1b",
+		"PostCanary node-1a",
+	}, runner.calls)
+}
+
+func TestPlanCanary(t *testing.T) {
+	c, cloud, _ := getCanaryTestSetup(intstr.FromInt(1), 30*time.Minute)
+	c.NodeInterval = time.Minute
+	c.ValidateCount = 1
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	plan, err := c.Plan(groups)
+	if !assert.NoError(t, err, "plan") {
+		return
+	}
+
+	assert.Equal(t, [][]string{{"node-1a"}, {"node-1b"}, {"node-1c"}}, planInstanceIDs(plan.Groups[0]), "batches")
+	assert.Equal(t, InstanceActionCanary, plan.Groups[0].Batches[0][0].Action, "canary action")
+	assert.Equal(t, InstanceActionReplace, plan.Groups[0].Batches[1][0].Action, "remaining action")
+	assert.Equal(t, 33*time.Minute, plan.Groups[0].EstimatedDuration.Duration, "duration")
+}
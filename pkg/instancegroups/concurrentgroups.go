@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/validation"
+)
+
+// maxConcurrentGroups returns the number of node instance groups to update at the same time.
+func (c *RollingUpdateCluster) maxConcurrentGroups() int {
+	if c.Interactive {
+		return 1
+	}
+	if c.MaxConcurrentGroups > 0 {
+		return c.MaxConcurrentGroups
+	}
+	if c.Cluster != nil && c.Cluster.Spec.RollingUpdateMaxConcurrentGroups != nil {
+		if n := int(*c.Cluster.Spec.RollingUpdateMaxConcurrentGroups); n > 0 {
+			return n
+		}
+	}
+	return 1
+}
+
+// rollingUpdateNodeGroups updates the node groups in order, up to maxConcurrentGroups at a time,
+// recording the result of each group. Once a group's canary fails, no further groups are started.
+func (c *RollingUpdateCluster) rollingUpdateNodeGroups(nodeGroups map[string]*cloudinstances.CloudInstanceGroup, results map[string]error, resultsMutex *sync.Mutex) error {
+	maxConcurrentGroups := c.maxConcurrentGroups()
+	if maxConcurrentGroups > 1 && c.ClusterValidator != nil {
+		klog.Infof("Updating up to %d node instance groups concurrently.", maxConcurrentGroups)
+
+		// Groups being updated concurrently share the results of validating the cluster,
+		// each ignoring failures which are not relevant to it.
+		validator := c.ClusterValidator
+		c.ClusterValidator = newSharedClusterValidator(validator, c.sharedValidationMaxAge())
+		defer func() {
+			c.ClusterValidator = validator
+		}()
+	}
+
+	var wg sync.WaitGroup
+	var canaryErr error
+	slots := make(chan struct{}, maxConcurrentGroups)
+
+	for _, k := range sortGroups(nodeGroups) {
+		slots <- struct{}{}
+
+		resultsMutex.Lock()
+		stop := canaryErr != nil
+		resultsMutex.Unlock()
+		if stop {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(k string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			err := c.rollingUpdateInstanceGroup(nodeGroups[k], c.NodeInterval)
+
+			resultsMutex.Lock()
+			results[k] = err
+			// A failed canary means the new specification is not safe to roll out to other groups
+			var e *canaryError
+			if errors.As(err, &e) && canaryErr == nil {
+				canaryErr = err
+			}
+			resultsMutex.Unlock()
+		}(k)
+	}
+
+	wg.Wait()
+
+	if canaryErr != nil {
+		return fmt.Errorf("stopping rolling-update: %v", canaryErr)
+	}
+	return nil
+}
+
+// sharedValidationMaxAge is how long a shared validation result may be reused. It is shorter than
+// the intervals between validation attempts, so that a group retrying validation never sees its own
+// previous result.
+func (c *RollingUpdateCluster) sharedValidationMaxAge() time.Duration {
+	maxAge := c.ValidateTickDuration
+	if c.ValidateSuccessDuration < maxAge {
+		maxAge = c.ValidateSuccessDuration
+	}
+	return maxAge / 2
+}
+
+// sharedClusterValidator validates the cluster at most once per maxAge, sharing the result between callers.
+type sharedClusterValidator struct {
+	mutex       sync.Mutex
+	validator   validation.ClusterValidator
+	maxAge      time.Duration
+	validatedAt time.Time
+	result      *validation.ValidationCluster
+	err         error
+}
+
+var _ validation.ClusterValidator = &sharedClusterValidator{}
+
+func newSharedClusterValidator(validator validation.ClusterValidator, maxAge time.Duration) *sharedClusterValidator {
+	return &sharedClusterValidator{
+		validator: validator,
+		maxAge:    maxAge,
+	}
+}
+
+func (v *sharedClusterValidator) Validate() (*validation.ValidationCluster, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.validatedAt.IsZero() || time.Since(v.validatedAt) >= v.maxAge {
+		v.result, v.err = v.validator.Validate()
+		v.validatedAt = time.Now()
+	}
+	return v.result, v.err
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/validation"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+)
+
+// concurrencyHookRunner records the largest number of hooks running at the same time.
+type concurrencyHookRunner struct {
+	mutex   sync.Mutex
+	running int
+	max     int
+}
+
+func (r *concurrencyHookRunner) RunHook(ctx context.Context, hook *kopsapi.RollingUpdateHook, hookContext *HookContext) error {
+	r.mutex.Lock()
+	r.running++
+	if r.running > r.max {
+		r.max = r.running
+	}
+	r.mutex.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	r.mutex.Lock()
+	r.running--
+	r.mutex.Unlock()
+	return nil
+}
+
+// countingClusterValidator counts the number of times the cluster is validated.
+type countingClusterValidator struct {
+	mutex sync.Mutex
+	calls int
+}
+
+func (v *countingClusterValidator) Validate() (*validation.ValidationCluster, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.calls++
+	return &validation.ValidationCluster{}, nil
+}
+
+// groupFailAfterOneNodeClusterValidator reports a failure relevant to a single group once one of its instances has been terminated.
+type groupFailAfterOneNodeClusterValidator struct {
+	Cloud         awsup.AWSCloud
+	InstanceGroup *kopsapi.InstanceGroup
+}
+
+func (v *groupFailAfterOneNodeClusterValidator) Validate() (*validation.ValidationCluster, error) {
+	result, err := (&failAfterOneNodeClusterValidator{Cloud: v.Cloud, Group: v.InstanceGroup.Name}).Validate()
+	for _, failure := range result.Failures {
+		failure.InstanceGroup = v.InstanceGroup
+	}
+	return result, err
+}
+
+func getConcurrentGroupsTestSetup(maxConcurrentGroups int32) (*RollingUpdateCluster, *awsup.MockAWSCloud, map[string]*cloudinstances.CloudInstanceGroup, *concurrencyHookRunner) {
+	c, cloud := getTestSetup()
+	c.Cluster.Spec.RollingUpdateMaxConcurrentGroups = fi.Int32(maxConcurrentGroups)
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		Hooks: []kopsapi.RollingUpdateHook{
+			{
+				Name:  "pre",
+				Phase: kopsapi.RollingUpdateHookPhasePreDrain,
+				Exec:  &kopsapi.RollingUpdateExecHook{Command: []string{"true"}},
+			},
+		},
+	}
+	runner := &concurrencyHookRunner{}
+	c.HookRunner = runner
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 2)
+	makeGroup(groups, c.K8sClient, cloud, "node-2", kopsapi.InstanceGroupRoleNode, 2, 2)
+	makeGroup(groups, c.K8sClient, cloud, "node-3", kopsapi.InstanceGroupRoleNode, 2, 2)
+	makeGroup(groups, c.K8sClient, cloud, "master-1", kopsapi.InstanceGroupRoleMaster, 2, 2)
+	return c, cloud, groups, runner
+}
+
+func TestRollingUpdateConcurrentGroups(t *testing.T) {
+	c, cloud, groups, runner := getConcurrentGroupsTestSetup(2)
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Equal(t, 2, runner.max, "concurrent groups")
+	assertGroupInstanceCount(t, cloud, "node-1", 0)
+	assertGroupInstanceCount(t, cloud, "node-2", 0)
+	assertGroupInstanceCount(t, cloud, "node-3", 0)
+	assertGroupInstanceCount(t, cloud, "master-1", 0)
+}
+
+func TestRollingUpdateConcurrentGroupsDefault(t *testing.T) {
+	c, _, groups, runner := getConcurrentGroupsTestSetup(4)
+	c.MaxConcurrentGroups = 1
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Equal(t, 1, runner.max, "concurrent groups")
+}
+
+func TestRollingUpdateConcurrentGroupsFailure(t *testing.T) {
+	c, cloud, groups, _ := getConcurrentGroupsTestSetup(2)
+	c.ClusterValidator = &instanceGroupNodeSpecificErrorClusterValidator{
+		InstanceGroup: groups["node-1"].InstanceGroup,
+	}
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	// A failure relevant to one group does not stop the other groups
+	assertGroupInstanceCount(t, cloud, "node-1", 2)
+	assertGroupInstanceCount(t, cloud, "node-2", 0)
+	assertGroupInstanceCount(t, cloud, "node-3", 0)
+}
+
+func TestRollingUpdateConcurrentGroupsCanaryFailure(t *testing.T) {
+	c, cloud, groups, _ := getConcurrentGroupsTestSetup(2)
+	canary := intstr.FromInt(1)
+	c.Cluster.Spec.RollingUpdate.Canary = &canary
+	c.ClusterValidator = &groupFailAfterOneNodeClusterValidator{
+		Cloud:         cloud,
+		InstanceGroup: groups["node-1"].InstanceGroup,
+	}
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	// The group updated alongside the failed canary completes, but no further group is started
+	assertGroupInstanceCount(t, cloud, "node-1", 1)
+	assertGroupInstanceCount(t, cloud, "node-2", 0)
+	assertGroupInstanceCount(t, cloud, "node-3", 2)
+}
+
+func TestSharedClusterValidator(t *testing.T) {
+	counting := &countingClusterValidator{}
+
+	shared := newSharedClusterValidator(counting, time.Hour)
+	for i := 0; i < 3; i++ {
+		_, err := shared.Validate()
+		assert.NoError(t, err, "validate")
+	}
+	assert.Equal(t, 1, counting.calls, "validations within maxAge")
+
+	shared = newSharedClusterValidator(counting, 0)
+	for i := 0; i < 3; i++ {
+		_, err := shared.Validate()
+		assert.NoError(t, err, "validate")
+	}
+	assert.Equal(t, 4, counting.calls, "validations after maxAge")
+}
+
+func TestPlanConcurrentGroups(t *testing.T) {
+	c, _, groups, _ := getConcurrentGroupsTestSetup(2)
+	c.MasterInterval = time.Minute
+	c.NodeInterval = time.Minute
+	c.ValidateCount = 1
+	delete(groups, "master-1")
+	groups["node-3"].NeedUpdate = groups["node-3"].NeedUpdate[:1]
+
+	plan, err := c.Plan(groups)
+	if !assert.NoError(t, err, "plan") {
+		return
+	}
+
+	// node-3 starts once node-1 or node-2 has completed
+	assert.Equal(t, 3*time.Minute, plan.EstimatedDuration.Duration, "duration")
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/eventstream"
+)
+
+// recordInstanceEvent records an event about an instance, if an event stream was requested.
+func (c *RollingUpdateCluster) recordInstanceEvent(eventType eventstream.Type, u *cloudinstances.CloudInstance) {
+	if c.Events == nil {
+		return
+	}
+
+	event := eventstream.Event{
+		Type:          eventType,
+		InstanceGroup: u.CloudInstanceGroup.InstanceGroup.ObjectMeta.Name,
+		InstanceID:    u.ID,
+	}
+	if u.Node != nil {
+		event.NodeName = u.Node.Name
+	}
+	c.Events.Record(event)
+}
+
+// recordGroupEvent records an event about an instance group, if an event stream was requested.
+func (c *RollingUpdateCluster) recordGroupEvent(eventType eventstream.Type, group *cloudinstances.CloudInstanceGroup, err error) {
+	if c.Events == nil {
+		return
+	}
+
+	event := eventstream.Event{
+		Type:          eventType,
+		InstanceGroup: group.InstanceGroup.ObjectMeta.Name,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	c.Events.Record(event)
+}
+
+// instancesValidated records that the cluster validated after the instances were terminated,
+// then runs their PostValidate hooks.
+func (c *RollingUpdateCluster) instancesValidated(instances []*cloudinstances.CloudInstance) error {
+	if !c.CloudOnly {
+		for _, u := range instances {
+			c.recordInstanceEvent(eventstream.InstanceValidated, u)
+		}
+	}
+	return c.runPostValidateHooks(instances)
+}
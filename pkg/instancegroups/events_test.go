@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/eventstream"
+)
+
+type recordingEventRecorder struct {
+	mutex  sync.Mutex
+	events []eventstream.Event
+}
+
+func (r *recordingEventRecorder) Record(event eventstream.Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingEventRecorder) summary() []string {
+	var summary []string
+	for _, event := range r.events {
+		s := string(event.Type) + " " + event.InstanceGroup
+		if event.InstanceID != "" {
+			s += " " + event.InstanceID
+		}
+		summary = append(summary, s)
+	}
+	return summary
+}
+
+func TestRollingUpdateEvents(t *testing.T) {
+	c, cloud := getTestSetup()
+	recorder := &recordingEventRecorder{}
+	c.Events = recorder
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 2)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Equal(t, []string{
+		"InstanceDrained node-1 node-1a",
+		"InstanceTerminated node-1 node-1a",
+		"InstanceValidated node-1 node-1a",
+		"InstanceDrained node-1 node-1b",
+		"InstanceTerminated node-1 node-1b",
+		"InstanceValidated node-1 node-1b",
+		"InstanceGroupCompleted node-1",
+	}, recorder.summary())
+	assert.Equal(t, "node-1a.local", recorder.events[0].NodeName, "node name")
+}
+
+func TestRollingUpdateEventsValidationFailure(t *testing.T) {
+	c, cloud := getTestSetup()
+	recorder := &recordingEventRecorder{}
+	c.Events = recorder
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 2)
+	c.ClusterValidator = &failAfterOneNodeClusterValidator{
+		Cloud: cloud,
+		Group: "node-1",
+	}
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assert.Equal(t, []string{
+		"InstanceDrained node-1 node-1a",
+		"InstanceTerminated node-1 node-1a",
+		"ValidationFailed node-1",
+		"InstanceGroupFailed node-1",
+	}, recorder.summary())
+	assert.NotEmpty(t, recorder.events[3].Error, "error of failed group")
+}
@@ -0,0 +1,375 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+const (
+	// defaultHookTimeout is the maximum time a hook may take if it does not specify a timeout.
+	defaultHookTimeout = 5 * time.Minute
+	// hookPollInterval is the interval between checks of the Job and PodDisruptionBudgets hooks.
+	hookPollInterval = 5 * time.Second
+	// hookJobTTL is the time after which a finished Job hook is deleted, should deleting it after the hook fail.
+	hookJobTTL = int32(time.Hour / time.Second)
+	// hookJobDeleteTimeout is the maximum time to wait for a Job hook to be deleted.
+	hookJobDeleteTimeout = 30 * time.Second
+)
+
+// HookContext describes the instance a rolling update hook is run for.
+type HookContext struct {
+	ClusterName   string                     `json:"clusterName"`
+	InstanceGroup string                     `json:"instanceGroup"`
+	InstanceID    string                     `json:"instanceID"`
+	NodeName      string                     `json:"nodeName,omitempty"`
+	Phase         api.RollingUpdateHookPhase `json:"phase"`
+}
+
+// env returns the environment variables describing the instance to exec and Job hooks.
+func (h *HookContext) env() map[string]string {
+	return map[string]string{
+		"KOPS_CLUSTER_NAME":   h.ClusterName,
+		"KOPS_INSTANCE_GROUP": h.InstanceGroup,
+		"KOPS_INSTANCE_ID":    h.InstanceID,
+		"KOPS_NODE_NAME":      h.NodeName,
+		"KOPS_HOOK_PHASE":     string(h.Phase),
+	}
+}
+
+// HookRunner runs a single rolling update hook.
+type HookRunner interface {
+	RunHook(ctx context.Context, hook *api.RollingUpdateHook, hookContext *HookContext) error
+}
+
+// runHooks runs the hooks of the instance's group for the given phase.
+func (c *RollingUpdateCluster) runHooks(phase api.RollingUpdateHookPhase, u *cloudinstances.CloudInstance) error {
+	settings := resolveSettings(c.Cluster, u.CloudInstanceGroup.InstanceGroup, 0)
+
+	for i := range settings.Hooks {
+		hook := &settings.Hooks[i]
+		if hook.Phase != phase {
+			continue
+		}
+
+		hookContext := &HookContext{
+			ClusterName:   c.ClusterName,
+			InstanceGroup: u.CloudInstanceGroup.InstanceGroup.ObjectMeta.Name,
+			InstanceID:    u.ID,
+			Phase:         phase,
+		}
+		if u.Node != nil {
+			hookContext.NodeName = u.Node.Name
+		}
+
+		timeout := defaultHookTimeout
+		if hook.Timeout != nil {
+			timeout = hook.Timeout.Duration
+		}
+
+		klog.Infof("Running %s hook %q for instance %q.", phase, hook.Name, u.ID)
+		ctx, cancel := context.WithTimeout(c.Ctx, timeout)
+		err := c.hookRunner().RunHook(ctx, hook, hookContext)
+		cancel()
+		if err != nil {
+			if hook.FailurePolicy == api.RollingUpdateHookFailurePolicyIgnore {
+				klog.Warningf("Ignoring failure of %s hook %q for instance %q: %v", phase, hook.Name, u.ID, err)
+				continue
+			}
+			return fmt.Errorf("%s hook %q failed for instance %q: %v", phase, hook.Name, u.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// runPostValidateHooks runs the PostValidate hooks for instances whose replacements have been validated.
+func (c *RollingUpdateCluster) runPostValidateHooks(instances []*cloudinstances.CloudInstance) error {
+	for _, u := range instances {
+		if err := c.runHooks(api.RollingUpdateHookPhasePostValidate, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasHooks returns true if any hook is configured for the given phase.
+func hasHooks(settings api.RollingUpdate, phase api.RollingUpdateHookPhase) bool {
+	for _, hook := range settings.Hooks {
+		if hook.Phase == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// terminatedInstances collects the instances terminated since their PostValidate hooks were last run.
+type terminatedInstances struct {
+	mutex     sync.Mutex
+	instances []*cloudinstances.CloudInstance
+}
+
+func (t *terminatedInstances) add(u *cloudinstances.CloudInstance) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.instances = append(t.instances, u)
+}
+
+func (t *terminatedInstances) len() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.instances)
+}
+
+// take returns the collected instances and resets the collection.
+func (t *terminatedInstances) take() []*cloudinstances.CloudInstance {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	instances := t.instances
+	t.instances = nil
+	return instances
+}
+
+func (c *RollingUpdateCluster) hookRunner() HookRunner {
+	if c.HookRunner != nil {
+		return c.HookRunner
+	}
+	return &defaultHookRunner{c: c}
+}
+
+// defaultHookRunner runs hooks as specified in the API.
+type defaultHookRunner struct {
+	c *RollingUpdateCluster
+}
+
+var _ HookRunner = &defaultHookRunner{}
+
+func (r *defaultHookRunner) RunHook(ctx context.Context, hook *api.RollingUpdateHook, hookContext *HookContext) error {
+	switch {
+	case hook.Exec != nil:
+		return runExecHook(ctx, hook.Exec, hookContext)
+	case hook.HTTP != nil:
+		return runHTTPHook(ctx, hook.HTTP, hookContext)
+	case hook.Job != nil:
+		return r.runJobHook(ctx, hook.Name, hook.Job, hookContext)
+	case hook.PodDisruptionBudgets != nil:
+		return r.runPodDisruptionBudgetsHook(ctx, hook.PodDisruptionBudgets)
+	default:
+		return fmt.Errorf("hook %q has no action", hook.Name)
+	}
+}
+
+func runExecHook(ctx context.Context, hook *api.RollingUpdateExecHook, hookContext *HookContext) error {
+	if len(hook.Command) == 0 {
+		return fmt.Errorf("command not set")
+	}
+
+	cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+	cmd.Env = os.Environ()
+	for k, v := range hookContext.env() {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error running %q: %v: %s", strings.Join(hook.Command, " "), err, string(output))
+	}
+	klog.V(2).Infof("output of %q: %s", strings.Join(hook.Command, " "), string(output))
+	return nil
+}
+
+func runHTTPHook(ctx context.Context, hook *api.RollingUpdateHTTPHook, hookContext *HookContext) error {
+	method := hook.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	body, err := json.Marshal(hookContext)
+	if err != nil {
+		return fmt.Errorf("error building request body: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error building request to %q: %v", hook.URL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request to %q: %v", hook.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %q from %q: %s", resp.Status, hook.URL, string(respBody))
+	}
+	return nil
+}
+
+func (r *defaultHookRunner) runJobHook(ctx context.Context, name string, hook *api.RollingUpdateJobHook, hookContext *HookContext) error {
+	if r.c.K8sClient == nil {
+		return fmt.Errorf("job hooks require a kubernetes client")
+	}
+
+	namespace := hook.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceSystem
+	}
+
+	var env []corev1.EnvVar
+	for k, v := range hookContext.env() {
+		env = append(env, corev1.EnvVar{Name: k, Value: v})
+	}
+
+	backoffLimit := int32(0)
+	ttl := hookJobTTL
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kops-rolling-update-" + name + "-",
+			Namespace:    namespace,
+			Labels: map[string]string{
+				"kops.k8s.io/rolling-update-hook": name,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: hook.ServiceAccountName,
+					Containers: []corev1.Container{
+						{
+							Name:    "hook",
+							Image:   hook.Image,
+							Command: hook.Command,
+							Env:     env,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	created, err := r.c.K8sClient.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("error creating job: %v", err)
+	}
+	klog.Infof("Waiting for job %s/%s to complete.", namespace, created.Name)
+
+	// Delete the job and its pods once we stop waiting for it, including when the hook times out.
+	defer func() {
+		deleteCtx, cancel := context.WithTimeout(context.Background(), hookJobDeleteTimeout)
+		defer cancel()
+		propagationPolicy := metav1.DeletePropagationBackground
+		err := r.c.K8sClient.BatchV1().Jobs(namespace).Delete(deleteCtx, created.Name, metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Warningf("error deleting job %s/%s: %v", namespace, created.Name, err)
+		}
+	}()
+
+	return wait.PollImmediateUntil(hookPollInterval, func() (bool, error) {
+		job, err := r.c.K8sClient.BatchV1().Jobs(namespace).Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			klog.Warningf("error getting job %s/%s: %v", namespace, created.Name, err)
+			return false, nil
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobComplete:
+				return true, nil
+			case batchv1.JobFailed:
+				return false, fmt.Errorf("job %s/%s failed: %s", namespace, created.Name, condition.Message)
+			}
+		}
+		return false, nil
+	}, ctx.Done())
+}
+
+func (r *defaultHookRunner) runPodDisruptionBudgetsHook(ctx context.Context, hook *api.RollingUpdatePodDisruptionBudgetsHook) error {
+	if r.c.K8sClient == nil {
+		return fmt.Errorf("podDisruptionBudgets hooks require a kubernetes client")
+	}
+
+	return wait.PollImmediateUntil(hookPollInterval, func() (bool, error) {
+		unhealthy, err := r.unhealthyPodDisruptionBudgets(ctx, hook)
+		if err != nil {
+			klog.Warningf("error listing PodDisruptionBudgets: %v", err)
+			return false, nil
+		}
+		if len(unhealthy) > 0 {
+			klog.Infof("Waiting for PodDisruptionBudgets: %s.", strings.Join(unhealthy, ", "))
+			return false, nil
+		}
+		return true, nil
+	}, ctx.Done())
+}
+
+// unhealthyPodDisruptionBudgets lists the PodDisruptionBudgets matching the hook which do not have enough healthy pods.
+// The policy/v1beta1 API is only used if the cluster does not serve policy/v1.
+func (r *defaultHookRunner) unhealthyPodDisruptionBudgets(ctx context.Context, hook *api.RollingUpdatePodDisruptionBudgetsHook) ([]string, error) {
+	options := metav1.ListOptions{LabelSelector: hook.Selector}
+
+	var unhealthy []string
+	pdbs, err := r.c.K8sClient.PolicyV1().PodDisruptionBudgets(hook.Namespace).List(ctx, options)
+	if err == nil {
+		for _, pdb := range pdbs.Items {
+			if pdb.Status.CurrentHealthy < pdb.Status.DesiredHealthy {
+				unhealthy = append(unhealthy, fmt.Sprintf("%s/%s (%d/%d healthy)", pdb.Namespace, pdb.Name, pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy))
+			}
+		}
+		return unhealthy, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	pdbsV1beta1, err := r.c.K8sClient.PolicyV1beta1().PodDisruptionBudgets(hook.Namespace).List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, pdb := range pdbsV1beta1.Items {
+		if pdb.Status.CurrentHealthy < pdb.Status.DesiredHealthy {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s/%s (%d/%d healthy)", pdb.Namespace, pdb.Name, pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy))
+		}
+	}
+	return unhealthy, nil
+}
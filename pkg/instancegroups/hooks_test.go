@@ -0,0 +1,253 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	testingclient "k8s.io/client-go/testing"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+type recordingHookRunner struct {
+	mutex sync.Mutex
+	calls []string
+	err   error
+}
+
+func (r *recordingHookRunner) RunHook(ctx context.Context, hook *kopsapi.RollingUpdateHook, hookContext *HookContext) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.calls = append(r.calls, string(hookContext.Phase)+" "+hookContext.InstanceID)
+	return r.err
+}
+
+func getHooksTestSetup(failurePolicy kopsapi.RollingUpdateHookFailurePolicy) (*RollingUpdateCluster, *recordingHookRunner, map[string]*cloudinstances.CloudInstanceGroup) {
+	c, cloud := getTestSetup()
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		Hooks: []kopsapi.RollingUpdateHook{
+			{
+				Name:          "pre",
+				Phase:         kopsapi.RollingUpdateHookPhasePreDrain,
+				FailurePolicy: failurePolicy,
+				Exec:          &kopsapi.RollingUpdateExecHook{Command: []string{"true"}},
+			},
+			{
+				Name:          "post",
+				Phase:         kopsapi.RollingUpdateHookPhasePostValidate,
+				FailurePolicy: failurePolicy,
+				Exec:          &kopsapi.RollingUpdateExecHook{Command: []string{"true"}},
+			},
+		},
+	}
+	runner := &recordingHookRunner{}
+	c.HookRunner = runner
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 2, 2)
+	return c, runner, groups
+}
+
+func TestRollingUpdateRunsHooks(t *testing.T) {
+	c, runner, groups := getHooksTestSetup("")
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Equal(t, []string{
+		"PreDrain node-1a",
+		"PostValidate node-1a",
+		"PreDrain node-1b",
+		"PostValidate node-1b",
+	}, runner.calls)
+}
+
+func TestRollingUpdateHookFailureStopsUpdate(t *testing.T) {
+	c, runner, groups := getHooksTestSetup(kopsapi.RollingUpdateHookFailurePolicyFail)
+	runner.err = errors.New("hook failed")
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.Error(t, err, "rolling update")
+
+	assert.Equal(t, []string{"PreDrain node-1a"}, runner.calls)
+}
+
+func TestRollingUpdateHookFailureIgnored(t *testing.T) {
+	c, runner, groups := getHooksTestSetup(kopsapi.RollingUpdateHookFailurePolicyIgnore)
+	runner.err = errors.New("hook failed")
+
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assert.Len(t, runner.calls, 4, "hooks run")
+}
+
+func TestExecHook(t *testing.T) {
+	hookContext := &HookContext{ClusterName: "test.k8s.local", InstanceID: "node-1a", Phase: kopsapi.RollingUpdateHookPhasePreDrain}
+
+	err := runExecHook(context.Background(), &kopsapi.RollingUpdateExecHook{Command: []string{"sh", "-c", `test "$KOPS_INSTANCE_ID" = node-1a`}}, hookContext)
+	assert.NoError(t, err, "hook with matching environment")
+
+	err = runExecHook(context.Background(), &kopsapi.RollingUpdateExecHook{Command: []string{"false"}}, hookContext)
+	assert.Error(t, err, "failing hook")
+}
+
+func TestExecHookTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := runExecHook(ctx, &kopsapi.RollingUpdateExecHook{Command: []string{"sleep", "10"}}, &HookContext{})
+	assert.Error(t, err, "hook exceeding timeout")
+}
+
+func TestHTTPHook(t *testing.T) {
+	var received HookContext
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if received.InstanceID == "fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	hookContext := &HookContext{ClusterName: "test.k8s.local", InstanceGroup: "node-1", InstanceID: "node-1a", Phase: kopsapi.RollingUpdateHookPhasePostValidate}
+	err := runHTTPHook(context.Background(), &kopsapi.RollingUpdateHTTPHook{URL: server.URL}, hookContext)
+	assert.NoError(t, err, "successful hook")
+	assert.Equal(t, http.MethodPost, method, "default method")
+	assert.Equal(t, *hookContext, received, "request body")
+
+	err = runHTTPHook(context.Background(), &kopsapi.RollingUpdateHTTPHook{URL: server.URL, Method: http.MethodPut}, &HookContext{InstanceID: "fail"})
+	assert.Error(t, err, "failing hook")
+	assert.Equal(t, http.MethodPut, method, "method")
+}
+
+func TestJobHookDeletesJob(t *testing.T) {
+	for _, complete := range []bool{true, false} {
+		c, _ := getTestSetup()
+		runner := &defaultHookRunner{c: c}
+		client := c.K8sClient.(*fake.Clientset)
+
+		// The fake clientset does not generate names
+		client.PrependReactor("create", "jobs", func(action testingclient.Action) (bool, runtime.Object, error) {
+			job := action.(testingclient.CreateAction).GetObject().(*batchv1.Job)
+			job.Name = job.GenerateName + "abcde"
+			assert.NotNil(t, job.Spec.TTLSecondsAfterFinished, "ttlSecondsAfterFinished")
+			return false, nil, nil
+		})
+		client.PrependReactor("get", "jobs", func(action testingclient.Action) (bool, runtime.Object, error) {
+			job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: action.(testingclient.GetAction).GetName(), Namespace: action.GetNamespace()}}
+			if complete {
+				job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+			}
+			return true, job, nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := runner.runJobHook(ctx, "test", &kopsapi.RollingUpdateJobHook{Image: "busybox"}, &HookContext{InstanceID: "node-1a"})
+		cancel()
+		if complete {
+			assert.NoError(t, err, "complete job")
+		} else {
+			assert.Error(t, err, "timed out job")
+		}
+
+		jobs, err := c.K8sClient.BatchV1().Jobs(metav1.NamespaceSystem).List(context.Background(), metav1.ListOptions{})
+		if assert.NoError(t, err, "listing jobs") {
+			assert.Empty(t, jobs.Items, "jobs after hook")
+		}
+
+		var deletes []testingclient.DeleteActionImpl
+		for _, action := range client.Actions() {
+			if action.Matches("delete", "jobs") {
+				deletes = append(deletes, action.(testingclient.DeleteActionImpl))
+			}
+		}
+		if assert.Len(t, deletes, 1, "job deletions") {
+			assert.Equal(t, "kops-rolling-update-test-abcde", deletes[0].Name, "deleted job")
+		}
+	}
+}
+
+func TestPodDisruptionBudgetsHook(t *testing.T) {
+	c, _ := getTestSetup()
+	runner := &defaultHookRunner{c: c}
+
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka", Labels: map[string]string{"app": "kafka"}},
+		Status:     policyv1.PodDisruptionBudgetStatus{CurrentHealthy: 2, DesiredHealthy: 3},
+	}
+	_, err := c.K8sClient.PolicyV1().PodDisruptionBudgets("kafka").Create(c.Ctx, pdb, metav1.CreateOptions{})
+	if !assert.NoError(t, err, "creating PodDisruptionBudget") {
+		return
+	}
+
+	err = runner.runPodDisruptionBudgetsHook(context.Background(), &kopsapi.RollingUpdatePodDisruptionBudgetsHook{Selector: "app!=kafka"})
+	assert.NoError(t, err, "no matching PodDisruptionBudget")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = runner.runPodDisruptionBudgetsHook(ctx, &kopsapi.RollingUpdatePodDisruptionBudgetsHook{Namespace: "kafka"})
+	assert.Error(t, err, "unhealthy PodDisruptionBudget")
+}
+
+func TestPodDisruptionBudgetsHookV1beta1(t *testing.T) {
+	c, _ := getTestSetup()
+	runner := &defaultHookRunner{c: c}
+
+	// Simulate a cluster which does not serve policy/v1
+	c.K8sClient.(*fake.Clientset).PrependReactor("list", "poddisruptionbudgets", func(action testingclient.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Version != "v1" {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	})
+
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "kafka"},
+		Status:     policyv1beta1.PodDisruptionBudgetStatus{CurrentHealthy: 2, DesiredHealthy: 3},
+	}
+	_, err := c.K8sClient.PolicyV1beta1().PodDisruptionBudgets("kafka").Create(c.Ctx, pdb, metav1.CreateOptions{})
+	if !assert.NoError(t, err, "creating PodDisruptionBudget") {
+		return
+	}
+
+	unhealthy, err := runner.unhealthyPodDisruptionBudgets(context.Background(), &kopsapi.RollingUpdatePodDisruptionBudgetsHook{Namespace: "kafka"})
+	assert.NoError(t, err, "listing PodDisruptionBudgets")
+	assert.Equal(t, []string{"kafka/kafka (2/3 healthy)"}, unhealthy)
+}
@@ -36,6 +36,7 @@ import (
 	"k8s.io/klog/v2"
 	api "k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/eventstream"
 	"k8s.io/kops/pkg/validation"
 	"k8s.io/kubectl/pkg/drain"
 )
@@ -80,6 +81,12 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 		return fmt.Errorf("rollingUpdate is missing a k8s client")
 	}
 
+	defer func() {
+		if err != nil {
+			c.recordGroupEvent(eventstream.InstanceGroupFailed, group, err)
+		}
+	}()
+
 	noneReady := len(group.Ready) == 0
 	numInstances := len(group.Ready) + len(group.NeedUpdate)
 	update := group.NeedUpdate
@@ -87,7 +94,11 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 		update = append(update, group.Ready...)
 	}
 
+	update = c.Progress.planGroup(group, update)
+
 	if len(update) == 0 {
+		c.Progress.recordGroupCompleted(group)
+		c.recordGroupEvent(eventstream.InstanceGroupCompleted, group, nil)
 		return nil
 	}
 
@@ -107,22 +118,7 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 	settings := resolveSettings(c.Cluster, group.InstanceGroup, numInstances)
 
 	runningDrains := 0
-	maxSurge := settings.MaxSurge.IntValue()
-	if maxSurge > len(update) {
-		maxSurge = len(update)
-	}
-	maxConcurrency := maxSurge + settings.MaxUnavailable.IntValue()
-
-	if group.InstanceGroup.Spec.Role == api.InstanceGroupRoleMaster && maxSurge != 0 {
-		// Masters are incapable of surging because they rely on registering themselves through
-		// the local apiserver. That apiserver depends on the local etcd, which relies on being
-		// joined to the etcd cluster.
-		maxSurge = 0
-		maxConcurrency = settings.MaxUnavailable.IntValue()
-		if maxConcurrency == 0 {
-			maxConcurrency = 1
-		}
-	}
+	maxSurge, maxConcurrency := c.updateLimits(group, settings, len(update))
 
 	nonWarmPool := []*cloudinstances.CloudInstance{}
 	// Run through the warm pool and delete all instances directly
@@ -139,15 +135,19 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 	}
 	update = nonWarmPool
 
-	if c.Interactive {
-		if maxSurge > 1 {
-			maxSurge = 1
+	update = prioritizeUpdate(update)
+
+	if numCanary := canaryCount(group, settings, len(update)); numCanary > 0 {
+		if err := c.updateCanaries(group, update[:numCanary], canaryBakeTime(settings), sleepAfterTerminate); err != nil {
+			return err
+		}
+		update = update[numCanary:]
+		noneReady = false
+		if maxSurge > len(update) {
+			maxSurge = len(update)
 		}
-		maxConcurrency = 1
 	}
 
-	update = prioritizeUpdate(update)
-
 	if maxSurge > 0 && !c.CloudOnly {
 		skippedNodes := 0
 		for numSurge := 1; numSurge <= maxSurge; numSurge++ {
@@ -182,10 +182,15 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 	}
 
 	terminateChan := make(chan error, maxConcurrency)
+	terminated := &terminatedInstances{}
 
 	for uIdx, u := range update {
 		go func(m *cloudinstances.CloudInstance) {
-			terminateChan <- c.drainTerminateAndWait(m, sleepAfterTerminate)
+			err := c.drainTerminateAndWait(m, sleepAfterTerminate)
+			if err == nil {
+				terminated.add(m)
+			}
+			terminateChan <- err
 		}(u)
 		runningDrains++
 
@@ -206,6 +211,11 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 			return waitForPendingBeforeReturningError(runningDrains, terminateChan, err)
 		}
 
+		err = c.instancesValidated(terminated.take())
+		if err != nil {
+			return waitForPendingBeforeReturningError(runningDrains, terminateChan, err)
+		}
+
 		if c.Interactive {
 			nodeName := ""
 			if u.Node != nil {
@@ -251,11 +261,54 @@ func (c *RollingUpdateCluster) rollingUpdateInstanceGroup(group *cloudinstances.
 		if err != nil {
 			return err
 		}
+	} else if terminated.len() > 0 && hasHooks(settings, api.RollingUpdateHookPhasePostValidate) {
+		// Instances swept up after the last validation must be validated before their hooks run
+		err = c.maybeValidate(" after terminating instance", c.ValidateCount, group)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := c.instancesValidated(terminated.take()); err != nil {
+		return err
 	}
 
+	c.Progress.recordGroupCompleted(group)
+	c.recordGroupEvent(eventstream.InstanceGroupCompleted, group, nil)
+
 	return nil
 }
 
+// updateLimits returns the number of instances to surge and the maximum number of instances
+// to update concurrently when updating numUpdate instances of a group.
+func (c *RollingUpdateCluster) updateLimits(group *cloudinstances.CloudInstanceGroup, settings api.RollingUpdate, numUpdate int) (maxSurge int, maxConcurrency int) {
+	maxSurge = settings.MaxSurge.IntValue()
+	if maxSurge > numUpdate {
+		maxSurge = numUpdate
+	}
+	maxConcurrency = maxSurge + settings.MaxUnavailable.IntValue()
+
+	if group.InstanceGroup.Spec.Role == api.InstanceGroupRoleMaster && maxSurge != 0 {
+		// Masters are incapable of surging because they rely on registering themselves through
+		// the local apiserver. That apiserver depends on the local etcd, which relies on being
+		// joined to the etcd cluster.
+		maxSurge = 0
+		maxConcurrency = settings.MaxUnavailable.IntValue()
+		if maxConcurrency == 0 {
+			maxConcurrency = 1
+		}
+	}
+
+	if c.Interactive {
+		if maxSurge > 1 {
+			maxSurge = 1
+		}
+		maxConcurrency = 1
+	}
+
+	return maxSurge, maxConcurrency
+}
+
 func prioritizeUpdate(update []*cloudinstances.CloudInstance) []*cloudinstances.CloudInstance {
 	// The priorities are, in order:
 	//   attached before detached
@@ -386,6 +439,10 @@ func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInst
 
 	isBastion := u.CloudInstanceGroup.InstanceGroup.IsBastion()
 
+	if err := c.runHooks(api.RollingUpdateHookPhasePreDrain, u); err != nil {
+		return err
+	}
+
 	if isBastion {
 		// We don't want to validate for bastions - they aren't part of the cluster
 	} else if c.CloudOnly {
@@ -403,6 +460,8 @@ func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInst
 				}
 				klog.Infof("Ignoring error draining node %q: %v", nodeName, err)
 			}
+			c.Progress.recordInstance(u, InstanceProgressDrained)
+			c.recordInstanceEvent(eventstream.InstanceDrained, u)
 		} else {
 			klog.Warningf("Skipping drain of instance %q, because it is not registered in kubernetes", instanceID)
 		}
@@ -425,6 +484,8 @@ func (c *RollingUpdateCluster) drainTerminateAndWait(u *cloudinstances.CloudInst
 		klog.Errorf("error deleting instance %q, node %q: %v", instanceID, nodeName, err)
 		return err
 	}
+	c.Progress.recordInstance(u, InstanceProgressTerminated)
+	c.recordInstanceEvent(eventstream.InstanceTerminated, u)
 
 	if err := c.reconcileInstanceGroup(); err != nil {
 		klog.Errorf("error reconciling instance group %q: %v", u.CloudInstanceGroup.HumanName, err)
@@ -456,6 +517,7 @@ func (c *RollingUpdateCluster) reconcileInstanceGroup() error {
 		Phase:              "",
 		TargetName:         "direct",
 		LifecycleOverrides: map[string]fi.Lifecycle{},
+		Out:                c.Out,
 	}
 
 	return applyCmd.Run(c.Ctx)
@@ -470,6 +532,7 @@ func (c *RollingUpdateCluster) maybeValidate(operation string, validateCount int
 		klog.Info("Validating the cluster.")
 
 		if err := c.validateClusterWithTimeout(validateCount, group); err != nil {
+			c.recordGroupEvent(eventstream.ValidationFailed, group, err)
 
 			if c.FailOnValidate {
 				klog.Errorf("Cluster did not validate within %s", c.ValidationTimeout)
@@ -0,0 +1,275 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+// InstanceAction is what a rolling update will do with an instance.
+type InstanceAction string
+
+const (
+	// InstanceActionDeleteWarmPool means the instance is in the warm pool and will be deleted directly.
+	InstanceActionDeleteWarmPool InstanceAction = "DeleteWarmPool"
+	// InstanceActionSurge means the instance will be detached, so that its replacement is created before it is drained and terminated.
+	InstanceActionSurge InstanceAction = "Surge"
+	// InstanceActionReplace means the instance will be drained and terminated.
+	InstanceActionReplace InstanceAction = "Replace"
+	// InstanceActionCanary means the instance will be drained and terminated, and its replacement
+	// must stay healthy for the canary bake time before other instances are updated.
+	InstanceActionCanary InstanceAction = "Canary"
+)
+
+// RollingUpdatePlan describes which instances a rolling update will replace, and in which order.
+type RollingUpdatePlan struct {
+	// Groups are the instance groups to update, in the order they will be updated.
+	Groups []*GroupPlan `json:"groups"`
+	// EstimatedDuration is the minimum time the rolling update will take, derived from the configured
+	// intervals and validation durations. It does not include the time taken by drains or for
+	// replacement instances to join the cluster.
+	EstimatedDuration metav1.Duration `json:"estimatedDuration"`
+}
+
+// GroupPlan describes the update of a single instance group.
+type GroupPlan struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+	// MaxSurge and MaxUnavailable are the resolved rolling update settings for the group.
+	MaxSurge       int `json:"maxSurge"`
+	MaxUnavailable int `json:"maxUnavailable"`
+	// DrainAndTerminate is false if instances will not be drained and terminated.
+	DrainAndTerminate bool `json:"drainAndTerminate"`
+	// CanaryBakeTime is how long the cluster must keep validating after the canary instances are replaced.
+	CanaryBakeTime *metav1.Duration `json:"canaryBakeTime,omitempty"`
+	// WarmPool are the warm pool instances which will be deleted before the update starts.
+	WarmPool []*InstancePlan `json:"warmPool,omitempty"`
+	// Batches are the instances to replace, grouped by the order they will be replaced in.
+	// Instances in the same batch are replaced concurrently.
+	Batches [][]*InstancePlan `json:"batches,omitempty"`
+	// EstimatedDuration is the minimum time updating the group will take.
+	EstimatedDuration metav1.Duration `json:"estimatedDuration"`
+}
+
+// InstancePlan describes what a rolling update will do with a single instance.
+type InstancePlan struct {
+	ID       string         `json:"id"`
+	NodeName string         `json:"nodeName,omitempty"`
+	Status   string         `json:"status"`
+	Action   InstanceAction `json:"action"`
+}
+
+// Plan computes the plan for a rolling update of the given groups, without changing anything.
+func (c *RollingUpdateCluster) Plan(groups map[string]*cloudinstances.CloudInstanceGroup) (*RollingUpdatePlan, error) {
+	byRole := make(map[api.InstanceGroupRole]map[string]*cloudinstances.CloudInstanceGroup)
+	for k, group := range groups {
+		if c.Progress.isGroupCompleted(group.InstanceGroup.ObjectMeta.Name) {
+			continue
+		}
+
+		role := group.InstanceGroup.Spec.Role
+		switch role {
+		case api.InstanceGroupRoleNode, api.InstanceGroupRoleAPIServer, api.InstanceGroupRoleMaster, api.InstanceGroupRoleBastion:
+		default:
+			return nil, fmt.Errorf("unknown group type for group %q", group.InstanceGroup.ObjectMeta.Name)
+		}
+		if byRole[role] == nil {
+			byRole[role] = make(map[string]*cloudinstances.CloudInstanceGroup)
+		}
+		byRole[role][k] = group
+	}
+
+	plan := &RollingUpdatePlan{}
+	var total time.Duration
+
+	// This matches the order in RollingUpdate: bastions (in parallel), then masters, apiservers and nodes
+	// (up to maxConcurrentGroups in parallel)
+	var bastionDuration time.Duration
+	for _, k := range sortGroups(byRole[api.InstanceGroupRoleBastion]) {
+		groupPlan := c.planInstanceGroup(byRole[api.InstanceGroupRoleBastion][k], c.BastionInterval)
+		plan.Groups = append(plan.Groups, groupPlan)
+		if groupPlan.EstimatedDuration.Duration > bastionDuration {
+			bastionDuration = groupPlan.EstimatedDuration.Duration
+		}
+	}
+	total += bastionDuration
+
+	for _, role := range []api.InstanceGroupRole{api.InstanceGroupRoleMaster, api.InstanceGroupRoleAPIServer} {
+		interval := c.NodeInterval
+		if role == api.InstanceGroupRoleMaster {
+			interval = c.MasterInterval
+		}
+		for _, k := range sortGroups(byRole[role]) {
+			groupPlan := c.planInstanceGroup(byRole[role][k], interval)
+			plan.Groups = append(plan.Groups, groupPlan)
+			total += groupPlan.EstimatedDuration.Duration
+		}
+	}
+
+	// Node groups are started in order as soon as one of the concurrent update slots is free
+	slots := make([]time.Duration, c.maxConcurrentGroups())
+	for _, k := range sortGroups(byRole[api.InstanceGroupRoleNode]) {
+		groupPlan := c.planInstanceGroup(byRole[api.InstanceGroupRoleNode][k], c.NodeInterval)
+		plan.Groups = append(plan.Groups, groupPlan)
+		first := 0
+		for i := range slots {
+			if slots[i] < slots[first] {
+				first = i
+			}
+		}
+		slots[first] += groupPlan.EstimatedDuration.Duration
+	}
+	var nodeDuration time.Duration
+	for _, d := range slots {
+		if d > nodeDuration {
+			nodeDuration = d
+		}
+	}
+	total += nodeDuration
+
+	plan.EstimatedDuration = metav1.Duration{Duration: total}
+	return plan, nil
+}
+
+// planInstanceGroup mirrors the decisions made by rollingUpdateInstanceGroup.
+func (c *RollingUpdateCluster) planInstanceGroup(group *cloudinstances.CloudInstanceGroup, sleepAfterTerminate time.Duration) *GroupPlan {
+	noneReady := len(group.Ready) == 0
+	numInstances := len(group.Ready) + len(group.NeedUpdate)
+	var update []*cloudinstances.CloudInstance
+	update = append(update, group.NeedUpdate...)
+	if c.Force {
+		update = append(update, group.Ready...)
+	}
+	update = c.Progress.pendingInstances(group, update)
+
+	settings := resolveSettings(c.Cluster, group.InstanceGroup, numInstances)
+
+	groupPlan := &GroupPlan{
+		Name:              group.InstanceGroup.ObjectMeta.Name,
+		Role:              string(group.InstanceGroup.Spec.Role),
+		MaxSurge:          settings.MaxSurge.IntValue(),
+		MaxUnavailable:    settings.MaxUnavailable.IntValue(),
+		DrainAndTerminate: *settings.DrainAndTerminate,
+	}
+
+	if len(update) == 0 {
+		return groupPlan
+	}
+
+	maxSurge, maxConcurrency := c.updateLimits(group, settings, len(update))
+
+	var nonWarmPool []*cloudinstances.CloudInstance
+	for _, u := range update {
+		if u.State == cloudinstances.WarmPool {
+			groupPlan.WarmPool = append(groupPlan.WarmPool, newInstancePlan(u, InstanceActionDeleteWarmPool))
+		} else {
+			nonWarmPool = append(nonWarmPool, u)
+		}
+	}
+	update = prioritizeUpdate(nonWarmPool)
+
+	validateDuration := c.minimumValidationDuration()
+
+	var drainDelay time.Duration
+	if !group.InstanceGroup.IsBastion() && !c.CloudOnly {
+		drainDelay = c.PostDrainDelay
+	}
+
+	var duration time.Duration
+	if numCanary := canaryCount(group, settings, len(update)); numCanary > 0 {
+		for _, u := range update[:numCanary] {
+			groupPlan.Batches = append(groupPlan.Batches, []*InstancePlan{newInstancePlan(u, InstanceActionCanary)})
+			duration += drainDelay + sleepAfterTerminate + validateDuration
+		}
+		bakeTime := canaryBakeTime(settings)
+		groupPlan.CanaryBakeTime = &metav1.Duration{Duration: bakeTime}
+		duration += bakeTime
+		update = update[numCanary:]
+		noneReady = false
+		if maxSurge > len(update) {
+			maxSurge = len(update)
+		}
+	}
+
+	surge := make(map[string]bool)
+	if maxSurge > 0 && !c.CloudOnly {
+		for i := len(update) - maxSurge; i < len(update); i++ {
+			if i >= 0 && update[i].Status != cloudinstances.CloudInstanceStatusDetached {
+				surge[update[i].ID] = true
+			}
+		}
+		if len(surge) > 0 {
+			duration += sleepAfterTerminate + validateDuration
+			noneReady = false
+		}
+	}
+
+	if !groupPlan.DrainAndTerminate {
+		for _, u := range update {
+			if surge[u.ID] {
+				groupPlan.Batches = append(groupPlan.Batches, []*InstancePlan{newInstancePlan(u, InstanceActionSurge)})
+			}
+		}
+		groupPlan.EstimatedDuration = metav1.Duration{Duration: duration}
+		return groupPlan
+	}
+
+	var batch []*InstancePlan
+	for i, u := range update {
+		action := InstanceActionReplace
+		if surge[u.ID] {
+			action = InstanceActionSurge
+		}
+		batch = append(batch, newInstancePlan(u, action))
+
+		// Until one replacement validates, only a single instance is updated if none are ready
+		if len(batch) < maxConcurrency && (!noneReady || i > 0) && i != len(update)-1 {
+			continue
+		}
+		groupPlan.Batches = append(groupPlan.Batches, batch)
+		duration += drainDelay + sleepAfterTerminate + validateDuration
+		batch = nil
+	}
+
+	groupPlan.EstimatedDuration = metav1.Duration{Duration: duration}
+	return groupPlan
+}
+
+// minimumValidationDuration is the shortest time a successful validation after updating an instance can take.
+func (c *RollingUpdateCluster) minimumValidationDuration() time.Duration {
+	if c.CloudOnly || c.ValidateCount <= 1 {
+		return 0
+	}
+	return time.Duration(c.ValidateCount-1) * c.ValidateSuccessDuration
+}
+
+func newInstancePlan(u *cloudinstances.CloudInstance, action InstanceAction) *InstancePlan {
+	p := &InstancePlan{
+		ID:     u.ID,
+		Status: u.Status,
+		Action: action,
+	}
+	if u.Node != nil {
+		p.NodeName = u.Node.Name
+	}
+	return p
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+func planInstanceIDs(groupPlan *GroupPlan) [][]string {
+	var batches [][]string
+	for _, batch := range groupPlan.Batches {
+		var ids []string
+		for _, i := range batch {
+			ids = append(ids, i.ID)
+		}
+		batches = append(batches, ids)
+	}
+	return batches
+}
+
+func TestPlanOrdersGroupsByRole(t *testing.T) {
+	c, cloud := getTestSetup()
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	plan, err := c.Plan(groups)
+	if !assert.NoError(t, err, "plan") {
+		return
+	}
+
+	var names []string
+	for _, g := range plan.Groups {
+		names = append(names, g.Name)
+	}
+	assert.Equal(t, []string{"bastion-1", "master-1", "node-1", "node-2"}, names, "group order")
+	assert.Equal(t, [][]string{{"node-1a"}, {"node-1b"}, {"node-1c"}}, planInstanceIDs(plan.Groups[2]), "node-1 batches")
+	assert.Equal(t, "node-1a.local", plan.Groups[2].Batches[0][0].NodeName, "node name")
+	assert.Equal(t, InstanceActionReplace, plan.Groups[2].Batches[0][0].Action, "action")
+
+	// Planning must not change anything
+	assertGroupInstanceCount(t, cloud, "node-1", 3)
+	assert.Empty(t, c.K8sClient.(*fake.Clientset).Actions(), "kubernetes actions")
+}
+
+func TestPlanMaxUnavailable(t *testing.T) {
+	c, cloud := getTestSetup()
+	two := intstr.FromInt(2)
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxUnavailable: &two,
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 5, 5)
+	plan, err := c.Plan(groups)
+	if !assert.NoError(t, err, "plan") {
+		return
+	}
+
+	// As no instance is ready, only one instance is updated until its replacement validates
+	assert.Equal(t, [][]string{{"node-1a"}, {"node-1b", "node-1c"}, {"node-1d", "node-1e"}}, planInstanceIDs(plan.Groups[0]), "batches")
+	assert.Equal(t, 2, plan.Groups[0].MaxUnavailable, "maxUnavailable")
+}
+
+func TestPlanMaxSurge(t *testing.T) {
+	c, cloud := getTestSetup()
+	one := intstr.FromInt(1)
+	c.Cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxSurge: &one,
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 2)
+	plan, err := c.Plan(groups)
+	if !assert.NoError(t, err, "plan") {
+		return
+	}
+
+	assert.Equal(t, [][]string{{"node-1a"}, {"node-1b"}}, planInstanceIDs(plan.Groups[0]), "batches")
+	assert.Equal(t, InstanceActionReplace, plan.Groups[0].Batches[0][0].Action, "first instance action")
+	assert.Equal(t, InstanceActionSurge, plan.Groups[0].Batches[1][0].Action, "surged instance action")
+}
+
+func TestPlanEstimatedDuration(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.MasterInterval = 3 * time.Minute
+	c.NodeInterval = 2 * time.Minute
+	c.BastionInterval = 1 * time.Minute
+	c.PostDrainDelay = 5 * time.Second
+	c.ValidateCount = 3
+	c.ValidateSuccessDuration = 10 * time.Second
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	plan, err := c.Plan(groups)
+	if !assert.NoError(t, err, "plan") {
+		return
+	}
+
+	validate := 20 * time.Second
+	bastion := 1*time.Minute + validate
+	master := 2 * (5*time.Second + 3*time.Minute + validate)
+	node := 3 * (5*time.Second + 2*time.Minute + validate)
+	assert.Equal(t, bastion, plan.Groups[0].EstimatedDuration.Duration, "bastion duration")
+	assert.Equal(t, master, plan.Groups[1].EstimatedDuration.Duration, "master duration")
+	assert.Equal(t, node, plan.Groups[2].EstimatedDuration.Duration, "node duration")
+	assert.Equal(t, bastion+master+2*node, plan.EstimatedDuration.Duration, "total duration")
+}
+
+func TestPlanSkipsCompletedGroups(t *testing.T) {
+	c, cloud := getTestSetup()
+	progress := &RollingUpdateProgress{
+		Groups: map[string]*GroupProgress{
+			"node-1": {Role: "Node", Status: GroupProgressCompleted},
+			"node-2": {
+				Role:   "Node",
+				Status: GroupProgressInProgress,
+				Instances: map[string]InstanceProgressStatus{
+					"node-2a": InstanceProgressTerminated,
+					"node-2b": InstanceProgressPending,
+					"node-2c": InstanceProgressPending,
+				},
+			},
+		},
+	}
+	c.Progress = NewProgressTracker(getTestProgressPath(), nil, ProgressOptions{}, progress)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 3)
+	makeGroup(groups, c.K8sClient, cloud, "node-2", kopsapi.InstanceGroupRoleNode, 3, 3)
+	plan, err := c.Plan(groups)
+	if !assert.NoError(t, err, "plan") || !assert.Len(t, plan.Groups, 1, "groups") {
+		return
+	}
+
+	assert.Equal(t, "node-2", plan.Groups[0].Name, "group")
+	assert.Equal(t, [][]string{{"node-2b"}, {"node-2c"}}, planInstanceIDs(plan.Groups[0]), "batches")
+}
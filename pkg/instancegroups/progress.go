@@ -0,0 +1,287 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/util/pkg/vfs"
+	"sigs.k8s.io/yaml"
+)
+
+// GroupProgressStatus is the state of an instance group within a rolling update.
+type GroupProgressStatus string
+
+const (
+	// GroupProgressPending means no instance of the group has been replaced yet.
+	GroupProgressPending GroupProgressStatus = "Pending"
+	// GroupProgressInProgress means the group is being updated.
+	GroupProgressInProgress GroupProgressStatus = "InProgress"
+	// GroupProgressCompleted means all planned instances were replaced and the cluster validated afterwards.
+	GroupProgressCompleted GroupProgressStatus = "Completed"
+)
+
+// InstanceProgressStatus is the state of a single instance within a rolling update.
+type InstanceProgressStatus string
+
+const (
+	// InstanceProgressPending means the instance has not been touched yet.
+	InstanceProgressPending InstanceProgressStatus = "Pending"
+	// InstanceProgressDrained means the node of the instance has been drained.
+	InstanceProgressDrained InstanceProgressStatus = "Drained"
+	// InstanceProgressTerminated means the instance has been terminated.
+	InstanceProgressTerminated InstanceProgressStatus = "Terminated"
+)
+
+// RollingUpdateProgress is the checkpoint of a rolling update, persisted in the state store.
+type RollingUpdateProgress struct {
+	// StartedAt is when the rolling update was started.
+	StartedAt metav1.Time `json:"startedAt"`
+	// UpdatedAt is when the checkpoint was last written.
+	UpdatedAt metav1.Time `json:"updatedAt"`
+	// Completed is true once the rolling update has finished successfully.
+	Completed bool `json:"completed,omitempty"`
+	// Options are the settings the rolling update was started with.
+	Options ProgressOptions `json:"options"`
+	// Groups holds the progress of each instance group, keyed by instance group name.
+	Groups map[string]*GroupProgress `json:"groups,omitempty"`
+}
+
+// ProgressOptions are the rolling update settings that are reused when resuming.
+type ProgressOptions struct {
+	Force               bool            `json:"force,omitempty"`
+	CloudOnly           bool            `json:"cloudOnly,omitempty"`
+	FailOnDrainError    bool            `json:"failOnDrainError,omitempty"`
+	FailOnValidate      bool            `json:"failOnValidate,omitempty"`
+	MasterInterval      metav1.Duration `json:"masterInterval"`
+	NodeInterval        metav1.Duration `json:"nodeInterval"`
+	BastionInterval     metav1.Duration `json:"bastionInterval"`
+	PostDrainDelay      metav1.Duration `json:"postDrainDelay"`
+	ValidationTimeout   metav1.Duration `json:"validationTimeout"`
+	ValidateCount       int             `json:"validateCount"`
+	InstanceGroups      []string        `json:"instanceGroups,omitempty"`
+	InstanceGroupRoles  []string        `json:"instanceGroupRoles,omitempty"`
+	MaxConcurrentGroups int             `json:"maxConcurrentGroups,omitempty"`
+}
+
+// GroupProgress is the progress of a single instance group.
+type GroupProgress struct {
+	// Role is the role of the instance group.
+	Role string `json:"role"`
+	// Status is the state of the instance group.
+	Status GroupProgressStatus `json:"status"`
+	// Instances maps the IDs of the instances planned for replacement to their state.
+	Instances map[string]InstanceProgressStatus `json:"instances,omitempty"`
+}
+
+// Count returns the number of instances of the group in the given state.
+func (g *GroupProgress) Count(status InstanceProgressStatus) int {
+	n := 0
+	for _, s := range g.Instances {
+		if s == status {
+			n++
+		}
+	}
+	return n
+}
+
+// ReadProgress reads the rolling update checkpoint from the given path.
+// It returns nil if no checkpoint has been written.
+func ReadProgress(p vfs.Path) (*RollingUpdateProgress, error) {
+	b, err := p.ReadFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading rolling update progress %q: %v", p, err)
+	}
+
+	progress := &RollingUpdateProgress{}
+	if err := yaml.Unmarshal(b, progress); err != nil {
+		return nil, fmt.Errorf("error parsing rolling update progress %q: %v", p, err)
+	}
+	return progress, nil
+}
+
+// ProgressTracker records the progress of a rolling update to the state store as it happens.
+type ProgressTracker struct {
+	mutex    sync.Mutex
+	path     vfs.Path
+	acl      vfs.ACL
+	progress *RollingUpdateProgress
+}
+
+// NewProgressTracker builds a ProgressTracker writing to path.
+// If progress is non-nil, the rolling update resumes from that checkpoint.
+func NewProgressTracker(path vfs.Path, acl vfs.ACL, options ProgressOptions, progress *RollingUpdateProgress) *ProgressTracker {
+	if progress == nil {
+		progress = &RollingUpdateProgress{
+			StartedAt: metav1.NewTime(time.Now()),
+			Options:   options,
+		}
+	}
+	if progress.Groups == nil {
+		progress.Groups = make(map[string]*GroupProgress)
+	}
+	return &ProgressTracker{
+		path:     path,
+		acl:      acl,
+		progress: progress,
+	}
+}
+
+// isGroupCompleted returns true if the group was completed by an earlier run.
+func (t *ProgressTracker) isGroupCompleted(name string) bool {
+	if t == nil {
+		return false
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	g := t.progress.Groups[name]
+	return g != nil && g.Status == GroupProgressCompleted
+}
+
+// planGroup records the instances to be replaced in a group. If the group has already been planned,
+// it returns only the planned instances which have not been terminated yet.
+func (t *ProgressTracker) planGroup(group *cloudinstances.CloudInstanceGroup, update []*cloudinstances.CloudInstance) []*cloudinstances.CloudInstance {
+	if t == nil {
+		return update
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	name := group.InstanceGroup.ObjectMeta.Name
+	g := t.progress.Groups[name]
+	if g == nil || g.Status == GroupProgressPending {
+		g = &GroupProgress{
+			Role:      string(group.InstanceGroup.Spec.Role),
+			Status:    GroupProgressInProgress,
+			Instances: make(map[string]InstanceProgressStatus),
+		}
+		for _, u := range update {
+			g.Instances[u.ID] = InstanceProgressPending
+		}
+		t.progress.Groups[name] = g
+		t.save()
+		return update
+	}
+
+	return g.remaining(update)
+}
+
+// pendingInstances returns the instances of a group which remain to be replaced, without recording anything.
+func (t *ProgressTracker) pendingInstances(group *cloudinstances.CloudInstanceGroup, update []*cloudinstances.CloudInstance) []*cloudinstances.CloudInstance {
+	if t == nil {
+		return update
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	g := t.progress.Groups[group.InstanceGroup.ObjectMeta.Name]
+	if g == nil || g.Status == GroupProgressPending {
+		return update
+	}
+	return g.remaining(update)
+}
+
+// remaining returns the instances in update which were planned for replacement and not yet terminated.
+func (g *GroupProgress) remaining(update []*cloudinstances.CloudInstance) []*cloudinstances.CloudInstance {
+	var remaining []*cloudinstances.CloudInstance
+	for _, u := range update {
+		status, found := g.Instances[u.ID]
+		if !found {
+			klog.V(2).Infof("Skipping instance %q, it was not planned for replacement when the rolling update started", u.ID)
+			continue
+		}
+		if status == InstanceProgressTerminated {
+			continue
+		}
+		remaining = append(remaining, u)
+	}
+	return remaining
+}
+
+// recordInstance records the state of an instance.
+func (t *ProgressTracker) recordInstance(u *cloudinstances.CloudInstance, status InstanceProgressStatus) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	g := t.progress.Groups[u.CloudInstanceGroup.InstanceGroup.ObjectMeta.Name]
+	if g == nil {
+		return
+	}
+	g.Instances[u.ID] = status
+	t.save()
+}
+
+// recordGroupCompleted records that all planned instances in a group have been replaced.
+func (t *ProgressTracker) recordGroupCompleted(group *cloudinstances.CloudInstanceGroup) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	name := group.InstanceGroup.ObjectMeta.Name
+	g := t.progress.Groups[name]
+	if g == nil {
+		g = &GroupProgress{
+			Role: string(group.InstanceGroup.Spec.Role),
+		}
+		t.progress.Groups[name] = g
+	}
+	g.Status = GroupProgressCompleted
+	t.save()
+}
+
+// recordCompleted records that the rolling update has finished.
+func (t *ProgressTracker) recordCompleted() {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.progress.Completed = true
+	t.save()
+}
+
+// save writes the checkpoint; the caller must hold the mutex.
+// Failures are logged rather than returned, so that they do not interrupt the rolling update.
+func (t *ProgressTracker) save() {
+	t.progress.UpdatedAt = metav1.NewTime(time.Now())
+
+	b, err := yaml.Marshal(t.progress)
+	if err != nil {
+		klog.Warningf("error serializing rolling update progress: %v", err)
+		return
+	}
+	if err := t.path.WriteFile(bytes.NewReader(b), t.acl); err != nil {
+		klog.Warningf("error writing rolling update progress to %q: %v", t.path, err)
+	}
+}
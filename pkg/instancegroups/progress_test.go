@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func getTestProgressPath() vfs.Path {
+	return vfs.NewMemFSPath(vfs.NewMemFSContext(), "memfs://tests/test.k8s.local/rolling-update/progress.yaml")
+}
+
+func TestRollingUpdateRecordsProgress(t *testing.T) {
+	c, cloud := getTestSetup()
+	path := getTestProgressPath()
+	c.Progress = NewProgressTracker(path, nil, ProgressOptions{ValidateCount: 2}, nil)
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	progress, err := ReadProgress(path)
+	if !assert.NoError(t, err, "reading progress") || !assert.NotNil(t, progress, "progress") {
+		return
+	}
+	assert.True(t, progress.Completed, "rolling update completed")
+	assert.Equal(t, 2, progress.Options.ValidateCount, "recorded options")
+	assert.Len(t, progress.Groups, 4, "groups")
+	for name, g := range progress.Groups {
+		assert.Equal(t, GroupProgressCompleted, g.Status, "group %s status", name)
+		assert.Equal(t, len(g.Instances), g.Count(InstanceProgressTerminated), "group %s terminated instances", name)
+	}
+	assert.Len(t, progress.Groups["node-1"].Instances, 3, "node-1 planned instances")
+}
+
+func TestRollingUpdateResumeSkipsCompletedWork(t *testing.T) {
+	c, cloud := getTestSetup()
+	path := getTestProgressPath()
+
+	progress := &RollingUpdateProgress{
+		Groups: map[string]*GroupProgress{
+			"bastion-1": {Role: "Bastion", Status: GroupProgressCompleted},
+			"master-1":  {Role: "Master", Status: GroupProgressCompleted},
+			"node-1":    {Role: "Node", Status: GroupProgressCompleted},
+			"node-2": {
+				Role:   "Node",
+				Status: GroupProgressInProgress,
+				Instances: map[string]InstanceProgressStatus{
+					"node-2a": InstanceProgressTerminated,
+					"node-2b": InstanceProgressDrained,
+					"node-2c": InstanceProgressPending,
+				},
+			},
+		},
+	}
+	c.Progress = NewProgressTracker(path, nil, ProgressOptions{}, progress)
+
+	groups := getGroupsAllNeedUpdate(c.K8sClient, cloud)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "bastion-1", 1)
+	assertGroupInstanceCount(t, cloud, "master-1", 2)
+	assertGroupInstanceCount(t, cloud, "node-1", 3)
+	assertGroupInstanceCount(t, cloud, "node-2", 1)
+
+	saved, err := ReadProgress(path)
+	if !assert.NoError(t, err, "reading progress") || !assert.NotNil(t, saved, "progress") {
+		return
+	}
+	assert.True(t, saved.Completed, "rolling update completed")
+	assert.Equal(t, GroupProgressCompleted, saved.Groups["node-2"].Status, "node-2 status")
+	assert.Equal(t, 3, saved.Groups["node-2"].Count(InstanceProgressTerminated), "node-2 terminated instances")
+}
+
+func TestRollingUpdateResumeIgnoresUnplannedInstances(t *testing.T) {
+	c, cloud := getTestSetup()
+	c.Force = true
+
+	progress := &RollingUpdateProgress{
+		Groups: map[string]*GroupProgress{
+			"node-1": {
+				Role:   "Node",
+				Status: GroupProgressInProgress,
+				Instances: map[string]InstanceProgressStatus{
+					"node-1a": InstanceProgressPending,
+				},
+			},
+		},
+	}
+	c.Progress = NewProgressTracker(getTestProgressPath(), nil, ProgressOptions{}, progress)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	makeGroup(groups, c.K8sClient, cloud, "node-1", kopsapi.InstanceGroupRoleNode, 3, 0)
+	err := c.RollingUpdate(groups, &kopsapi.InstanceGroupList{})
+	assert.NoError(t, err, "rolling update")
+
+	assertGroupInstanceCount(t, cloud, "node-1", 2)
+}
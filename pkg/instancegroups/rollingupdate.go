@@ -19,6 +19,7 @@ package instancegroups
 import (
 	"context"
 	"fmt"
+	"io"
 	"sort"
 	"sync"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"k8s.io/klog/v2"
 	api "k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/eventstream"
 	"k8s.io/kops/pkg/validation"
 	"k8s.io/kops/upup/pkg/fi"
 )
@@ -77,6 +79,25 @@ type RollingUpdateCluster struct {
 
 	// ValidateCount is the amount of time that a cluster needs to be validated after single node update
 	ValidateCount int
+
+	// Progress records the progress of the rolling update to the state store, so it can be resumed.
+	// If nil, progress is not recorded.
+	Progress *ProgressTracker
+
+	// MaxConcurrentGroups is the maximum number of node instance groups to update at the same time.
+	// If zero, the cluster's rollingUpdateMaxConcurrentGroups setting is used, defaulting to 1.
+	MaxConcurrentGroups int
+
+	// HookRunner runs the rolling update hooks configured for the instance groups.
+	// If nil, hooks are run as specified in the API.
+	HookRunner HookRunner
+
+	// Events receives an event as each instance is drained, terminated and validated.
+	// If nil, no events are recorded.
+	Events eventstream.Recorder
+
+	// Out is where messages from reconciling instance groups are written, os.Stdout if nil.
+	Out io.Writer
 }
 
 // AdjustNeedUpdate adjusts the set of instances that need updating, using factors outside those known by the cloud implementation
@@ -102,6 +123,11 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 	nodeGroups := make(map[string]*cloudinstances.CloudInstanceGroup)
 	bastionGroups := make(map[string]*cloudinstances.CloudInstanceGroup)
 	for k, group := range groups {
+		if c.Progress.isGroupCompleted(group.InstanceGroup.ObjectMeta.Name) {
+			klog.Infof("Skipping instance group %q, it was completed by an earlier rolling update", group.InstanceGroup.ObjectMeta.Name)
+			continue
+		}
+
 		switch group.InstanceGroup.Spec.Role {
 		case api.InstanceGroupRoleNode:
 			nodeGroups[k] = group
@@ -180,22 +206,19 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 
 	// Upgrade nodes
 	{
-		// We run nodes in series, even if they are in separate instance groups
+		// By default we run nodes in series, even if they are in separate instance groups
 		// typically they will not being separate instance groups. If you roll the nodes in parallel
 		// you can get into a scenario where you can evict multiple statefulset pods from the same
 		// statefulset at the same time. Further improvements needs to be made to protect from this as
-		// well.
+		// well. Clusters whose node groups are independent may opt in to updating several at once
+		// with MaxConcurrentGroups.
 
 		for k := range nodeGroups {
 			results[k] = fmt.Errorf("function panic nodes")
 		}
 
-		for _, k := range sortGroups(nodeGroups) {
-			err := c.rollingUpdateInstanceGroup(nodeGroups[k], c.NodeInterval)
-
-			results[k] = err
-
-			// TODO: Bail on error?
+		if err := c.rollingUpdateNodeGroups(nodeGroups, results, &resultsMutex); err != nil {
+			return err
 		}
 	}
 
@@ -205,6 +228,8 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 		}
 	}
 
+	c.Progress.recordCompleted()
+
 	klog.Infof("Rolling update completed for cluster %q!", c.ClusterName)
 	return nil
 }
@@ -39,6 +39,15 @@ func resolveSettings(cluster *kops.Cluster, group *kops.InstanceGroup, numInstan
 		if rollingUpdate.MaxSurge == nil {
 			rollingUpdate.MaxSurge = def.MaxSurge
 		}
+		if rollingUpdate.Hooks == nil {
+			rollingUpdate.Hooks = def.Hooks
+		}
+		if rollingUpdate.Canary == nil {
+			rollingUpdate.Canary = def.Canary
+		}
+		if rollingUpdate.CanaryBakeTime == nil {
+			rollingUpdate.CanaryBakeTime = def.CanaryBakeTime
+		}
 	}
 
 	if rollingUpdate.DrainAndTerminate == nil {
@@ -77,5 +86,16 @@ func resolveSettings(cluster *kops.Cluster, group *kops.InstanceGroup, numInstan
 		rollingUpdate.MaxUnavailable = &unavailableInt
 	}
 
+	if rollingUpdate.Canary == nil {
+		val := intstr.FromInt(0)
+		rollingUpdate.Canary = &val
+	}
+
+	if rollingUpdate.Canary.Type == intstr.String {
+		canary, _ := intstr.GetValueFromIntOrPercent(rollingUpdate.Canary, numInstances, true)
+		canaryInt := intstr.FromInt(canary)
+		rollingUpdate.Canary = &canaryInt
+	}
+
 	return rollingUpdate
 }
@@ -47,6 +47,11 @@ func TestSettings(t *testing.T) {
 			defaultValue:    intstr.FromInt(0),
 			nonDefaultValue: intstr.FromInt(2),
 		},
+		{
+			name:            "Canary",
+			defaultValue:    intstr.FromInt(0),
+			nonDefaultValue: intstr.FromInt(1),
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			defaultCluster := &kops.RollingUpdate{}
@@ -221,3 +226,62 @@ func TestAWSDefault(t *testing.T) {
 	assert.Equal(t, intstr.Int, resolved.MaxUnavailable.Type)
 	assert.Equal(t, int32(0), resolved.MaxUnavailable.IntVal)
 }
+
+func TestHooks(t *testing.T) {
+	clusterHooks := []kops.RollingUpdateHook{
+		{
+			Name:  "cluster",
+			Phase: kops.RollingUpdateHookPhasePreDrain,
+			Exec:  &kops.RollingUpdateExecHook{Command: []string{"true"}},
+		},
+	}
+	groupHooks := []kops.RollingUpdateHook{
+		{
+			Name:  "group",
+			Phase: kops.RollingUpdateHookPhasePostValidate,
+			Exec:  &kops.RollingUpdateExecHook{Command: []string{"true"}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name          string
+		clusterHooks  []kops.RollingUpdateHook
+		groupHooks    []kops.RollingUpdateHook
+		expectedHooks []kops.RollingUpdateHook
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:          "cluster",
+			clusterHooks:  clusterHooks,
+			expectedHooks: clusterHooks,
+		},
+		{
+			name:          "group",
+			groupHooks:    groupHooks,
+			expectedHooks: groupHooks,
+		},
+		{
+			name:          "group overrides cluster",
+			clusterHooks:  clusterHooks,
+			groupHooks:    groupHooks,
+			expectedHooks: groupHooks,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster := kops.Cluster{
+				Spec: kops.ClusterSpec{
+					RollingUpdate: &kops.RollingUpdate{Hooks: tc.clusterHooks},
+				},
+			}
+			instanceGroup := kops.InstanceGroup{
+				Spec: kops.InstanceGroupSpec{
+					RollingUpdate: &kops.RollingUpdate{Hooks: tc.groupHooks},
+				},
+			}
+			resolved := resolveSettings(&cluster, &instanceGroup, 1)
+			assert.Equal(t, tc.expectedHooks, resolved.Hooks)
+		})
+	}
+}
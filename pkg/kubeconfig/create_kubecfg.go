@@ -122,6 +122,21 @@ func BuildKubecfg(cluster *kops.Cluster, keyStore fi.Keystore, secretStore fi.Se
 		} else {
 			return nil, fmt.Errorf("cannot find CA certificate")
 		}
+
+		// During a CA rotation the API server may be serving a certificate issued by any CA in the keyset
+		if caStore, ok := keyStore.(fi.CAStore); ok {
+			pool, err := caStore.FindCertificatePool(fi.CertificateIDCA)
+			if err != nil {
+				return nil, fmt.Errorf("error fetching CA certificates: %v", err)
+			}
+			if pool != nil && len(pool.Secondary) != 0 {
+				bundle, err := pool.AsString()
+				if err != nil {
+					return nil, err
+				}
+				b.CACert = []byte(bundle)
+			}
+		}
 	}
 
 	if admin != 0 {
@@ -17,8 +17,11 @@ limitations under the License.
 package gcemodel
 
 import (
+	"fmt"
+
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/wellknownports"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/gcetasks"
 )
@@ -110,6 +113,9 @@ func (b *FirewallModelBuilder) Build(c *fi.ModelBuilderContext) error {
 			TargetTags: []string{b.GCETagForRole(kops.InstanceGroupRoleMaster)},
 			Allowed:    []string{"tcp:443", "tcp:4194"},
 		}
+		if b.UseKopsControllerForNodeBootstrap() {
+			t.Allowed = append(t.Allowed, fmt.Sprintf("tcp:%d", wellknownports.KopsControllerPort))
+		}
 		c.AddTask(t)
 	}
 
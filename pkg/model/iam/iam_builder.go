@@ -252,6 +252,7 @@ func (r *NodeRoleAPIServer) BuildAWSPolicy(b *PolicyBuilder) (*Policy, error) {
 	if p, err = b.AddS3Permissions(p); err != nil {
 		return nil, fmt.Errorf("failed to generate AWS IAM S3 access statements: %v", err)
 	}
+	addStateStoreEncryptionPermissions(b, p)
 
 	if b.KMSKeys != nil && len(b.KMSKeys) != 0 {
 		addKMSIAMPolicies(p, stringorslice.Slice(b.KMSKeys), b.Cluster.Spec.IAM.Legacy)
@@ -302,6 +303,7 @@ func (r *NodeRoleMaster) BuildAWSPolicy(b *PolicyBuilder) (*Policy, error) {
 	if p, err = b.AddS3Permissions(p); err != nil {
 		return nil, fmt.Errorf("failed to generate AWS IAM S3 access statements: %v", err)
 	}
+	addStateStoreEncryptionPermissions(b, p)
 
 	if b.KMSKeys != nil && len(b.KMSKeys) != 0 {
 		addKMSIAMPolicies(p, stringorslice.Slice(b.KMSKeys), b.Cluster.Spec.IAM.Legacy)
@@ -361,6 +363,7 @@ func (r *NodeRoleNode) BuildAWSPolicy(b *PolicyBuilder) (*Policy, error) {
 	if p, err = b.AddS3Permissions(p); err != nil {
 		return nil, fmt.Errorf("failed to generate AWS IAM S3 access statements: %v", err)
 	}
+	addStateStoreEncryptionPermissions(b, p)
 
 	if !b.UseServiceAccountIAM && b.Cluster.Spec.IAM.Legacy {
 		addLegacyDNSControllerPermissions(b, p)
@@ -835,6 +838,38 @@ func addKMSIAMPolicies(p *Policy, resource stringorslice.StringOrSlice, legacyIA
 	})
 }
 
+// addStateStoreEncryptionPermissions allows decrypting the secrets and private keys in the state store,
+// if they are encrypted with an AWS KMS key, including a previous key. Access to keys identified by an alias
+// must be granted separately.
+func addStateStoreEncryptionPermissions(b *PolicyBuilder, p *Policy) {
+	encryption := b.Cluster.Spec.StateStoreEncryption
+	if encryption == nil {
+		return
+	}
+
+	var keyARNs []string
+	for _, uri := range append([]string{encryption.KeyEncryptionKey}, encryption.PreviousKeyEncryptionKeys...) {
+		if !strings.HasPrefix(uri, "awskms://") {
+			continue
+		}
+		keyARN := strings.TrimPrefix(uri, "awskms://")
+		if !strings.Contains(keyARN, ":key/") {
+			klog.Warningf("cannot grant access to state store encryption key %q; grant kms:Decrypt on the key with additionalPolicies", keyARN)
+			continue
+		}
+		keyARNs = append(keyARNs, keyARN)
+	}
+	if len(keyARNs) == 0 {
+		return
+	}
+
+	p.Statement = append(p.Statement, &Statement{
+		Effect:   StatementEffectAllow,
+		Action:   stringorslice.Of("kms:Decrypt"),
+		Resource: stringorslice.Slice(keyARNs),
+	})
+}
+
 func addNodeEC2Policies(p *Policy, resource stringorslice.StringOrSlice) {
 	// Protokube makes a DescribeInstances call, DescribeRegions when finding S3 State Bucket
 	p.Statement = append(p.Statement, &Statement{
@@ -18,6 +18,7 @@ package iam
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -226,3 +227,43 @@ func TestPolicyGeneration(t *testing.T) {
 		golden.AssertMatchesFile(t, actualPolicy, x.Policy)
 	}
 }
+
+func TestStateStoreEncryptionPermissions(t *testing.T) {
+	keyARN := "arn:aws:kms:us-test-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	previousKeyARN := "arn:aws:kms:us-test-1:123456789012:key/5678abcd-12ab-34cd-56ef-1234567890ab"
+	for _, role := range []Subject{&NodeRoleMaster{}, &NodeRoleAPIServer{}, &NodeRoleNode{}} {
+		b := &PolicyBuilder{
+			Cluster: &kops.Cluster{
+				Spec: kops.ClusterSpec{
+					ConfigStore: "s3://kops-tests/iam-builder-test.k8s.local",
+					IAM:         &kops.IAMSpec{},
+					StateStoreEncryption: &kops.StateStoreEncryptionSpec{
+						KeyEncryptionKey:          "awskms://" + keyARN,
+						PreviousKeyEncryptionKeys: []string{"awskms://" + previousKeyARN},
+					},
+				},
+			},
+			Role:   role,
+			Region: "us-test-1",
+		}
+		b.Cluster.SetName("iam-builder-test.k8s.local")
+
+		p, err := b.BuildAWSPolicy()
+		if err != nil {
+			t.Fatalf("unexpected error generating policy for %T: %v", role, err)
+		}
+
+		found := false
+		for _, statement := range p.Statement {
+			if len(statement.Action.Value()) != 1 || statement.Action.Value()[0] != "kms:Decrypt" {
+				continue
+			}
+			if reflect.DeepEqual(statement.Resource.Value(), []string{keyARN, previousKeyARN}) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("policy for %T does not allow decrypting with the state store encryption keys", role)
+		}
+	}
+}
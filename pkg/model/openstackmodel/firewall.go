@@ -317,6 +317,25 @@ func (b *FirewallModelBuilder) addKubeletRules(c *fi.ModelBuilderContext, sgMap
 	return nil
 }
 
+// addKopsControllerRules - Allow nodes to bootstrap from kops-controller
+func (b *FirewallModelBuilder) addKopsControllerRules(c *fi.ModelBuilderContext, sgMap map[string]*openstacktasks.SecurityGroup) {
+	if !b.UseKopsControllerForNodeBootstrap() {
+		return
+	}
+	masterSG := sgMap[b.SecurityGroupName(kops.InstanceGroupRoleMaster)]
+	nodeSG := sgMap[b.SecurityGroupName(kops.InstanceGroupRoleNode)]
+	kopsControllerIngress := &openstacktasks.SecurityGroupRule{
+		Lifecycle:    b.Lifecycle,
+		Direction:    s(string(rules.DirIngress)),
+		Protocol:     s(IPProtocolTCP),
+		EtherType:    s(IPV4),
+		PortRangeMin: i(wellknownports.KopsControllerPort),
+		PortRangeMax: i(wellknownports.KopsControllerPort),
+	}
+	// allow 3988 port from nodeSG to masterSG
+	b.addDirectionalGroupRule(c, masterSG, nodeSG, kopsControllerIngress)
+}
+
 // addNodeExporterRules - Allow 9100 TCP port from nodesg
 func (b *FirewallModelBuilder) addNodeExporterRules(c *fi.ModelBuilderContext, sgMap map[string]*openstacktasks.SecurityGroup) error {
 	masterName := b.SecurityGroupName(kops.InstanceGroupRoleMaster)
@@ -628,6 +647,8 @@ func (b *FirewallModelBuilder) Build(c *fi.ModelBuilderContext) error {
 	b.addKubeletRules(c, sgMap)
 	//Add Node exporter Rules
 	b.addNodeExporterRules(c, sgMap)
+	//Add kops-controller Rules
+	b.addKopsControllerRules(c, sgMap)
 	// Protokube Rules
 	b.addProtokubeRules(c, sgMap)
 	//Allow necessary local traffic
@@ -74,7 +74,7 @@ func (b *ServerGroupModelBuilder) buildInstances(c *fi.ModelBuilderContext, sg *
 		return err
 	}
 	igMeta[openstack.TagKopsNetwork] = netName
-	igMeta["KopsInstanceGroup"] = ig.Name
+	igMeta[openstack.TagKopsInstanceGroup] = ig.Name
 	igMeta["KopsRole"] = string(ig.Spec.Role)
 	igMeta[openstack.INSTANCE_GROUP_GENERATION] = fmt.Sprintf("%d", ig.GetGeneration())
 	igMeta[openstack.CLUSTER_GENERATION] = fmt.Sprintf("%d", b.Cluster.GetGeneration())
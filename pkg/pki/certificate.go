@@ -91,6 +91,39 @@ func ParsePEMCertificate(pemData []byte) (*Certificate, error) {
 	return c, nil
 }
 
+// ParsePEMCertificates parses all the certificates in the PEM data, such as a CA bundle, in order
+func ParsePEMCertificates(pemData []byte) ([]*Certificate, error) {
+	var certs []*Certificate
+	for {
+		block, rest := pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, &Certificate{
+				Subject:     cert.Subject,
+				Certificate: cert,
+				PublicKey:   cert.PublicKey,
+				IsCA:        cert.IsCA,
+			})
+		} else {
+			klog.Infof("Ignoring unexpected PEM block: %q", block.Type)
+		}
+
+		pemData = rest
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("could not parse certificate")
+	}
+	return certs, nil
+}
+
 var _ io.WriterTo = &Certificate{}
 
 func parsePEMCertificate(pemData []byte) (*x509.Certificate, error) {
@@ -165,3 +165,30 @@ func TestCertificateRoundTrip(t *testing.T) {
 		t.Fatalf("unexpected output from Certificate WriteTo: %q", b.String())
 	}
 }
+
+func TestParsePEMCertificates(t *testing.T) {
+	var bundle bytes.Buffer
+	var serials []string
+	for i := 1; i <= 2; i++ {
+		cert, _, _, err := IssueCert(&IssueCertRequest{
+			Type:    "ca",
+			Subject: pkix.Name{CommonName: "kubernetes"},
+			Serial:  BuildPKISerial(int64(i)),
+		}, nil)
+		require.NoError(t, err)
+		_, err = cert.WriteTo(&bundle)
+		require.NoError(t, err)
+		serials = append(serials, cert.Certificate.SerialNumber.String())
+	}
+
+	certs, err := ParsePEMCertificates(bundle.Bytes())
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	for i, cert := range certs {
+		assert.Equal(t, serials[i], cert.Certificate.SerialNumber.String(), "certificate %d", i)
+		assert.True(t, cert.IsCA, "certificate %d", i)
+	}
+
+	_, err = ParsePEMCertificates([]byte("not a certificate"))
+	assert.Error(t, err)
+}
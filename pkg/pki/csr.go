@@ -46,10 +46,9 @@ func BuildPKISerial(timestamp int64) *big.Int {
 }
 
 func signNewCertificate(privateKey *PrivateKey, template *x509.Certificate, signer *x509.Certificate, signerPrivateKey *PrivateKey) (*Certificate, error) {
-	if template.PublicKey == nil {
-		rsaPrivateKey, ok := privateKey.Key.(*rsa.PrivateKey)
-		if ok {
-			template.PublicKey = rsaPrivateKey.Public()
+	if template.PublicKey == nil && privateKey != nil {
+		if publicKey, err := privateKey.Public(); err == nil {
+			template.PublicKey = publicKey
 		}
 	}
 
@@ -86,6 +85,11 @@ func signNewCertificate(privateKey *PrivateKey, template *x509.Certificate, sign
 		template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
 	}
 
+	// Only RSA keys are used for key encipherment
+	if _, isRSA := template.PublicKey.(*rsa.PublicKey); !isRSA {
+		template.KeyUsage &^= x509.KeyUsageKeyEncipherment
+	}
+
 	if template.ExtKeyUsage == nil && !template.IsCA {
 		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
 	}
@@ -48,6 +48,8 @@ type IssueCertRequest struct {
 	PublicKey crypto.PublicKey
 	// PrivateKey is the private key for this certificate. If both this and PublicKey are nil, a new private key will be generated.
 	PrivateKey *PrivateKey
+	// KeyAlgorithm is the algorithm of the private key to generate, one of KeyAlgorithms. The default is RSA.
+	KeyAlgorithm string
 	// Validity is the certificate validity. The default is 10 years.
 	Validity time.Duration
 
@@ -137,7 +139,7 @@ func IssueCert(request *IssueCertRequest, keystore Keystore) (issuedCertificate
 		template.PublicKey = request.PublicKey
 	} else if privateKey == nil {
 		var err error
-		privateKey, err = GeneratePrivateKey()
+		privateKey, err = GeneratePrivateKeyWithAlgorithm(request.KeyAlgorithm)
 		if err != nil {
 			return nil, nil, nil, err
 		}
@@ -222,3 +222,50 @@ func TestIssueCert(t *testing.T) {
 	}
 
 }
+
+func TestIssueCertKeyAlgorithms(t *testing.T) {
+	for _, caAlgorithm := range KeyAlgorithms {
+		for _, algorithm := range KeyAlgorithms {
+			t.Run(caAlgorithm+"/"+algorithm, func(t *testing.T) {
+				caCertificate, caPrivateKey, _, err := IssueCert(&IssueCertRequest{
+					Type:         "ca",
+					Subject:      pkix.Name{CommonName: "Test CA"},
+					KeyAlgorithm: caAlgorithm,
+				}, nil)
+				require.NoError(t, err)
+				caKeyAlgorithm, err := caPrivateKey.Algorithm()
+				require.NoError(t, err)
+				assert.Equal(t, caAlgorithm, caKeyAlgorithm, "CA key algorithm")
+
+				keystore := &mockKeystore{
+					t:      t,
+					signer: "signer",
+					cert:   caCertificate,
+					key:    caPrivateKey,
+				}
+				certificate, key, _, err := IssueCert(&IssueCertRequest{
+					Signer:       "signer",
+					Type:         "server",
+					Subject:      pkix.Name{CommonName: "Test server"},
+					KeyAlgorithm: algorithm,
+				}, keystore)
+				require.NoError(t, err)
+
+				cert := certificate.Certificate
+				assert.NoError(t, cert.CheckSignatureFrom(caCertificate.Certificate), "check signature")
+				keyAlgorithm, err := key.Algorithm()
+				require.NoError(t, err)
+				assert.Equal(t, algorithm, keyAlgorithm, "key algorithm")
+				publicKey, err := key.Public()
+				require.NoError(t, err)
+				assert.Equal(t, publicKey, cert.PublicKey, "certificate public key matches private key")
+
+				expectedKeyUsage := x509.KeyUsageDigitalSignature
+				if algorithm == KeyAlgorithmRSA {
+					expectedKeyUsage |= x509.KeyUsageKeyEncipherment
+				}
+				assert.Equal(t, expectedKeyUsage, cert.KeyUsage, "KeyUsage")
+			})
+		}
+	}
+}
@@ -19,6 +19,9 @@ package pki
 import (
 	"bytes"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	crypto_rand "crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -38,6 +41,20 @@ import (
 // (as generating RSA keys can be a bottleneck for testing)
 var DefaultPrivateKeySize = 2048
 
+const (
+	// KeyAlgorithmRSA generates RSA keys of DefaultPrivateKeySize bits
+	KeyAlgorithmRSA = "RSA"
+	// KeyAlgorithmECDSAP256 generates ECDSA keys on the NIST P-256 curve
+	KeyAlgorithmECDSAP256 = "ECDSA-P256"
+	// KeyAlgorithmECDSAP384 generates ECDSA keys on the NIST P-384 curve
+	KeyAlgorithmECDSAP384 = "ECDSA-P384"
+	// KeyAlgorithmEd25519 generates Ed25519 keys
+	KeyAlgorithmEd25519 = "Ed25519"
+)
+
+// KeyAlgorithms are the supported algorithms for generating private keys
+var KeyAlgorithms = []string{KeyAlgorithmRSA, KeyAlgorithmECDSAP256, KeyAlgorithmECDSAP384, KeyAlgorithmEd25519}
+
 func ParsePEMPrivateKey(data []byte) (*PrivateKey, error) {
 	k, err := parsePEMPrivateKey(data)
 	if err != nil {
@@ -49,7 +66,41 @@ func ParsePEMPrivateKey(data []byte) (*PrivateKey, error) {
 	return &PrivateKey{Key: k}, nil
 }
 
+// GeneratePrivateKey generates an RSA private key.
 func GeneratePrivateKey() (*PrivateKey, error) {
+	return GeneratePrivateKeyWithAlgorithm(KeyAlgorithmRSA)
+}
+
+// GeneratePrivateKeyWithAlgorithm generates a private key with one of the KeyAlgorithms.
+// If the algorithm is empty, an RSA key is generated.
+func GeneratePrivateKeyWithAlgorithm(algorithm string) (*PrivateKey, error) {
+	switch algorithm {
+	case "", KeyAlgorithmRSA:
+		return generateRSAPrivateKey()
+	case KeyAlgorithmECDSAP256:
+		return generateECDSAPrivateKey(elliptic.P256())
+	case KeyAlgorithmECDSAP384:
+		return generateECDSAPrivateKey(elliptic.P384())
+	case KeyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(crypto_rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("error generating Ed25519 private key: %v", err)
+		}
+		return &PrivateKey{Key: key}, nil
+	default:
+		return nil, fmt.Errorf("unknown key algorithm %q", algorithm)
+	}
+}
+
+func generateECDSAPrivateKey(curve elliptic.Curve) (*PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(curve, crypto_rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("error generating ECDSA private key: %v", err)
+	}
+	return &PrivateKey{Key: key}, nil
+}
+
+func generateRSAPrivateKey() (*PrivateKey, error) {
 	var rsaKeySize = DefaultPrivateKeySize
 
 	if os.Getenv("KOPS_RSA_PRIVATE_KEY_SIZE") != "" {
@@ -75,6 +126,35 @@ type PrivateKey struct {
 	Key crypto.PrivateKey
 }
 
+// Public returns the public key corresponding to the private key.
+func (k *PrivateKey) Public() (crypto.PublicKey, error) {
+	signer, ok := k.Key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unknown private key type: %T", k.Key)
+	}
+	return signer.Public(), nil
+}
+
+// Algorithm returns the algorithm of the private key, as one of the KeyAlgorithms.
+func (k *PrivateKey) Algorithm() (string, error) {
+	switch pk := k.Key.(type) {
+	case *rsa.PrivateKey:
+		return KeyAlgorithmRSA, nil
+	case *ecdsa.PrivateKey:
+		switch pk.Curve {
+		case elliptic.P256():
+			return KeyAlgorithmECDSAP256, nil
+		case elliptic.P384():
+			return KeyAlgorithmECDSAP384, nil
+		}
+		return "", fmt.Errorf("unknown ECDSA curve %q", pk.Curve.Params().Name)
+	case ed25519.PrivateKey:
+		return KeyAlgorithmEd25519, nil
+	default:
+		return "", fmt.Errorf("unknown private key type: %T", k.Key)
+	}
+}
+
 func (k *PrivateKey) AsString() (string, error) {
 	// Nicer behaviour because this is called from templates
 	if k == nil {
@@ -153,7 +233,14 @@ func (k *PrivateKey) WriteTo(w io.Writer) (int64, error) {
 
 	switch pk := k.Key.(type) {
 	case *rsa.PrivateKey:
+		// RSA keys are written in PKCS#1 form, so that they remain readable by older versions
 		err = pem.Encode(w, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(pk)})
+	case *ecdsa.PrivateKey, ed25519.PrivateKey:
+		var der []byte
+		der, err = x509.MarshalPKCS8PrivateKey(pk)
+		if err == nil {
+			err = pem.Encode(w, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		}
 	default:
 		return 0, fmt.Errorf("unknown private key type: %T", k.Key)
 	}
@@ -194,6 +281,9 @@ func parsePEMPrivateKey(pemData []byte) (crypto.PrivateKey, error) {
 				return nil, err
 			}
 			return k.(crypto.PrivateKey), nil
+		} else if block.Type == "EC PRIVATE KEY" {
+			klog.V(10).Infof("Parsing pem block: %q", block.Type)
+			return x509.ParseECPrivateKey(block.Bytes)
 		} else {
 			klog.Infof("Ignoring unexpected PEM block: %q", block.Type)
 		}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/kops/pkg/certificates"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// ValidateCertificateExpiry returns a failure for each certificate in use by the cluster which expires within the threshold.
+func ValidateCertificateExpiry(keyStore fi.CAStore, threshold time.Duration) ([]*ValidationError, error) {
+	entries, err := certificates.List(keyStore)
+	if err != nil {
+		return nil, fmt.Errorf("error listing certificates: %v", err)
+	}
+	return certificateExpiryFailures(entries, threshold, time.Now()), nil
+}
+
+func certificateExpiryFailures(entries []*certificates.Entry, threshold time.Duration, now time.Time) []*ValidationError {
+	var failures []*ValidationError
+	for _, entry := range certificates.ExpiringWithin(entries, threshold, now) {
+		if !entry.InUse {
+			continue
+		}
+
+		var message string
+		if entry.NotAfter.Before(now) {
+			message = fmt.Sprintf("certificate %q of keyset %q expired at %s", entry.ID, entry.Keyset, entry.NotAfter.UTC().Format(time.RFC3339))
+		} else {
+			message = fmt.Sprintf("certificate %q of keyset %q expires at %s", entry.ID, entry.Keyset, entry.NotAfter.UTC().Format(time.RFC3339))
+		}
+		if entry.Component != "" {
+			message += "; it is used as the " + entry.Component
+		}
+		failures = append(failures, &ValidationError{
+			Kind:    "Certificate",
+			Name:    entry.Keyset,
+			Message: message,
+		})
+	}
+	return failures
+}
@@ -19,6 +19,7 @@ package validation
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/certificates"
 	"k8s.io/kops/pkg/cloudinstances"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
@@ -892,3 +894,30 @@ func Test_ValidateDetachedNodesNotValidated(t *testing.T) {
 		printDebug(t, v)
 	}
 }
+
+func Test_ValidateCertificateExpiry(t *testing.T) {
+	now := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	entries := []*certificates.Entry{
+		{Keyset: "ca", ID: "1", Component: "Kubernetes CA", NotAfter: now.Add(10 * 24 * time.Hour), Primary: true, InUse: true},
+		{Keyset: "ca", ID: "0", NotAfter: now.Add(-time.Hour)},
+		{Keyset: "kubelet", ID: "1", NotAfter: now.Add(-time.Hour), Primary: true, InUse: true},
+		{Keyset: "service-account", ID: "1", NotAfter: now.Add(100 * 24 * time.Hour), Primary: true, InUse: true},
+	}
+
+	failures := certificateExpiryFailures(entries, 30*24*time.Hour, now)
+	expected := []*ValidationError{
+		{
+			Kind:    "Certificate",
+			Name:    "ca",
+			Message: "certificate \"1\" of keyset \"ca\" expires at 2021-03-11T00:00:00Z; it is used as the Kubernetes CA",
+		},
+		{
+			Kind:    "Certificate",
+			Name:    "kubelet",
+			Message: "certificate \"1\" of keyset \"kubelet\" expired at 2021-02-28T23:00:00Z",
+		},
+	}
+	assert.Equal(t, expected, failures)
+
+	assert.Empty(t, certificateExpiryFailures(entries[3:], 30*24*time.Hour, now))
+}
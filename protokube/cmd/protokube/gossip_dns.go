@@ -0,0 +1,77 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	"k8s.io/klog/v2"
+	gossipdns "k8s.io/kops/protokube/pkg/gossip/dns"
+)
+
+// gossipDNSInterface is the dummy interface to which we add the address of the gossip DNS server, if it is not already assigned
+const gossipDNSInterface = "kops-gossip-dns"
+
+// runGossipDNSServer serves the gossip DNS records on port 53 of the link-local address, adding the address to the node if needed
+func runGossipDNSServer(server *gossipdns.DNSServer, address string) error {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return fmt.Errorf("invalid gossip DNS address %q", address)
+	}
+	if err := ensureLocalAddress(ip); err != nil {
+		return err
+	}
+
+	listen := net.JoinHostPort(ip.String(), "53")
+	klog.Infof("serving gossip DNS records on %s", listen)
+	return server.ListenAndServe(listen)
+}
+
+// ensureLocalAddress adds the address to a dummy interface, unless it is already assigned to an interface
+func ensureLocalAddress(ip net.IP) error {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return fmt.Errorf("error listing interface addresses: %v", err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return nil
+		}
+	}
+
+	klog.Infof("adding address %s to interface %s", ip, gossipDNSInterface)
+	if _, err := net.InterfaceByName(gossipDNSInterface); err != nil {
+		if err := runIP("link", "add", gossipDNSInterface, "type", "dummy"); err != nil {
+			return err
+		}
+	}
+	if err := runIP("link", "set", gossipDNSInterface, "up"); err != nil {
+		return err
+	}
+	return runIP("addr", "add", ip.String()+"/32", "dev", gossipDNSInterface)
+}
+
+func runIP(args ...string) error {
+	out, err := exec.Command("ip", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error running ip %s: %v: %s", strings.Join(args, " "), err, string(out))
+	}
+	return nil
+}
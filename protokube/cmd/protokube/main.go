@@ -63,10 +63,11 @@ func main() {
 func run() error {
 	var zones []string
 	var applyTaints, initializeRBAC, containerized, master, tlsAuth bool
-	var cloud, clusterID, dnsServer, dnsProviderID, dnsInternalSuffix, gossipSecret, gossipListen, gossipProtocol, gossipSecretSecondary, gossipListenSecondary, gossipProtocolSecondary string
+	var cloud, clusterID, dnsServer, dnsProviderID, dnsInternalSuffix, gossipSecret, gossipListen, gossipProtocol, gossipSecretSecondary, gossipListenSecondary, gossipProtocolSecondary, gossipDNSListen string
 	var flagChannels, tlsCert, tlsKey, tlsCA, peerCert, peerKey, peerCA string
 	var etcdBackupImage, etcdBackupStore, etcdImageSource, etcdElectionTimeout, etcdHeartbeatInterval string
 	var dnsUpdateInterval int
+	gossipDNSHostsFile := true
 
 	flag.BoolVar(&applyTaints, "apply-taints", applyTaints, "Apply taints to nodes based on the role")
 	flag.BoolVar(&containerized, "containerized", containerized, "Set if we are running containerized.")
@@ -84,6 +85,8 @@ func run() error {
 	flag.StringVar(&gossipProtocolSecondary, "gossip-protocol-secondary", "memberlist", "mesh/memberlist")
 	flag.StringVar(&gossipListenSecondary, "gossip-listen-secondary", fmt.Sprintf("0.0.0.0:%d", wellknownports.ProtokubeGossipMemberlist), "address:port on which to bind for gossip")
 	flags.StringVar(&gossipSecretSecondary, "gossip-secret-secondary", gossipSecret, "Secret to use to secure gossip")
+	flags.StringVar(&gossipDNSListen, "gossip-dns-listen", gossipDNSListen, "If set, serve the gossip DNS records on port 53 of this link-local IP address")
+	flags.BoolVar(&gossipDNSHostsFile, "gossip-dns-hosts-file", gossipDNSHostsFile, "Write the gossip DNS records to /etc/hosts")
 	flag.StringVar(&peerCA, "peer-ca", peerCA, "Path to a file containing the peer ca in PEM format")
 	flag.StringVar(&peerCert, "peer-cert", peerCert, "Path to a file containing the peer certificate")
 	flag.StringVar(&peerKey, "peer-key", peerKey, "Path to a file containing the private key for the peers")
@@ -243,8 +246,19 @@ func run() error {
 	var dnsProvider protokube.DNSProvider
 
 	if dnsProviderID == "gossip" {
-		dnsTarget := &gossipdns.HostsFile{
-			Path: path.Join(rootfs, "etc/hosts"),
+		var dnsTarget gossipdns.MultiTarget
+		if gossipDNSHostsFile {
+			dnsTarget = append(dnsTarget, &gossipdns.HostsFile{
+				Path: path.Join(rootfs, "etc/hosts"),
+			})
+		}
+		if gossipDNSListen != "" {
+			gossipDNSServer := gossipdns.NewDNSServer()
+			dnsTarget = append(dnsTarget, gossipDNSServer)
+			go func() {
+				err := runGossipDNSServer(gossipDNSServer, gossipDNSListen)
+				klog.Fatalf("gossip DNS server exited unexpectedly: %v", err)
+			}()
 		}
 
 		var gossipSeeds gossip.SeedProvider
@@ -359,7 +373,7 @@ func run() error {
 				return fmt.Errorf("unexpected zone flags: %q", err)
 			}
 
-			dnsController, err = dns.NewDNSController([]dnsprovider.Interface{dnsProvider}, zoneRules, dnsUpdateInterval)
+			dnsController, err = dns.NewDNSController([]dnsprovider.Interface{dnsProvider}, zoneRules, dnsUpdateInterval, nil)
 			if err != nil {
 				return err
 			}
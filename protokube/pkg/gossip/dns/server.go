@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	miekgdns "github.com/miekg/dns"
+	"k8s.io/klog/v2"
+)
+
+// recordTTL is the TTL of the records we serve; it is short because records change as the gossip state changes
+const recordTTL = 10
+
+// DNSServer serves the gossip DNS records over DNS, so that they can be resolved by CoreDNS
+type DNSServer struct {
+	// mutex protects the following mutable state
+	mutex sync.Mutex
+	// zones are the fully qualified names of the zones we are authoritative for
+	zones []string
+	// records are the records by lower-case fully qualified name
+	records map[string][]DNSRecord
+}
+
+var _ DNSTarget = &DNSServer{}
+var _ miekgdns.Handler = &DNSServer{}
+
+// NewDNSServer builds a DNSServer, which serves no records until the first Update
+func NewDNSServer() *DNSServer {
+	return &DNSServer{
+		records: make(map[string][]DNSRecord),
+	}
+}
+
+// Update replaces the records served with those in the snapshot
+func (s *DNSServer) Update(snapshot *DNSViewSnapshot) error {
+	klog.V(2).Infof("Updating DNS server with snapshot version %v", snapshot.version)
+
+	var zones []string
+	records := make(map[string][]DNSRecord)
+	for _, zone := range snapshot.ListZones() {
+		zones = append(zones, miekgdns.Fqdn(strings.ToLower(zone.Name)))
+		for _, record := range snapshot.RecordsForZone(zone) {
+			switch record.RrsType {
+			case "A", "AAAA", "CNAME":
+				name := miekgdns.Fqdn(strings.ToLower(record.Name))
+				records[name] = append(records[name], record)
+			case "NS":
+				// The zone's placeholder record
+			default:
+				klog.Warningf("skipping record of unhandled type: %v", record)
+			}
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.zones = zones
+	s.records = records
+	return nil
+}
+
+// ListenAndServe serves DNS over UDP and TCP on the address, until one of the servers fails
+func (s *DNSServer) ListenAndServe(addr string) error {
+	errors := make(chan error, 2)
+	for _, network := range []string{"udp", "tcp"} {
+		server := &miekgdns.Server{Addr: addr, Net: network, Handler: s}
+		go func() {
+			errors <- server.ListenAndServe()
+		}()
+	}
+	return <-errors
+}
+
+// ServeDNS implements miekgdns.Handler
+func (s *DNSServer) ServeDNS(w miekgdns.ResponseWriter, req *miekgdns.Msg) {
+	m := s.answer(req)
+	if err := w.WriteMsg(m); err != nil {
+		klog.Warningf("error writing DNS response: %v", err)
+	}
+}
+
+// answer builds the response to a query
+func (s *DNSServer) answer(req *miekgdns.Msg) *miekgdns.Msg {
+	m := new(miekgdns.Msg)
+	m.SetReply(req)
+
+	if req.Opcode != miekgdns.OpcodeQuery || len(req.Question) != 1 {
+		m.SetRcode(req, miekgdns.RcodeNotImplemented)
+		return m
+	}
+	q := req.Question[0]
+	name := strings.ToLower(q.Name)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.inZone(name) {
+		m.SetRcode(req, miekgdns.RcodeRefused)
+		return m
+	}
+	m.Authoritative = true
+
+	records, found := s.records[name]
+	if !found {
+		m.SetRcode(req, miekgdns.RcodeNameError)
+		return m
+	}
+
+	for _, record := range records {
+		if record.RrsType == "CNAME" {
+			// A name with a CNAME has no other records; we follow the alias if we know the target
+			for _, target := range record.Rrdatas {
+				m.Answer = append(m.Answer, &miekgdns.CNAME{Hdr: header(name, miekgdns.TypeCNAME), Target: miekgdns.Fqdn(target)})
+				if q.Qtype != miekgdns.TypeCNAME {
+					m.Answer = append(m.Answer, s.addresses(miekgdns.Fqdn(strings.ToLower(target)), q.Qtype)...)
+				}
+			}
+			return m
+		}
+	}
+
+	m.Answer = append(m.Answer, s.addresses(name, q.Qtype)...)
+	return m
+}
+
+// addresses returns the A or AAAA records for the name
+func (s *DNSServer) addresses(name string, qtype uint16) []miekgdns.RR {
+	var answers []miekgdns.RR
+	for _, record := range s.records[name] {
+		for _, value := range record.Rrdatas {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				klog.Warningf("ignoring invalid address %q for %s", value, name)
+				continue
+			}
+			switch {
+			case record.RrsType == "A" && qtype == miekgdns.TypeA && ip.To4() != nil:
+				answers = append(answers, &miekgdns.A{Hdr: header(name, miekgdns.TypeA), A: ip.To4()})
+			case record.RrsType == "AAAA" && qtype == miekgdns.TypeAAAA && ip.To4() == nil:
+				answers = append(answers, &miekgdns.AAAA{Hdr: header(name, miekgdns.TypeAAAA), AAAA: ip})
+			}
+		}
+	}
+	return answers
+}
+
+// inZone returns true if the name is in one of our zones
+func (s *DNSServer) inZone(name string) bool {
+	for _, zone := range s.zones {
+		if miekgdns.IsSubDomain(zone, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func header(name string, rrtype uint16) miekgdns.RR_Header {
+	return miekgdns.RR_Header{Name: name, Rrtype: rrtype, Class: miekgdns.ClassINET, Ttl: recordTTL}
+}
+
+// MultiTarget updates several DNS targets with each snapshot
+type MultiTarget []DNSTarget
+
+var _ DNSTarget = MultiTarget{}
+
+// Update updates each of the targets, returning an error if any of them fail
+func (m MultiTarget) Update(snapshot *DNSViewSnapshot) error {
+	var errs []error
+	for _, target := range m {
+		if err := target.Update(snapshot); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("error updating DNS targets: %v", errs)
+	}
+	return nil
+}
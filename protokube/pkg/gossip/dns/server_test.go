@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	miekgdns "github.com/miekg/dns"
+)
+
+func testSnapshot() *DNSViewSnapshot {
+	return &DNSViewSnapshot{
+		version: 1,
+		zoneMap: map[string]*dnsViewSnapshotZone{
+			"local": {
+				Name: "local",
+				Records: map[string]DNSRecord{
+					"NS::local":                            {Name: "local", RrsType: "NS", Rrdatas: []string{"gossip"}},
+					"A::api.internal.example.k8s.local":    {Name: "api.internal.example.k8s.local", RrsType: "A", Rrdatas: []string{"10.0.0.1", "10.0.0.2"}},
+					"AAAA::api.internal.example.k8s.local": {Name: "api.internal.example.k8s.local", RrsType: "AAAA", Rrdatas: []string{"2001:db8::1"}},
+					"CNAME::api.example.k8s.local":         {Name: "api.example.k8s.local", RrsType: "CNAME", Rrdatas: []string{"api.internal.example.k8s.local"}},
+				},
+			},
+		},
+	}
+}
+
+func TestDNSServerAnswer(t *testing.T) {
+	s := NewDNSServer()
+	if err := s.Update(testSnapshot()); err != nil {
+		t.Fatalf("unexpected error updating server: %v", err)
+	}
+
+	grid := []struct {
+		Name     string
+		Type     uint16
+		Rcode    int
+		Expected []string
+	}{
+		{
+			Name:     "api.internal.example.k8s.local.",
+			Type:     miekgdns.TypeA,
+			Expected: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			Name:     "API.Internal.Example.k8s.local.",
+			Type:     miekgdns.TypeAAAA,
+			Expected: []string{"2001:db8::1"},
+		},
+		{
+			Name:     "api.example.k8s.local.",
+			Type:     miekgdns.TypeA,
+			Expected: []string{"api.internal.example.k8s.local.", "10.0.0.1", "10.0.0.2"},
+		},
+		{
+			Name: "api.internal.example.k8s.local.",
+			Type: miekgdns.TypeTXT,
+		},
+		{
+			Name:  "missing.example.k8s.local.",
+			Type:  miekgdns.TypeA,
+			Rcode: miekgdns.RcodeNameError,
+		},
+		{
+			Name:  "example.com.",
+			Type:  miekgdns.TypeA,
+			Rcode: miekgdns.RcodeRefused,
+		},
+	}
+
+	for _, g := range grid {
+		req := new(miekgdns.Msg)
+		req.SetQuestion(g.Name, g.Type)
+		m := s.answer(req)
+		if m.Rcode != g.Rcode {
+			t.Errorf("%s %s: unexpected rcode %s, expected %s", g.Name, miekgdns.TypeToString[g.Type], miekgdns.RcodeToString[m.Rcode], miekgdns.RcodeToString[g.Rcode])
+			continue
+		}
+		if actual := answerValues(m); !reflect.DeepEqual(actual, g.Expected) {
+			t.Errorf("%s %s: unexpected answers %v, expected %v", g.Name, miekgdns.TypeToString[g.Type], actual, g.Expected)
+		}
+	}
+}
+
+func TestDNSServerServe(t *testing.T) {
+	s := NewDNSServer()
+	if err := s.Update(testSnapshot()); err != nil {
+		t.Fatalf("unexpected error updating server: %v", err)
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error listening: %v", err)
+	}
+	server := &miekgdns.Server{PacketConn: pc, Handler: s}
+	go server.ActivateAndServe()
+	defer server.Shutdown()
+
+	req := new(miekgdns.Msg)
+	req.SetQuestion("api.internal.example.k8s.local.", miekgdns.TypeA)
+	m, err := miekgdns.Exchange(req, pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("unexpected error querying server: %v", err)
+	}
+	if !m.Authoritative {
+		t.Errorf("expected response to be authoritative")
+	}
+	if actual, expected := answerValues(m), []string{"10.0.0.1", "10.0.0.2"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected answers %v, expected %v", actual, expected)
+	}
+}
+
+func answerValues(m *miekgdns.Msg) []string {
+	var values []string
+	for _, rr := range m.Answer {
+		switch rr := rr.(type) {
+		case *miekgdns.A:
+			values = append(values, rr.A.String())
+		case *miekgdns.AAAA:
+			values = append(values, rr.AAAA.String())
+		case *miekgdns.CNAME:
+			values = append(values, rr.Target)
+		}
+	}
+	return values
+}
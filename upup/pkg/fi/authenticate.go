@@ -16,6 +16,11 @@ limitations under the License.
 
 package fi
 
+import (
+	"context"
+	"net/http"
+)
+
 // Authenticator generates authentication credentials for requests.
 type Authenticator interface {
 	CreateToken(body []byte) (string, error)
@@ -32,5 +37,7 @@ type VerifyResult struct {
 
 // Verifier verifies authentication credentials for requests.
 type Verifier interface {
-	VerifyToken(token string, body []byte) (*VerifyResult, error)
+	// VerifyToken verifies the token of a request with the given body.
+	// The raw request is provided for verifiers which also check the source of the request.
+	VerifyToken(ctx context.Context, rawRequest *http.Request, token string, body []byte) (*VerifyResult, error)
 }
@@ -64,6 +64,12 @@ type HasVFSPath interface {
 	VFSPath() vfs.Path
 }
 
+// HasEncryptPlaintext is implemented by stores which can encrypt the key material written before state store encryption was enabled
+type HasEncryptPlaintext interface {
+	// EncryptPlaintext rewrites any unencrypted files containing key material, encrypting them with the key encryption key of the cluster
+	EncryptPlaintext() error
+}
+
 type CAStore interface {
 	Keystore
 
@@ -91,6 +97,10 @@ type CAStore interface {
 
 	// DeleteKeysetItem will delete the specified item from the Keyset
 	DeleteKeysetItem(item *kops.Keyset, id string) error
+
+	// UpdateKeysetRotation sets the primary item of the Keyset and records the state of a staged rotation.
+	// An empty primaryID makes the item with the highest id primary; a nil rotation records that no rotation is in progress.
+	UpdateKeysetRotation(name string, primaryID string, rotation *kops.KeysetRotation) error
 }
 
 // SSHCredentialStore holds SSHCredential objects
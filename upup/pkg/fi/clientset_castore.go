@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"golang.org/x/crypto/ssh"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -70,6 +71,10 @@ type keyset struct {
 	legacyFormat bool
 	items        map[string]*keysetItem
 	primary      *keysetItem
+	// primaryID is the id of the primary item, if it is not the item with the highest id
+	primaryID string
+	// rotation is the state of a staged rotation of the keyset, if one is in progress
+	rotation *kops.KeysetRotation
 }
 
 // keysetItem is a parsed KeysetItem
@@ -83,7 +88,9 @@ func parseKeyset(o *kops.Keyset) (*keyset, error) {
 	name := o.Name
 
 	keyset := &keyset{
-		items: make(map[string]*keysetItem),
+		items:     make(map[string]*keysetItem),
+		primaryID: o.Spec.PrimaryId,
+		rotation:  o.Spec.Rotation,
 	}
 
 	for _, key := range o.Spec.Keys {
@@ -134,6 +141,10 @@ func (c *ClientsetCAStore) loadKeyset(ctx context.Context, name string) (*keyset
 
 // findPrimary returns the primary keysetItem in the keyset
 func (k *keyset) findPrimary() *keysetItem {
+	if item := k.items[k.primaryID]; item != nil {
+		return item
+	}
+
 	var primary *keysetItem
 	var primaryVersion *big.Int
 
@@ -152,8 +163,38 @@ func (k *keyset) findPrimary() *keysetItem {
 	return primary
 }
 
+// secondaryCertificates returns the certificates of the items other than the primary.
+// They are ordered by id, so that bundles built from them are stable.
+func (k *keyset) secondaryCertificates() []*pki.Certificate {
+	var ids []string
+	for id, item := range k.items {
+		if k.primary != nil && id == k.primary.id {
+			continue
+		}
+		if item.certificate == nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var certs []*pki.Certificate
+	for _, id := range ids {
+		certs = append(certs, k.items[id].certificate)
+	}
+	return certs
+}
+
 // FindPrimary returns the primary KeysetItem in the Keyset
 func FindPrimary(keyset *kops.Keyset) *kops.KeysetItem {
+	if keyset.Spec.PrimaryId != "" {
+		for i := range keyset.Spec.Keys {
+			if keyset.Spec.Keys[i].Id == keyset.Spec.PrimaryId {
+				return &keyset.Spec.Keys[i]
+			}
+		}
+	}
+
 	var primary *kops.KeysetItem
 	var primaryVersion *big.Int
 	for i := range keyset.Spec.Keys {
@@ -217,12 +258,7 @@ func (c *ClientsetCAStore) FindCertificatePool(name string) (*CertificatePool, e
 			pool.Primary = keyset.primary.certificate
 		}
 
-		for id, item := range keyset.items {
-			if id == keyset.primary.id {
-				continue
-			}
-			pool.Secondary = append(pool.Secondary, item.certificate)
-		}
+		pool.Secondary = keyset.secondaryCertificates()
 	}
 	return pool, nil
 }
@@ -518,6 +554,36 @@ func (c *ClientsetCAStore) DeleteKeysetItem(item *kops.Keyset, id string) error
 	}
 }
 
+// UpdateKeysetRotation implements CAStore::UpdateKeysetRotation
+func (c *ClientsetCAStore) UpdateKeysetRotation(name string, primaryID string, rotation *kops.KeysetRotation) error {
+	ctx := context.TODO()
+
+	client := c.clientset.Keysets(c.namespace)
+	keyset, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error reading Keyset %q: %v", name, err)
+	}
+
+	if primaryID != "" {
+		found := false
+		for _, ki := range keyset.Spec.Keys {
+			if ki.Id == primaryID {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("KeysetItem %q not found in Keyset %q", primaryID, name)
+		}
+	}
+
+	keyset.Spec.PrimaryId = primaryID
+	keyset.Spec.Rotation = rotation
+	if _, err := client.Update(ctx, keyset, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating Keyset %q: %v", name, err)
+	}
+	return nil
+}
+
 // DeleteSSHCredential implements SSHCredentialStore::DeleteSSHCredential
 func (c *ClientsetCAStore) DeleteSSHCredential(item *kops.SSHCredential) error {
 	ctx := context.TODO()
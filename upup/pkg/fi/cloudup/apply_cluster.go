@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
 	"os"
@@ -136,6 +137,16 @@ type ApplyClusterCmd struct {
 
 	// TaskMap is the map of tasks that we built (output)
 	TaskMap map[string]fi.Task
+
+	// Out is where messages and the dry-run report are written, os.Stdout if not set
+	Out io.Writer
+}
+
+func (c *ApplyClusterCmd) out() io.Writer {
+	if c.Out == nil {
+		return os.Stdout
+	}
+	return c.Out
 }
 
 func (c *ApplyClusterCmd) Run(ctx context.Context) error {
@@ -246,14 +257,14 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 				return fmt.Errorf("error parsing last kops version updated: %v", err)
 			}
 			if version.GT(semver.MustParse(kopsbase.Version)) {
-				fmt.Printf("\n")
-				fmt.Printf("%s\n", starline)
-				fmt.Printf("\n")
-				fmt.Printf("The cluster was last updated by kops version %s\n", kopsVersionUpdated)
-				fmt.Printf("To permit updating by the older version %s, run with the --allow-kops-downgrade flag\n", kopsbase.Version)
-				fmt.Printf("\n")
-				fmt.Printf("%s\n", starline)
-				fmt.Printf("\n")
+				fmt.Fprintf(c.out(), "\n")
+				fmt.Fprintf(c.out(), "%s\n", starline)
+				fmt.Fprintf(c.out(), "\n")
+				fmt.Fprintf(c.out(), "The cluster was last updated by kops version %s\n", kopsVersionUpdated)
+				fmt.Fprintf(c.out(), "To permit updating by the older version %s, run with the --allow-kops-downgrade flag\n", kopsbase.Version)
+				fmt.Fprintf(c.out(), "\n")
+				fmt.Fprintf(c.out(), "%s\n", starline)
+				fmt.Fprintf(c.out(), "\n")
 				return fmt.Errorf("kops version older than last used to update the cluster")
 			}
 		} else if err != os.ErrNotExist {
@@ -293,6 +304,17 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 		return err
 	}
 
+	if cluster.Spec.StateStoreEncryption != nil && c.TargetName != TargetDryRun {
+		// Encrypt any key material which was written before state store encryption was enabled
+		for _, store := range []interface{}{keyStore, secretStore} {
+			if encrypter, ok := store.(fi.HasEncryptPlaintext); ok {
+				if err := encrypter.EncryptPlaintext(); err != nil {
+					return fmt.Errorf("error encrypting state store: %v", err)
+				}
+			}
+		}
+	}
+
 	addonsClient := c.Clientset.AddonsFor(cluster)
 	addons, err := addonsClient.List()
 	if err != nil {
@@ -318,16 +340,16 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 		}
 
 		if warn {
-			fmt.Println("")
-			fmt.Printf("%s\n", starline)
-			fmt.Println("")
-			fmt.Println("Kubelet anonymousAuth is currently turned on. This allows RBAC escalation and remote code execution possibilities.")
-			fmt.Println("It is highly recommended you turn it off by setting 'spec.kubelet.anonymousAuth' to 'false' via 'kops edit cluster'")
-			fmt.Println("")
-			fmt.Println("See https://kops.sigs.k8s.io/security/#kubelet-api")
-			fmt.Println("")
-			fmt.Printf("%s\n", starline)
-			fmt.Println("")
+			fmt.Fprintln(c.out(), "")
+			fmt.Fprintf(c.out(), "%s\n", starline)
+			fmt.Fprintln(c.out(), "")
+			fmt.Fprintln(c.out(), "Kubelet anonymousAuth is currently turned on. This allows RBAC escalation and remote code execution possibilities.")
+			fmt.Fprintln(c.out(), "It is highly recommended you turn it off by setting 'spec.kubelet.anonymousAuth' to 'false' via 'kops edit cluster'")
+			fmt.Fprintln(c.out(), "")
+			fmt.Fprintln(c.out(), "See https://kops.sigs.k8s.io/security/#kubelet-api")
+			fmt.Fprintln(c.out(), "")
+			fmt.Fprintf(c.out(), "%s\n", starline)
+			fmt.Fprintln(c.out(), "")
 		}
 	}
 
@@ -337,9 +359,9 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 			return fmt.Errorf("could not load encryptionconfig secret: %v", err)
 		}
 		if secret == nil {
-			fmt.Println("")
-			fmt.Println("You have encryptionConfig enabled, but no encryptionconfig secret has been set.")
-			fmt.Println("See `kops create secret encryptionconfig -h` and https://kubernetes.io/docs/tasks/administer-cluster/encrypt-data/")
+			fmt.Fprintln(c.out(), "")
+			fmt.Fprintln(c.out(), "You have encryptionConfig enabled, but no encryptionconfig secret has been set.")
+			fmt.Fprintln(c.out(), "See `kops create secret encryptionconfig -h` and https://kubernetes.io/docs/tasks/administer-cluster/encrypt-data/")
 			return fmt.Errorf("could not find encryptionconfig secret")
 		}
 	}
@@ -351,9 +373,9 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 			return fmt.Errorf("could not load the ciliumpassword secret: %w", err)
 		}
 		if secret == nil {
-			fmt.Println("")
-			fmt.Println("You have cilium encryption enabled, but no ciliumpassword secret has been set.")
-			fmt.Println("See `kops create secret ciliumpassword -h`")
+			fmt.Fprintln(c.out(), "")
+			fmt.Fprintln(c.out(), "You have cilium encryption enabled, but no ciliumpassword secret has been set.")
+			fmt.Fprintln(c.out(), "See `kops create secret ciliumpassword -h`")
 			return fmt.Errorf("could not find ciliumpassword secret")
 		}
 	}
@@ -431,9 +453,9 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 
 	case kops.CloudProviderALI:
 		{
-			fmt.Println("")
-			fmt.Println("aliyun support has been deprecated due to lack of maintainers. It may be removed in a future version of kOps.")
-			fmt.Println("")
+			fmt.Fprintln(c.out(), "")
+			fmt.Fprintln(c.out(), "aliyun support has been deprecated due to lack of maintainers. It may be removed in a future version of kOps.")
+			fmt.Fprintln(c.out(), "")
 
 			if !AlphaAllowALI.Enabled() {
 				return fmt.Errorf("aliyun support is currently alpha, and is feature-gated.  export KOPS_FEATURE_FLAGS=AlphaAllowALI")
@@ -727,7 +749,7 @@ func (c *ApplyClusterCmd) Run(ctx context.Context) error {
 		shouldPrecreateDNS = false
 
 	case TargetDryRun:
-		target = fi.NewDryRunTarget(assetBuilder, os.Stdout)
+		target = fi.NewDryRunTarget(assetBuilder, c.out())
 		dryRun = true
 
 		// Avoid making changes on a dry-run
@@ -856,31 +878,31 @@ func (c *ApplyClusterCmd) validateKopsVersion() error {
 	}
 
 	if recommended != nil && !required {
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
-		fmt.Printf("A new kops version is available: %s", recommended)
-		fmt.Printf("\n")
-		fmt.Printf("Upgrading is recommended\n")
-		fmt.Printf("More information: %s\n", buildPermalink("upgrade_kops", recommended.String()))
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "A new kops version is available: %s", recommended)
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "Upgrading is recommended\n")
+		fmt.Fprintf(c.out(), "More information: %s\n", buildPermalink("upgrade_kops", recommended.String()))
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
 	} else if required {
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
 		if recommended != nil {
-			fmt.Printf("a new kops version is available: %s\n", recommended)
+			fmt.Fprintf(c.out(), "a new kops version is available: %s\n", recommended)
 		}
-		fmt.Println("")
-		fmt.Printf("This version of kops (%s) is no longer supported; upgrading is required\n", kopsbase.Version)
-		fmt.Printf("(you can bypass this check by exporting KOPS_RUN_OBSOLETE_VERSION)\n")
-		fmt.Println("")
-		fmt.Printf("More information: %s\n", buildPermalink("upgrade_kops", recommended.String()))
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
+		fmt.Fprintln(c.out(), "")
+		fmt.Fprintf(c.out(), "This version of kops (%s) is no longer supported; upgrading is required\n", kopsbase.Version)
+		fmt.Fprintf(c.out(), "(you can bypass this check by exporting KOPS_RUN_OBSOLETE_VERSION)\n")
+		fmt.Fprintln(c.out(), "")
+		fmt.Fprintf(c.out(), "More information: %s\n", buildPermalink("upgrade_kops", recommended.String()))
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
 	}
 
 	if required {
@@ -910,14 +932,14 @@ func (c *ApplyClusterCmd) validateKubernetesVersion() error {
 		tooNewVersion.Pre = nil
 		tooNewVersion.Build = nil
 		if util.IsKubernetesGTE(tooNewVersion.String(), *parsed) {
-			fmt.Printf("\n")
-			fmt.Printf("%s\n", starline)
-			fmt.Printf("\n")
-			fmt.Printf("This version of kubernetes is not yet supported; upgrading kops is required\n")
-			fmt.Printf("(you can bypass this check by exporting KOPS_RUN_TOO_NEW_VERSION)\n")
-			fmt.Printf("\n")
-			fmt.Printf("%s\n", starline)
-			fmt.Printf("\n")
+			fmt.Fprintf(c.out(), "\n")
+			fmt.Fprintf(c.out(), "%s\n", starline)
+			fmt.Fprintf(c.out(), "\n")
+			fmt.Fprintf(c.out(), "This version of kubernetes is not yet supported; upgrading kops is required\n")
+			fmt.Fprintf(c.out(), "(you can bypass this check by exporting KOPS_RUN_TOO_NEW_VERSION)\n")
+			fmt.Fprintf(c.out(), "\n")
+			fmt.Fprintf(c.out(), "%s\n", starline)
+			fmt.Fprintf(c.out(), "\n")
 			if os.Getenv("KOPS_RUN_TOO_NEW_VERSION") == "" {
 				return fmt.Errorf("kops upgrade is required")
 			}
@@ -925,25 +947,25 @@ func (c *ApplyClusterCmd) validateKubernetesVersion() error {
 	}
 
 	if !util.IsKubernetesGTE(OldestSupportedKubernetesVersion, *parsed) {
-		fmt.Printf("This version of Kubernetes is no longer supported; upgrading Kubernetes is required\n")
-		fmt.Printf("\n")
-		fmt.Printf("More information: %s\n", buildPermalink("upgrade_k8s", OldestRecommendedKubernetesVersion))
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
+		fmt.Fprintf(c.out(), "This version of Kubernetes is no longer supported; upgrading Kubernetes is required\n")
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "More information: %s\n", buildPermalink("upgrade_k8s", OldestRecommendedKubernetesVersion))
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
 		return fmt.Errorf("kubernetes upgrade is required")
 	}
 	if !util.IsKubernetesGTE(OldestRecommendedKubernetesVersion, *parsed) {
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
-		fmt.Printf("Kops support for this Kubernetes version is deprecated and will be removed in a future release.\n")
-		fmt.Printf("\n")
-		fmt.Printf("Upgrading Kubernetes is recommended\n")
-		fmt.Printf("More information: %s\n", buildPermalink("upgrade_k8s", OldestRecommendedKubernetesVersion))
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "Kops support for this Kubernetes version is deprecated and will be removed in a future release.\n")
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "Upgrading Kubernetes is recommended\n")
+		fmt.Fprintf(c.out(), "More information: %s\n", buildPermalink("upgrade_k8s", OldestRecommendedKubernetesVersion))
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
 
 	}
 
@@ -973,31 +995,31 @@ func (c *ApplyClusterCmd) validateKubernetesVersion() error {
 	}
 
 	if recommended != nil && !required {
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
-		fmt.Printf("A new kubernetes version is available: %s\n", recommended)
-		fmt.Printf("Upgrading is recommended (try kops upgrade cluster)\n")
-		fmt.Printf("\n")
-		fmt.Printf("More information: %s\n", buildPermalink("upgrade_k8s", recommended.String()))
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "A new kubernetes version is available: %s\n", recommended)
+		fmt.Fprintf(c.out(), "Upgrading is recommended (try kops upgrade cluster)\n")
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "More information: %s\n", buildPermalink("upgrade_k8s", recommended.String()))
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
 	} else if required {
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
 		if recommended != nil {
-			fmt.Printf("A new kubernetes version is available: %s\n", recommended)
+			fmt.Fprintf(c.out(), "A new kubernetes version is available: %s\n", recommended)
 		}
-		fmt.Printf("\n")
-		fmt.Printf("This version of kubernetes is no longer supported; upgrading is required\n")
-		fmt.Printf("(you can bypass this check by exporting KOPS_RUN_OBSOLETE_VERSION)\n")
-		fmt.Printf("\n")
-		fmt.Printf("More information: %s\n", buildPermalink("upgrade_k8s", recommended.String()))
-		fmt.Printf("\n")
-		fmt.Printf("%s\n", starline)
-		fmt.Printf("\n")
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "This version of kubernetes is no longer supported; upgrading is required\n")
+		fmt.Fprintf(c.out(), "(you can bypass this check by exporting KOPS_RUN_OBSOLETE_VERSION)\n")
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "More information: %s\n", buildPermalink("upgrade_k8s", recommended.String()))
+		fmt.Fprintf(c.out(), "\n")
+		fmt.Fprintf(c.out(), "%s\n", starline)
+		fmt.Fprintf(c.out(), "\n")
 	}
 
 	if required {
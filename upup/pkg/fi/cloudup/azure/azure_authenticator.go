@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const AzureAuthenticationTokenPrefix = "x-azure-attested "
+
+// attestedDocumentURL is the location of the attested data of the instance,
+// documented in https://docs.microsoft.com/en-us/azure/virtual-machines/linux/instance-metadata-service.
+const attestedDocumentURL = "http://169.254.169.254/metadata/attested/document"
+
+type azureAuthenticator struct {
+	metadataURL string
+	client      *http.Client
+}
+
+var _ fi.Authenticator = &azureAuthenticator{}
+
+// NewAzureAuthenticator returns an Authenticator which authenticates requests with the attested data of the instance.
+func NewAzureAuthenticator() (fi.Authenticator, error) {
+	return &azureAuthenticator{
+		metadataURL: attestedDocumentURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+func (a *azureAuthenticator) CreateToken(body []byte) (string, error) {
+	req, err := http.NewRequest("GET", a.metadataURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating attested data request: %v", err)
+	}
+	req.Header.Add("Metadata", "True")
+	q := req.URL.Query()
+	q.Add("api-version", "2020-09-01")
+	q.Add("nonce", attestedNonce(body))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("querying attested data: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading attested data: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("received status code %d from metadata server: %s", resp.StatusCode, string(b))
+	}
+
+	document := struct {
+		Encoding  string `json:"encoding"`
+		Signature string `json:"signature"`
+	}{}
+	if err := json.Unmarshal(b, &document); err != nil {
+		return "", fmt.Errorf("parsing attested data: %v", err)
+	}
+	if document.Encoding != "pkcs7" {
+		return "", fmt.Errorf("attested data has unexpected encoding %q", document.Encoding)
+	}
+
+	return AzureAuthenticationTokenPrefix + document.Signature, nil
+}
+
+// attestedNonce returns the nonce of the attested data for a request with the given body.
+// The metadata service only accepts nonces of up to 10 digits, so the nonce
+// is derived from the SHA-256 of the body.
+func attestedNonce(body []byte) string {
+	sha := sha256.Sum256(body)
+	return fmt.Sprintf("%010d", binary.BigEndian.Uint64(sha[:8])%10000000000)
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	nodeidentityazure "k8s.io/kops/pkg/nodeidentity/azure"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const (
+	// attestedSignerName is the name of the certificates which sign attested data.
+	attestedSignerName = "metadata.azure.com"
+	// attestedTimeLayout is the layout of the timestamps in attested data.
+	attestedTimeLayout = "01/02/06 15:04:05 -0700"
+	// issuerCertificateDomain is the domain we fetch the issuer certificates of attested data from.
+	issuerCertificateDomain = "microsoft.com"
+)
+
+type AzureVerifierOptions struct {
+	// ClusterName is the name of the cluster.
+	ClusterName string `json:"clusterName"`
+	// SubscriptionID is the subscription of the cluster.
+	SubscriptionID string `json:"subscriptionID"`
+	// ResourceGroupName is the resource group of the cluster.
+	ResourceGroupName string `json:"resourceGroupName"`
+}
+
+// attestedData is the signed content of the attested data of an instance.
+type attestedData struct {
+	Nonce          string `json:"nonce"`
+	VMID           string `json:"vmId"`
+	SubscriptionID string `json:"subscriptionId"`
+	TimeStamp      struct {
+		CreatedOn string `json:"createdOn"`
+		ExpiresOn string `json:"expiresOn"`
+	} `json:"timeStamp"`
+}
+
+type azureVerifier struct {
+	opt AzureVerifierOptions
+
+	vmScaleSets   VMScaleSetsClient
+	vmScaleSetVMs VMScaleSetVMsClient
+
+	// roots are the trusted root certificates; the system roots are used if nil.
+	roots *x509.CertPool
+	// fetchCertificate fetches issuer certificates which are not included in the attested data.
+	fetchCertificate func(certURL string) (*x509.Certificate, error)
+	now              func() time.Time
+
+	mutex         sync.Mutex
+	intermediates map[string]*x509.Certificate
+}
+
+var _ fi.Verifier = &azureVerifier{}
+
+// NewAzureVerifier returns a Verifier for the attested data of VM Scale Set VMs in the cluster.
+func NewAzureVerifier(opt *AzureVerifierOptions) (fi.Verifier, error) {
+	authorizer, err := auth.NewAuthorizerFromEnvironment()
+	if err != nil {
+		return nil, fmt.Errorf("error creating an authorizer: %v", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	return &azureVerifier{
+		opt:           *opt,
+		vmScaleSets:   newVMScaleSetsClientImpl(opt.SubscriptionID, authorizer),
+		vmScaleSetVMs: newVMScaleSetVMsClientImpl(opt.SubscriptionID, authorizer),
+		fetchCertificate: func(certURL string) (*x509.Certificate, error) {
+			return fetchCertificate(client, certURL)
+		},
+		now:           time.Now,
+		intermediates: make(map[string]*x509.Certificate),
+	}, nil
+}
+
+func (a *azureVerifier) VerifyToken(ctx context.Context, rawRequest *http.Request, token string, body []byte) (*fi.VerifyResult, error) {
+	if !strings.HasPrefix(token, AzureAuthenticationTokenPrefix) {
+		return nil, fmt.Errorf("incorrect authorization type")
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(token, AzureAuthenticationTokenPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("decoding attested data: %v", err)
+	}
+
+	signed, err := verifyPKCS7(der)
+	if err != nil {
+		return nil, fmt.Errorf("verifying attested data: %v", err)
+	}
+	now := a.now()
+	if err := a.verifySigner(signed, now); err != nil {
+		return nil, err
+	}
+
+	data := &attestedData{}
+	if err := json.Unmarshal(signed.Content, data); err != nil {
+		return nil, fmt.Errorf("parsing attested data: %v", err)
+	}
+	// Verify the attested data was issued for this particular body content.
+	if data.Nonce != attestedNonce(body) {
+		return nil, fmt.Errorf("incorrect nonce")
+	}
+	expiresOn, err := time.Parse(attestedTimeLayout, data.TimeStamp.ExpiresOn)
+	if err != nil {
+		return nil, fmt.Errorf("parsing expiry of attested data: %v", err)
+	}
+	if now.After(expiresOn) {
+		return nil, fmt.Errorf("attested data expired at %v", expiresOn)
+	}
+	if !strings.EqualFold(data.SubscriptionID, a.opt.SubscriptionID) {
+		return nil, fmt.Errorf("vm is in subscription %q, not %q", data.SubscriptionID, a.opt.SubscriptionID)
+	}
+	if data.VMID == "" {
+		return nil, fmt.Errorf("attested data does not include the vm id")
+	}
+
+	vmScaleSets, err := a.vmScaleSets.List(ctx, a.opt.ResourceGroupName)
+	if err != nil {
+		return nil, fmt.Errorf("listing VM Scale Sets: %v", err)
+	}
+	for _, vmss := range vmScaleSets {
+		if vmss.Name == nil || vmss.Tags[TagClusterName] == nil || *vmss.Tags[TagClusterName] != a.opt.ClusterName {
+			continue
+		}
+		vms, err := a.vmScaleSetVMs.List(ctx, a.opt.ResourceGroupName, *vmss.Name)
+		if err != nil {
+			return nil, fmt.Errorf("listing VMs of VM Scale Set %q: %v", *vmss.Name, err)
+		}
+		for _, vm := range vms {
+			properties := vm.VirtualMachineScaleSetVMProperties
+			if properties == nil || properties.VMID == nil || !strings.EqualFold(*properties.VMID, data.VMID) {
+				continue
+			}
+			if properties.OsProfile == nil || properties.OsProfile.ComputerName == nil {
+				return nil, fmt.Errorf("vm %q does not have a computer name", data.VMID)
+			}
+			igName := vmss.Tags[nodeidentityazure.InstanceGroupNameTag]
+			if igName == nil || *igName == "" {
+				return nil, fmt.Errorf("VM Scale Set %q does not have tag %q", *vmss.Name, nodeidentityazure.InstanceGroupNameTag)
+			}
+			return &fi.VerifyResult{
+				NodeName:          strings.ToLower(*properties.OsProfile.ComputerName),
+				InstanceGroupName: *igName,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("vm %q not found in the VM Scale Sets of the cluster", data.VMID)
+}
+
+// verifySigner verifies the attested data was signed by the metadata service.
+func (a *azureVerifier) verifySigner(signed *pkcs7Signed, now time.Time) error {
+	signer := signed.Signer
+	if signer.Subject.CommonName != attestedSignerName && !strings.HasSuffix(signer.Subject.CommonName, "."+attestedSignerName) {
+		return fmt.Errorf("attested data signed by unexpected certificate %q", signer.Subject.CommonName)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range signed.Certificates {
+		if cert != signer {
+			intermediates.AddCert(cert)
+		}
+	}
+	opts := x509.VerifyOptions{
+		Roots:         a.roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	_, err := signer.Verify(opts)
+	if _, ok := err.(x509.UnknownAuthorityError); ok && len(signer.IssuingCertificateURL) != 0 {
+		// The issuer certificate is not included in the attested data, so we fetch it from the location in the signer certificate.
+		// The signer is not trusted yet, so we only fetch certificates from Microsoft.
+		for _, issuerURL := range signer.IssuingCertificateURL {
+			u, err := url.Parse(issuerURL)
+			if err != nil || (u.Hostname() != issuerCertificateDomain && !strings.HasSuffix(u.Hostname(), "."+issuerCertificateDomain)) {
+				continue
+			}
+			issuer, err := a.intermediate(issuerURL)
+			if err != nil {
+				return err
+			}
+			intermediates.AddCert(issuer)
+		}
+		_, err = signer.Verify(opts)
+	}
+	if err != nil {
+		return fmt.Errorf("verifying certificate of attested data: %v", err)
+	}
+	return nil
+}
+
+// intermediate returns the certificate at the url, caching it for later requests.
+func (a *azureVerifier) intermediate(certURL string) (*x509.Certificate, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if cert := a.intermediates[certURL]; cert != nil {
+		return cert, nil
+	}
+	cert, err := a.fetchCertificate(certURL)
+	if err != nil {
+		return nil, err
+	}
+	a.intermediates[certURL] = cert
+	return cert, nil
+}
+
+func fetchCertificate(client *http.Client, certURL string) (*x509.Certificate, error) {
+	resp, err := client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching certificate %q: %v", certURL, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading certificate %q: %v", certURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received status code %d fetching certificate %q", resp.StatusCode, certURL)
+	}
+	cert, err := x509.ParseCertificate(b)
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate %q: %v", certURL, err)
+	}
+	return cert, nil
+}
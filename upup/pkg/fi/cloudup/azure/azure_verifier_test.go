@@ -0,0 +1,345 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	"go.mozilla.org/pkcs7"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+type fakeVMScaleSets []compute.VirtualMachineScaleSet
+
+func (f fakeVMScaleSets) CreateOrUpdate(ctx context.Context, resourceGroupName, vmScaleSetName string, parameters compute.VirtualMachineScaleSet) (*compute.VirtualMachineScaleSet, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f fakeVMScaleSets) List(ctx context.Context, resourceGroupName string) ([]compute.VirtualMachineScaleSet, error) {
+	return f, nil
+}
+
+func (f fakeVMScaleSets) Delete(ctx context.Context, resourceGroupName, vmssName string) error {
+	return fmt.Errorf("not implemented")
+}
+
+type fakeVMScaleSetVMs map[string][]compute.VirtualMachineScaleSetVM
+
+func (f fakeVMScaleSetVMs) List(ctx context.Context, resourceGroupName, vmssName string) ([]compute.VirtualMachineScaleSetVM, error) {
+	return f[vmssName], nil
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+// newTestCertificate creates a certificate with the given template, signed by the parent or self-signed if the parent is nil.
+func newTestCertificate(t *testing.T, template *x509.Certificate, parent *testCA) *testCA {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	// The signing time attribute is set to the current time, which must be within the validity of the signer.
+	template.NotAfter = time.Now().Add(24 * time.Hour)
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parsing certificate: %v", err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// signTestPKCS7 creates DER encoded PKCS#7 signed data of the content. If tamper is not nil, it is called
+// with the signed data before it is encoded.
+func signTestPKCS7(t *testing.T, content []byte, signer *testCA, withAttributes bool, tamper func(sd *pkcs7.SignedData)) []byte {
+	sd, err := pkcs7.NewSignedData(content)
+	if err != nil {
+		t.Fatalf("creating signed data: %v", err)
+	}
+	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if withAttributes {
+		err = sd.AddSigner(signer.cert, signer.key, pkcs7.SignerInfoConfig{})
+	} else {
+		err = sd.SignWithoutAttr(signer.cert, signer.key, pkcs7.SignerInfoConfig{})
+	}
+	if err != nil {
+		t.Fatalf("signing: %v", err)
+	}
+	if tamper != nil {
+		tamper(sd)
+	}
+	der, err := sd.Finish()
+	if err != nil {
+		t.Fatalf("encoding signed data: %v", err)
+	}
+	return der
+}
+
+func TestAzureVerifier(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	const intermediateURL = "http://www.microsoft.com/pkiops/certs/test-intermediate.crt"
+
+	root := newTestCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "root"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, nil)
+	intermediate := newTestCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "intermediate"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, root)
+	signer := newTestCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "eastus.metadata.azure.com"}, IssuingCertificateURL: []string{intermediateURL}}, intermediate)
+	otherSigner := newTestCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "example.com"}, IssuingCertificateURL: []string{intermediateURL}}, intermediate)
+	untrustedRoot := newTestCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "root"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, nil)
+	untrustedSigner := newTestCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "metadata.azure.com"}}, untrustedRoot)
+
+	vmScaleSets := fakeVMScaleSets{
+		{
+			Name: to.StringPtr("nodes.minimal.example.com"),
+			Tags: map[string]*string{
+				TagClusterName:              to.StringPtr("minimal.example.com"),
+				"kops.k8s.io_instancegroup": to.StringPtr("nodes"),
+			},
+		},
+		{
+			Name: to.StringPtr("nodes.other.example.com"),
+			Tags: map[string]*string{
+				TagClusterName:              to.StringPtr("other.example.com"),
+				"kops.k8s.io_instancegroup": to.StringPtr("nodes"),
+			},
+		},
+	}
+	vmScaleSetVMs := fakeVMScaleSetVMs{
+		"nodes.minimal.example.com": {
+			{
+				VirtualMachineScaleSetVMProperties: &compute.VirtualMachineScaleSetVMProperties{
+					VMID:      to.StringPtr("02aab8a4-74ef-476e-8182-f6d2ba4166a6"),
+					OsProfile: &compute.OSProfile{ComputerName: to.StringPtr("Nodes-minimal-example-com000001")},
+				},
+			},
+		},
+		"nodes.other.example.com": {
+			{
+				VirtualMachineScaleSetVMProperties: &compute.VirtualMachineScaleSetVMProperties{
+					VMID:      to.StringPtr("7c6e4f3a-0000-4000-8000-000000000000"),
+					OsProfile: &compute.OSProfile{ComputerName: to.StringPtr("nodes-other-example-com000001")},
+				},
+			},
+		},
+	}
+
+	// otherContent is encoded signed content that differs from the content that was signed.
+	otherContent := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: []byte{asn1.TagOctetString, 2, '{', '}'}}
+
+	validData := func(nonce string) *attestedData {
+		data := &attestedData{
+			Nonce:          nonce,
+			VMID:           "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+			SubscriptionID: "8e8e8e8e-0000-4000-8000-000000000000",
+		}
+		data.TimeStamp.CreatedOn = "05/01/21 06:00:00 -0000"
+		data.TimeStamp.ExpiresOn = "05/01/21 18:00:00 -0000"
+		return data
+	}
+
+	grid := []struct {
+		name           string
+		modify         func(data *attestedData)
+		signer         *testCA
+		withAttributes bool
+		tamper         func(sd *pkcs7.SignedData)
+		verifyBody     string
+		expectedErr    string
+	}{
+		{
+			name:           "valid with authenticated attributes",
+			withAttributes: true,
+		},
+		{
+			name: "valid without authenticated attributes",
+		},
+		{
+			name:        "different body",
+			verifyBody:  "other",
+			expectedErr: "incorrect nonce",
+		},
+		{
+			name:        "expired",
+			modify:      func(data *attestedData) { data.TimeStamp.ExpiresOn = "05/01/21 11:00:00 -0000" },
+			expectedErr: "attested data expired",
+		},
+		{
+			name:        "other subscription",
+			modify:      func(data *attestedData) { data.SubscriptionID = "00000000-0000-4000-8000-000000000000" },
+			expectedErr: "not \"8e8e8e8e-0000-4000-8000-000000000000\"",
+		},
+		{
+			name:        "vm in other cluster",
+			modify:      func(data *attestedData) { data.VMID = "7c6e4f3a-0000-4000-8000-000000000000" },
+			expectedErr: "not found in the VM Scale Sets of the cluster",
+		},
+		{
+			name:        "unexpected signer",
+			signer:      otherSigner,
+			expectedErr: "signed by unexpected certificate \"example.com\"",
+		},
+		{
+			name:        "untrusted signer",
+			signer:      untrustedSigner,
+			expectedErr: "verifying certificate of attested data",
+		},
+		{
+			name:           "wrong message digest attribute",
+			withAttributes: true,
+			tamper: func(sd *pkcs7.SignedData) {
+				sd.GetSignedData().ContentInfo.Content = otherContent
+			},
+			expectedErr: "Message digest mismatch",
+		},
+		{
+			name: "wrong content without authenticated attributes",
+			tamper: func(sd *pkcs7.SignedData) {
+				sd.GetSignedData().ContentInfo.Content = otherContent
+			},
+			expectedErr: "verifying signature",
+		},
+		{
+			name:           "signer not in certificates",
+			withAttributes: true,
+			tamper: func(sd *pkcs7.SignedData) {
+				sd.GetSignedData().SignerInfos[0].IssuerAndSerialNumber.SerialNumber = big.NewInt(1)
+			},
+			expectedErr: "No certificate for signer",
+		},
+		{
+			name:           "extra signer",
+			withAttributes: true,
+			tamper: func(sd *pkcs7.SignedData) {
+				if err := sd.AddSigner(otherSigner.cert, otherSigner.key, pkcs7.SignerInfoConfig{}); err != nil {
+					t.Errorf("adding signer: %v", err)
+				}
+			},
+			expectedErr: "signed data has 2 signers",
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			s := signer
+			if g.signer != nil {
+				s = g.signer
+			}
+
+			metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Metadata") != "True" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				data := validData(r.URL.Query().Get("nonce"))
+				if g.modify != nil {
+					g.modify(data)
+				}
+				content, err := json.Marshal(data)
+				if err != nil {
+					t.Errorf("marshalling attested data: %v", err)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"encoding":  "pkcs7",
+					"signature": base64.StdEncoding.EncodeToString(signTestPKCS7(t, content, s, g.withAttributes, g.tamper)),
+				})
+			}))
+			defer metadataServer.Close()
+
+			authenticator := &azureAuthenticator{metadataURL: metadataServer.URL, client: metadataServer.Client()}
+			body := []byte("body")
+			token, err := authenticator.CreateToken(body)
+			if err != nil {
+				t.Fatalf("creating token: %v", err)
+			}
+			if !strings.HasPrefix(token, AzureAuthenticationTokenPrefix) {
+				t.Fatalf("token %q does not have prefix %q", token, AzureAuthenticationTokenPrefix)
+			}
+
+			roots := x509.NewCertPool()
+			roots.AddCert(root.cert)
+			verifier := &azureVerifier{
+				opt: AzureVerifierOptions{
+					ClusterName:       "minimal.example.com",
+					SubscriptionID:    "8e8e8e8e-0000-4000-8000-000000000000",
+					ResourceGroupName: "minimal.example.com",
+				},
+				vmScaleSets:   vmScaleSets,
+				vmScaleSetVMs: vmScaleSetVMs,
+				roots:         roots,
+				fetchCertificate: func(certURL string) (*x509.Certificate, error) {
+					if certURL != intermediateURL {
+						return nil, fmt.Errorf("unexpected certificate %q", certURL)
+					}
+					return intermediate.cert, nil
+				},
+				now:           func() time.Time { return now },
+				intermediates: make(map[string]*x509.Certificate),
+			}
+			if g.verifyBody != "" {
+				body = []byte(g.verifyBody)
+			}
+			result, err := verifier.VerifyToken(context.Background(), nil, token, body)
+			if g.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), g.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", g.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := fi.VerifyResult{NodeName: "nodes-minimal-example-com000001", InstanceGroupName: "nodes"}
+			if *result != expected {
+				t.Errorf("unexpected result %+v, expected %+v", *result, expected)
+			}
+		})
+	}
+}
+
+func TestAttestedNonce(t *testing.T) {
+	nonce := attestedNonce([]byte("body"))
+	if len(nonce) != 10 {
+		t.Errorf("nonce %q does not have 10 digits", nonce)
+	}
+	if nonce == attestedNonce([]byte("other")) {
+		t.Errorf("nonce %q is the same for different bodies", nonce)
+	}
+}
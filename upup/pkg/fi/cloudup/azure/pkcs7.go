@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+
+	"go.mozilla.org/pkcs7"
+)
+
+// pkcs7Signed is verified PKCS#7 signed data.
+type pkcs7Signed struct {
+	// Content is the signed content.
+	Content []byte
+	// Signer is the certificate of the signer.
+	Signer *x509.Certificate
+	// Certificates are all the certificates included in the signed data.
+	Certificates []*x509.Certificate
+}
+
+// verifyPKCS7 parses PKCS#7 signed data, as used by Azure attested data, and verifies it was signed by the key
+// of the included signer certificate. Only signed data with a single signer is accepted.
+// The caller must verify the signer certificate.
+func verifyPKCS7(der []byte) (*pkcs7Signed, error) {
+	p7, err := pkcs7.Parse(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signed data: %v", err)
+	}
+	if len(p7.Signers) != 1 {
+		return nil, fmt.Errorf("signed data has %d signers", len(p7.Signers))
+	}
+
+	// Verify checks the signature, and the message digest of the content if the signer has authenticated attributes.
+	if err := p7.Verify(); err != nil {
+		return nil, fmt.Errorf("verifying signature: %v", err)
+	}
+	// Verify does not check the content type attribute, so we check it here.
+	if len(p7.Signers[0].AuthenticatedAttributes) != 0 {
+		var contentType asn1.ObjectIdentifier
+		if err := p7.UnmarshalSignedAttribute(pkcs7.OIDAttributeContentType, &contentType); err != nil {
+			return nil, fmt.Errorf("parsing content type attribute: %v", err)
+		}
+		if !contentType.Equal(pkcs7.OIDData) {
+			return nil, fmt.Errorf("content type attribute %v is not data", contentType)
+		}
+	}
+
+	signer := p7.GetOnlySigner()
+	if signer == nil {
+		return nil, fmt.Errorf("certificate of signer not found")
+	}
+
+	return &pkcs7Signed{
+		Content:      p7.Content,
+		Signer:       signer,
+		Certificates: p7.Certificates,
+	}, nil
+}
@@ -580,6 +580,8 @@ func (b *BootstrapChannelBuilder) buildAddons(c *fi.ModelBuilderContext) (*chann
 				KubernetesVersion: ">=1.9.0",
 				Id:                id,
 				NeedsPKI:          true,
+				// The webhook certificate is issued by cert-manager
+				DependsOn: []string{"certmanager.io"},
 			})
 		}
 
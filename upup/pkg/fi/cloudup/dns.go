@@ -26,6 +26,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/dns-controller/pkg/dns"
 	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
+	_ "k8s.io/kops/dnsprovider/pkg/dnsprovider/providers/rfc2136"
 	"k8s.io/kops/dnsprovider/pkg/dnsprovider/rrstype"
 	"k8s.io/kops/pkg/apis/kops"
 	apimodel "k8s.io/kops/pkg/apis/kops/model"
@@ -45,8 +46,19 @@ const (
 	PlaceholderTTLDigitialOcean = 60
 )
 
+// dnsProvider returns the DNS provider of the cloud, unless another provider is named by the
+// KOPS_DNS_PROVIDER environment variable, for example for a DNS server supporting RFC2136 updates.
+// The configuration file of that provider is read from the path in KOPS_DNS_PROVIDER_CONFIG.
+func dnsProvider(cloud fi.Cloud) (dnsprovider.Interface, error) {
+	if name := os.Getenv("KOPS_DNS_PROVIDER"); name != "" {
+		klog.V(2).Infof("Using DNS provider %q from KOPS_DNS_PROVIDER", name)
+		return dnsprovider.InitDnsProvider(name, os.Getenv("KOPS_DNS_PROVIDER_CONFIG"))
+	}
+	return cloud.DNS()
+}
+
 func findZone(cluster *kops.Cluster, cloud fi.Cloud) (dnsprovider.Zone, error) {
-	dns, err := cloud.DNS()
+	dns, err := dnsProvider(cloud)
 	if err != nil {
 		return nil, fmt.Errorf("error building DNS provider: %v", err)
 	}
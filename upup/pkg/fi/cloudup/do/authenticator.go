@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package do
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const DOAuthenticationTokenPrefix = "x-digitalocean-droplet-id "
+
+// dropletIDMetadataURL is the location of the id of the droplet in the metadata.
+const dropletIDMetadataURL = "http://169.254.169.254/metadata/v1/id"
+
+type doAuthenticator struct {
+	metadataURL string
+	client      *http.Client
+}
+
+var _ fi.Authenticator = &doAuthenticator{}
+
+// NewDOAuthenticator returns an Authenticator which identifies requests by the id of the droplet.
+// DigitalOcean does not provide signed instance identity documents, so the verifier
+// also checks that requests come from an address of the droplet.
+func NewDOAuthenticator() (fi.Authenticator, error) {
+	return &doAuthenticator{
+		metadataURL: dropletIDMetadataURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+func (a *doAuthenticator) CreateToken(body []byte) (string, error) {
+	resp, err := a.client.Get(a.metadataURL)
+	if err != nil {
+		return "", fmt.Errorf("querying droplet metadata: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading droplet metadata: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("received status code %d from metadata server: %s", resp.StatusCode, string(b))
+	}
+
+	dropletID := strings.TrimSpace(string(b))
+	if dropletID == "" {
+		return "", fmt.Errorf("droplet metadata does not contain the droplet id")
+	}
+	return DOAuthenticationTokenPrefix + dropletID, nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package do
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"k8s.io/kops/pkg/resources/digitalocean"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+type DOVerifierOptions struct {
+	// ClusterName is the name of the cluster.
+	ClusterName string `json:"clusterName"`
+	// Region is the DigitalOcean region of the cluster.
+	Region string `json:"region"`
+}
+
+// dropletGetter is the part of the droplets API used to identify droplets.
+type dropletGetter interface {
+	Get(ctx context.Context, dropletID int) (*godo.Droplet, *godo.Response, error)
+}
+
+type doVerifier struct {
+	opt      DOVerifierOptions
+	droplets dropletGetter
+}
+
+var _ fi.Verifier = &doVerifier{}
+
+// NewDOVerifier returns a Verifier for requests from droplets in the cluster,
+// using DIGITALOCEAN_ACCESS_TOKEN to look up the droplets.
+func NewDOVerifier(opt *DOVerifierOptions) (fi.Verifier, error) {
+	cloud, err := digitalocean.NewCloud(opt.Region)
+	if err != nil {
+		return nil, err
+	}
+	return &doVerifier{
+		opt:      *opt,
+		droplets: cloud.Client.Droplets,
+	}, nil
+}
+
+// VerifyToken verifies the token is the id of a droplet of the cluster, and the request came from one of its addresses.
+// The id is not secret, so this only authenticates the source address of the request, see docs/architecture/kops-controller.md.
+func (d *doVerifier) VerifyToken(ctx context.Context, rawRequest *http.Request, token string, body []byte) (*fi.VerifyResult, error) {
+	if !strings.HasPrefix(token, DOAuthenticationTokenPrefix) {
+		return nil, fmt.Errorf("incorrect authorization type")
+	}
+	dropletID, err := strconv.Atoi(strings.TrimPrefix(token, DOAuthenticationTokenPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("invalid droplet id: %v", err)
+	}
+
+	droplet, _, err := d.droplets.Get(ctx, dropletID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching droplet %d: %v", dropletID, err)
+	}
+	if droplet.Status != "active" {
+		return nil, fmt.Errorf("droplet %d has status %q", dropletID, droplet.Status)
+	}
+
+	clusterTag := TagKubernetesClusterNamePrefix + ":" + SafeClusterName(d.opt.ClusterName)
+	igTagPrefix := digitalocean.TagKubernetesInstanceGroup + ":"
+	inCluster := false
+	igName := ""
+	for _, tag := range droplet.Tags {
+		if tag == clusterTag {
+			inCluster = true
+		}
+		if strings.HasPrefix(tag, igTagPrefix) {
+			igName = strings.TrimPrefix(tag, igTagPrefix)
+		}
+	}
+	if !inCluster {
+		return nil, fmt.Errorf("droplet %d does not have tag %q", dropletID, clusterTag)
+	}
+	if igName == "" {
+		return nil, fmt.Errorf("droplet %d does not have an instance group tag", dropletID)
+	}
+
+	// The id of a droplet is not a secret, so we require the request to come from one of its addresses.
+	host, _, err := net.SplitHostPort(rawRequest.RemoteAddr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing remote address %q: %v", rawRequest.RemoteAddr, err)
+	}
+	remoteIP := net.ParseIP(host)
+	if !dropletHasAddress(droplet, remoteIP) {
+		return nil, fmt.Errorf("request from %s did not come from an address of droplet %d", host, dropletID)
+	}
+
+	return &fi.VerifyResult{
+		NodeName:          strings.ToLower(droplet.Name),
+		InstanceGroupName: igName,
+	}, nil
+}
+
+// dropletHasAddress returns true if the ip is one of the private or public addresses of the droplet.
+func dropletHasAddress(droplet *godo.Droplet, ip net.IP) bool {
+	if droplet.Networks == nil || ip == nil {
+		return false
+	}
+	for _, network := range droplet.Networks.V4 {
+		if ip.Equal(net.ParseIP(network.IPAddress)) {
+			return true
+		}
+	}
+	for _, network := range droplet.Networks.V6 {
+		if ip.Equal(net.ParseIP(network.IPAddress)) {
+			return true
+		}
+	}
+	return false
+}
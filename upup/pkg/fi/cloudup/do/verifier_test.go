@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package do
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+type fakeDroplets map[int]*godo.Droplet
+
+func (f fakeDroplets) Get(ctx context.Context, dropletID int) (*godo.Droplet, *godo.Response, error) {
+	if droplet := f[dropletID]; droplet != nil {
+		return droplet, nil, nil
+	}
+	return nil, nil, fmt.Errorf("droplet %d not found", dropletID)
+}
+
+func TestDOVerifier(t *testing.T) {
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("2756294\n"))
+	}))
+	defer metadataServer.Close()
+
+	authenticator := &doAuthenticator{metadataURL: metadataServer.URL, client: metadataServer.Client()}
+	token, err := authenticator.CreateToken([]byte("body"))
+	if err != nil {
+		t.Fatalf("creating token: %v", err)
+	}
+	if token != DOAuthenticationTokenPrefix+"2756294" {
+		t.Fatalf("unexpected token %q", token)
+	}
+
+	droplet := func() *godo.Droplet {
+		return &godo.Droplet{
+			ID:     2756294,
+			Name:   "nodes-minimal-example-com-1",
+			Status: "active",
+			Tags:   []string{"KubernetesCluster:minimal-example-com", "kops-instancegroup:nodes"},
+			Networks: &godo.Networks{
+				V4: []godo.NetworkV4{
+					{IPAddress: "10.110.0.5", Type: "private"},
+					{IPAddress: "203.0.113.5", Type: "public"},
+				},
+			},
+		}
+	}
+
+	grid := []struct {
+		name        string
+		token       string
+		remoteAddr  string
+		modify      func(d *godo.Droplet)
+		expectedErr string
+	}{
+		{
+			name:       "private address",
+			remoteAddr: "10.110.0.5:4321",
+		},
+		{
+			name:        "other address",
+			remoteAddr:  "10.110.0.6:4321",
+			expectedErr: "did not come from an address",
+		},
+		{
+			name:       "other cluster",
+			remoteAddr: "10.110.0.5:4321",
+			modify: func(d *godo.Droplet) {
+				d.Tags = []string{"KubernetesCluster:other-example-com", "kops-instancegroup:nodes"}
+			},
+			expectedErr: "does not have tag \"KubernetesCluster:minimal-example-com\"",
+		},
+		{
+			name:        "no instance group",
+			remoteAddr:  "10.110.0.5:4321",
+			modify:      func(d *godo.Droplet) { d.Tags = []string{"KubernetesCluster:minimal-example-com"} },
+			expectedErr: "does not have an instance group tag",
+		},
+		{
+			name:        "unknown droplet",
+			token:       DOAuthenticationTokenPrefix + "1",
+			remoteAddr:  "10.110.0.5:4321",
+			expectedErr: "fetching droplet 1",
+		},
+		{
+			name:        "invalid droplet id",
+			token:       DOAuthenticationTokenPrefix + "abc",
+			remoteAddr:  "10.110.0.5:4321",
+			expectedErr: "invalid droplet id",
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			d := droplet()
+			if g.modify != nil {
+				g.modify(d)
+			}
+			verifier := &doVerifier{
+				opt:      DOVerifierOptions{ClusterName: "minimal.example.com"},
+				droplets: fakeDroplets{d.ID: d},
+			}
+
+			tok := token
+			if g.token != "" {
+				tok = g.token
+			}
+			result, err := verifier.VerifyToken(context.Background(), &http.Request{RemoteAddr: g.remoteAddr}, tok, []byte("body"))
+			if g.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), g.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", g.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := fi.VerifyResult{NodeName: "nodes-minimal-example-com-1", InstanceGroupName: "nodes"}
+			if *result != expected {
+				t.Errorf("unexpected result %+v, expected %+v", *result, expected)
+			}
+		})
+	}
+}
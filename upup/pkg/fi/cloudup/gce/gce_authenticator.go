@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gce
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const GCEAuthenticationTokenPrefix = "x-gce-identity "
+
+// identityMetadataURL is the metadata endpoint which returns identity tokens for the default service account of the instance.
+const identityMetadataURL = "http://169.254.169.254/computeMetadata/v1/instance/service-accounts/default/identity"
+
+type gceAuthenticator struct {
+	metadataURL string
+	client      *http.Client
+}
+
+var _ fi.Authenticator = &gceAuthenticator{}
+
+// NewGCEAuthenticator returns an Authenticator which authenticates requests with instance identity tokens.
+func NewGCEAuthenticator() (fi.Authenticator, error) {
+	return &gceAuthenticator{
+		metadataURL: identityMetadataURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+func (a *gceAuthenticator) CreateToken(body []byte) (string, error) {
+	u, err := url.Parse(a.metadataURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing metadata URL %q: %v", a.metadataURL, err)
+	}
+	// The full format includes the instance details in the token, and the audience ensures the token
+	// is only valid for this particular body content.
+	q := u.Query()
+	q.Set("audience", identityAudience(body))
+	q.Set("format", "full")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("creating identity request: %v", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("querying instance identity: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading instance identity: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("received status code %d from metadata server: %s", resp.StatusCode, string(b))
+	}
+
+	return GCEAuthenticationTokenPrefix + strings.TrimSpace(string(b)), nil
+}
+
+// identityAudience returns the audience of the identity token for a request with the given body.
+func identityAudience(body []byte) string {
+	sha := sha256.Sum256(body)
+	return "kops-controller:" + base64.RawURLEncoding.EncodeToString(sha[:])
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	compute "google.golang.org/api/compute/v1"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"k8s.io/klog/v2"
+	nodeidentitygce "k8s.io/kops/pkg/nodeidentity/gce"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const (
+	// googleCertsURL is the location of the keys which sign instance identity tokens.
+	googleCertsURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+	// keysMaxAge is how long we use the signing keys before fetching them again.
+	keysMaxAge = time.Hour
+	// keysMinRefreshInterval limits how often tokens signed by an unknown key cause the keys to be fetched.
+	keysMinRefreshInterval = time.Minute
+)
+
+type GCEVerifierOptions struct {
+	// ProjectID is the GCP project of the cluster.
+	ProjectID string `json:"projectID"`
+	// ClusterName is the name of the cluster.
+	ClusterName string `json:"clusterName"`
+}
+
+// identityClaims are the claims of an instance identity token in the full format.
+type identityClaims struct {
+	jwt.Claims
+	Google struct {
+		ComputeEngine struct {
+			ProjectID    string `json:"project_id"`
+			Zone         string `json:"zone"`
+			InstanceID   string `json:"instance_id"`
+			InstanceName string `json:"instance_name"`
+		} `json:"compute_engine"`
+	} `json:"google"`
+}
+
+// verifierCompute is the part of the compute API used to identify instances.
+type verifierCompute interface {
+	GetInstance(ctx context.Context, project, zone, name string) (*compute.Instance, error)
+	GetInstanceGroupManager(ctx context.Context, project, zone, name string) (*compute.InstanceGroupManager, error)
+	GetManagedInstance(ctx context.Context, project, zone, migName string, instanceID uint64) (*compute.ManagedInstance, error)
+	GetInstanceTemplate(ctx context.Context, project, name string) (*compute.InstanceTemplate, error)
+}
+
+type gceVerifier struct {
+	opt     GCEVerifierOptions
+	compute verifierCompute
+	keys    *keySet
+	now     func() time.Time
+}
+
+var _ fi.Verifier = &gceVerifier{}
+
+// NewGCEVerifier returns a Verifier for instance identity tokens of instances in the cluster.
+func NewGCEVerifier(opt *GCEVerifierOptions) (fi.Verifier, error) {
+	computeService, err := compute.NewService(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("building compute API client: %v", err)
+	}
+
+	return &gceVerifier{
+		opt:     *opt,
+		compute: &computeVerifier{service: computeService},
+		keys: &keySet{
+			url:    googleCertsURL,
+			client: &http.Client{Timeout: 30 * time.Second},
+		},
+		now: time.Now,
+	}, nil
+}
+
+func (v *gceVerifier) VerifyToken(ctx context.Context, rawRequest *http.Request, token string, body []byte) (*fi.VerifyResult, error) {
+	if !strings.HasPrefix(token, GCEAuthenticationTokenPrefix) {
+		return nil, fmt.Errorf("incorrect authorization type")
+	}
+	token = strings.TrimPrefix(token, GCEAuthenticationTokenPrefix)
+
+	tok, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("parsing identity token: %v", err)
+	}
+	if len(tok.Headers) != 1 {
+		return nil, fmt.Errorf("identity token has %d signatures", len(tok.Headers))
+	}
+	if tok.Headers[0].Algorithm != string(jose.RS256) {
+		return nil, fmt.Errorf("identity token has unexpected algorithm %q", tok.Headers[0].Algorithm)
+	}
+
+	now := v.now()
+	key, err := v.keys.key(ctx, tok.Headers[0].KeyID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &identityClaims{}
+	if err := tok.Claims(key.Key, claims); err != nil {
+		return nil, fmt.Errorf("verifying identity token: %v", err)
+	}
+	if claims.Issuer != "https://accounts.google.com" && claims.Issuer != "accounts.google.com" {
+		return nil, fmt.Errorf("identity token has unexpected issuer %q", claims.Issuer)
+	}
+	if claims.Expiry == nil {
+		return nil, fmt.Errorf("identity token does not expire")
+	}
+	// Verify the token was issued for this particular body content.
+	err = claims.ValidateWithLeeway(jwt.Expected{
+		Audience: jwt.Audience{identityAudience(body)},
+		Time:     now,
+	}, time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("validating identity token: %v", err)
+	}
+
+	computeEngine := claims.Google.ComputeEngine
+	if computeEngine.ProjectID != v.opt.ProjectID {
+		return nil, fmt.Errorf("instance is in project %q, not %q", computeEngine.ProjectID, v.opt.ProjectID)
+	}
+	instanceID, err := strconv.ParseUint(computeEngine.InstanceID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("identity token has invalid instance id %q", computeEngine.InstanceID)
+	}
+
+	instance, err := v.compute.GetInstance(ctx, v.opt.ProjectID, computeEngine.Zone, computeEngine.InstanceName)
+	if err != nil {
+		return nil, fmt.Errorf("fetching instance %q: %v", computeEngine.InstanceName, err)
+	}
+	// The name may have been reused by a newer instance
+	if instance.Id != instanceID {
+		return nil, fmt.Errorf("instance %q has id %d, not %d", instance.Name, instance.Id, instanceID)
+	}
+	if instance.Status != "RUNNING" {
+		return nil, fmt.Errorf("instance %q has status %q", instance.Name, instance.Status)
+	}
+
+	// The metadata of the instance is mutable from the instance, so we look at the MIG configuration instead.
+	createdBy := getMetadataValue(instance.Metadata, "created-by")
+	if createdBy == "" {
+		return nil, fmt.Errorf("instance %q does not have created-by metadata", instance.Name)
+	}
+	mig, err := v.compute.GetInstanceGroupManager(ctx, v.opt.ProjectID, computeEngine.Zone, LastComponent(createdBy))
+	if err != nil {
+		return nil, fmt.Errorf("fetching managed instance group of instance %q: %v", instance.Name, err)
+	}
+	// Check the instance is indeed managed by the MIG, which can't be spoofed without GCE API access.
+	managed, err := v.compute.GetManagedInstance(ctx, v.opt.ProjectID, computeEngine.Zone, mig.Name, instance.Id)
+	if err != nil {
+		return nil, err
+	}
+	if managed.Version == nil {
+		return nil, fmt.Errorf("instance %q does not have a version in managed instance group %q", instance.Name, mig.Name)
+	}
+	template, err := v.compute.GetInstanceTemplate(ctx, v.opt.ProjectID, LastComponent(managed.Version.InstanceTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("fetching instance template of instance %q: %v", instance.Name, err)
+	}
+	if template.Properties == nil {
+		return nil, fmt.Errorf("instance template %q does not have properties", template.Name)
+	}
+
+	if clusterName := getMetadataValue(template.Properties.Metadata, "cluster-name"); clusterName != v.opt.ClusterName {
+		return nil, fmt.Errorf("instance %q is in cluster %q, not %q", instance.Name, clusterName, v.opt.ClusterName)
+	}
+	igName := getMetadataValue(template.Properties.Metadata, nodeidentitygce.MetadataKeyInstanceGroupName)
+	if igName == "" {
+		return nil, fmt.Errorf("instance template %q does not have an instance group name", template.Name)
+	}
+
+	return &fi.VerifyResult{
+		NodeName:          instance.Name,
+		InstanceGroupName: igName,
+	}, nil
+}
+
+func getMetadataValue(metadata *compute.Metadata, key string) string {
+	if metadata == nil {
+		return ""
+	}
+	for _, item := range metadata.Items {
+		if item.Key == key && item.Value != nil {
+			return *item.Value
+		}
+	}
+	return ""
+}
+
+// keySet caches the keys which sign instance identity tokens.
+type keySet struct {
+	url    string
+	client *http.Client
+
+	mutex   sync.Mutex
+	keys    *jose.JSONWebKeySet
+	fetched time.Time
+}
+
+// key returns the signing key with the given id, fetching the keys if they are stale or the key is unknown.
+func (k *keySet) key(ctx context.Context, kid string, now time.Time) (*jose.JSONWebKey, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if k.keys == nil || now.Sub(k.fetched) > keysMaxAge || (len(k.keys.Key(kid)) == 0 && now.Sub(k.fetched) > keysMinRefreshInterval) {
+		keys, err := k.fetch(ctx)
+		if err != nil {
+			if k.keys == nil {
+				return nil, err
+			}
+			klog.Warningf("using cached signing keys: %v", err)
+		} else {
+			k.keys = keys
+			k.fetched = now
+		}
+	}
+
+	keys := k.keys.Key(kid)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("identity token signed by unknown key %q", kid)
+	}
+	return &keys[0], nil
+}
+
+func (k *keySet) fetch(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", k.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching signing keys: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing keys: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received status code %d fetching signing keys: %s", resp.StatusCode, string(b))
+	}
+
+	keys := &jose.JSONWebKeySet{}
+	if err := json.Unmarshal(b, keys); err != nil {
+		return nil, fmt.Errorf("parsing signing keys: %v", err)
+	}
+	return keys, nil
+}
+
+// computeVerifier implements verifierCompute with the compute API.
+type computeVerifier struct {
+	service *compute.Service
+}
+
+var _ verifierCompute = &computeVerifier{}
+
+func (c *computeVerifier) GetInstance(ctx context.Context, project, zone, name string) (*compute.Instance, error) {
+	return c.service.Instances.Get(project, zone, name).Context(ctx).Do()
+}
+
+func (c *computeVerifier) GetInstanceGroupManager(ctx context.Context, project, zone, name string) (*compute.InstanceGroupManager, error) {
+	return c.service.InstanceGroupManagers.Get(project, zone, name).Context(ctx).Do()
+}
+
+func (c *computeVerifier) GetManagedInstance(ctx context.Context, project, zone, migName string, instanceID uint64) (*compute.ManagedInstance, error) {
+	filter := "id=" + strconv.FormatUint(instanceID, 10)
+	instances, err := c.service.InstanceGroupManagers.ListManagedInstances(project, zone, migName).Filter(filter).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("listing instances of managed instance group %q: %v", migName, err)
+	}
+	for _, instance := range instances.ManagedInstances {
+		if instance.Id == instanceID {
+			return instance, nil
+		}
+	}
+	return nil, fmt.Errorf("instance %d is not managed by managed instance group %q", instanceID, migName)
+}
+
+func (c *computeVerifier) GetInstanceTemplate(ctx context.Context, project, name string) (*compute.InstanceTemplate, error) {
+	return c.service.InstanceTemplates.Get(project, name).Context(ctx).Do()
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gce
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	compute "google.golang.org/api/compute/v1"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+type fakeVerifierCompute struct {
+	instances map[string]*compute.Instance
+	migs      map[string]*compute.InstanceGroupManager
+	managed   map[string][]*compute.ManagedInstance
+	templates map[string]*compute.InstanceTemplate
+}
+
+var _ verifierCompute = &fakeVerifierCompute{}
+
+func (f *fakeVerifierCompute) GetInstance(ctx context.Context, project, zone, name string) (*compute.Instance, error) {
+	if instance := f.instances[project+"/"+zone+"/"+name]; instance != nil {
+		return instance, nil
+	}
+	return nil, fmt.Errorf("instance %s not found", name)
+}
+
+func (f *fakeVerifierCompute) GetInstanceGroupManager(ctx context.Context, project, zone, name string) (*compute.InstanceGroupManager, error) {
+	if mig := f.migs[project+"/"+zone+"/"+name]; mig != nil {
+		return mig, nil
+	}
+	return nil, fmt.Errorf("mig %s not found", name)
+}
+
+func (f *fakeVerifierCompute) GetManagedInstance(ctx context.Context, project, zone, migName string, instanceID uint64) (*compute.ManagedInstance, error) {
+	for _, instance := range f.managed[project+"/"+zone+"/"+migName] {
+		if instance.Id == instanceID {
+			return instance, nil
+		}
+	}
+	return nil, fmt.Errorf("instance %d not managed by %s", instanceID, migName)
+}
+
+func (f *fakeVerifierCompute) GetInstanceTemplate(ctx context.Context, project, name string) (*compute.InstanceTemplate, error) {
+	if template := f.templates[project+"/"+name]; template != nil {
+		return template, nil
+	}
+	return nil, fmt.Errorf("template %s not found", name)
+}
+
+func metadataItems(kv ...string) *compute.Metadata {
+	m := &compute.Metadata{}
+	for i := 0; i < len(kv); i += 2 {
+		value := kv[i+1]
+		m.Items = append(m.Items, &compute.MetadataItems{Key: kv[i], Value: &value})
+	}
+	return m
+}
+
+func TestGCEVerifier(t *testing.T) {
+	now := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	keysServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "key-1", Algorithm: "RS256", Use: "sig"}}}
+		_ = json.NewEncoder(w).Encode(keys)
+	}))
+	defer keysServer.Close()
+
+	// The claims are modified by each test case before the token is issued
+	var claims *identityClaims
+	var signingKey *rsa.PrivateKey
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Query().Get("format") != "full" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: signingKey}, (&jose.SignerOptions{}).WithHeader("kid", "key-1"))
+		if err != nil {
+			t.Errorf("creating signer: %v", err)
+			return
+		}
+		c := *claims
+		if c.Audience == nil {
+			c.Audience = jwt.Audience{r.URL.Query().Get("audience")}
+		}
+		token, err := jwt.Signed(signer).Claims(c).CompactSerialize()
+		if err != nil {
+			t.Errorf("signing token: %v", err)
+			return
+		}
+		_, _ = w.Write([]byte(token))
+	}))
+	defer metadataServer.Close()
+
+	fake := &fakeVerifierCompute{
+		instances: map[string]*compute.Instance{
+			"testproject/us-test1-a/nodes-abcd": {
+				Id:       1234,
+				Name:     "nodes-abcd",
+				Status:   "RUNNING",
+				Metadata: metadataItems("created-by", "projects/123/zones/us-test1-a/instanceGroupManagers/a-nodes-minimal-example-com"),
+			},
+		},
+		migs: map[string]*compute.InstanceGroupManager{
+			"testproject/us-test1-a/a-nodes-minimal-example-com": {Name: "a-nodes-minimal-example-com"},
+		},
+		managed: map[string][]*compute.ManagedInstance{
+			"testproject/us-test1-a/a-nodes-minimal-example-com": {
+				{Id: 1234, Version: &compute.ManagedInstanceVersion{InstanceTemplate: "https://www.googleapis.com/compute/v1/projects/testproject/global/instanceTemplates/nodes-minimal-example-com-1"}},
+			},
+		},
+		templates: map[string]*compute.InstanceTemplate{
+			"testproject/nodes-minimal-example-com-1": {
+				Name: "nodes-minimal-example-com-1",
+				Properties: &compute.InstanceProperties{
+					Metadata: metadataItems("cluster-name", "minimal.example.com", "kops-k8s-io-instance-group-name", "nodes"),
+				},
+			},
+		},
+	}
+
+	validClaims := func() *identityClaims {
+		c := &identityClaims{
+			Claims: jwt.Claims{
+				Issuer:   "https://accounts.google.com",
+				IssuedAt: jwt.NewNumericDate(now.Add(-time.Minute)),
+				Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+			},
+		}
+		c.Google.ComputeEngine.ProjectID = "testproject"
+		c.Google.ComputeEngine.Zone = "us-test1-a"
+		c.Google.ComputeEngine.InstanceID = "1234"
+		c.Google.ComputeEngine.InstanceName = "nodes-abcd"
+		return c
+	}
+
+	grid := []struct {
+		name        string
+		modify      func(c *identityClaims)
+		otherKey    bool
+		verifyBody  string
+		clusterName string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:        "different body",
+			verifyBody:  "other",
+			expectedErr: "invalid audience claim",
+		},
+		{
+			name:        "expired",
+			modify:      func(c *identityClaims) { c.Expiry = jwt.NewNumericDate(now.Add(-time.Hour)) },
+			expectedErr: "token is expired",
+		},
+		{
+			name:        "wrong issuer",
+			modify:      func(c *identityClaims) { c.Issuer = "https://example.com" },
+			expectedErr: "unexpected issuer",
+		},
+		{
+			name:        "wrong key",
+			otherKey:    true,
+			expectedErr: "verifying identity token",
+		},
+		{
+			name:        "wrong project",
+			modify:      func(c *identityClaims) { c.Google.ComputeEngine.ProjectID = "otherproject" },
+			expectedErr: "not \"testproject\"",
+		},
+		{
+			name:        "recreated instance",
+			modify:      func(c *identityClaims) { c.Google.ComputeEngine.InstanceID = "5678" },
+			expectedErr: "has id 1234, not 5678",
+		},
+		{
+			name:        "wrong cluster",
+			clusterName: "other.example.com",
+			expectedErr: "not \"other.example.com\"",
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			claims = validClaims()
+			if g.modify != nil {
+				g.modify(claims)
+			}
+			signingKey = key
+			if g.otherKey {
+				signingKey = otherKey
+			}
+
+			authenticator := &gceAuthenticator{metadataURL: metadataServer.URL, client: metadataServer.Client()}
+			body := []byte("body")
+			token, err := authenticator.CreateToken(body)
+			if err != nil {
+				t.Fatalf("creating token: %v", err)
+			}
+			if !strings.HasPrefix(token, GCEAuthenticationTokenPrefix) {
+				t.Fatalf("token %q does not have prefix %q", token, GCEAuthenticationTokenPrefix)
+			}
+
+			clusterName := "minimal.example.com"
+			if g.clusterName != "" {
+				clusterName = g.clusterName
+			}
+			verifier := &gceVerifier{
+				opt:     GCEVerifierOptions{ProjectID: "testproject", ClusterName: clusterName},
+				compute: fake,
+				keys:    &keySet{url: keysServer.URL, client: keysServer.Client()},
+				now:     func() time.Time { return now },
+			}
+			if g.verifyBody != "" {
+				body = []byte(g.verifyBody)
+			}
+			result, err := verifier.VerifyToken(context.Background(), nil, token, body)
+			if g.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), g.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", g.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := fi.VerifyResult{NodeName: "nodes-abcd", InstanceGroupName: "nodes"}
+			if *result != expected {
+				t.Errorf("unexpected result %+v, expected %+v", *result, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+const OpenstackAuthenticationTokenPrefix = "x-openstack-metadata "
+
+// metadataURL is the location of the metadata of the instance.
+const metadataURL = "http://169.254.169.254/openstack/latest/meta_data.json"
+
+type openstackAuthenticator struct {
+	metadataURL string
+	client      *http.Client
+}
+
+var _ fi.Authenticator = &openstackAuthenticator{}
+
+// NewOpenstackAuthenticator returns an Authenticator which identifies requests by the id of the instance.
+// OpenStack does not provide signed instance identity documents, so the verifier
+// also checks that requests come from an address of the instance.
+func NewOpenstackAuthenticator() (fi.Authenticator, error) {
+	return &openstackAuthenticator{
+		metadataURL: metadataURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+func (a *openstackAuthenticator) CreateToken(body []byte) (string, error) {
+	resp, err := a.client.Get(a.metadataURL)
+	if err != nil {
+		return "", fmt.Errorf("querying instance metadata: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading instance metadata: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("received status code %d from metadata server: %s", resp.StatusCode, string(b))
+	}
+
+	metadata := struct {
+		UUID string `json:"uuid"`
+	}{}
+	if err := json.Unmarshal(b, &metadata); err != nil {
+		return "", fmt.Errorf("parsing instance metadata: %v", err)
+	}
+	if metadata.UUID == "" {
+		return "", fmt.Errorf("instance metadata does not contain the instance id")
+	}
+
+	return OpenstackAuthenticationTokenPrefix + metadata.UUID, nil
+}
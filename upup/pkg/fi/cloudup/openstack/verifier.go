@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// TagKopsInstanceGroup is the metadata key of the name of the instance group of a server.
+const TagKopsInstanceGroup = "KopsInstanceGroup"
+
+var serverIDRegexp = regexp.MustCompile(`^[0-9a-fA-F-]+$`)
+
+type OpenstackVerifierOptions struct {
+	// ClusterName is the name of the cluster.
+	ClusterName string `json:"clusterName"`
+}
+
+type openstackVerifier struct {
+	opt OpenstackVerifierOptions
+
+	getServer func(id string) (*servers.Server, error)
+}
+
+var _ fi.Verifier = &openstackVerifier{}
+
+// NewOpenstackVerifier returns a Verifier for requests from servers in the cluster,
+// using the credentials in the environment to look up the servers.
+func NewOpenstackVerifier(opt *OpenstackVerifierOptions) (fi.Verifier, error) {
+	env, err := openstack.AuthOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	region := os.Getenv("OS_REGION_NAME")
+	if region == "" {
+		return nil, fmt.Errorf("unable to find region")
+	}
+
+	provider, err := openstack.NewClient(env.IdentityEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	ua := gophercloud.UserAgent{}
+	ua.Prepend("kops/kops-controller")
+	provider.UserAgent = ua
+
+	// kops-controller should be able to renew its tokens against the OpenStack API
+	env.AllowReauth = true
+	if err := openstack.Authenticate(provider, env); err != nil {
+		return nil, err
+	}
+
+	novaClient, err := openstack.NewComputeV2(provider, gophercloud.EndpointOpts{
+		Type:   "compute",
+		Region: region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error building nova client: %v", err)
+	}
+
+	return &openstackVerifier{
+		opt: *opt,
+		getServer: func(id string) (*servers.Server, error) {
+			return servers.Get(novaClient, id).Extract()
+		},
+	}, nil
+}
+
+// VerifyToken verifies the token is the id of a server of the cluster, and the request came from one of its addresses.
+// The id is not secret, so this only authenticates the source address of the request, see docs/architecture/kops-controller.md.
+func (o *openstackVerifier) VerifyToken(ctx context.Context, rawRequest *http.Request, token string, body []byte) (*fi.VerifyResult, error) {
+	if !strings.HasPrefix(token, OpenstackAuthenticationTokenPrefix) {
+		return nil, fmt.Errorf("incorrect authorization type")
+	}
+	serverID := strings.TrimPrefix(token, OpenstackAuthenticationTokenPrefix)
+	if !serverIDRegexp.MatchString(serverID) {
+		return nil, fmt.Errorf("invalid server id %q", serverID)
+	}
+
+	server, err := o.getServer(serverID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching server %q: %v", serverID, err)
+	}
+	if server.Status != "ACTIVE" {
+		return nil, fmt.Errorf("server %q has status %q", serverID, server.Status)
+	}
+	if clusterName := server.Metadata[TagClusterName]; clusterName != o.opt.ClusterName {
+		return nil, fmt.Errorf("server %q is in cluster %q, not %q", serverID, clusterName, o.opt.ClusterName)
+	}
+
+	// The id of a server is not a secret, so we require the request to come from one of its addresses.
+	remoteIP, err := remoteIP(rawRequest)
+	if err != nil {
+		return nil, err
+	}
+	if !serverHasAddress(server, remoteIP) {
+		return nil, fmt.Errorf("request from %s did not come from an address of server %q", remoteIP, serverID)
+	}
+
+	igName := server.Metadata[TagKopsInstanceGroup]
+	if igName == "" {
+		return nil, fmt.Errorf("server %q does not have %s metadata", serverID, TagKopsInstanceGroup)
+	}
+
+	return &fi.VerifyResult{
+		NodeName:          strings.ToLower(server.Name),
+		InstanceGroupName: igName,
+	}, nil
+}
+
+func remoteIP(rawRequest *http.Request) (net.IP, error) {
+	host, _, err := net.SplitHostPort(rawRequest.RemoteAddr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing remote address %q: %v", rawRequest.RemoteAddr, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, fmt.Errorf("parsing remote address %q", rawRequest.RemoteAddr)
+	}
+	return ip, nil
+}
+
+// serverHasAddress returns true if the ip is one of the fixed or floating addresses of the server.
+func serverHasAddress(server *servers.Server, ip net.IP) bool {
+	for _, addresses := range server.Addresses {
+		list, ok := addresses.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, address := range list {
+			m, ok := address.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			addr, _ := m["addr"].(string)
+			if ip.Equal(net.ParseIP(addr)) {
+				return true
+			}
+		}
+	}
+	return false
+}
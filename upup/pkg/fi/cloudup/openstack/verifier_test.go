@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func Test_OpenstackVerifier(t *testing.T) {
+	const serverID = "0b3e6dd4-6b6e-4c7d-9d2e-2e7f1c8b7a10"
+
+	metadataServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"uuid": "` + serverID + `", "name": "nodes-1-minimal-example-com", "meta": {"KopsInstanceGroup": "nodes"}}`))
+	}))
+	defer metadataServer.Close()
+
+	authenticator := &openstackAuthenticator{metadataURL: metadataServer.URL, client: metadataServer.Client()}
+	token, err := authenticator.CreateToken([]byte("body"))
+	if err != nil {
+		t.Fatalf("creating token: %v", err)
+	}
+	if token != OpenstackAuthenticationTokenPrefix+serverID {
+		t.Fatalf("unexpected token %q", token)
+	}
+
+	server := func() *servers.Server {
+		return &servers.Server{
+			ID:     serverID,
+			Name:   "Nodes-1-minimal-example-com",
+			Status: "ACTIVE",
+			Metadata: map[string]string{
+				TagClusterName:       "minimal.example.com",
+				TagKopsInstanceGroup: "nodes",
+			},
+			Addresses: map[string]interface{}{
+				"minimal.example.com": []interface{}{
+					map[string]interface{}{"addr": "192.168.1.10", "version": float64(4), "OS-EXT-IPS:type": "fixed"},
+					map[string]interface{}{"addr": "10.0.0.10", "version": float64(4), "OS-EXT-IPS:type": "floating"},
+				},
+			},
+		}
+	}
+
+	grid := []struct {
+		name        string
+		token       string
+		remoteAddr  string
+		modify      func(s *servers.Server)
+		expectedErr string
+	}{
+		{
+			name:       "fixed address",
+			remoteAddr: "192.168.1.10:4321",
+		},
+		{
+			name:       "floating address",
+			remoteAddr: "10.0.0.10:4321",
+		},
+		{
+			name:        "other address",
+			remoteAddr:  "192.168.1.11:4321",
+			expectedErr: "did not come from an address",
+		},
+		{
+			name:        "other cluster",
+			remoteAddr:  "192.168.1.10:4321",
+			modify:      func(s *servers.Server) { s.Metadata[TagClusterName] = "other.example.com" },
+			expectedErr: "not \"minimal.example.com\"",
+		},
+		{
+			name:        "not active",
+			remoteAddr:  "192.168.1.10:4321",
+			modify:      func(s *servers.Server) { s.Status = "SHUTOFF" },
+			expectedErr: "has status",
+		},
+		{
+			name:        "unknown server",
+			token:       OpenstackAuthenticationTokenPrefix + "3f1c",
+			remoteAddr:  "192.168.1.10:4321",
+			expectedErr: "fetching server",
+		},
+		{
+			name:        "invalid server id",
+			token:       OpenstackAuthenticationTokenPrefix + "../flavors",
+			remoteAddr:  "192.168.1.10:4321",
+			expectedErr: "invalid server id",
+		},
+		{
+			name:        "other authorization",
+			token:       "x-aws-sts abc",
+			remoteAddr:  "192.168.1.10:4321",
+			expectedErr: "incorrect authorization type",
+		},
+	}
+	for _, g := range grid {
+		t.Run(g.name, func(t *testing.T) {
+			s := server()
+			if g.modify != nil {
+				g.modify(s)
+			}
+			verifier := &openstackVerifier{
+				opt: OpenstackVerifierOptions{ClusterName: "minimal.example.com"},
+				getServer: func(id string) (*servers.Server, error) {
+					if id != s.ID {
+						return nil, fmt.Errorf("server %q not found", id)
+					}
+					return s, nil
+				},
+			}
+
+			tok := token
+			if g.token != "" {
+				tok = g.token
+			}
+			result, err := verifier.VerifyToken(context.Background(), &http.Request{RemoteAddr: g.remoteAddr}, tok, []byte("body"))
+			if g.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), g.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", g.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := fi.VerifyResult{NodeName: "nodes-1-minimal-example-com", InstanceGroupName: "nodes"}
+			if *result != expected {
+				t.Errorf("unexpected result %+v, expected %+v", *result, expected)
+			}
+		})
+	}
+}
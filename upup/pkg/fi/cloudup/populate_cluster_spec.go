@@ -224,7 +224,7 @@ func (c *populateClusterSpec) run(clientset simple.Clientset) error {
 		cluster.Spec.KubernetesVersion = versionWithoutV
 	}
 	if cluster.Spec.DNSZone == "" && !dns.IsGossipHostname(cluster.ObjectMeta.Name) {
-		dns, err := cloud.DNS()
+		dns, err := dnsProvider(cloud)
 		if err != nil {
 			return err
 		}
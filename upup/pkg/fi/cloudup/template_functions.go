@@ -52,7 +52,10 @@ import (
 	"k8s.io/kops/pkg/wellknownports"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/do"
 	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 	"k8s.io/kops/util/pkg/env"
 )
 
@@ -110,6 +113,13 @@ func (tf *TemplateFunctions) AddTo(dest template.FuncMap, secretStore fi.SecretS
 	dest["NodeLocalDNSHealthCheck"] = func() string {
 		return fmt.Sprintf("%d", wellknownports.NodeLocalDNSHealthCheck)
 	}
+	// GossipDNSListen is the address on which protokube serves the gossip DNS records, if it does
+	dest["GossipDNSListen"] = func() string {
+		if !dns.IsGossipHostname(cluster.Spec.MasterInternalName) || cluster.Spec.GossipConfig == nil || cluster.Spec.GossipConfig.DNS == nil {
+			return ""
+		}
+		return fi.StringValue(cluster.Spec.GossipConfig.DNS.Listen)
+	}
 
 	dest["KopsControllerArgv"] = tf.KopsControllerArgv
 	dest["KopsControllerConfig"] = tf.KopsControllerConfig
@@ -495,6 +505,26 @@ func (tf *TemplateFunctions) KopsControllerConfig() (string, error) {
 				NodesRoles: nodesRoles.List(),
 				Region:     tf.Region,
 			}
+		case kops.CloudProviderGCE:
+			config.Server.Provider.GCE = &gce.GCEVerifierOptions{
+				ProjectID:   cluster.Spec.Project,
+				ClusterName: tf.ClusterName(),
+			}
+		case kops.CloudProviderOpenstack:
+			config.Server.Provider.OpenStack = &openstack.OpenstackVerifierOptions{
+				ClusterName: tf.ClusterName(),
+			}
+		case kops.CloudProviderAzure:
+			config.Server.Provider.Azure = &azure.AzureVerifierOptions{
+				ClusterName:       tf.ClusterName(),
+				SubscriptionID:    cluster.Spec.CloudConfig.Azure.SubscriptionID,
+				ResourceGroupName: cluster.AzureResourceGroupName(),
+			}
+		case kops.CloudProviderDO:
+			config.Server.Provider.DO = &do.DOVerifierOptions{
+				ClusterName: tf.ClusterName(),
+				Region:      tf.Region,
+			}
 		default:
 			return "", fmt.Errorf("unsupported cloud provider %s", cluster.Spec.CloudProvider)
 		}
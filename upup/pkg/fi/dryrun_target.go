@@ -102,6 +102,18 @@ func (t *DryRunTarget) Render(a, e, changes Task) error {
 	return nil
 }
 
+// changedTasks returns the tasks which would be created or updated.
+func (t *DryRunTarget) changedTasks() map[Task]bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	changed := make(map[Task]bool)
+	for _, r := range t.changes {
+		changed[r.e] = true
+	}
+	return changed
+}
+
 func (t *DryRunTarget) Delete(deletion Deletion) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"k8s.io/klog/v2"
+	"k8s.io/kops/pkg/eventstream"
 )
 
 type executor struct {
@@ -43,6 +44,18 @@ type taskState struct {
 type RunTasksOptions struct {
 	MaxTaskDuration         time.Duration
 	WaitAfterAllTasksFailed time.Duration
+
+	// Events, if set, receives an event when each task is started and when it completes or fails
+	Events eventstream.Recorder
+
+	// Trace, if set, records the start, end and error of each attempt to run a task
+	Trace *Trace
+
+	// MaxConcurrentTasks is the maximum number of tasks run at the same time; if zero, there is no limit
+	MaxConcurrentTasks int
+
+	// Limiter, if set, further limits when tasks may run, for example by task type
+	Limiter TaskLimiter
 }
 
 func (o *RunTasksOptions) InitDefaults() {
@@ -83,6 +96,11 @@ func (e *executor) RunTasks(taskMap map[string]Task) error {
 		}
 	}
 
+	if e.options.Trace != nil {
+		e.options.Trace.start(taskStates)
+		defer e.options.Trace.finish()
+	}
+
 	for {
 		var canRun []*taskState
 		doneCount := 0
@@ -176,6 +194,11 @@ func (e *executor) forkJoin(tasks []*taskState) []error {
 		return nil
 	}
 
+	var slots chan struct{}
+	if e.options.MaxConcurrentTasks > 0 {
+		slots = make(chan struct{}, e.options.MaxConcurrentTasks)
+	}
+
 	var wg sync.WaitGroup
 	results := make([]error, len(tasks))
 	for i := 0; i < len(tasks); i++ {
@@ -183,8 +206,31 @@ func (e *executor) forkJoin(tasks []*taskState) []error {
 		go func(ts *taskState, index int) {
 			results[index] = fmt.Errorf("function panic")
 			defer wg.Done()
+			// Wait for the task type's limits first, so tasks of other types can use the free slots
+			if e.options.Limiter != nil {
+				release := e.options.Limiter.Acquire(ts.task)
+				defer release()
+			}
+			if slots != nil {
+				slots <- struct{}{}
+				defer func() { <-slots }()
+			}
 			klog.V(2).Infof("Executing task %q: %v\n", ts.key, ts.task)
+			start := time.Now()
+			e.recordTaskEvent(eventstream.TaskStarted, ts, start, nil)
 			results[index] = ts.task.Run(e.context)
+			err := results[index]
+			if _, ok := err.(*ExistsAndWarnIfChangesError); ok {
+				err = nil
+			}
+			if e.options.Trace != nil {
+				e.options.Trace.recordAttempt(ts.key, start, time.Now(), err)
+			}
+			if err == nil {
+				e.recordTaskEvent(eventstream.TaskCompleted, ts, start, nil)
+			} else {
+				e.recordTaskEvent(eventstream.TaskFailed, ts, start, err)
+			}
 		}(tasks[i], i)
 	}
 
@@ -192,3 +238,25 @@ func (e *executor) forkJoin(tasks []*taskState) []error {
 
 	return results
 }
+
+// recordTaskEvent records an event for the task, if an event stream was requested.
+func (e *executor) recordTaskEvent(eventType eventstream.Type, ts *taskState, start time.Time, err error) {
+	if e.options.Events == nil {
+		return
+	}
+
+	event := eventstream.Event{
+		Type:     eventType,
+		Task:     ts.key,
+		TaskType: TypeNameForTask(ts.task),
+	}
+	if eventType == eventstream.TaskStarted {
+		event.Time = start
+	} else {
+		event.DurationSeconds = time.Since(start).Seconds()
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	e.options.Events.Record(event)
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/kops/pkg/eventstream"
+)
+
+// executorTestTask is a task which fails a number of times before succeeding.
+type executorTestTask struct {
+	Name         string
+	Dependencies []Task
+	Failures     int
+
+	mutex sync.Mutex
+	runs  int
+}
+
+var _ HasDependencies = &executorTestTask{}
+
+func (t *executorTestTask) GetDependencies(tasks map[string]Task) []Task {
+	return t.Dependencies
+}
+
+func (t *executorTestTask) Run(c *Context) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.runs++
+	if t.runs <= t.Failures {
+		return fmt.Errorf("failure %d", t.runs)
+	}
+	return nil
+}
+
+type recordingEventRecorder struct {
+	mutex  sync.Mutex
+	events []eventstream.Event
+}
+
+func (r *recordingEventRecorder) Record(event eventstream.Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingEventRecorder) summary() []string {
+	var summary []string
+	for _, event := range r.events {
+		s := string(event.Type) + " " + event.Task
+		if event.Error != "" {
+			s += ": " + event.Error
+		}
+		summary = append(summary, s)
+	}
+	return summary
+}
+
+func TestExecutorEvents(t *testing.T) {
+	network := &executorTestTask{Name: "network"}
+	subnet := &executorTestTask{Name: "subnet", Dependencies: []Task{network}, Failures: 1}
+	tasks := map[string]Task{
+		"executorTestTask/network": network,
+		"executorTestTask/subnet":  subnet,
+	}
+
+	recorder := &recordingEventRecorder{}
+	e := &executor{
+		context: &Context{},
+		options: RunTasksOptions{
+			MaxTaskDuration: time.Minute,
+			Events:          recorder,
+		},
+	}
+	if err := e.RunTasks(tasks); err != nil {
+		t.Fatalf("unexpected error running tasks: %v", err)
+	}
+
+	expected := []string{
+		"TaskStarted executorTestTask/network",
+		"TaskCompleted executorTestTask/network",
+		"TaskStarted executorTestTask/subnet",
+		"TaskFailed executorTestTask/subnet: failure 1",
+		"TaskStarted executorTestTask/subnet",
+		"TaskCompleted executorTestTask/subnet",
+	}
+	if actual := recorder.summary(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected events %v, expected %v", actual, expected)
+	}
+	for _, event := range recorder.events {
+		if event.TaskType != "executorTestTask" {
+			t.Errorf("unexpected task type %q", event.TaskType)
+		}
+	}
+}
+
+func TestExecutorTrace(t *testing.T) {
+	network := &executorTestTask{Name: "network"}
+	subnet := &executorTestTask{Name: "subnet", Dependencies: []Task{network}, Failures: 1}
+	bucket := &executorTestTask{Name: "bucket"}
+	tasks := map[string]Task{
+		"executorTestTask/network": network,
+		"executorTestTask/subnet":  subnet,
+		"executorTestTask/bucket":  bucket,
+	}
+
+	trace := NewTrace()
+	e := &executor{
+		context: &Context{},
+		options: RunTasksOptions{
+			MaxTaskDuration: time.Minute,
+			Trace:           trace,
+		},
+	}
+	if err := e.RunTasks(tasks); err != nil {
+		t.Fatalf("unexpected error running tasks: %v", err)
+	}
+
+	subnetTrace := trace.Tasks["executorTestTask/subnet"]
+	if len(subnetTrace.Attempts) != 2 || subnetTrace.Attempts[0].Error != "failure 1" || subnetTrace.Attempts[1].Error != "" || !subnetTrace.Done {
+		t.Errorf("unexpected attempts of subnet: %+v", subnetTrace)
+	}
+	if !reflect.DeepEqual(subnetTrace.Dependencies, []string{"executorTestTask/network"}) {
+		t.Errorf("unexpected dependencies of subnet: %v", subnetTrace.Dependencies)
+	}
+
+	var criticalPath []string
+	for _, tt := range trace.CriticalPath() {
+		criticalPath = append(criticalPath, tt.Key)
+	}
+	if expected := []string{"executorTestTask/network", "executorTestTask/subnet"}; !reflect.DeepEqual(criticalPath, expected) {
+		t.Errorf("unexpected critical path %v, expected %v", criticalPath, expected)
+	}
+
+	var chrome bytes.Buffer
+	if err := trace.WriteChromeTrace(&chrome); err != nil {
+		t.Fatalf("unexpected error writing chrome trace: %v", err)
+	}
+	var parsed struct {
+		TraceEvents []struct {
+			Name  string `json:"name"`
+			Phase string `json:"ph"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(chrome.Bytes(), &parsed); err != nil {
+		t.Fatalf("unexpected error parsing chrome trace: %v", err)
+	}
+	if len(parsed.TraceEvents) != 4 {
+		t.Errorf("expected an event per attempt, got %d", len(parsed.TraceEvents))
+	}
+
+	var summary bytes.Buffer
+	if err := trace.WriteSummary(&summary, 10); err != nil {
+		t.Fatalf("unexpected error writing summary: %v", err)
+	}
+	if !strings.Contains(summary.String(), "Ran 3 tasks") || !strings.Contains(summary.String(), "1 needed more than one attempt") {
+		t.Errorf("unexpected summary:\n%s", summary.String())
+	}
+}
+
+// concurrencyTestTask records the largest number of tasks running at the same time.
+type concurrencyTestTask struct {
+	Counter *concurrencyCounter
+}
+
+var _ HasDependencies = &concurrencyTestTask{}
+
+func (t *concurrencyTestTask) GetDependencies(tasks map[string]Task) []Task {
+	return nil
+}
+
+type concurrencyCounter struct {
+	mutex   sync.Mutex
+	running int
+	max     int
+}
+
+func (t *concurrencyTestTask) Run(c *Context) error {
+	t.Counter.mutex.Lock()
+	t.Counter.running++
+	if t.Counter.running > t.Counter.max {
+		t.Counter.max = t.Counter.running
+	}
+	t.Counter.mutex.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	t.Counter.mutex.Lock()
+	t.Counter.running--
+	t.Counter.mutex.Unlock()
+	return nil
+}
+
+func TestExecutorMaxConcurrentTasks(t *testing.T) {
+	counter := &concurrencyCounter{}
+	tasks := make(map[string]Task)
+	for i := 0; i < 10; i++ {
+		tasks[fmt.Sprintf("concurrencyTestTask/%d", i)] = &concurrencyTestTask{Counter: counter}
+	}
+
+	e := &executor{
+		context: &Context{},
+		options: RunTasksOptions{
+			MaxTaskDuration:    time.Minute,
+			MaxConcurrentTasks: 3,
+		},
+	}
+	if err := e.RunTasks(tasks); err != nil {
+		t.Fatalf("unexpected error running tasks: %v", err)
+	}
+	if counter.max != 3 {
+		t.Errorf("expected at most 3 concurrent tasks, got %d", counter.max)
+	}
+}
+
+func TestTaskTypeLimits(t *testing.T) {
+	for _, taskType := range []string{"concurrencyTestTask", "fi.concurrencyTestTask"} {
+		counter := &concurrencyCounter{}
+		tasks := make(map[string]Task)
+		for i := 0; i < 6; i++ {
+			tasks[fmt.Sprintf("concurrencyTestTask/%d", i)] = &concurrencyTestTask{Counter: counter}
+		}
+
+		limiter, err := NewTaskTypeLimits(map[string]int{taskType: 2}, map[string]float32{taskType: 1000})
+		if err != nil {
+			t.Fatalf("unexpected error building limits: %v", err)
+		}
+		e := &executor{
+			context: &Context{},
+			options: RunTasksOptions{
+				MaxTaskDuration: time.Minute,
+				Limiter:         limiter,
+			},
+		}
+		if err := e.RunTasks(tasks); err != nil {
+			t.Fatalf("unexpected error running tasks: %v", err)
+		}
+		if counter.max != 2 {
+			t.Errorf("expected at most 2 concurrent tasks limited by %q, got %d", taskType, counter.max)
+		}
+	}
+
+	if _, err := NewTaskTypeLimits(map[string]int{"SecurityGroupRule": 0}, nil); err == nil {
+		t.Errorf("expected error for a concurrency limit of zero")
+	}
+}
@@ -211,6 +211,7 @@ func (_ *Keypair) Render(c *fi.Context, a, e, changes *Keypair) error {
 			Subject:        *subjectPkix,
 			AlternateNames: e.AlternateNames,
 			PrivateKey:     privateKey,
+			KeyAlgorithm:   c.Cluster.KeyAlgorithm(name),
 			Serial:         serial,
 		}
 		cert, privateKey, _, err := pki.IssueCert(&req, c.Keystore)
@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// TaskGraphNode is a task in a TaskGraph.
+type TaskGraphNode struct {
+	Key       string `json:"key"`
+	TaskType  string `json:"taskType"`
+	Lifecycle string `json:"lifecycle,omitempty"`
+	// Changed is whether a dry run found changes to the task, or nil if the graph was not built from a dry run.
+	Changed *bool `json:"changed,omitempty"`
+	// InCycle is true if the task is part of a circular dependency.
+	InCycle bool `json:"inCycle,omitempty"`
+}
+
+// TaskGraphEdge is a dependency between two tasks in a TaskGraph.
+// The edge points from the dependency to the task which depends on it, the order in which they run.
+type TaskGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TaskGraph is the graph of tasks and their dependencies, for debugging the order in which tasks
+// are run and the changes they make.
+type TaskGraph struct {
+	Nodes []*TaskGraphNode `json:"nodes"`
+	Edges []TaskGraphEdge  `json:"edges"`
+}
+
+// BuildTaskGraph builds the graph of the tasks. If target is a DryRunTarget which has been run,
+// each node records whether changes were found to the task.
+func BuildTaskGraph(tasks map[string]Task, target Target) *TaskGraph {
+	var changed map[Task]bool
+	if dryRun, ok := target.(*DryRunTarget); ok {
+		changed = dryRun.changedTasks()
+	}
+
+	dependencies := FindTaskDependencies(tasks)
+	inCycle := findCycles(dependencies)
+
+	g := &TaskGraph{
+		Nodes: []*TaskGraphNode{},
+		Edges: []TaskGraphEdge{},
+	}
+	for k, task := range tasks {
+		node := &TaskGraphNode{
+			Key:      k,
+			TaskType: TypeNameForTask(task),
+			InCycle:  inCycle[k],
+		}
+		if hl, ok := task.(HasLifecycle); ok && hl.GetLifecycle() != nil {
+			node.Lifecycle = string(*hl.GetLifecycle())
+		}
+		if changed != nil {
+			c := changed[task]
+			node.Changed = &c
+		}
+		g.Nodes = append(g.Nodes, node)
+
+		for _, dep := range dependencies[k] {
+			g.Edges = append(g.Edges, TaskGraphEdge{From: dep, To: k})
+		}
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].Key < g.Nodes[j].Key
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// findCycles returns the tasks which are part of a circular dependency, using Tarjan's algorithm
+// for strongly connected components.
+func findCycles(dependencies map[string][]string) map[string]bool {
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	inCycle := make(map[string]bool)
+
+	var visit func(k string)
+	visit = func(k string) {
+		index[k] = len(index)
+		lowLink[k] = index[k]
+		stack = append(stack, k)
+		onStack[k] = true
+
+		for _, dep := range dependencies[k] {
+			if _, visited := index[dep]; !visited {
+				visit(dep)
+				if lowLink[dep] < lowLink[k] {
+					lowLink[k] = lowLink[dep]
+				}
+			} else if onStack[dep] && index[dep] < lowLink[k] {
+				lowLink[k] = index[dep]
+			}
+		}
+
+		if lowLink[k] != index[k] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == k {
+				break
+			}
+		}
+		if len(component) > 1 {
+			for _, c := range component {
+				inCycle[c] = true
+			}
+		}
+	}
+
+	var keys []string
+	for k, deps := range dependencies {
+		keys = append(keys, k)
+		for _, dep := range deps {
+			if dep == k {
+				inCycle[k] = true
+			}
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, visited := index[k]; !visited {
+			visit(k)
+		}
+	}
+	return inCycle
+}
+
+// WriteJSON writes the graph as JSON.
+func (g *TaskGraph) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling task graph: %v", err)
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+	return err
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language. Tasks with changes are filled,
+// and tasks which are part of a circular dependency are outlined in red.
+func (g *TaskGraph) WriteDOT(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "digraph tasks {\n  rankdir=LR;\n  node [shape=box];\n"); err != nil {
+		return err
+	}
+	for _, node := range g.Nodes {
+		label := node.Key + "\n" + node.TaskType
+		if node.Lifecycle != "" {
+			label += " (" + node.Lifecycle + ")"
+		}
+		attrs := "label=" + strconv.Quote(label)
+		if node.Changed != nil && *node.Changed {
+			attrs += ", style=filled, fillcolor=lightyellow"
+		}
+		if node.InCycle {
+			attrs += ", color=red, penwidth=2"
+		}
+		if _, err := fmt.Fprintf(w, "  %s [%s];\n", strconv.Quote(node.Key), attrs); err != nil {
+			return err
+		}
+	}
+	for _, edge := range g.Edges {
+		if _, err := fmt.Fprintf(w, "  %s -> %s;\n", strconv.Quote(edge.From), strconv.Quote(edge.To)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "}\n")
+	return err
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildTaskGraph(t *testing.T) {
+	network := &executorTestTask{Name: "network"}
+	subnet := &executorTestTask{Name: "subnet", Dependencies: []Task{network}}
+	tasks := map[string]Task{
+		"executorTestTask/network": network,
+		"executorTestTask/subnet":  subnet,
+	}
+
+	target := NewDryRunTarget(nil, &bytes.Buffer{})
+	if err := target.Render((*executorTestTask)(nil), subnet, subnet); err != nil {
+		t.Fatalf("unexpected error rendering: %v", err)
+	}
+
+	g := BuildTaskGraph(tasks, target)
+	if len(g.Nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(g.Nodes))
+	}
+	for _, node := range g.Nodes {
+		if node.TaskType != "executorTestTask" {
+			t.Errorf("unexpected task type %q", node.TaskType)
+		}
+		if node.Changed == nil {
+			t.Fatalf("expected changes to be recorded for %q", node.Key)
+		}
+		if expected := node.Key == "executorTestTask/subnet"; *node.Changed != expected {
+			t.Errorf("expected changed of %q to be %v", node.Key, expected)
+		}
+		if node.InCycle {
+			t.Errorf("unexpected cycle at %q", node.Key)
+		}
+	}
+	expectedEdges := []TaskGraphEdge{{From: "executorTestTask/network", To: "executorTestTask/subnet"}}
+	if !reflect.DeepEqual(g.Edges, expectedEdges) {
+		t.Errorf("unexpected edges %v, expected %v", g.Edges, expectedEdges)
+	}
+
+	var dot bytes.Buffer
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatalf("unexpected error writing DOT: %v", err)
+	}
+	if !strings.Contains(dot.String(), `"executorTestTask/network" -> "executorTestTask/subnet";`) {
+		t.Errorf("missing edge in DOT:\n%s", dot.String())
+	}
+
+	var js bytes.Buffer
+	if err := g.WriteJSON(&js); err != nil {
+		t.Fatalf("unexpected error writing JSON: %v", err)
+	}
+	var parsed TaskGraph
+	if err := json.Unmarshal(js.Bytes(), &parsed); err != nil {
+		t.Fatalf("unexpected error parsing JSON: %v", err)
+	}
+	if !reflect.DeepEqual(&parsed, g) {
+		t.Errorf("JSON did not round-trip:\n%s", js.String())
+	}
+}
+
+func TestBuildTaskGraphCycles(t *testing.T) {
+	a := &executorTestTask{Name: "a"}
+	b := &executorTestTask{Name: "b", Dependencies: []Task{a}}
+	c := &executorTestTask{Name: "c", Dependencies: []Task{b}}
+	d := &executorTestTask{Name: "d", Dependencies: []Task{c}}
+	a.Dependencies = []Task{c}
+	tasks := map[string]Task{
+		"executorTestTask/a": a,
+		"executorTestTask/b": b,
+		"executorTestTask/c": c,
+		"executorTestTask/d": d,
+	}
+
+	g := BuildTaskGraph(tasks, nil)
+	var inCycle []string
+	for _, node := range g.Nodes {
+		if node.Changed != nil {
+			t.Errorf("unexpected changes recorded without a dry run for %q", node.Key)
+		}
+		if node.InCycle {
+			inCycle = append(inCycle, node.Key)
+		}
+	}
+	if expected := []string{"executorTestTask/a", "executorTestTask/b", "executorTestTask/c"}; !reflect.DeepEqual(inCycle, expected) {
+		t.Errorf("unexpected tasks in cycle %v, expected %v", inCycle, expected)
+	}
+}
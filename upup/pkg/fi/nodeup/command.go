@@ -38,6 +38,10 @@ import (
 	"k8s.io/kops/pkg/configserver"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/azure"
+	"k8s.io/kops/upup/pkg/fi/cloudup/do"
+	"k8s.io/kops/upup/pkg/fi/cloudup/gce"
+	"k8s.io/kops/upup/pkg/fi/cloudup/openstack"
 	"k8s.io/kops/upup/pkg/fi/nodeup/cloudinit"
 	"k8s.io/kops/upup/pkg/fi/nodeup/local"
 	"k8s.io/kops/upup/pkg/fi/nodeup/nodetasks"
@@ -57,14 +61,20 @@ import (
 // MaxTaskDuration is the amount of time to keep trying for; we retry for a long time - there is not really any great fallback
 const MaxTaskDuration = 365 * 24 * time.Hour
 
+// CertificateRenewalWindow is how long before the kubelet client certificate expires that the certificates of the node are renewed
+const CertificateRenewalWindow = 60 * 24 * time.Hour
+
 // NodeUpCommand is the configuration for nodeup
 type NodeUpCommand struct {
 	CacheDir       string
 	ConfigLocation string
 	Target         string
-	cluster        *api.Cluster
-	config         *nodeup.Config
-	instanceGroup  *api.InstanceGroup
+	// RenewCertificates renews the certificates which kops-controller issued to the node, if they are about to expire
+	RenewCertificates bool
+
+	cluster       *api.Cluster
+	config        *nodeup.Config
+	instanceGroup *api.InstanceGroup
 }
 
 // Run is responsible for perform the nodeup process
@@ -258,7 +268,24 @@ func (c *NodeUpCommand) Run(out io.Writer) error {
 		return err
 	}
 
-	if api.CloudProviderID(c.cluster.Spec.CloudProvider) == api.CloudProviderAWS {
+	if c.RenewCertificates {
+		if modelContext.HasAPIServer || !modelContext.UseKopsControllerForNodeBootstrap() {
+			klog.Infof("certificates of this node are not issued by kops-controller; nothing to renew")
+			return nil
+		}
+		certificate, err := model.LoadKubeconfigCertificate(modelContext.KubeletKubeConfig())
+		if err != nil {
+			return err
+		}
+		if remaining := time.Until(certificate.Leaf.NotAfter); remaining > CertificateRenewalWindow {
+			klog.Infof("kubelet client certificate expires in %v; not renewing", remaining.Round(time.Hour))
+			return nil
+		}
+		klog.Infof("kubelet client certificate expires at %v; renewing certificates", certificate.Leaf.NotAfter)
+		modelContext.KubeletClientCertificate = certificate
+	}
+
+	if api.CloudProviderID(c.cluster.Spec.CloudProvider) == api.CloudProviderAWS && !c.RenewCertificates {
 		instanceIDBytes, err := vfs.Context.ReadFile("metadata://aws/meta-data/instance-id")
 		if err != nil {
 			return fmt.Errorf("error reading instance-id from AWS metadata: %v", err)
@@ -271,56 +298,28 @@ func (c *NodeUpCommand) Run(out io.Writer) error {
 		}
 	}
 
-	if err := loadKernelModules(modelContext); err != nil {
-		return err
-	}
-
 	loader := &Loader{}
-	loader.Builders = append(loader.Builders, &model.NTPBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.MiscUtilsBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.DirectoryBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.UpdateServiceBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.VolumesBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.ContainerdBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.DockerBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.ProtokubeBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.CloudConfigBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.FileAssetsBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.HookBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.KubeletBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.KubectlBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.EtcdBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.LogrotateBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.ManifestsBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.PackagesBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.SecretBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.FirewallBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.SysctlBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.KubeAPIServerBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.KubeControllerManagerBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.KubeSchedulerBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.EtcdManagerTLSBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.KubeProxyBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.KopsControllerBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &model.AWSEBSCSIDriverBuilder{NodeupModelContext: modelContext})
-
-	loader.Builders = append(loader.Builders, &networking.CommonBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &networking.CalicoBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &networking.CiliumBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &networking.KuberouterBuilder{NodeupModelContext: modelContext})
-	loader.Builders = append(loader.Builders, &networking.LyftVPCBuilder{NodeupModelContext: modelContext})
-
-	loader.Builders = append(loader.Builders, &model.BootstrapClientBuilder{NodeupModelContext: modelContext})
+	if c.RenewCertificates {
+		// Only the certificates are renewed; any other change to the configuration of the node is applied by a rolling update
+		loader.Builders = append(loader.Builders, &model.CertificateRenewalBuilder{NodeupModelContext: modelContext})
+	} else {
+		if err := loadKernelModules(modelContext); err != nil {
+			return err
+		}
+		loader.Builders = nodeBuilders(modelContext)
+	}
 	taskMap, err := loader.Build()
 	if err != nil {
 		return fmt.Errorf("error building loader: %v", err)
 	}
 
-	for i, image := range c.config.Images[architecture] {
-		taskMap["LoadImage."+strconv.Itoa(i)] = &nodetasks.LoadImageTask{
-			Sources: image.Sources,
-			Hash:    image.Hash,
-			Runtime: c.cluster.Spec.ContainerRuntime,
+	if !c.RenewCertificates {
+		for i, image := range c.config.Images[architecture] {
+			taskMap["LoadImage."+strconv.Itoa(i)] = &nodetasks.LoadImageTask{
+				Sources: image.Sources,
+				Hash:    image.Hash,
+				Runtime: c.cluster.Spec.ContainerRuntime,
+			}
 		}
 	}
 	// Protokube load image task is in ProtokubeBuilder
@@ -362,6 +361,16 @@ func (c *NodeUpCommand) Run(out io.Writer) error {
 		klog.Exitf("error closing target: %v", err)
 	}
 
+	if c.RenewCertificates && c.Target == "direct" {
+		// kubelet only loads its certificates when it starts
+		klog.Infof("restarting kubelet to load the renewed certificates")
+		output, err := exec.Command("systemctl", "restart", "kubelet.service").CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("error restarting kubelet: %v\nOutput: %s", err, output)
+		}
+		return nil
+	}
+
 	warmPool := c.cluster.Spec.WarmPool.ResolveDefaults(modelContext.InstanceGroup)
 	if warmPool.IsEnabled() && warmPool.EnableLifecycleHook {
 		if api.CloudProviderID(c.cluster.Spec.CloudProvider) == api.CloudProviderAWS {
@@ -374,6 +383,48 @@ func (c *NodeUpCommand) Run(out io.Writer) error {
 	return nil
 }
 
+// nodeBuilders returns the builders which configure the node
+func nodeBuilders(modelContext *model.NodeupModelContext) []fi.ModelBuilder {
+	var builders []fi.ModelBuilder
+	builders = append(builders, &model.NTPBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.MiscUtilsBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.DirectoryBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.UpdateServiceBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.VolumesBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.ContainerdBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.DockerBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.ProtokubeBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.CloudConfigBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.FileAssetsBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.HookBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.KubeletBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.KubectlBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.EtcdBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.LogrotateBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.ManifestsBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.PackagesBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.SecretBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.FirewallBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.SysctlBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.KubeAPIServerBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.KubeControllerManagerBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.KubeSchedulerBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.EtcdManagerTLSBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.KubeProxyBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.KopsControllerBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &model.AWSEBSCSIDriverBuilder{NodeupModelContext: modelContext})
+
+	builders = append(builders, &networking.CommonBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &networking.CalicoBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &networking.CiliumBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &networking.KuberouterBuilder{NodeupModelContext: modelContext})
+	builders = append(builders, &networking.LyftVPCBuilder{NodeupModelContext: modelContext})
+
+	builders = append(builders, &model.BootstrapClientBuilder{NodeupModelContext: modelContext})
+
+	return builders
+}
+
 func completeWarmingLifecycleAction(cloud awsup.AWSCloud, modelContext *model.NodeupModelContext) error {
 	asgName := modelContext.InstanceGroup.GetName() + "." + modelContext.Cluster.GetName()
 	hookName := "kops-warmpool"
@@ -681,6 +732,30 @@ func getNodeConfigFromServer(ctx context.Context, config *nodeup.ConfigServerOpt
 			return nil, err
 		}
 		authenticator = a
+	case api.CloudProviderGCE:
+		a, err := gce.NewGCEAuthenticator()
+		if err != nil {
+			return nil, err
+		}
+		authenticator = a
+	case api.CloudProviderOpenstack:
+		a, err := openstack.NewOpenstackAuthenticator()
+		if err != nil {
+			return nil, err
+		}
+		authenticator = a
+	case api.CloudProviderAzure:
+		a, err := azure.NewAzureAuthenticator()
+		if err != nil {
+			return nil, err
+		}
+		authenticator = a
+	case api.CloudProviderDO:
+		a, err := do.NewDOAuthenticator()
+		if err != nil {
+			return nil, err
+		}
+		authenticator = a
 	default:
 		return nil, fmt.Errorf("unsupported cloud provider %s", config.CloudProvider)
 	}
@@ -94,7 +94,7 @@ func (b *BootstrapClientTask) Run(c *fi.Context) error {
 		key, ok := b.keys[name]
 		if !ok {
 			var err error
-			key, err = pki.GeneratePrivateKey()
+			key, err = pki.GeneratePrivateKeyWithAlgorithm(c.Cluster.KeyAlgorithm(name))
 			if err != nil {
 				return fmt.Errorf("generating private key: %v", err)
 			}
@@ -103,12 +103,21 @@ func (b *BootstrapClientTask) Run(c *fi.Context) error {
 			b.keys[name] = key
 		}
 
-		pkData, err := x509.MarshalPKIXPublicKey(key.Key.(*rsa.PrivateKey).Public())
+		publicKey, err := key.Public()
+		if err != nil {
+			return err
+		}
+		pkData, err := x509.MarshalPKIXPublicKey(publicKey)
 		if err != nil {
 			return fmt.Errorf("marshalling public key: %v", err)
 		}
+		// RSA keys keep the PEM type older versions of kops-controller expect
+		pemType := "PUBLIC KEY"
+		if _, ok := publicKey.(*rsa.PublicKey); ok {
+			pemType = "RSA PUBLIC KEY"
+		}
 		// TODO perhaps send a CSR instead to prove we own the private key?
-		req.Certs[name] = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: pkData}))
+		req.Certs[name] = string(pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: pkData}))
 	}
 
 	resp, err := b.Client.QueryBootstrap(ctx, &req)
@@ -139,6 +148,9 @@ type KopsBootstrapClient struct {
 
 	// BaseURL is the base URL for the server
 	BaseURL url.URL
+	// ClientCertificate is the current kubelet client certificate of the node.
+	// If set, it is presented to kops-controller to renew the certificates of the node instead of bootstrapping.
+	ClientCertificate *tls.Certificate
 
 	httpClient *http.Client
 }
@@ -148,13 +160,18 @@ func (b *KopsBootstrapClient) QueryBootstrap(ctx context.Context, req *nodeup.Bo
 		certPool := x509.NewCertPool()
 		certPool.AppendCertsFromPEM(b.CA)
 
+		tlsConfig := &tls.Config{
+			RootCAs:    certPool,
+			MinVersion: tls.VersionTLS12,
+		}
+		if b.ClientCertificate != nil {
+			tlsConfig.Certificates = []tls.Certificate{*b.ClientCertificate}
+		}
+
 		b.httpClient = &http.Client{
 			Timeout: time.Duration(15) * time.Second,
 			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					RootCAs:    certPool,
-					MinVersion: tls.VersionTLS12,
-				},
+				TLSClientConfig: tlsConfig,
 			},
 		}
 	}
@@ -177,8 +194,13 @@ func (b *KopsBootstrapClient) QueryBootstrap(ctx context.Context, req *nodeup.Bo
 		return nil, err
 	}
 
+	action := "bootstrap"
+	if b.ClientCertificate != nil {
+		action = "renew"
+	}
+
 	bootstrapURL := b.BaseURL
-	bootstrapURL.Path = path.Join(bootstrapURL.Path, "/bootstrap")
+	bootstrapURL.Path = path.Join(bootstrapURL.Path, "/"+action)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", bootstrapURL.String(), bytes.NewReader(reqBytes))
 	if err != nil {
 		return nil, err
@@ -207,7 +229,7 @@ func (b *KopsBootstrapClient) QueryBootstrap(ctx context.Context, req *nodeup.Bo
 				detail = scanner.Text()
 			}
 		}
-		return nil, fmt.Errorf("bootstrap returned status code %d: %s", resp.StatusCode, detail)
+		return nil, fmt.Errorf("%s returned status code %d: %s", action, resp.StatusCode, detail)
 	}
 
 	var bootstrapResp nodeup.BootstrapResponse
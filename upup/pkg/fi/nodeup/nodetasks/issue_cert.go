@@ -150,6 +150,7 @@ func (e *IssueCert) Run(c *fi.Context) error {
 		Subject:        e.Subject.toPKIXName(),
 		AlternateNames: e.AlternateNames,
 		Validity:       time.Hour * time.Duration(validHours),
+		KeyAlgorithm:   c.Cluster.KeyAlgorithm(e.Name),
 	}
 
 	klog.Infof("signing certificate for %q", e.Name)
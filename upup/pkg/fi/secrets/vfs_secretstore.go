@@ -18,6 +18,7 @@ package secrets
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -25,6 +26,7 @@ import (
 	"k8s.io/klog/v2"
 	"k8s.io/kops/pkg/acls"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/envelope"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/util/pkg/vfs"
 )
@@ -79,7 +81,7 @@ func (c *VFSSecretStore) MirrorTo(basedir vfs.Path) error {
 
 		klog.Infof("mirroring secret %s -> %s", name, p)
 
-		err = createSecret(secret, p, acl, true)
+		err = createSecret(c.cluster, secret, p, acl, true)
 		if err != nil {
 			return fmt.Errorf("error writing secret %q for mirror: %v", name, err)
 		}
@@ -88,6 +90,18 @@ func (c *VFSSecretStore) MirrorTo(basedir vfs.Path) error {
 	return nil
 }
 
+// EncryptPlaintext implements fi.HasEncryptPlaintext
+func (c *VFSSecretStore) EncryptPlaintext() error {
+	files, err := c.basedir.ReadDir()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error listing secrets directory: %v", err)
+	}
+	return fi.EncryptPlaintextFiles(c.cluster, files)
+}
+
 func BuildVfsSecretPath(basedir vfs.Path, name string) vfs.Path {
 	return basedir.Join(name)
 }
@@ -156,7 +170,7 @@ func (c *VFSSecretStore) GetOrCreateSecret(id string, secret *fi.Secret) (*fi.Se
 			return nil, false, err
 		}
 
-		err = createSecret(secret, p, acl, false)
+		err = createSecret(c.cluster, secret, p, acl, false)
 		if err != nil {
 			if os.IsExist(err) && i == 0 {
 				klog.Infof("Got already-exists error when writing secret; likely due to concurrent creation.  Will retry")
@@ -188,7 +202,7 @@ func (c *VFSSecretStore) ReplaceSecret(id string, secret *fi.Secret) (*fi.Secret
 		return nil, err
 	}
 
-	err = createSecret(secret, p, acl, true)
+	err = createSecret(c.cluster, secret, p, acl, true)
 	if err != nil {
 		return nil, fmt.Errorf("unable to write secret: %v", err)
 	}
@@ -208,6 +222,10 @@ func (c *VFSSecretStore) loadSecret(p vfs.Path) (*fi.Secret, error) {
 			return nil, nil
 		}
 	}
+	data, err = envelope.Open(context.TODO(), c.cluster, data)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting secret from %q: %v", p, err)
+	}
 	s := &fi.Secret{}
 	err = json.Unmarshal(data, s)
 	if err != nil {
@@ -216,12 +234,17 @@ func (c *VFSSecretStore) loadSecret(p vfs.Path) (*fi.Secret, error) {
 	return s, nil
 }
 
-// createSecret will create the Secret, overwriting an existing secret if replace is true
-func createSecret(s *fi.Secret, p vfs.Path, acl vfs.ACL, replace bool) error {
+// createSecret will create the Secret, overwriting an existing secret if replace is true.
+// The secret is encrypted if state store encryption is enabled for the cluster.
+func createSecret(cluster *kops.Cluster, s *fi.Secret, p vfs.Path, acl vfs.ACL, replace bool) error {
 	data, err := json.Marshal(s)
 	if err != nil {
 		return fmt.Errorf("error serializing secret: %v", err)
 	}
+	data, err = envelope.SealForCluster(context.TODO(), cluster, data)
+	if err != nil {
+		return fmt.Errorf("error encrypting secret: %v", err)
+	}
 
 	rs := bytes.NewReader(data)
 	if replace {
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// TaskLimiter controls when the executor may run tasks, for example to avoid cloud API throttling.
+// Implementations must be safe for concurrent use.
+type TaskLimiter interface {
+	// Acquire blocks until the task may run. The returned function is called when the task has finished.
+	Acquire(task Task) (release func())
+}
+
+// TaskTypeLimits is a TaskLimiter which limits the number of tasks of a type running at the same time,
+// and the rate at which they are started.
+//
+// Task types are identified either by their name, such as "SecurityGroupRule", or by their name
+// qualified with their package, such as "awstasks.SecurityGroupRule".
+type TaskTypeLimits struct {
+	concurrency map[string]chan struct{}
+	rate        map[string]flowcontrol.RateLimiter
+}
+
+var _ TaskLimiter = &TaskTypeLimits{}
+
+// NewTaskTypeLimits builds a TaskTypeLimits from the maximum number of concurrent tasks of each type,
+// and the maximum number of tasks of each type started per second.
+func NewTaskTypeLimits(concurrency map[string]int, qps map[string]float32) (*TaskTypeLimits, error) {
+	l := &TaskTypeLimits{
+		concurrency: make(map[string]chan struct{}),
+		rate:        make(map[string]flowcontrol.RateLimiter),
+	}
+	for taskType, n := range concurrency {
+		if n <= 0 {
+			return nil, fmt.Errorf("concurrency limit of task type %q must be positive", taskType)
+		}
+		l.concurrency[taskType] = make(chan struct{}, n)
+	}
+	for taskType, n := range qps {
+		if n <= 0 {
+			return nil, fmt.Errorf("rate limit of task type %q must be positive", taskType)
+		}
+		l.rate[taskType] = flowcontrol.NewTokenBucketRateLimiter(n, 1)
+	}
+	return l, nil
+}
+
+// Acquire implements TaskLimiter.
+func (l *TaskTypeLimits) Acquire(task Task) func() {
+	qualified := strings.TrimPrefix(fmt.Sprintf("%T", task), "*")
+	name := TypeNameForTask(task)
+
+	slots := l.concurrency[qualified]
+	if slots == nil {
+		slots = l.concurrency[name]
+	}
+	if slots != nil {
+		slots <- struct{}{}
+	}
+
+	rate := l.rate[qualified]
+	if rate == nil {
+		rate = l.rate[name]
+	}
+	if rate != nil {
+		rate.Accept()
+	}
+
+	return func() {
+		if slots != nil {
+			<-slots
+		}
+	}
+}
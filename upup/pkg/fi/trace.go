@@ -0,0 +1,314 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// TaskAttempt is a single run of a task by the executor.
+type TaskAttempt struct {
+	Start time.Time
+	End   time.Time
+	// Error is the error returned by the attempt, or empty if it succeeded.
+	Error string
+}
+
+// TaskTrace records the runs of a task.
+type TaskTrace struct {
+	Key          string
+	TaskType     string
+	Dependencies []string
+	Attempts     []TaskAttempt
+	// Done is true if the task completed successfully.
+	Done bool
+}
+
+// Start returns the start of the first attempt of the task.
+func (t *TaskTrace) Start() time.Time {
+	if len(t.Attempts) == 0 {
+		return time.Time{}
+	}
+	return t.Attempts[0].Start
+}
+
+// End returns the end of the last attempt of the task.
+func (t *TaskTrace) End() time.Time {
+	if len(t.Attempts) == 0 {
+		return time.Time{}
+	}
+	return t.Attempts[len(t.Attempts)-1].End
+}
+
+// Elapsed returns the time from the start of the first attempt of the task to the end of the last,
+// including any time spent waiting to be retried.
+func (t *TaskTrace) Elapsed() time.Duration {
+	return t.End().Sub(t.Start())
+}
+
+// Running returns the total time spent running attempts of the task.
+func (t *TaskTrace) Running() time.Duration {
+	var d time.Duration
+	for _, a := range t.Attempts {
+		d += a.End.Sub(a.Start)
+	}
+	return d
+}
+
+// Trace records the execution of tasks by the executor.
+// It is safe for concurrent use.
+type Trace struct {
+	mutex sync.Mutex
+	Start time.Time
+	End   time.Time
+	Tasks map[string]*TaskTrace
+}
+
+// NewTrace builds an empty Trace, to be passed in RunTasksOptions.
+func NewTrace() *Trace {
+	return &Trace{
+		Tasks: make(map[string]*TaskTrace),
+	}
+}
+
+func (t *Trace) start(taskStates map[string]*taskState) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.Start = time.Now()
+	for k, ts := range taskStates {
+		tt := &TaskTrace{
+			Key:      k,
+			TaskType: TypeNameForTask(ts.task),
+		}
+		for _, dep := range ts.dependencies {
+			tt.Dependencies = append(tt.Dependencies, dep.key)
+		}
+		sort.Strings(tt.Dependencies)
+		t.Tasks[k] = tt
+	}
+}
+
+func (t *Trace) recordAttempt(key string, start time.Time, end time.Time, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	tt := t.Tasks[key]
+	if tt == nil {
+		return
+	}
+	attempt := TaskAttempt{Start: start, End: end}
+	if err != nil {
+		attempt.Error = err.Error()
+	} else {
+		tt.Done = true
+	}
+	tt.Attempts = append(tt.Attempts, attempt)
+}
+
+func (t *Trace) finish() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.End = time.Now()
+}
+
+// sortedTasks returns the tasks which were run, in the order they were first started.
+func (t *Trace) sortedTasks() []*TaskTrace {
+	var tasks []*TaskTrace
+	for _, tt := range t.Tasks {
+		if len(tt.Attempts) != 0 {
+			tasks = append(tasks, tt)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].Start().Equal(tasks[j].Start()) {
+			return tasks[i].Start().Before(tasks[j].Start())
+		}
+		return tasks[i].Key < tasks[j].Key
+	})
+	return tasks
+}
+
+// CriticalPath returns the chain of dependencies which determined when the last task finished,
+// starting with a task which had no dependencies. Each task in the chain is the dependency
+// of the next which finished last.
+func (t *Trace) CriticalPath() []*TaskTrace {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var last *TaskTrace
+	for _, tt := range t.sortedTasks() {
+		if last == nil || tt.End().After(last.End()) {
+			last = tt
+		}
+	}
+
+	var path []*TaskTrace
+	for tt := last; tt != nil; {
+		path = append([]*TaskTrace{tt}, path...)
+		var gating *TaskTrace
+		for _, k := range tt.Dependencies {
+			dep := t.Tasks[k]
+			if dep == nil || len(dep.Attempts) == 0 {
+				continue
+			}
+			if gating == nil || dep.End().After(gating.End()) {
+				gating = dep
+			}
+		}
+		tt = gating
+	}
+	return path
+}
+
+type chromeTrace struct {
+	TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string             `json:"displayTimeUnit"`
+}
+
+// chromeTraceEvent is a complete event of the Chrome trace event format.
+type chromeTraceEvent struct {
+	Name      string            `json:"name"`
+	Category  string            `json:"cat"`
+	Phase     string            `json:"ph"`
+	Timestamp int64             `json:"ts"`
+	Duration  int64             `json:"dur"`
+	PID       int               `json:"pid"`
+	TID       int               `json:"tid"`
+	Args      map[string]string `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the trace in the Chrome trace event format, which can be loaded
+// into chrome://tracing or https://ui.perfetto.dev. Each attempt is a separate event;
+// attempts which ran at the same time are placed on different threads.
+func (t *Trace) WriteChromeTrace(w io.Writer) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	type attempt struct {
+		task  *TaskTrace
+		index int
+	}
+	var attempts []attempt
+	for _, tt := range t.sortedTasks() {
+		for i := range tt.Attempts {
+			attempts = append(attempts, attempt{task: tt, index: i})
+		}
+	}
+	sort.SliceStable(attempts, func(i, j int) bool {
+		return attempts[i].task.Attempts[attempts[i].index].Start.Before(attempts[j].task.Attempts[attempts[j].index].Start)
+	})
+
+	trace := chromeTrace{
+		TraceEvents:     []chromeTraceEvent{},
+		DisplayTimeUnit: "ms",
+	}
+	var threadEnds []time.Time
+	for _, a := range attempts {
+		ta := a.task.Attempts[a.index]
+
+		tid := -1
+		for i, end := range threadEnds {
+			if !end.After(ta.Start) {
+				tid = i
+				break
+			}
+		}
+		if tid == -1 {
+			tid = len(threadEnds)
+			threadEnds = append(threadEnds, time.Time{})
+		}
+		threadEnds[tid] = ta.End
+
+		args := map[string]string{
+			"attempt": fmt.Sprintf("%d", a.index+1),
+		}
+		if ta.Error != "" {
+			args["error"] = ta.Error
+		}
+		trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+			Name:      a.task.Key,
+			Category:  a.task.TaskType,
+			Phase:     "X",
+			Timestamp: ta.Start.Sub(t.Start).Microseconds(),
+			Duration:  ta.End.Sub(ta.Start).Microseconds(),
+			PID:       1,
+			TID:       tid + 1,
+			Args:      args,
+		})
+	}
+
+	b, err := json.MarshalIndent(trace, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling trace: %v", err)
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+	return err
+}
+
+// WriteSummary writes a text summary of the trace: the slowest tasks and the critical path.
+func (t *Trace) WriteSummary(w io.Writer, maxTasks int) error {
+	criticalPath := t.CriticalPath()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	tasks := t.sortedTasks()
+	retried := 0
+	for _, tt := range tasks {
+		if len(tt.Attempts) > 1 {
+			retried++
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Ran %d tasks in %v; %d needed more than one attempt.\n", len(tasks), t.End.Sub(t.Start).Round(time.Millisecond), retried)
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Elapsed() > tasks[j].Elapsed()
+	})
+	if len(tasks) > maxTasks {
+		tasks = tasks[:maxTasks]
+	}
+	fmt.Fprintf(tw, "\nSlowest tasks:\n")
+	fmt.Fprintf(tw, "TASK\tATTEMPTS\tRUNNING\tELAPSED\tLAST ERROR\n")
+	for _, tt := range tasks {
+		lastError := ""
+		if !tt.Done {
+			lastError = tt.Attempts[len(tt.Attempts)-1].Error
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%v\t%v\t%s\n", tt.Key, len(tt.Attempts), tt.Running().Round(time.Millisecond), tt.Elapsed().Round(time.Millisecond), lastError)
+	}
+
+	if len(criticalPath) != 0 {
+		fmt.Fprintf(tw, "\nCritical path:\n")
+		fmt.Fprintf(tw, "TASK\tSTARTED\tFINISHED\tATTEMPTS\n")
+		for _, tt := range criticalPath {
+			fmt.Fprintf(tw, "%s\t+%v\t+%v\t%d\n", tt.Key, tt.Start().Sub(t.Start).Round(time.Millisecond), tt.End().Sub(t.Start).Round(time.Millisecond), len(tt.Attempts))
+		}
+	}
+
+	return tw.Flush()
+}
@@ -18,6 +18,7 @@ package fi
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math/big"
 	"os"
@@ -29,6 +30,7 @@ import (
 	"k8s.io/kops/pkg/acls"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/v1alpha2"
+	"k8s.io/kops/pkg/envelope"
 	"k8s.io/kops/pkg/kopscodecs"
 	"k8s.io/kops/pkg/pki"
 	"k8s.io/kops/pkg/sshcredentials"
@@ -118,6 +120,11 @@ func (c *VFSCAStore) loadKeysetBundle(p vfs.Path) (*keyset, error) {
 		return nil, fmt.Errorf("unable to read bundle %q: %v", p, err)
 	}
 
+	data, err = envelope.Open(context.TODO(), c.cluster, data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt bundle %q: %v", p, err)
+	}
+
 	o, legacyFormat, err := c.parseKeysetYaml(data)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing bundle %q: %v", p, err)
@@ -136,6 +143,8 @@ func (k *keyset) ToAPIObject(name string, includePrivateKeyMaterial bool) (*kops
 	o := &kops.Keyset{}
 	o.Name = name
 	o.Spec.Type = kops.SecretTypeKeypair
+	o.Spec.PrimaryId = k.primaryID
+	o.Spec.Rotation = k.rotation
 
 	for _, ki := range k.items {
 		oki := kops.KeysetItem{
@@ -178,6 +187,13 @@ func (c *VFSCAStore) writeKeysetBundle(p vfs.Path, name string, keyset *keyset,
 		return err
 	}
 
+	if includePrivateKeyMaterial {
+		objectData, err = envelope.SealForCluster(context.TODO(), c.cluster, objectData)
+		if err != nil {
+			return fmt.Errorf("error encrypting keyset %q: %v", name, err)
+		}
+	}
+
 	acl, err := acls.GetACL(p, c.cluster)
 	if err != nil {
 		return err
@@ -312,15 +328,7 @@ func (c *VFSCAStore) FindCertificatePool(name string) (*CertificatePool, error)
 			pool.Primary = certs.primary.certificate
 		}
 
-		for k, cert := range certs.items {
-			if certs.primary != nil && k == certs.primary.id {
-				continue
-			}
-			if cert.certificate == nil {
-				continue
-			}
-			pool.Secondary = append(pool.Secondary, cert.certificate)
-		}
+		pool.Secondary = certs.secondaryCertificates()
 	}
 	return pool, nil
 }
@@ -344,6 +352,63 @@ func (c *VFSCAStore) FindCertificateKeyset(name string) (*kops.Keyset, error) {
 	return o, nil
 }
 
+// EncryptPlaintext implements HasEncryptPlaintext
+func (c *VFSCAStore) EncryptPlaintext() error {
+	files, err := c.basedir.Join("private").ReadTree()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("error listing private keys: %v", err)
+	}
+	return EncryptPlaintextFiles(c.cluster, files)
+}
+
+// EncryptPlaintextFiles encrypts the files which are not already encrypted with the key encryption key of the cluster,
+// including files encrypted with a previous key encryption key. It does nothing if state store encryption is not enabled.
+func EncryptPlaintextFiles(cluster *kops.Cluster, files []vfs.Path) error {
+	ctx := context.TODO()
+
+	kek, err := envelope.ForCluster(cluster)
+	if err != nil || kek == nil {
+		return err
+	}
+
+	for _, p := range files {
+		data, err := p.ReadFile()
+		if err != nil {
+			return fmt.Errorf("error reading %q: %v", p, err)
+		}
+		if envelope.IsEncrypted(data) {
+			uri, err := envelope.SealedWith(data)
+			if err != nil {
+				return fmt.Errorf("error reading %q: %v", p, err)
+			}
+			if uri == kek.URI() {
+				continue
+			}
+			data, err = envelope.Open(ctx, cluster, data)
+			if err != nil {
+				return fmt.Errorf("error decrypting %q: %v", p, err)
+			}
+		}
+
+		sealed, err := envelope.Seal(ctx, kek, data)
+		if err != nil {
+			return fmt.Errorf("error encrypting %q: %v", p, err)
+		}
+		acl, err := acls.GetACL(p, cluster)
+		if err != nil {
+			return err
+		}
+		klog.Infof("encrypting %s", p)
+		if err := p.WriteFile(bytes.NewReader(sealed), acl); err != nil {
+			return fmt.Errorf("error writing %q: %v", p, err)
+		}
+	}
+	return nil
+}
+
 // ListKeysets implements CAStore::ListKeysets
 func (c *VFSCAStore) ListKeysets() ([]*kops.Keyset, error) {
 	keysets := make(map[string]*kops.Keyset)
@@ -495,6 +560,10 @@ func mirrorKeyset(cluster *kops.Cluster, basedir vfs.Path, keyset *kops.Keyset)
 			if err != nil {
 				return err
 			}
+			data, err = envelope.SealForCluster(context.TODO(), cluster, data)
+			if err != nil {
+				return fmt.Errorf("error encrypting keyset %q: %v", keyset.Name, err)
+			}
 			p := basedir.Join("private", keyset.Name, "keyset.yaml")
 			acl, err := acls.GetACL(p, cluster)
 			if err != nil {
@@ -685,13 +754,17 @@ func (c *VFSCAStore) storePrivateKey(name string, ki *keysetItem) error {
 		if _, err := ki.privateKey.WriteTo(&data); err != nil {
 			return err
 		}
+		sealed, err := envelope.SealForCluster(context.TODO(), c.cluster, data.Bytes())
+		if err != nil {
+			return fmt.Errorf("error encrypting private key %q: %v", name, err)
+		}
 
 		p := c.buildPrivateKeyPath(name, ki.id)
 		acl, err := acls.GetACL(p, c.cluster)
 		if err != nil {
 			return err
 		}
-		return p.WriteFile(bytes.NewReader(data.Bytes()), acl)
+		return p.WriteFile(bytes.NewReader(sealed), acl)
 	}
 }
 
@@ -884,6 +957,53 @@ func (c *VFSCAStore) DeleteKeysetItem(item *kops.Keyset, id string) error {
 	}
 }
 
+// UpdateKeysetRotation implements CAStore::UpdateKeysetRotation
+func (c *VFSCAStore) UpdateKeysetRotation(name string, primaryID string, rotation *kops.KeysetRotation) error {
+	{
+		p := c.buildCertificatePoolPath(name)
+		ks, err := c.loadCertificates(p)
+		if err != nil {
+			return err
+		}
+		if ks == nil {
+			return fmt.Errorf("keyset %q not found", name)
+		}
+		if primaryID != "" && ks.items[primaryID] == nil {
+			return fmt.Errorf("keyset %q does not contain %q", name, primaryID)
+		}
+
+		ks.primaryID = primaryID
+		ks.rotation = rotation
+		if err := c.writeKeysetBundle(p, name, ks, false); err != nil {
+			return fmt.Errorf("error writing bundle: %v", err)
+		}
+	}
+
+	{
+		p := c.buildPrivateKeyPoolPath(name)
+		ks, err := c.loadPrivateKeys(p)
+		if err != nil {
+			return err
+		}
+		// Keysets created with AddCert have no private keys
+		if ks != nil {
+			ks.primaryID = primaryID
+			ks.rotation = rotation
+			if err := c.writeKeysetBundle(p, name, ks, true); err != nil {
+				return fmt.Errorf("error writing bundle: %v", err)
+			}
+		}
+	}
+
+	if name == CertificateIDCA {
+		c.mutex.Lock()
+		c.cachedCA = nil
+		c.mutex.Unlock()
+	}
+
+	return nil
+}
+
 func (c *VFSCAStore) DeleteSSHCredential(item *kops.SSHCredential) error {
 	if item.Spec.PublicKey == "" {
 		return fmt.Errorf("must specific public key to delete SSHCredential")
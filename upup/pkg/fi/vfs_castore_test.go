@@ -425,3 +425,43 @@ spec:
 	}
 
 }
+
+func TestKeysetPrimaryId(t *testing.T) {
+	o := &kops.Keyset{}
+	o.Name = "ca"
+	o.Spec.Type = kops.SecretTypeKeypair
+	o.Spec.Keys = []kops.KeysetItem{{Id: "100"}, {Id: "300"}, {Id: "200"}}
+
+	grid := []struct {
+		primaryID string
+		expected  string
+	}{
+		{primaryID: "", expected: "300"},
+		{primaryID: "100", expected: "100"},
+		{primaryID: "200", expected: "200"},
+		{primaryID: "400", expected: "300"},
+	}
+	for _, g := range grid {
+		o.Spec.PrimaryId = g.primaryID
+		o.Spec.Rotation = &kops.KeysetRotation{Stage: kops.KeysetRotationStageAdded, PreviousId: "300", NewId: "400"}
+
+		ks, err := parseKeyset(o)
+		if err != nil {
+			t.Fatalf("unexpected error parsing keyset: %v", err)
+		}
+		if ks.primary == nil || ks.primary.id != g.expected {
+			t.Errorf("primaryId %q: expected primary %q, got %v", g.primaryID, g.expected, ks.primary)
+		}
+		if primary := FindPrimary(o); primary == nil || primary.Id != g.expected {
+			t.Errorf("primaryId %q: expected FindPrimary to return %q, got %v", g.primaryID, g.expected, primary)
+		}
+
+		actual, err := ks.ToAPIObject("ca", false)
+		if err != nil {
+			t.Fatalf("unexpected error converting keyset: %v", err)
+		}
+		if actual.Spec.PrimaryId != g.primaryID || actual.Spec.Rotation == nil || actual.Spec.Rotation.Stage != kops.KeysetRotationStageAdded {
+			t.Errorf("primaryId %q: rotation state did not round-trip: %v", g.primaryID, actual.Spec)
+		}
+	}
+}